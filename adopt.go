@@ -0,0 +1,163 @@
+package gofastcaddy
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/youfun/gofastcaddy/internal/routes"
+	"github.com/youfun/gofastcaddy/internal/utils"
+)
+
+// AdoptOptions 接管现有配置的选项
+type AdoptOptions struct {
+	DryRun  bool     // 只生成报告，不修改配置
+	Servers []string // 只处理这些 HTTP 服务器 (为空表示全部)
+}
+
+// AdoptedRoute 被接管（或试运行时将被接管）的路由
+type AdoptedRoute struct {
+	Server string `json:"server"` // 所在的 HTTP 服务器
+	Index  int    `json:"index"`  // 在服务器路由列表中的位置
+	Host   string `json:"host"`   // 匹配的主机名
+	ID     string `json:"id"`     // 分配的 @id
+}
+
+// SkippedRoute 未被接管的路由及原因
+type SkippedRoute struct {
+	Server string `json:"server"` // 所在的 HTTP 服务器
+	Index  int    `json:"index"`  // 在服务器路由列表中的位置
+	Reason string `json:"reason"` // 跳过原因
+}
+
+// AdoptReport 接管现有配置的结果报告
+type AdoptReport struct {
+	DryRun  bool           `json:"dry_run"` // 是否为试运行
+	Adopted []AdoptedRoute `json:"adopted"` // 已接管的路由
+	Skipped []SkippedRoute `json:"skipped"` // 跳过的路由
+}
+
+// AdoptExisting 接管由 Caddyfile 等方式创建的现有反向代理路由
+// 识别“单个主机匹配 + 反向代理”形态的顶层路由（含 caddy adapt 生成的单层 subroute 包装），
+// 为其分配与 AddReverseProxy 相同约定的 @id（即小写的主机名，见 routes.HostRouteID）。接管只会写入 @id，
+// 不会修改匹配器和处理器；其他形态的路由记录在报告的 Skipped 中并说明原因
+func (fc *FastCaddy) AdoptExisting(opts AdoptOptions) (*AdoptReport, error) {
+	cfg, err := fc.API.GetConfig("/")
+	if err != nil {
+		return nil, err
+	}
+
+	report := &AdoptReport{DryRun: opts.DryRun}
+	servers, _ := lookupMap(cfg, "apps", "http", "servers")
+	names := make([]string, 0, len(servers))
+	for name := range servers {
+		if len(opts.Servers) == 0 || utils.StringSliceContains(opts.Servers, name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	used := make(map[string]bool)
+	collectIDs(cfg, used)
+
+	for _, name := range names {
+		server, _ := servers[name].(map[string]interface{})
+		routeList, _ := server["routes"].([]interface{})
+		for i, raw := range routeList {
+			route, _ := raw.(map[string]interface{})
+			skip := func(reason string) {
+				report.Skipped = append(report.Skipped, SkippedRoute{Server: name, Index: i, Reason: reason})
+			}
+			if route == nil {
+				skip("不是 JSON 对象")
+				continue
+			}
+			if id, ok := route["@id"].(string); ok && id != "" {
+				skip(fmt.Sprintf("已有 @id %s", id))
+				continue
+			}
+			host, reason := proxyRouteHost(route)
+			if reason != "" {
+				skip(reason)
+				continue
+			}
+			id := routes.HostRouteID(host)
+			if used[id] {
+				skip(fmt.Sprintf("@id %s 已被占用", id))
+				continue
+			}
+
+			if !opts.DryRun {
+				if err := fc.Routes.AdoptRoute(name, i, host, id); err != nil {
+					return report, fmt.Errorf("接管路由 %s/%d 失败: %w", name, i, err)
+				}
+			}
+			used[id] = true
+			report.Adopted = append(report.Adopted, AdoptedRoute{Server: name, Index: i, Host: host, ID: id})
+		}
+	}
+	return report, nil
+}
+
+// proxyRouteHost 判断路由是否为可接管的反向代理形态，返回其主机名或跳过原因 - 内部辅助函数
+func proxyRouteHost(route map[string]interface{}) (string, string) {
+	matchers, _ := route["match"].([]interface{})
+	if len(matchers) != 1 {
+		return "", fmt.Sprintf("有 %d 个匹配器集合, 需要恰好 1 个", len(matchers))
+	}
+	matcher, _ := matchers[0].(map[string]interface{})
+	hosts, _ := matcher["host"].([]interface{})
+	if len(matcher) != 1 || len(hosts) != 1 {
+		return "", "匹配器不是单个主机名"
+	}
+	host, _ := hosts[0].(string)
+	if host == "" {
+		return "", "主机名为空"
+	}
+
+	handlers, _ := route["handle"].([]interface{})
+	if len(handlers) != 1 {
+		return "", fmt.Sprintf("有 %d 个处理器, 需要恰好 1 个", len(handlers))
+	}
+	handler, _ := handlers[0].(map[string]interface{})
+	switch handler["handler"] {
+	case "reverse_proxy":
+		return host, ""
+	case "subroute":
+		// caddy adapt 会把站点块包装为只含一个无匹配器路由的 subroute
+		inner, _ := handler["routes"].([]interface{})
+		if len(inner) != 1 {
+			return "", "subroute 包含多个路由"
+		}
+		innerRoute, _ := inner[0].(map[string]interface{})
+		if _, ok := innerRoute["match"]; ok {
+			return "", "subroute 内的路由带有匹配器"
+		}
+		innerHandlers, _ := innerRoute["handle"].([]interface{})
+		if len(innerHandlers) != 1 {
+			return "", "subroute 内的路由不是单个处理器"
+		}
+		if h, _ := innerHandlers[0].(map[string]interface{}); h["handler"] == "reverse_proxy" {
+			return host, ""
+		}
+		return "", "subroute 内的处理器不是 reverse_proxy"
+	default:
+		return "", fmt.Sprintf("处理器 %v 不是 reverse_proxy", handler["handler"])
+	}
+}
+
+// collectIDs 收集配置中已使用的 @id - 内部辅助函数
+func collectIDs(value interface{}, used map[string]bool) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if id, ok := v["@id"].(string); ok {
+			used[id] = true
+		}
+		for _, item := range v {
+			collectIDs(item, used)
+		}
+	case []interface{}:
+		for _, item := range v {
+			collectIDs(item, used)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package gofastcaddy
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestAdoptExistingAdaptedConfig(t *testing.T) {
+	// testdata/adapted.json 模拟 caddy adapt 对 Caddyfile 站点块的转换结果
+	adapted, err := os.ReadFile("testdata/adapted.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantAdopted := []AdoptedRoute{
+		{Server: "srv0", Index: 0, Host: "App.example.com", ID: "app.example.com"},
+		{Server: "srv0", Index: 1, Host: "api.example.com", ID: "api.example.com"},
+	}
+	wantSkipped := []SkippedRoute{
+		{Server: "srv0", Index: 2, Reason: "subroute 内的路由不是单个处理器"},
+		{Server: "srv0", Index: 3, Reason: "匹配器不是单个主机名"},
+		{Server: "srv0", Index: 4, Reason: "已有 @id managed.example.com"},
+		{Server: "srv0", Index: 5, Reason: "@id app.example.com 已被占用"},
+	}
+
+	tests := []struct {
+		name   string
+		dryRun bool
+	}{
+		{name: "试运行", dryRun: true},
+		{name: "接管", dryRun: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fc, _ := newTestFastCaddy(t, string(adapted))
+			report, err := fc.AdoptExisting(AdoptOptions{DryRun: tt.dryRun})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(report.Adopted, wantAdopted) {
+				t.Errorf("Adopted = %+v\n期望 %+v", report.Adopted, wantAdopted)
+			}
+			if !reflect.DeepEqual(report.Skipped, wantSkipped) {
+				t.Errorf("Skipped = %+v\n期望 %+v", report.Skipped, wantSkipped)
+			}
+
+			for _, adopted := range wantAdopted {
+				exists, err := fc.API.IDExists(adopted.ID)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if exists == tt.dryRun {
+					t.Errorf("@id %s 存在 = %v, 试运行 = %v", adopted.ID, exists, tt.dryRun)
+				}
+			}
+			if tt.dryRun {
+				return
+			}
+			// 接管后可以通过 ID 管理路由，匹配器和处理器保持不变
+			route, err := fc.API.GetByID("app.example.com")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := handlerNames(route); !reflect.DeepEqual(got, []string{"subroute"}) {
+				t.Errorf("处理器 = %v", got)
+			}
+			again, err := fc.AdoptExisting(AdoptOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(again.Adopted) != 0 {
+				t.Errorf("重复接管: %+v", again.Adopted)
+			}
+		})
+	}
+}
+
+// handlerNames 返回路由处理器链中各处理器的名称 - 测试辅助函数
+func handlerNames(route map[string]interface{}) []string {
+	var names []string
+	handlers, _ := route["handle"].([]interface{})
+	for _, raw := range handlers {
+		handler, _ := raw.(map[string]interface{})
+		name, _ := handler["handler"].(string)
+		names = append(names, name)
+	}
+	return names
+}
@@ -0,0 +1,76 @@
+package gofastcaddy
+
+import (
+	"testing"
+)
+
+// unknownApps 由其他工具管理、fastcaddy 不理解的应用 - 测试辅助常量
+const unknownApps = `"layer4":{"servers":{"ssh":{"listen":[":22"],"routes":[{"handle":[{"handler":"proxy","upstreams":[{"dial":["git:22"]}]}]}]}}},` +
+	`"events":{"subscriptions":[{"events":["cert_obtained"],"handlers":[{"handler":"exec","command":"reload.sh"}]}]}`
+
+func TestUnknownAppsSurviveFullConfigWrites(t *testing.T) {
+	noTrust := false
+	tests := []struct {
+		name    string
+		initial string
+		write   func(fc *FastCaddy) error
+	}{
+		{
+			name:    "SetupCaddy",
+			initial: `{"apps":{` + unknownApps + `}}`,
+			write:   func(fc *FastCaddy) error { return fc.SetupCaddy("", "", true, &noTrust) },
+		},
+		{
+			name:    "Reconcile",
+			initial: `{"apps":{` + unknownApps + `,"http":{"servers":{"srv0":{"listen":[":443"],"routes":[]}}}}}`,
+			write: func(fc *FastCaddy) error {
+				_, err := fc.Reconcile(reconcileSpec, ReconcileOptions{})
+				return err
+			},
+		},
+		{
+			name:    "MigrateManagedConfig",
+			initial: `{"apps":{` + unknownApps + `,"http":{"servers":{"srv0":{"routes":[{"@id":"App.Example.com","match":[{"host":["App.Example.com"]}],"handle":[]}]}}}}}`,
+			write: func(fc *FastCaddy) error {
+				changes, err := fc.MigrateManagedConfig(false)
+				if err == nil && len(changes) == 0 {
+					t.Error("期望迁移产生修改")
+				}
+				return err
+			},
+		},
+		{
+			name:    "NestedSetConfig 写回整个配置",
+			initial: `{"apps":{` + unknownApps + `}}`,
+			write: func(fc *FastCaddy) error {
+				return fc.Config.NestedSetConfig(map[string]interface{}{"servers": map[string]interface{}{}}, "apps", "http")
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fc, _ := newTestFastCaddy(t, tt.initial)
+			before := map[string]string{}
+			for _, name := range []string{"layer4", "events"} {
+				raw, err := fc.API.GetRawConfig("/apps/" + name)
+				if err != nil {
+					t.Fatal(err)
+				}
+				before[name] = string(raw)
+			}
+
+			if err := tt.write(fc); err != nil {
+				t.Fatalf("写入配置: %v", err)
+			}
+			for name, want := range before {
+				raw, err := fc.API.GetRawConfig("/apps/" + name)
+				if err != nil {
+					t.Fatalf("应用 %s 被删除: %v", name, err)
+				}
+				if string(raw) != want {
+					t.Errorf("应用 %s 被修改:\n%s\n原为:\n%s", name, raw, want)
+				}
+			}
+		})
+	}
+}
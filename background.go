@@ -0,0 +1,108 @@
+package gofastcaddy
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultDockerWatchInterval WatchDocker 的默认同步间隔
+const DefaultDockerWatchInterval = 30 * time.Second
+
+// background FastCaddy 启动的后台组件 - 所有后台 goroutine 都通过 startBackground 启动，
+// 因此 Close 不会遗漏任何组件
+type background struct {
+	mu         sync.Mutex
+	closed     bool
+	components map[string]*component
+}
+
+// component 单个后台组件
+type component struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// startBackground 在后台 goroutine 中运行 run，并登记到 Close 的停止列表 - 内部辅助函数
+// run 应在 ctx 取消后尽快返回；同名组件已在运行或 FastCaddy 已关闭时返回错误
+func (fc *FastCaddy) startBackground(name string, run func(ctx context.Context)) error {
+	fc.bg.mu.Lock()
+	defer fc.bg.mu.Unlock()
+	if fc.bg.closed {
+		return fmt.Errorf("FastCaddy 已关闭, 无法启动后台组件 %s", name)
+	}
+	if _, ok := fc.bg.components[name]; ok {
+		return fmt.Errorf("后台组件 %s 已在运行", name)
+	}
+	if fc.bg.components == nil {
+		fc.bg.components = make(map[string]*component)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &component{cancel: cancel, done: make(chan struct{})}
+	fc.bg.components[name] = c
+	go func() {
+		defer close(c.done)
+		run(ctx)
+	}()
+	return nil
+}
+
+// Close 停止 FastCaddy 启动的全部后台组件并等待其退出
+// ctx 结束时仍未退出的组件会在返回的错误中列出；Close 之后不能再启动后台组件，重复调用是安全的
+func (fc *FastCaddy) Close(ctx context.Context) error {
+	fc.bg.mu.Lock()
+	fc.bg.closed = true
+	components := fc.bg.components
+	fc.bg.components = nil
+	fc.bg.mu.Unlock()
+
+	for _, c := range components {
+		c.cancel()
+	}
+	var stuck []string
+	for name, c := range components {
+		select {
+		case <-c.done:
+		case <-ctx.Done():
+			stuck = append(stuck, name)
+		}
+	}
+	if len(stuck) > 0 {
+		sort.Strings(stuck)
+		return fmt.Errorf("后台组件未能在期限内停止: %s: %w", strings.Join(stuck, ", "), ctx.Err())
+	}
+	return nil
+}
+
+// WatchDocker 在后台按 interval 周期调用 SyncFromDocker，直到 Close
+// interval 为 0 时使用默认值；同步失败时调用 onError (nil 时通过 Routes.Warn 报告)，不会停止后台同步
+func (fc *FastCaddy) WatchDocker(labelPrefix string, interval time.Duration, onError func(error)) error {
+	if interval <= 0 {
+		interval = DefaultDockerWatchInterval
+	}
+	if onError == nil {
+		onError = func(err error) {
+			if warn := fc.Routes.Warn; warn != nil {
+				warn(fmt.Sprintf("同步 Docker 路由失败: %v", err))
+			}
+		}
+	}
+	return fc.startBackground("docker:"+labelPrefix, func(ctx context.Context) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if err := fc.SyncFromDocker(ctx, labelPrefix); err != nil && ctx.Err() == nil {
+				onError(err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	})
+}
@@ -0,0 +1,65 @@
+package gofastcaddy
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCloseStopsBackground(t *testing.T) {
+	dockerAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[]`))
+	}))
+	defer dockerAPI.Close()
+	t.Setenv("DOCKER_HOST", strings.Replace(dockerAPI.URL, "http://", "tcp://", 1))
+	fc, _ := newTestFastCaddy(t, `{"apps":{"http":{"servers":{"srv0":{"listen":[":443"],"routes":[]}}}}}`)
+
+	// 先同步一次建立连接，之后的基线包含测试服务器和保持的空闲连接
+	if err := fc.SyncFromDocker(context.Background(), "fc"); err != nil {
+		t.Fatal(err)
+	}
+	closeIdle(fc)
+	baseline := runtime.NumGoroutine()
+
+	for _, prefix := range []string{"fc", "other"} {
+		if err := fc.WatchDocker(prefix, 5*time.Millisecond, func(err error) { t.Error(err) }); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := fc.startBackground("blocking", func(ctx context.Context) { <-ctx.Done() }); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(30 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := fc.Close(ctx); err != nil {
+		t.Fatalf("Close 失败: %v", err)
+	}
+	if err := fc.WatchDocker("late", 0, nil); err == nil {
+		t.Error("Close 之后不应能启动后台组件")
+	}
+
+	// 后台组件并发同步时可能新建连接，关闭空闲连接后等待 goroutine 数回到基线
+	closeIdle(fc)
+	deadline := time.Now().Add(2 * time.Second)
+	for runtime.NumGoroutine() > baseline {
+		if time.Now().After(deadline) {
+			pprof.Lookup("goroutine").WriteTo(os.Stderr, 1)
+			t.Fatalf("Close 后 goroutine 数 = %d, 基线 %d", runtime.NumGoroutine(), baseline)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// closeIdle 关闭管理端点和 Docker (默认传输层) 的空闲连接 - 测试辅助函数
+func closeIdle(fc *FastCaddy) {
+	fc.API.HTTPClient.CloseIdleConnections()
+	http.DefaultTransport.(*http.Transport).CloseIdleConnections()
+}
@@ -0,0 +1,39 @@
+package gofastcaddy
+
+import (
+	"github.com/youfun/gofastcaddy/internal/api"
+	"github.com/youfun/gofastcaddy/internal/tls"
+	"github.com/youfun/gofastcaddy/pkg/types"
+)
+
+// Capabilities 当前构建支持的功能 - 供下游工具在运行时探测，避免依赖反射
+// 功能落地时在 GetCapabilities 中同步维护；列表类字段直接读取各注册表，不会与实现脱节
+type Capabilities struct {
+	Version       string   `json:"version"`        // 库版本号
+	DryRun        bool     `json:"dry_run"`        // 是否支持不发送请求的试运行模式
+	Plan          bool     `json:"plan"`           // 是否支持可审阅的变更计划 (NewPlan/Apply)
+	Reconcile     bool     `json:"reconcile"`      // 是否支持声明式收敛
+	StateVerify   bool     `json:"state_verify"`   // 是否支持状态哈希与漂移检测 (VerifyState)
+	DNSProviders  []string `json:"dns_providers"`  // 内置的 DNS 提供商
+	TypedHandlers []string `json:"typed_handlers"` // 已注册的类型化处理器模块
+	TypedMatchers []string `json:"typed_matchers"` // 已注册的类型化匹配器模块
+}
+
+// Version 返回库版本号 - 发布时通过 ldflags 设置，默认为 dev
+func Version() string {
+	return api.Version
+}
+
+// GetCapabilities 返回当前构建支持的功能
+func GetCapabilities() Capabilities {
+	return Capabilities{
+		Version:       api.Version,
+		DryRun:        true,
+		Plan:          true,
+		Reconcile:     true,
+		StateVerify:   true,
+		DNSProviders:  tls.DNSProviders(),
+		TypedHandlers: types.HandlerModules(),
+		TypedMatchers: types.MatcherModules(),
+	}
+}
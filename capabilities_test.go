@@ -0,0 +1,25 @@
+package gofastcaddy
+
+import "testing"
+
+func TestGetCapabilities(t *testing.T) {
+	caps := GetCapabilities()
+	tests := []struct {
+		name string
+		got  bool
+		want bool
+	}{
+		{"DryRun", caps.DryRun, true}, // WithDryRun
+		{"Plan", caps.Plan, true},
+		{"StateVerify", caps.StateVerify, true},
+		{"Reconcile", caps.Reconcile, true},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s = %v, 期望 %v", tt.name, tt.got, tt.want)
+		}
+	}
+	if len(caps.TypedHandlers) == 0 || len(caps.TypedMatchers) == 0 {
+		t.Errorf("类型化模块列表为空: %+v", caps)
+	}
+}
@@ -0,0 +1,247 @@
+package gofastcaddy
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/youfun/gofastcaddy/internal/api"
+)
+
+// 数据面访问的默认参数
+const (
+	DefaultDataPlaneAddress     = "localhost:443" // HTTPS 请求连接的数据面地址
+	DefaultDataPlaneHTTPAddress = "localhost:80"  // HTTP 请求连接的数据面地址
+	DefaultDataPlaneTimeout     = 10 * time.Second
+)
+
+// DataPlaneConfig 访问 Caddy 数据面 (实际对外服务的端口) 的设置 - 探测数据面的功能共用，见 DataPlaneClient
+// 请求 URL 中的主机名只用于 Host 头和 SNI，连接总是发往 Address/HTTPAddress；
+// 证书校验默认开启，使用内部证书时由 SetupCaddy (本地模式) 或 TrustInternalCA 自动信任内部 CA 根证书
+type DataPlaneConfig struct {
+	Address     string // HTTPS 请求连接的地址 host:port (为空表示 DefaultDataPlaneAddress)
+	HTTPAddress string // HTTP 请求连接的地址 host:port (为空表示 DefaultDataPlaneHTTPAddress)
+
+	// RootCAs 校验数据面证书的 CA (nil 表示系统根证书)；已信任内部 CA 时其根证书会加入该证书池的副本
+	RootCAs *x509.CertPool
+
+	// ServerNames SNI 覆盖：请求主机名 -> TLS 握手和证书校验使用的名称
+	ServerNames map[string]string
+
+	Timeout time.Duration // 连接和单次请求的超时 (0 表示 DefaultDataPlaneTimeout)
+
+	// InsecureSkipVerify 不校验数据面证书 - 仅用于测试环境
+	InsecureSkipVerify bool
+}
+
+// dataPlaneTrust 内部 CA 的信任状态 - 内部类型
+type dataPlaneTrust struct {
+	mu       sync.Mutex
+	internal bool                // 是否需要信任内部 CA (SetupCaddy 本地模式或 TrustInternalCA)
+	roots    []*x509.Certificate // 已获取的内部 CA 根证书 (nil 表示尚未获取)
+}
+
+// TrustInternalCA 在数据面访问中信任 Caddy 内部 CA (local) 的根证书
+// SetupCaddy 以本地模式运行后会自动开启；根证书在第一次调用 DataPlaneClient 时通过 /pki 端点获取
+func (fc *FastCaddy) TrustInternalCA() {
+	fc.trust.mu.Lock()
+	defer fc.trust.mu.Unlock()
+	fc.trust.internal = true
+}
+
+// DataPlaneClient 返回按 DataPlane 设置访问数据面的 HTTP 客户端
+// 例如 client.Get("https://app.example.com/health") 会连接 DataPlane.Address，以 app.example.com 进行 SNI 和证书校验；
+// 不跟随重定向。需要信任内部 CA 但 PKI 应用尚未初始化时返回错误，不会退回到跳过校验
+func (fc *FastCaddy) DataPlaneClient() (*http.Client, error) {
+	dp := fc.DataPlane
+	httpsAddress, httpAddress, timeout := dp.settings()
+
+	roots, err := fc.dataPlaneRoots(dp.RootCAs)
+	if err != nil {
+		return nil, err
+	}
+	base := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		RootCAs:            roots,
+		InsecureSkipVerify: dp.InsecureSkipVerify,
+	}
+
+	dialer := &net.Dialer{Timeout: timeout}
+	transport := &http.Transport{
+		Proxy: nil, // 数据面地址通常是本机或内网地址，不经过代理
+		DialContext: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			return dialer.DialContext(ctx, network, httpAddress)
+		},
+		DialTLSContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			host, _, err := net.SplitHostPort(addr)
+			if err != nil {
+				host = addr
+			}
+			conn, err := dialer.DialContext(ctx, network, httpsAddress)
+			if err != nil {
+				return nil, err
+			}
+			config := base.Clone()
+			config.ServerName = host
+			if name, ok := dp.ServerNames[host]; ok {
+				config.ServerName = name
+			}
+			tlsConn := tls.Client(conn, config)
+			if err := tlsConn.HandshakeContext(ctx); err != nil {
+				conn.Close()
+				return nil, err
+			}
+			return tlsConn, nil
+		},
+		TLSHandshakeTimeout: timeout,
+	}
+	return &http.Client{
+		Transport: transport,
+		Timeout:   timeout,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}, nil
+}
+
+// DataPlaneCertificate 返回数据面为主机名提供的叶子证书 - 用于检查证书有效期
+// 连接地址和 SNI 覆盖同 DataPlaneClient；只读取证书，不校验证书链，已过期或不受信任的证书同样返回
+func (fc *FastCaddy) DataPlaneCertificate(ctx context.Context, host string) (*x509.Certificate, error) {
+	dp := fc.DataPlane
+	httpsAddress, _, timeout := dp.settings()
+
+	serverName := host
+	if name, ok := dp.ServerNames[host]; ok {
+		serverName = name
+	}
+	dialer := &tls.Dialer{
+		NetDialer: &net.Dialer{Timeout: timeout},
+		Config: &tls.Config{
+			MinVersion:         tls.VersionTLS12,
+			ServerName:         serverName,
+			InsecureSkipVerify: true, // 只读取证书的有效期
+		},
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	conn, err := dialer.DialContext(ctx, "tcp", httpsAddress)
+	if err != nil {
+		return nil, fmt.Errorf("连接数据面 %s (SNI %s) 失败: %w", httpsAddress, serverName, err)
+	}
+	defer conn.Close()
+	certs := conn.(*tls.Conn).ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("数据面没有为 %s 返回证书", serverName)
+	}
+	return certs[0], nil
+}
+
+// VerifyProxy 通过数据面请求 https://host/path，检查主机名经 Caddy 代理后能否正常响应
+// 使用 DataPlaneClient，证书校验规则相同；返回响应状态码，请求失败或状态码为 5xx (如上游不可达时的 502) 时返回错误。
+// 可作为 RolloutOptions.Probe 在发布过程中检查数据面
+func (fc *FastCaddy) VerifyProxy(ctx context.Context, host, path string) (int, error) {
+	client, err := fc.DataPlaneClient()
+	if err != nil {
+		return 0, err
+	}
+	defer client.CloseIdleConnections()
+
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://"+host+path, nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("通过数据面请求 %s 失败: %w", host, err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return resp.StatusCode, fmt.Errorf("通过数据面请求 %s%s 返回 %d", host, path, resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// settings 返回填充默认值后的 HTTPS 地址、HTTP 地址和超时 - 内部辅助函数
+func (dp DataPlaneConfig) settings() (httpsAddress, httpAddress string, timeout time.Duration) {
+	httpsAddress, httpAddress, timeout = dp.Address, dp.HTTPAddress, dp.Timeout
+	if httpsAddress == "" {
+		httpsAddress = DefaultDataPlaneAddress
+	}
+	if httpAddress == "" {
+		httpAddress = DefaultDataPlaneHTTPAddress
+	}
+	if timeout <= 0 {
+		timeout = DefaultDataPlaneTimeout
+	}
+	return httpsAddress, httpAddress, timeout
+}
+
+// dataPlaneRoots 返回校验数据面证书的 CA 证书池 - 内部辅助函数
+// 需要信任内部 CA 时获取其根证书 (只获取一次) 并加入 configured (nil 表示系统根证书) 的副本
+func (fc *FastCaddy) dataPlaneRoots(configured *x509.CertPool) (*x509.CertPool, error) {
+	fc.trust.mu.Lock()
+	defer fc.trust.mu.Unlock()
+
+	if !fc.trust.internal {
+		return configured, nil
+	}
+	if fc.trust.roots == nil {
+		info, err := fc.API.GetCACertificate(api.DefaultCAID)
+		if err != nil {
+			return nil, fmt.Errorf("获取内部 CA 根证书失败: %w", err)
+		}
+		roots, err := parseCertificates([]byte(info.RootCertificate))
+		if err != nil {
+			return nil, fmt.Errorf("解析内部 CA %s 的根证书失败: %w", api.DefaultCAID, err)
+		}
+		if len(roots) == 0 {
+			return nil, fmt.Errorf("内部 CA %s 没有返回根证书", api.DefaultCAID)
+		}
+		fc.trust.roots = roots
+	}
+
+	var pool *x509.CertPool
+	if configured != nil {
+		pool = configured.Clone()
+	} else if system, err := x509.SystemCertPool(); err == nil {
+		pool = system
+	} else {
+		pool = x509.NewCertPool()
+	}
+	for _, cert := range fc.trust.roots {
+		pool.AddCert(cert)
+	}
+	return pool, nil
+}
+
+// parseCertificates 解析 PEM 内容中的全部证书 - 内部辅助函数
+func parseCertificates(data []byte) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return certs, nil
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+}
@@ -0,0 +1,58 @@
+package gofastcaddy
+
+import (
+	"context"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestVerifyProxy(t *testing.T) {
+	dataPlane := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Host != "app.example.com" {
+			w.WriteHeader(http.StatusMisdirectedRequest)
+			return
+		}
+		switch r.URL.Path {
+		case "/health":
+			w.WriteHeader(http.StatusOK)
+		default:
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer dataPlane.Close()
+	trusted := x509.NewCertPool()
+	trusted.AddCert(dataPlane.Certificate())
+
+	tests := []struct {
+		name       string
+		rootCAs    *x509.CertPool
+		path       string
+		wantStatus int
+		wantErr    bool
+	}{
+		{name: "上游正常", rootCAs: trusted, path: "/health", wantStatus: http.StatusOK},
+		{name: "省略前导斜杠", rootCAs: trusted, path: "health", wantStatus: http.StatusOK},
+		{name: "上游不可达", rootCAs: trusted, path: "/down", wantStatus: http.StatusBadGateway, wantErr: true},
+		{name: "默认校验证书", path: "/health", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fc, _ := newTestFastCaddy(t, "")
+			fc.DataPlane = DataPlaneConfig{
+				Address: dataPlane.Listener.Addr().String(),
+				RootCAs: tt.rootCAs,
+				// httptest 的证书签发给 example.com
+				ServerNames: map[string]string{"app.example.com": "example.com"},
+			}
+			status, err := fc.VerifyProxy(context.Background(), "app.example.com", tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, 期望错误 %v", err, tt.wantErr)
+			}
+			if status != tt.wantStatus {
+				t.Errorf("状态码 = %d, 期望 %d", status, tt.wantStatus)
+			}
+		})
+	}
+}
@@ -1,11 +1,18 @@
 package gofastcaddy
 
 import (
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"time"
+
 	"github.com/youfun/gofastcaddy/internal/api"
 	"github.com/youfun/gofastcaddy/internal/config"
 	"github.com/youfun/gofastcaddy/internal/routes"
 	"github.com/youfun/gofastcaddy/internal/tls"
 	"github.com/youfun/gofastcaddy/internal/utils"
+	"github.com/youfun/gofastcaddy/pkg/types"
 )
 
 // FastCaddy 主要客户端 - 提供 Caddy 配置管理的统一接口
@@ -15,15 +22,40 @@ type FastCaddy struct {
 	Config *config.Manager  // 配置管理器  
 	TLS    *tls.Manager     // TLS 管理器
 	Routes *routes.Manager  // 路由管理器
+
+	lastState     map[string]interface{} // 最近一次 GetStateHash 读取的受管配置
+	lastStateHash string                 // lastState 对应的哈希
+
+	// StateStore 保存 GetStateHash 的快照 (nil 表示只保存在内存中)，使重启后的 VerifyState 能给出逐项漂移
+	StateStore StateStore
+
+	// DataPlane 访问数据面 (实际对外服务的端口) 的设置，见 DataPlaneClient
+	DataPlane DataPlaneConfig
+
+	bg    background     // 后台组件，由 Close 统一停止
+	trust dataPlaneTrust // 数据面访问对内部 CA 的信任状态
 }
 
 // New 创建新的 FastCaddy 客户端实例
-func New() *FastCaddy {
+// 管理端点默认为 http://localhost:2019，可通过 CADDY_ADMIN_URL 环境变量或 WithBaseURL 选项覆盖；
+// 选项作用于所有子管理器共享的 API 客户端
+func New(opts ...Option) *FastCaddy {
+	return NewWithClient(api.NewClient(opts...))
+}
+
+// NewWithURL 创建指向指定管理端点的 FastCaddy 客户端实例
+func NewWithURL(baseURL string, opts ...Option) *FastCaddy {
+	return NewWithClient(api.NewClientWithURL(baseURL, opts...))
+}
+
+// NewWithClient 使用指定的 API 客户端创建 FastCaddy 实例
+// 所有子管理器共享同一个客户端，修改 fc.API 的设置会同时作用于它们
+func NewWithClient(client *api.Client) *FastCaddy {
 	return &FastCaddy{
-		API:    api.NewClient(),
-		Config: config.NewManager(),
-		TLS:    tls.NewManager(),
-		Routes: routes.NewManager(),
+		API:    client,
+		Config: config.NewManagerWithClient(client),
+		TLS:    tls.NewManagerWithClient(client),
+		Routes: routes.NewManagerWithClient(client),
 	}
 }
 
@@ -55,9 +87,17 @@ func (fc *FastCaddy) SetupCaddy(cfToken, serverName string, local bool, installT
 
 	// 初始化路由配置
 	if serverName == "" {
-		serverName = "srv0" // 默认服务器名
+		serverName = routes.DefaultServerName // 默认服务器名
 	}
-	return fc.Routes.InitRoutes(serverName, 1)
+	if err := fc.Routes.InitRoutes(serverName, 1); err != nil {
+		return err
+	}
+
+	// 本地模式使用内部证书，之后访问数据面时信任内部 CA
+	if local {
+		fc.TrustInternalCA()
+	}
+	return nil
 }
 
 // AddReverseProxy 添加反向代理 - 便利方法
@@ -78,28 +118,240 @@ func (fc *FastCaddy) AddSubReverseProxy(domain, subdomain string, ports interfac
 	return fc.Routes.AddSubReverseProxyWithPorts(domain, subdomain, ports, host)
 }
 
+// AddReverseProxyMultiHost 添加匹配多个主机名的反向代理路由 - 便利方法
+func (fc *FastCaddy) AddReverseProxyMultiHost(id string, hosts []string, toURL string) error {
+	return fc.Routes.AddReverseProxyMultiHost(id, hosts, toURL)
+}
+
+// RemoveHost 从路由中移除主机名，dropRoute 为 true 或没有其他主机名时删除整个路由 - 便利方法
+func (fc *FastCaddy) RemoveHost(host string, dropRoute bool) error {
+	return fc.Routes.RemoveHost(host, dropRoute)
+}
+
+// GetProxyTarget 可能返回的错误 - 见 routes.Manager.GetProxyTarget
+var (
+	ErrHostNotServed   = routes.ErrHostNotServed
+	ErrNotProxy        = routes.ErrNotProxy
+	ErrDynamicUpstream = routes.ErrDynamicUpstream
+)
+
+// GetProxyTarget 返回主机名当前代理到的上游地址 - 便利方法
+// 错误可通过 errors.Is 与 ErrHostNotServed、ErrNotProxy、ErrDynamicUpstream 比较
+func (fc *FastCaddy) GetProxyTarget(host string) ([]string, error) {
+	return fc.Routes.GetProxyTarget(host)
+}
+
+// LookupProxyTarget 返回主机名当前的上游地址和蓝绿发布的分组名称 - 便利方法
+func (fc *FastCaddy) LookupProxyTarget(host string) (routes.ProxyTarget, error) {
+	return fc.Routes.LookupProxyTarget(host)
+}
+
+// ResolveHost 查找处理主机名请求的路由 - 便利方法
+func (fc *FastCaddy) ResolveHost(host string) (routes.HostResolution, error) {
+	return fc.Routes.ResolveHost(host)
+}
+
+// BatchAddSubReverseProxy 批量添加子域名反向代理 - 便利方法
+// 所有子路由在一次请求中追加到通配符路由
+func (fc *FastCaddy) BatchAddSubReverseProxy(domain string, entries []routes.SubProxySpec) error {
+	return fc.Routes.BatchAddSubReverseProxy(domain, entries)
+}
+
+// SyncFromDocker 根据 Docker 容器标签同步反向代理路由 - 便利方法
+func (fc *FastCaddy) SyncFromDocker(ctx context.Context, labelPrefix string) error {
+	return fc.Routes.SyncFromDocker(ctx, labelPrefix)
+}
+
+// PruneEmptyWildcards 删除子路由已全部移除的通配符路由 - 便利方法
+// dropTLSPolicies 为 true 时同时删除只覆盖对应 *.domain 的 TLS 自动化策略
+func (fc *FastCaddy) PruneEmptyWildcards(opts routes.PruneOptions, dropTLSPolicies bool) ([]string, error) {
+	pruned, err := fc.Routes.PruneEmptyWildcardsWithOptions(opts)
+	if err != nil || opts.DryRun || !dropTLSPolicies {
+		return pruned, err
+	}
+	for _, id := range pruned {
+		domain := strings.TrimPrefix(id, routes.WildcardRoutePrefix)
+		if _, err := fc.TLS.RemoveSubjectPolicies("*." + domain); err != nil {
+			return pruned, err
+		}
+	}
+	return pruned, nil
+}
+
 // DeleteRoute 删除路由 - 便利方法
 // 通过路由 ID 删除特定路由
 func (fc *FastCaddy) DeleteRoute(id string) error {
 	return fc.Routes.DeleteByID(id)
 }
 
+// UndoDelete 恢复保留期限内被 DeleteRoute 删除的路由 - 便利方法，需要 WithDeleteGrace
+func (fc *FastCaddy) UndoDelete(id string) error {
+	return fc.Routes.UndoDelete(id)
+}
+
 // HasID 检查 ID 是否存在 - 便利方法
+//
+// Deprecated: 连接失败也会返回 false，请使用 IDExists
 func (fc *FastCaddy) HasID(id string) bool {
 	return fc.API.HasID(id)
 }
 
 // HasPath 检查路径是否存在 - 便利方法
+//
+// Deprecated: 连接失败也会返回 false，请使用 PathExists
 func (fc *FastCaddy) HasPath(path string) bool {
 	return fc.API.HasPath(path)
 }
 
+// IDExists 检查 ID 是否存在 - 便利方法，管理端点不可达时返回错误
+func (fc *FastCaddy) IDExists(id string) (bool, error) {
+	return fc.API.IDExists(id)
+}
+
+// PathExists 检查路径是否存在 - 便利方法，管理端点不可达时返回错误
+func (fc *FastCaddy) PathExists(path string) (bool, error) {
+	return fc.API.PathExists(path)
+}
+
 // GetConfig 获取配置 - 便利方法
 func (fc *FastCaddy) GetConfig(path string) (map[string]interface{}, error) {
 	return fc.API.GetConfig(path)
 }
 
+// GetConfigWithMethod 使用指定方法请求配置路径并解码响应 - 便利方法，见 api.Client.GetConfigWithMethod
+func (fc *FastCaddy) GetConfigWithMethod(path, method string) (map[string]interface{}, error) {
+	return fc.API.GetConfigWithMethod(path, method)
+}
+
+// GetConfigInto 获取配置并解码到 dest - 便利方法，dest 须为指针
+func (fc *FastCaddy) GetConfigInto(path string, dest interface{}) error {
+	return fc.API.GetConfigInto(path, dest)
+}
+
+// GetByIDInto 通过 ID 获取配置并解码到 dest - 便利方法，dest 须为指针
+// 例如 var r types.Route; fc.GetByIDInto("example.com", &r)
+func (fc *FastCaddy) GetByIDInto(id string, dest interface{}) error {
+	return fc.API.GetByIDInto(id, dest)
+}
+
 // PutConfig 设置配置 - 便利方法
+//
+// Deprecated: 请改用 PostConfig、CreateConfig 或 PatchConfig
 func (fc *FastCaddy) PutConfig(data interface{}, path, method string) error {
 	return fc.API.PutConfig(data, path, method)
 }
+
+// PutConfigIfAbsent 配置路径不存在时创建值，返回是否进行了创建 - 便利方法
+func (fc *FastCaddy) PutConfigIfAbsent(data interface{}, path string) (bool, error) {
+	return fc.API.PutConfigIfAbsent(data, path)
+}
+
+// LoadConfig 通过 /load 端点原子地替换整个配置 - 便利方法
+func (fc *FastCaddy) LoadConfig(cfg interface{}) error {
+	return fc.API.LoadConfig(cfg)
+}
+
+// AdaptCaddyfile 将 Caddyfile 转换为 JSON 配置 - 便利方法，结果可交给 LoadConfig
+func (fc *FastCaddy) AdaptCaddyfile(caddyfile string) (map[string]interface{}, error) {
+	return fc.API.AdaptCaddyfile(caddyfile)
+}
+
+// AdaptWarning Caddyfile 转换的非致命警告 - 见 api.AdaptWarning
+type AdaptWarning = api.AdaptWarning
+
+// LoadCaddyfile 将 Caddyfile 转换为 JSON 后通过 /load 加载 - 便利方法
+// 返回适配器给出的非致命警告；转换失败时不会修改当前配置
+func (fc *FastCaddy) LoadCaddyfile(caddyfile string) ([]AdaptWarning, error) {
+	cfg, warnings, err := fc.API.AdaptRaw([]byte(caddyfile))
+	if err != nil {
+		return nil, err
+	}
+	return warnings, fc.API.LoadRaw(cfg)
+}
+
+// Ping 检查 Caddy 管理端点是否可达 - 便利方法，建议在 SetupCaddy 之前调用
+func (fc *FastCaddy) Ping() error {
+	return fc.API.Ping()
+}
+
+// WaitForCaddy 等待 Caddy 管理端点就绪，最多等待 timeout (0 表示只受 ctx 限制) - 便利方法
+// 适用于 Caddy 与控制器同时启动的场景，建议在 SetupCaddy 之前调用
+func (fc *FastCaddy) WaitForCaddy(ctx context.Context, timeout time.Duration) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	return fc.API.WaitForAdmin(ctx, 0)
+}
+
+// RecordedRequests 返回试运行模式下记录的写请求 - 便利方法，需要 WithDryRun
+func (fc *FastCaddy) RecordedRequests() []RecordedRequest {
+	return fc.API.RecordedRequests()
+}
+
+// SetTimeout 修改单个请求的总超时，0 表示不限制 - 便利方法，见 api.Client.SetTimeout
+func (fc *FastCaddy) SetTimeout(d time.Duration) {
+	fc.API.SetTimeout(d)
+}
+
+// Stop 让 Caddy 优雅退出 - 便利方法，需要等待进程退出时再调用 WaitForShutdown
+func (fc *FastCaddy) Stop() error {
+	return fc.API.Stop()
+}
+
+// WaitForShutdown 阻塞直到管理端点不再响应或 ctx 结束 - 便利方法
+func (fc *FastCaddy) WaitForShutdown(ctx context.Context) error {
+	return fc.API.WaitForShutdown(ctx)
+}
+
+// ExportConfig 将完整配置的原始 JSON 写入 w - 便利方法，适合备份到文件
+func (fc *FastCaddy) ExportConfig(w io.Writer) error {
+	data, err := fc.API.GetRawConfig("/")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// GetFullConfig 获取完整的 Caddy 配置 - 便利方法
+func (fc *FastCaddy) GetFullConfig() (*types.CaddyConfig, error) {
+	return fc.API.GetFullConfig()
+}
+
+// GetApp 获取指定应用的原始 JSON 配置 - 便利方法
+func (fc *FastCaddy) GetApp(name string) (json.RawMessage, error) {
+	return fc.API.GetApp(name)
+}
+
+// DeleteConfig 删除指定配置路径的值 - 便利方法
+func (fc *FastCaddy) DeleteConfig(path string) error {
+	return fc.Config.DeletePath(path)
+}
+
+// DeleteConfigPath 删除指定配置路径的值 - 便利方法，同 DeleteConfig
+// 路径开头的 '/' 可省略，如 "apps/http/servers/srv0" 与 "/apps/http/servers/srv0" 等价
+func (fc *FastCaddy) DeleteConfigPath(path string) error {
+	return fc.DeleteConfig(path)
+}
+
+// PostConfig 设置配置（数组路径则追加） - 便利方法
+func (fc *FastCaddy) PostConfig(data interface{}, path string) error {
+	return fc.API.PostConfig(data, path)
+}
+
+// CreateConfig 创建新配置（数组索引则插入） - 便利方法
+func (fc *FastCaddy) CreateConfig(data interface{}, path string) error {
+	return fc.API.CreateConfig(data, path)
+}
+
+// AppendConfig 将 items 中的元素追加到数组路径末尾 - 便利方法
+func (fc *FastCaddy) AppendConfig(items interface{}, arrayPath string) error {
+	return fc.API.AppendConfig(items, arrayPath)
+}
+
+// PatchConfig 替换已存在的配置 - 便利方法
+func (fc *FastCaddy) PatchConfig(data interface{}, path string) error {
+	return fc.API.PatchConfig(data, path)
+}
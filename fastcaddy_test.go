@@ -0,0 +1,148 @@
+package gofastcaddy
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/youfun/gofastcaddy/pkg/clienttest"
+)
+
+func TestNewSharesAdminURL(t *testing.T) {
+	fake := clienttest.NewFakeCaddy(`{"apps":{"http":{"servers":{"srv0":{"listen":[":443"],"routes":[]}}}}}`)
+	defer fake.Close()
+	// 环境变量中的地址带有末尾斜杠
+	t.Setenv("CADDY_ADMIN", "")
+	t.Setenv("CADDY_ADMIN_URL", fake.URL+"/")
+
+	fc := New()
+	if err := fc.Routes.AddReverseProxy("app.example.com", "localhost:8080"); err != nil {
+		t.Fatal(err)
+	}
+	if err := fc.Routes.DeleteByID("app.example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if err := fc.TLS.AddTLSInternalConfig(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fc.Config.ListApps(); err != nil {
+		t.Fatal(err)
+	}
+	if err := fc.Config.DeletePath("/apps/tls"); err != nil {
+		t.Fatal(err)
+	}
+
+	// 路由、TLS、配置管理器的请求都发往同一个管理端点
+	requests := strings.Join(fake.Requests(), "\n")
+	for _, want := range []string{"DELETE /id/app.example.com/", "/config/apps/tls", "DELETE /config/apps/tls/"} {
+		if !strings.Contains(requests, want) {
+			t.Errorf("缺少请求 %s:\n%s", want, requests)
+		}
+	}
+}
+
+func TestNewHonorsCaddyAdmin(t *testing.T) {
+	fake := clienttest.NewFakeCaddy(`{"apps":{"http":{"servers":{"srv0":{"listen":[":443"],"routes":[]}}}}}`)
+	defer fake.Close()
+	// 与 caddy 命令行相同的写法，优先于 CADDY_ADMIN_URL
+	t.Setenv("CADDY_ADMIN", "tcp/"+strings.TrimPrefix(fake.URL, "http://"))
+	t.Setenv("CADDY_ADMIN_URL", "http://127.0.0.1:1")
+
+	fc := New()
+	if fc.API.BaseURL != fake.URL {
+		t.Errorf("BaseURL = %s, 期望 %s", fc.API.BaseURL, fake.URL)
+	}
+	if err := fc.TLS.AddTLSInternalConfig(); err != nil {
+		t.Fatal(err)
+	}
+	if err := fc.Routes.AddReverseProxy("app.example.com", "localhost:8080"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fc.Config.ListApps(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExportConfig(t *testing.T) {
+	const raw = "{\"apps\": {\"tls\": {}, \"http\": {}}}\n"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(raw))
+	}))
+	defer server.Close()
+
+	var buf bytes.Buffer
+	if err := New(WithBaseURL(server.URL)).ExportConfig(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != raw {
+		t.Errorf("导出 = %q, 期望 %q", buf.String(), raw)
+	}
+}
+
+func TestLoadCaddyfile(t *testing.T) {
+	const adapted = `{"apps":{"http":{"servers":{"srv0":{"listen":[":443"]}}}}}`
+	tests := []struct {
+		name      string
+		caddyfile string
+		wantErr   bool
+		wantLoad  bool
+	}{
+		{name: "转换后加载", caddyfile: "example.com {\n\treverse_proxy app:80\n}", wantLoad: true},
+		{name: "语法错误时不加载", caddyfile: "example.com {\n\tbogus\n}", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var loaded []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				switch {
+				case r.URL.Path == "/load":
+					loaded = append(loaded, string(body))
+				case strings.Contains(string(body), "bogus"):
+					http.Error(w, `{"error":"Caddyfile:2: unrecognized directive: bogus"}`, http.StatusBadRequest)
+				default:
+					w.Write([]byte(`{"result":` + adapted + `,"warnings":[{"message":"not formatted"}]}`))
+				}
+			}))
+			defer server.Close()
+
+			warnings, err := New(WithBaseURL(server.URL)).LoadCaddyfile(tt.caddyfile)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, 期望错误 %v", err, tt.wantErr)
+			}
+			if !tt.wantLoad {
+				if len(loaded) != 0 {
+					t.Errorf("转换失败后不应加载: %v", loaded)
+				}
+				return
+			}
+			if len(warnings) != 1 || warnings[0].Message != "not formatted" {
+				t.Errorf("警告 = %+v", warnings)
+			}
+			if len(loaded) != 1 || loaded[0] != adapted {
+				t.Errorf("加载的配置 = %v, 期望 [%s]", loaded, adapted)
+			}
+		})
+	}
+}
+
+func TestWaitForCaddyTimeout(t *testing.T) {
+	// 关闭后的服务器地址不再有人监听
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	start := time.Now()
+	err := New(WithBaseURL(server.URL)).WaitForCaddy(context.Background(), 100*time.Millisecond)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, 期望超时", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("超时后仍等待了 %v", elapsed)
+	}
+}
@@ -0,0 +1,115 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// CaddyfileAdapter Caddyfile 适配器名称
+const CaddyfileAdapter = "caddyfile"
+
+// AdaptWarning 适配器给出的非致命问题
+type AdaptWarning struct {
+	File      string `json:"file,omitempty"`      // 文件名
+	Line      int    `json:"line,omitempty"`      // 行号
+	Directive string `json:"directive,omitempty"` // 指令名称
+	Message   string `json:"message,omitempty"`   // 警告内容
+}
+
+// String 返回可读的警告信息
+func (w AdaptWarning) String() string {
+	var location string
+	if w.File != "" {
+		location = fmt.Sprintf("%s:%d: ", w.File, w.Line)
+	}
+	if w.Directive != "" {
+		return fmt.Sprintf("%s%s: %s", location, w.Directive, w.Message)
+	}
+	return location + w.Message
+}
+
+// AdaptResult /adapt 端点的响应
+type AdaptResult struct {
+	Config   map[string]interface{} `json:"result"`             // 转换得到的 JSON 配置
+	Warnings []AdaptWarning         `json:"warnings,omitempty"` // 非致命问题
+}
+
+// AdaptURL 返回 /adapt 端点的完整 URL
+func (c *Client) AdaptURL() string {
+	return c.BaseURL + "/adapt"
+}
+
+// AdaptCaddyfile 通过 /adapt 端点将 Caddyfile 转换为 JSON 配置
+// 结果可直接交给 LoadConfig；需要查看警告时使用 Adapt
+func (c *Client) AdaptCaddyfile(caddyfile string) (map[string]interface{}, error) {
+	result, err := c.Adapt(context.Background(), []byte(caddyfile), CaddyfileAdapter)
+	if err != nil {
+		return nil, err
+	}
+	return result.Config, nil
+}
+
+// AdaptRaw 通过 /adapt 端点转换 Caddyfile，返回 Caddy 输出的原始 JSON 配置和警告
+// 结果可直接交给 LoadRaw，避免经过 map 解码再序列化
+func (c *Client) AdaptRaw(caddyfile []byte) ([]byte, []AdaptWarning, error) {
+	result, err := c.adapt(context.Background(), caddyfile, CaddyfileAdapter)
+	if err != nil {
+		return nil, nil, err
+	}
+	return result.Config, result.Warnings, nil
+}
+
+// Adapt 通过 /adapt 端点使用指定适配器 (如 caddyfile) 转换配置，返回结果和警告
+// 转换只在 Caddy 中进行，不会修改当前运行的配置
+func (c *Client) Adapt(ctx context.Context, body []byte, adapter string) (*AdaptResult, error) {
+	raw, err := c.adapt(ctx, body, adapter)
+	if err != nil {
+		return nil, err
+	}
+	result := &AdaptResult{Warnings: raw.Warnings}
+	if err := c.decodeJSON(bytes.NewReader(raw.Config), &result.Config); err != nil {
+		return nil, fmt.Errorf("解析转换结果失败: %w", err)
+	}
+	return result, nil
+}
+
+// rawAdaptResult 未解码配置的 /adapt 响应 - 内部类型
+type rawAdaptResult struct {
+	Config   json.RawMessage `json:"result"`
+	Warnings []AdaptWarning  `json:"warnings,omitempty"`
+}
+
+// adapt 发送 /adapt 请求 - 内部辅助函数
+// 转换失败（如 Caddyfile 语法错误）时返回的 APIError 中带有 Caddy 给出的文件和行号
+func (c *Client) adapt(ctx context.Context, body []byte, adapter string) (*rawAdaptResult, error) {
+	ctx, cancel := c.operationContext(ctx, readTimeout)
+	defer cancel()
+
+	if adapter == "" {
+		return nil, fmt.Errorf("适配器名称不能为空")
+	}
+	req, err := c.newRequest(ctx, http.MethodPost, c.AdaptURL(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "text/"+strings.ToLower(adapter))
+
+	resp, err := c.send(req, body)
+	if err != nil {
+		return nil, requestError(ctx, "转换配置失败", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.newAPIError("转换配置", resp)
+	}
+
+	var result rawAdaptResult
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("解析响应 JSON 失败: %w", err)
+	}
+	return &result, nil
+}
@@ -0,0 +1,73 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// adaptServer 模拟 /adapt 端点：含有 "bogus" 指令的 Caddyfile 返回 Caddy 的 400 错误 - 测试辅助函数
+func adaptServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/adapt" || r.Header.Get("Content-Type") != "text/caddyfile" {
+			http.Error(w, `{"error":"unexpected request"}`, http.StatusBadRequest)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		if strings.Contains(string(body), "bogus") {
+			http.Error(w, `{"error":"adapting config using caddyfile: Caddyfile:2: unrecognized directive: bogus"}`, http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"result":{"apps":{"http":{"servers":{"srv0":{"listen":[":443"]}}}}},` +
+			`"warnings":[{"file":"Caddyfile","line":1,"message":"input is not formatted with 'caddy fmt'"}]}`))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestAdapt(t *testing.T) {
+	c := NewClientWithURL(adaptServer(t).URL)
+
+	raw, warnings, err := c.AdaptRaw([]byte("example.com {\n\treverse_proxy app:80\n}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(raw), `{"apps":{"http":{"servers":{"srv0":{"listen":[":443"]}}}}}`; got != want {
+		t.Errorf("AdaptRaw = %s, 期望 %s", got, want)
+	}
+	want := []AdaptWarning{{File: "Caddyfile", Line: 1, Message: "input is not formatted with 'caddy fmt'"}}
+	if !reflect.DeepEqual(warnings, want) {
+		t.Errorf("警告 = %+v, 期望 %+v", warnings, want)
+	}
+	if got := warnings[0].String(); got != "Caddyfile:1: input is not formatted with 'caddy fmt'" {
+		t.Errorf("警告信息 = %q", got)
+	}
+
+	cfg, err := c.AdaptCaddyfile("example.com {\n\treverse_proxy app:80\n}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cfg["apps"].(map[string]interface{})["http"]; !ok {
+		t.Errorf("AdaptCaddyfile = %v", cfg)
+	}
+}
+
+func TestAdaptMalformedCaddyfile(t *testing.T) {
+	c := NewClientWithURL(adaptServer(t).URL)
+
+	_, _, err := c.AdaptRaw([]byte("example.com {\n\tbogus\n}"))
+	if err == nil {
+		t.Fatal("期望转换失败")
+	}
+	// 错误信息带有 Caddy 给出的文件、行号和原因
+	if !strings.Contains(err.Error(), "Caddyfile:2: unrecognized directive: bogus") {
+		t.Errorf("错误信息 = %v", err)
+	}
+	if !IsBadRequest(err) {
+		t.Errorf("期望 400 错误: %v", err)
+	}
+}
@@ -0,0 +1,102 @@
+package api
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"os"
+)
+
+// AdminTLSOptions 连接 https:// 管理端点的 TLS 设置 - 见 WithAdminTLS
+// 证书、私钥和 CA 可以从文件读取，也可以直接提供 PEM 内容；同时设置时以 PEM 内容为准
+type AdminTLSOptions struct {
+	CertFile string // 客户端证书文件 (mTLS)
+	KeyFile  string // 客户端私钥文件 (mTLS)
+	CertPEM  []byte // 客户端证书 PEM 内容
+	KeyPEM   []byte // 客户端私钥 PEM 内容
+
+	CAFile string // 校验管理端点证书的 CA 文件 (为空表示使用系统根证书)
+	CAPEM  []byte // 校验管理端点证书的 CA PEM 内容
+
+	ServerName string // 校验证书时使用的主机名 (为空表示使用 URL 中的主机名，管理端点以 IP 访问时需要设置)
+
+	// InsecureSkipVerify 不校验管理端点的证书 - 仅用于测试环境，连接可被中间人窃听
+	InsecureSkipVerify bool
+}
+
+// Config 根据选项生成 tls.Config - 证书或 CA 无法读取或解析时返回错误
+func (o AdminTLSOptions) Config() (*tls.Config, error) {
+	config := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         o.ServerName,
+		InsecureSkipVerify: o.InsecureSkipVerify,
+	}
+
+	certPEM, keyPEM := o.CertPEM, o.KeyPEM
+	if certPEM == nil && o.CertFile != "" {
+		data, err := os.ReadFile(o.CertFile)
+		if err != nil {
+			return nil, fmt.Errorf("读取客户端证书失败: %w", err)
+		}
+		certPEM = data
+	}
+	if keyPEM == nil && o.KeyFile != "" {
+		data, err := os.ReadFile(o.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("读取客户端私钥失败: %w", err)
+		}
+		keyPEM = data
+	}
+	if (certPEM == nil) != (keyPEM == nil) {
+		return nil, fmt.Errorf("客户端证书和私钥需要同时设置")
+	}
+	if certPEM != nil {
+		cert, err := tls.X509KeyPair(certPEM, keyPEM)
+		if err != nil {
+			return nil, fmt.Errorf("解析客户端证书失败: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	caPEM := o.CAPEM
+	if caPEM == nil && o.CAFile != "" {
+		data, err := os.ReadFile(o.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("读取 CA 证书失败: %w", err)
+		}
+		caPEM = data
+	}
+	if caPEM != nil {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("CA 证书中没有有效的 PEM 证书")
+		}
+		config.RootCAs = pool
+	}
+	return config, nil
+}
+
+// WithAdminTLS 按 opts 生成 TLS 配置连接 https:// 管理端点，见 AdminTLSOptions
+// 与 WithTLSConfig 相同，只对 *http.Transport 类型的传输层生效；
+// 证书或 CA 无效时每个 https:// 请求都会返回该错误，需要立即检查时可先调用 opts.Config
+func WithAdminTLS(opts AdminTLSOptions) Option {
+	return func(c *Client) {
+		config, err := opts.Config()
+		if err != nil {
+			c.tlsConfig = nil
+			c.tlsErr = err
+			return
+		}
+		c.tlsConfig = config
+		c.tlsErr = nil
+	}
+}
+
+// failingTLSDialer 返回总是失败的 TLS 拨号函数，用于报告 WithAdminTLS 的配置错误 - 内部辅助函数
+func failingTLSDialer(err error) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(context.Context, string, string) (net.Conn, error) {
+		return nil, fmt.Errorf("管理端点 TLS 配置无效: %w", err)
+	}
+}
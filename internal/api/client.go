@@ -2,28 +2,189 @@ package api
 
 import (
 	"bytes"
+	"context"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"regexp"
 	"strings"
+	"sync"
 	"time"
+
+	"github.com/youfun/gofastcaddy/internal/utils"
+	"github.com/youfun/gofastcaddy/pkg/types"
 )
 
+// Version 库版本号 - 发布时通过 -ldflags "-X github.com/youfun/gofastcaddy/internal/api.Version=v1.2.3" 设置
+var Version = "dev"
+
 // Client Caddy API 客户端 - 封装与 Caddy REST API 的交互
 type Client struct {
 	BaseURL    string       // Caddy API 基础 URL (默认: http://localhost:2019)
 	HTTPClient *http.Client // HTTP 客户端
+
+	// Retry 传输层错误（连接被拒绝、超时等）的重试策略
+	// 只重试 GET、PUT、PATCH 和带有 If-Match 的 POST；其他 POST 和 DELETE 可能已被处理，不会重试
+	Retry RetryPolicy
+	// RetryStatuses 按 Retry 策略重试的响应状态码 (为空表示不按状态码重试)，见 WithRetryStatuses
+	RetryStatuses []int
+	// WriteRetry 配置写入冲突的重试策略，仅在 PUT/PATCH (以及带有 If-Match 的 POST) 收到 409、503
+	// 或包含 ConfigChangingMessage 的错误响应（Caddy 正在重载配置）时生效。两种策略独立计数、互不消耗对方的次数，
+	// 因此一次写操作最多发送 1 + Retry.MaxRetries + WriteRetry.MaxRetries 个请求
+	WriteRetry RetryPolicy
+	// ReadTimeout、WriteTimeout、LoadTimeout 分别为读操作、写操作和大体积上传的默认超时，
+	// 仅在调用方的 ctx 没有截止时间时生效 (0 表示不设置)。
+	// 三者都受 HTTPClient.Timeout 的总体限制，需要更长的超时时应同时调大或清零 HTTPClient.Timeout
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	LoadTimeout  time.Duration
+
+	// MaxBodySize 请求体大小上限（字节），超过时拒绝发送而不是占满控制进程的内存 (0 表示不限制)
+	MaxBodySize int64
+
+	// UseNumber 读取配置时将 JSON 数字解码为 json.Number 而不是 float64，
+	// 避免超过 2^53 的整数（如以纳秒表示的时长、max_size）在读改写时丢失精度；
+	// json.Number 在写回时按原样输出
+	UseNumber bool
+
+	// VerifyWrites 写入成功后读回目标路径并校验内容，见 WithVerifyWrites
+	VerifyWrites bool
+
+	// Marshal 请求体的序列化函数 (nil 表示使用 CompactMarshal)，见 WithMarshaler
+	Marshal MarshalFunc
+
+	// Debug 每次管理 API 调用的调试回调 (nil 表示关闭)，DebugBodyLimit 为调试事件中请求体和响应体的截断长度
+	Debug          DebugHook
+	DebugBodyLimit int
+	// DebugIndent 为 true 时调试事件中的 JSON 请求体以缩进格式输出，不影响实际发送的内容，见 WithDebugIndent
+	DebugIndent bool
+
+	// Logger 每次管理 API 调用的日志回调 (nil 表示关闭)，见 WithLogger
+	Logger RequestLogger
+
+	// CompressRequests 使用 gzip 压缩长度不小于 CompressThreshold (0 表示 DefaultCompressThreshold) 的请求体，
+	// 见 WithRequestCompression；MaxBodySize 按压缩前的长度计算
+	CompressRequests  bool
+	CompressThreshold int
+
+	// RedactedKeys 在错误信息和调试事件中脱敏的 JSON 键 (nil 表示使用 DefaultRedactedKeys)，见 WithRedactedKeys
+	RedactedKeys []string
+
+	// Instrumentation 每次管理 API 调用的指标回调 (nil 表示关闭)，见 WithInstrumentation
+	Instrumentation Instrumentation
+
+	// DryRun 试运行记录器 (nil 表示正常发送请求)，见 WithDryRun
+	DryRun *DryRunRecorder
+
+	// DeleteGrace 路由删除后可撤销的保留期限 (0 表示直接删除)，见 WithDeleteGrace
+	DeleteGrace time.Duration
+
+	// OptimisticConcurrency 读取-修改-写回时使用 ETag/If-Match 检测并发修改，见 WithOptimisticConcurrency
+	OptimisticConcurrency bool
+	// Provenance 为 fastcaddy 创建的路由写入来源信息 (版本、创建时间、操作)，见 WithProvenance
+	Provenance bool
+	// UpdateTimestamps 在路由的创建和修改请求中写入最后修改时间，见 WithUpdateTimestamps
+	UpdateTimestamps bool
+
+	// OptimisticRetries 乐观并发冲突 (412) 时的最大重试次数 (0 表示使用 DefaultOptimisticRetries)
+	OptimisticRetries int
+
+	proxy   proxyFunc      // 选项设置的代理策略 (nil 表示使用默认的环境变量代理)
+	timeout *time.Duration // 选项设置的请求超时 (nil 表示保持 HTTP 客户端的设置)
+	socket  string         // 管理端点的 unix 套接字路径 (为空表示使用 TCP)
+
+	tlsConfig *tls.Config // 选项设置的 TLS 配置 (nil 表示使用传输层的默认设置)
+	tlsErr    error       // WithAdminTLS 生成 TLS 配置时的错误，https:// 请求时返回
+
+	maxIdleConns    int            // 选项设置的最大空闲连接数 (0 表示保持传输层的设置)
+	idleConnTimeout time.Duration  // 选项设置的空闲连接保留时长 (0 表示保持传输层的设置)
+	keepAlive       *time.Duration // 选项设置的 TCP keep-alive 间隔 (nil 表示保持传输层的设置)
+
+	writeMu       sync.Mutex  // 多步写操作的锁，见 LockWrites
+	sharedWriteMu *sync.Mutex // Clone 的副本与原客户端共用的写锁 (nil 表示使用 writeMu)
+
+	httpClientMu sync.RWMutex // 保护 SetTimeout 对 HTTPClient 的替换
+
+	redactMu      sync.Mutex     // 保护 redactPattern
+	redactPattern *regexp.Regexp // 由 RedactedKeys 编译的脱敏正则 (nil 表示尚未编译)
+
+	middleware []Middleware // Use 注册的中间件，按注册顺序由外向内
+
+	headers   http.Header // 选项设置的自定义请求头
+	authToken string      // 选项设置的 Bearer 令牌
+
+	basicAuth *basicAuth // 选项设置的 HTTP Basic 认证信息 (nil 表示不发送)
 }
 
+// 常量定义 - 管理端点地址
+const (
+	DefaultBaseURL = "http://localhost:2019" // 默认的 Caddy 管理端点
+	AdminEnv       = utils.AdminEnv          // 与 caddy 命令行共用的管理端点地址环境变量
+	AdminURLEnv    = utils.AdminURLEnv       // 覆盖管理端点地址的环境变量
+)
+
 // NewClient 创建新的 Caddy API 客户端
-func NewClient() *Client {
-	return &Client{
-		BaseURL: "http://localhost:2019",
+// 依次使用 CADDY_ADMIN、CADDY_ADMIN_URL 环境变量中的地址 (见 utils.GetAdminAddress)，
+// 都未设置时使用 http://localhost:2019
+func NewClient(opts ...Option) *Client {
+	baseURL := DefaultBaseURL
+	if env := utils.GetAdminAddress(); env != "" {
+		baseURL = NormalizeBaseURL(env)
+	}
+	return NewClientWithURL(baseURL, opts...)
+}
+
+// NewClientWithURL 使用指定的管理端点地址创建 Caddy API 客户端
+// 地址会经过 NormalizeBaseURL 规范化，如 caddy:2020/ 变为 http://caddy:2020；
+// 与 Caddy 的 admin 配置相同，unix//run/caddy/admin.sock 表示通过 unix 套接字连接
+// (只在创建时解析，之后直接修改 BaseURL 为 unix/ 地址不会生效)
+func NewClientWithURL(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		BaseURL: NormalizeBaseURL(baseURL),
 		HTTPClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
 	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.socket == "" && strings.HasPrefix(c.BaseURL, UnixSocketPrefix) {
+		c.socket = strings.TrimPrefix(c.BaseURL, UnixSocketPrefix)
+	}
+	if c.socket != "" {
+		c.BaseURL = unixBaseURL
+	}
+	c.applyTransport()
+	if c.timeout != nil && c.HTTPClient.Timeout != *c.timeout {
+		client := *c.HTTPClient
+		client.Timeout = *c.timeout
+		c.HTTPClient = &client
+	}
+	return c
+}
+
+// NormalizeBaseURL 规范化管理端点地址
+// 缺少 scheme 时补充 http://，并去掉末尾的斜杠；空字符串返回默认地址，unix/ 地址保持不变。
+// 同时接受 caddy 管理地址的写法：tcp/ 前缀会被去掉，:2019 这样省略主机的地址使用 localhost
+func NormalizeBaseURL(baseURL string) string {
+	baseURL = strings.TrimSpace(baseURL)
+	if baseURL == "" {
+		return DefaultBaseURL
+	}
+	if strings.HasPrefix(baseURL, UnixSocketPrefix) {
+		return baseURL
+	}
+	baseURL = strings.TrimPrefix(baseURL, "tcp/")
+	if strings.HasPrefix(baseURL, ":") {
+		baseURL = "localhost" + baseURL
+	}
+	if !strings.Contains(baseURL, "://") {
+		baseURL = "http://" + baseURL
+	}
+	return strings.TrimRight(baseURL, "/")
 }
 
 // GetIDURL 根据路径生成 ID 的完整 URL - 用于通过 ID 访问配置
@@ -36,7 +197,7 @@ func (c *Client) GetIDURL(path string) string {
 	if !strings.HasSuffix(path, "/") {
 		path = path + "/"
 	}
-	return fmt.Sprintf("%s/id%s", c.BaseURL, path)
+	return fmt.Sprintf("%s/id%s", c.BaseURL, escapePath(path))
 }
 
 // GetConfigURL 根据路径生成配置的完整 URL - 用于访问配置路径
@@ -49,24 +210,42 @@ func (c *Client) GetConfigURL(path string) string {
 	if !strings.HasSuffix(path, "/") {
 		path = path + "/"
 	}
-	return fmt.Sprintf("%s/config%s", c.BaseURL, path)
+	return fmt.Sprintf("%s/config%s", c.BaseURL, escapePath(path))
+}
+
+// escapePath 对路径的每一段进行 URL 转义，保留 '/' 分隔符 - 内部辅助函数
+// 使包含空格、'#'、'%' 或非 ASCII 字符的 ID 能够正确传递给 Caddy
+func escapePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
 }
 
 // GetByID 通过 ID 获取配置 - 对应 Python 的 gid(path) 函数
 func (c *Client) GetByID(path string) (map[string]interface{}, error) {
+	return c.GetByIDContext(context.Background(), path)
+}
+
+// GetByIDContext 通过 ID 获取配置 - 支持取消和超时的 GetByID
+func (c *Client) GetByIDContext(ctx context.Context, path string) (map[string]interface{}, error) {
+	ctx, cancel := c.operationContext(ctx, readTimeout)
+	defer cancel()
+
 	url := c.GetIDURL(path)
-	resp, err := c.HTTPClient.Get(url)
+	resp, err := c.do(ctx, http.MethodGet, url, nil)
 	if err != nil {
-		return nil, fmt.Errorf("获取 ID 配置失败: %w", err)
+		return nil, requestError(ctx, "获取 ID 配置失败", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("获取 ID 配置失败, 状态码: %d", resp.StatusCode)
+		return nil, c.newAPIError("获取 ID 配置", resp)
 	}
 
 	var result map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+	if err := c.decodeJSON(resp.Body, &result); err != nil {
 		return nil, fmt.Errorf("解析响应 JSON 失败: %w", err)
 	}
 
@@ -75,108 +254,590 @@ func (c *Client) GetByID(path string) (map[string]interface{}, error) {
 
 // GetConfig 获取指定路径的配置 - 对应 Python 的 gcfg(path, method) 函数
 func (c *Client) GetConfig(path string) (map[string]interface{}, error) {
+	return c.GetConfigContext(context.Background(), path)
+}
+
+// GetConfigContext 获取指定路径的配置 - 支持取消和超时的 GetConfig
+func (c *Client) GetConfigContext(ctx context.Context, path string) (map[string]interface{}, error) {
+	ctx, cancel := c.operationContext(ctx, readTimeout)
+	defer cancel()
+
 	url := c.GetConfigURL(path)
-	resp, err := c.HTTPClient.Get(url)
+	resp, err := c.do(ctx, http.MethodGet, url, nil)
 	if err != nil {
-		return nil, fmt.Errorf("获取配置失败: %w", err)
+		return nil, requestError(ctx, "获取配置失败", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("获取配置失败, 状态码: %d", resp.StatusCode)
+		return nil, c.newAPIError("获取配置", resp)
 	}
 
 	var result map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+	if err := c.decodeJSON(resp.Body, &result); err != nil {
 		return nil, fmt.Errorf("解析响应 JSON 失败: %w", err)
 	}
 
 	return result, nil
 }
 
+// GetConfigWithMethod 使用指定方法请求配置路径并解码响应 - 对应 Python 的 gcfg(path, method) 函数
+// method 为空或 GET 时等同于 GetConfig。Caddy 自身只通过 GET 读取配置，
+// 非 GET 的读取只在管理端点前的网关要求特定方法 (如只放行 POST 的 POST-as-GET 网关) 时需要；
+// 响应体为空时返回 nil
+func (c *Client) GetConfigWithMethod(path, method string) (map[string]interface{}, error) {
+	return c.GetConfigWithMethodContext(context.Background(), path, method)
+}
+
+// GetConfigWithMethodContext 支持取消和超时的 GetConfigWithMethod
+func (c *Client) GetConfigWithMethodContext(ctx context.Context, path, method string) (map[string]interface{}, error) {
+	if method == "" || strings.EqualFold(method, http.MethodGet) {
+		return c.GetConfigContext(ctx, path)
+	}
+
+	data, err := c.sendRequestWithResponse(ctx, method, c.GetConfigURL(path), nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, nil
+	}
+	var result map[string]interface{}
+	if err := c.decodeJSON(bytes.NewReader(data), &result); err != nil {
+		return nil, fmt.Errorf("解析响应 JSON 失败: %w", err)
+	}
+	return result, nil
+}
+
 // HasID 检查指定 ID 是否已设置 - 对应 Python 的 has_id(id) 函数
+//
+// Deprecated: 任何错误 (包括连接失败) 都视为不存在，请使用 IDExists
 func (c *Client) HasID(id string) bool {
-	_, err := c.GetByID(id)
+	return c.HasIDContext(context.Background(), id)
+}
+
+// HasIDContext 检查指定 ID 是否已设置 - 支持取消和超时的 HasID
+func (c *Client) HasIDContext(ctx context.Context, id string) bool {
+	_, err := c.GetByIDContext(ctx, id)
 	return err == nil
 }
 
 // HasPath 检查指定路径是否已设置 - 对应 Python 的 has_path(path) 函数
+//
+// Deprecated: 任何错误 (包括连接失败) 都视为不存在，请使用 PathExists
 func (c *Client) HasPath(path string) bool {
-	_, err := c.GetConfig(path)
+	return c.HasPathContext(context.Background(), path)
+}
+
+// HasPathContext 检查指定路径是否已设置 - 支持取消和超时的 HasPath
+func (c *Client) HasPathContext(ctx context.Context, path string) bool {
+	_, err := c.GetConfigContext(ctx, path)
 	return err == nil
 }
 
+// IDExists 检查指定 ID 是否已设置 - 404 或无效遍历路径 (400) 视为不存在，其他失败返回错误
+func (c *Client) IDExists(id string) (bool, error) {
+	return c.IDExistsContext(context.Background(), id)
+}
+
+// IDExistsContext 支持取消和超时的 IDExists
+func (c *Client) IDExistsContext(ctx context.Context, id string) (bool, error) {
+	return c.exists(ctx, "获取 ID 配置", c.GetIDURL(id))
+}
+
+// PathExists 检查指定路径是否已设置 - 404、值为 null 或中间的键不存在 (400 invalid traversal path) 视为不存在，
+// 其他失败返回错误
+func (c *Client) PathExists(path string) (bool, error) {
+	return c.PathExistsContext(context.Background(), path)
+}
+
+// PathExistsContext 支持取消和超时的 PathExists
+func (c *Client) PathExistsContext(ctx context.Context, path string) (bool, error) {
+	return c.exists(ctx, "获取配置", c.GetConfigURL(path))
+}
+
+// exists 读取 URL 判断配置是否存在 - 内部辅助函数
+func (c *Client) exists(ctx context.Context, op, url string) (bool, error) {
+	data, err := c.getRaw(ctx, op, url)
+	if IsNotFound(err) || IsInvalidPath(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	data = bytes.TrimSpace(data)
+	return len(data) > 0 && !bytes.Equal(data, []byte("null")), nil
+}
+
 // PutByID 将配置数据放入指定 ID 路径 - 对应 Python 的 pid(d, path, method) 函数
+// method 只接受 POST、PUT、PATCH，其他方法直接返回错误
+//
+// Deprecated: method 参数容易误用，请改用 PostByID、CreateByID 或 PatchByID
 func (c *Client) PutByID(data interface{}, path, method string) error {
+	return c.PutByIDContext(context.Background(), data, path, method)
+}
+
+// PutByIDContext 将配置数据放入指定 ID 路径 - 支持取消和超时的 PutByID
+//
+// Deprecated: method 参数容易误用，请改用 PostByIDContext、CreateByIDContext 或 PatchByIDContext
+func (c *Client) PutByIDContext(ctx context.Context, data interface{}, path, method string) error {
+	m, err := writeMethod(method)
+	if err != nil {
+		return err
+	}
 	url := c.GetIDURL(path)
-	return c.sendRequest(method, url, data)
+	return c.sendRequest(ctx, m, url, data)
 }
 
 // PutConfig 将配置数据放入指定配置路径 - 对应 Python 的 pcfg(d, path, method) 函数
+// method 只接受 POST、PUT、PATCH，其他方法直接返回错误
+//
+// Deprecated: method 参数容易误用，请改用 PostConfig、CreateConfig 或 PatchConfig
 func (c *Client) PutConfig(data interface{}, path, method string) error {
+	return c.PutConfigContext(context.Background(), data, path, method)
+}
+
+// PutConfigContext 将配置数据放入指定配置路径 - 支持取消和超时的 PutConfig
+//
+// Deprecated: method 参数容易误用，请改用 PostConfigContext、CreateConfigContext 或 PatchConfigContext
+func (c *Client) PutConfigContext(ctx context.Context, data interface{}, path, method string) error {
+	m, err := writeMethod(method)
+	if err != nil {
+		return err
+	}
 	url := c.GetConfigURL(path)
-	return c.sendRequest(method, url, data)
+	return c.sendRequest(ctx, m, url, data)
+}
+
+// PutConfigResponse 将配置数据放入指定配置路径并返回 Caddy 的原始响应体
+// method 只接受 POST、PUT、PATCH；Caddy 对大多数写操作返回空响应体
+func (c *Client) PutConfigResponse(data interface{}, path, method string) ([]byte, error) {
+	return c.PutConfigResponseContext(context.Background(), data, path, method)
+}
+
+// PutConfigResponseContext 支持取消和超时的 PutConfigResponse
+func (c *Client) PutConfigResponseContext(ctx context.Context, data interface{}, path, method string) ([]byte, error) {
+	m, err := writeMethod(method)
+	if err != nil {
+		return nil, err
+	}
+	return c.sendRequestWithResponse(ctx, m, c.GetConfigURL(path), data)
+}
+
+// PutConfigResult 将配置数据放入指定配置路径并返回解码后的响应
+// 响应体为空时返回 nil；method 只接受 POST、PUT、PATCH
+func (c *Client) PutConfigResult(data interface{}, path, method string) (map[string]interface{}, error) {
+	return c.PutConfigResultContext(context.Background(), data, path, method)
+}
+
+// PutConfigResultContext 支持取消和超时的 PutConfigResult
+func (c *Client) PutConfigResultContext(ctx context.Context, data interface{}, path, method string) (map[string]interface{}, error) {
+	body, err := c.PutConfigResponseContext(ctx, data, path, method)
+	if err != nil {
+		return nil, err
+	}
+	return c.decodeResult(body)
+}
+
+// PutByIDResult 将配置数据放入指定 ID 路径并返回解码后的响应
+// 响应体为空时返回 nil；method 只接受 POST、PUT、PATCH
+func (c *Client) PutByIDResult(data interface{}, path, method string) (map[string]interface{}, error) {
+	return c.PutByIDResultContext(context.Background(), data, path, method)
+}
+
+// PutByIDResultContext 支持取消和超时的 PutByIDResult
+func (c *Client) PutByIDResultContext(ctx context.Context, data interface{}, path, method string) (map[string]interface{}, error) {
+	m, err := writeMethod(method)
+	if err != nil {
+		return nil, err
+	}
+	body, err := c.sendRequestWithResponse(ctx, m, c.GetIDURL(path), data)
+	if err != nil {
+		return nil, err
+	}
+	return c.decodeResult(body)
+}
+
+// PostConfig 设置配置路径的值 - 对象不存在则创建、存在则替换；路径指向数组时追加元素
+func (c *Client) PostConfig(data interface{}, path string) error {
+	return c.PostConfigContext(context.Background(), data, path)
+}
+
+// PostConfigContext 支持取消和超时的 PostConfig
+func (c *Client) PostConfigContext(ctx context.Context, data interface{}, path string) error {
+	return c.sendRequest(ctx, http.MethodPost, c.GetConfigURL(path), data)
+}
+
+// CreateConfig 在配置路径创建新值 - 对象已存在时 Caddy 会报错；路径指向数组索引时在该位置插入
+func (c *Client) CreateConfig(data interface{}, path string) error {
+	return c.CreateConfigContext(context.Background(), data, path)
+}
+
+// CreateConfigContext 支持取消和超时的 CreateConfig
+func (c *Client) CreateConfigContext(ctx context.Context, data interface{}, path string) error {
+	return c.sendRequest(ctx, http.MethodPut, c.GetConfigURL(path), data)
+}
+
+// PutConfigIfAbsent 配置路径不存在时创建值，返回是否进行了创建
+// 路径已存在 (包括值为 null 以外的任何值) 时不做任何写入；检查与创建之间被其他进程抢先创建时
+// Caddy 返回 409，同样视为已存在。管理端点不可达时返回错误而不是当作不存在。
+// 根路径 "/" 在新启动的 Caddy 中值为 null，Caddy 拒绝对其 PUT，因此根路径改用 POST 设置
+func (c *Client) PutConfigIfAbsent(data interface{}, path string) (bool, error) {
+	return c.PutConfigIfAbsentContext(context.Background(), data, path)
+}
+
+// PutConfigIfAbsentContext 支持取消和超时的 PutConfigIfAbsent
+func (c *Client) PutConfigIfAbsentContext(ctx context.Context, data interface{}, path string) (bool, error) {
+	exists, err := c.PathExistsContext(ctx, path)
+	if err != nil || exists {
+		return false, err
+	}
+	if strings.Trim(path, "/") == "" {
+		err = c.PostConfigContext(ctx, data, path)
+	} else {
+		err = c.CreateConfigContext(ctx, data, path)
+	}
+	if IsConflict(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// PatchConfig 替换配置路径上已存在的值 - 路径不存在时 Caddy 会报错
+func (c *Client) PatchConfig(data interface{}, path string) error {
+	return c.PatchConfigContext(context.Background(), data, path)
+}
+
+// PatchConfigContext 支持取消和超时的 PatchConfig
+func (c *Client) PatchConfigContext(ctx context.Context, data interface{}, path string) error {
+	return c.sendRequest(ctx, http.MethodPatch, c.GetConfigURL(path), data)
+}
+
+// PostByID 设置 ID 路径的值 - 语义同 PostConfig
+func (c *Client) PostByID(data interface{}, path string) error {
+	return c.PostByIDContext(context.Background(), data, path)
+}
+
+// PostByIDContext 支持取消和超时的 PostByID
+func (c *Client) PostByIDContext(ctx context.Context, data interface{}, path string) error {
+	return c.sendRequest(ctx, http.MethodPost, c.GetIDURL(path), data)
+}
+
+// AppendConfig 将 items 中的元素逐个追加到 arrayPath 指向的数组末尾
+// 自动添加 Caddy 的 "/..." 展开后缀并使用 POST；items 必须序列化为 JSON 数组
+func (c *Client) AppendConfig(items interface{}, arrayPath string) error {
+	return c.AppendConfigContext(context.Background(), items, arrayPath)
+}
+
+// AppendConfigContext 支持取消和超时的 AppendConfig
+func (c *Client) AppendConfigContext(ctx context.Context, items interface{}, arrayPath string) error {
+	if err := checkArray(items); err != nil {
+		return err
+	}
+	return c.PostConfigContext(ctx, items, appendPath(arrayPath))
+}
+
+// AppendByID 将 items 中的元素逐个追加到 ID 路径指向的数组末尾 - 语义同 AppendConfig
+// 例如 c.AppendByID(routes, "wildcard-example.com/handle/0/routes")
+func (c *Client) AppendByID(items interface{}, idPath string) error {
+	return c.AppendByIDContext(context.Background(), items, idPath)
+}
+
+// AppendByIDContext 支持取消和超时的 AppendByID
+func (c *Client) AppendByIDContext(ctx context.Context, items interface{}, idPath string) error {
+	if err := checkArray(items); err != nil {
+		return err
+	}
+	return c.PostByIDContext(ctx, items, appendPath(idPath))
+}
+
+// appendPath 为数组路径添加 "/..." 展开后缀，已有后缀或以斜杠结尾时不重复添加 - 内部辅助函数
+func appendPath(path string) string {
+	path = strings.TrimRight(path, "/")
+	if strings.HasSuffix(path, "/...") || path == "..." {
+		return path
+	}
+	return path + "/..."
+}
+
+// checkArray 检查追加的数据是否序列化为 JSON 数组 - 内部辅助函数
+func checkArray(items interface{}) error {
+	data, err := json.Marshal(items)
+	if err != nil {
+		return fmt.Errorf("序列化请求数据失败: %w", err)
+	}
+	if !bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) {
+		return fmt.Errorf("追加的数据必须是数组, 实际为 %T", items)
+	}
+	return nil
+}
+
+// CreateByID 在 ID 路径创建新值 - 语义同 CreateConfig
+func (c *Client) CreateByID(data interface{}, path string) error {
+	return c.CreateByIDContext(context.Background(), data, path)
+}
+
+// CreateByIDContext 支持取消和超时的 CreateByID
+func (c *Client) CreateByIDContext(ctx context.Context, data interface{}, path string) error {
+	return c.sendRequest(ctx, http.MethodPut, c.GetIDURL(path), data)
+}
+
+// PatchByID 替换 ID 路径上已存在的值 - 语义同 PatchConfig
+func (c *Client) PatchByID(data interface{}, path string) error {
+	return c.PatchByIDContext(context.Background(), data, path)
+}
+
+// PatchByIDContext 支持取消和超时的 PatchByID
+func (c *Client) PatchByIDContext(ctx context.Context, data interface{}, path string) error {
+	return c.sendRequest(ctx, http.MethodPatch, c.GetIDURL(path), data)
 }
 
 // DeleteByID 删除指定 ID 的配置 - 对应 Python 的 del_id(id) 函数
 func (c *Client) DeleteByID(id string) error {
-	url := c.GetIDURL(id)
-	req, err := http.NewRequest("DELETE", url, nil)
-	if err != nil {
-		return fmt.Errorf("创建删除请求失败: %w", err)
+	return c.DeleteByIDContext(context.Background(), id)
+}
+
+// DeleteByIDContext 删除指定 ID 的配置 - 支持取消和超时的 DeleteByID
+func (c *Client) DeleteByIDContext(ctx context.Context, id string) error {
+	ctx, cancel := c.operationContext(ctx, writeTimeout)
+	defer cancel()
+
+	return c.delete(ctx, c.GetIDURL(id))
+}
+
+// DeleteConfig 删除指定配置路径的值 - 路径可包含数组下标，如 /apps/http/servers/srv0/routes/3
+// 路径不存在时返回 "路径不存在" 错误，仍可通过 IsNotFound 识别
+func (c *Client) DeleteConfig(path string) error {
+	return c.DeleteConfigContext(context.Background(), path)
+}
+
+// DeleteConfigContext 删除指定配置路径的值 - 支持取消和超时的 DeleteConfig
+func (c *Client) DeleteConfigContext(ctx context.Context, path string) error {
+	ctx, cancel := c.operationContext(ctx, writeTimeout)
+	defer cancel()
+
+	if err := c.delete(ctx, c.GetConfigURL(path)); err != nil {
+		if IsNotFound(err) {
+			return fmt.Errorf("路径不存在: %s: %w", path, err)
+		}
+		return err
 	}
+	return nil
+}
 
-	resp, err := c.HTTPClient.Do(req)
+// delete 发送 DELETE 请求，200 和 204 视为成功 - 内部辅助函数
+func (c *Client) delete(ctx context.Context, url string) error {
+	resp, err := c.do(ctx, http.MethodDelete, url, nil)
 	if err != nil {
-		return fmt.Errorf("发送删除请求失败: %w", err)
+		return requestError(ctx, "发送删除请求失败", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
-		return fmt.Errorf("删除配置失败, 状态码: %d", resp.StatusCode)
+		return c.newAPIError("删除配置", resp)
 	}
 
 	return nil
 }
 
+// writeMethod 校验写操作使用的 HTTP 方法 - 内部辅助函数
+// GET 等只读方法携带请求体发给 Caddy 不会产生任何效果，因此直接拒绝
+func writeMethod(method string) (string, error) {
+	m := strings.ToUpper(method)
+	switch m {
+	case http.MethodPost, http.MethodPut, http.MethodPatch:
+		return m, nil
+	default:
+		return "", fmt.Errorf("不支持的写操作方法: %q (仅允许 POST、PUT、PATCH)", method)
+	}
+}
+
 // sendRequest 发送 HTTP 请求的通用方法 - 内部辅助函数
-func (c *Client) sendRequest(method, url string, data interface{}) error {
-	var body io.Reader
+func (c *Client) sendRequest(ctx context.Context, method, url string, data interface{}) error {
+	_, err := c.sendRequestWithResponse(ctx, method, url, data)
+	return err
+}
+
+// sendRequestWithResponse 发送 HTTP 请求并返回成功响应的原始响应体 - 内部辅助函数
+func (c *Client) sendRequestWithResponse(ctx context.Context, method, url string, data interface{}) ([]byte, error) {
+	ctx, cancel := c.operationContext(ctx, writeTimeout)
+	defer cancel()
+
+	var body []byte
 	if data != nil {
-		jsonData, err := json.Marshal(data)
+		jsonData, err := c.marshal(data)
 		if err != nil {
-			return fmt.Errorf("序列化请求数据失败: %w", err)
+			return nil, fmt.Errorf("序列化请求数据失败: %w", err)
 		}
-		body = bytes.NewBuffer(jsonData)
+		body = jsonData
+	}
+
+	if c.MaxBodySize > 0 && int64(len(body)) > c.MaxBodySize {
+		return nil, bodyTooLarge(int64(len(body)), c.MaxBodySize)
+	}
+
+	resp, err := c.do(ctx, strings.ToUpper(method), url, body)
+	if err != nil {
+		return nil, requestError(ctx, "发送 HTTP 请求失败", err)
+	}
+	defer resp.Body.Close()
+	if err := c.checkResponse(resp); err != nil {
+		return nil, err
 	}
 
-	req, err := http.NewRequest(strings.ToUpper(method), url, body)
+	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return fmt.Errorf("创建 HTTP 请求失败: %w", err)
+		return nil, requestError(ctx, "读取响应失败", err)
+	}
+	if body != nil && c.shouldVerify(ctx, strings.ToUpper(method), url) {
+		if err := c.verifyWrite(ctx, strings.ToUpper(method), url, body); err != nil {
+			return respBody, err
+		}
+	}
+	return respBody, nil
+}
+
+// decodeResult 解码写操作的响应体 - 内部辅助函数
+func (c *Client) decodeResult(body []byte) (map[string]interface{}, error) {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil, nil
 	}
+	var result map[string]interface{}
+	if err := c.decodeJSON(bytes.NewReader(body), &result); err != nil {
+		return nil, fmt.Errorf("解析响应 JSON 失败: %w", err)
+	}
+	return result, nil
+}
+
+// decodeJSON 按 UseNumber 设置解码 JSON - 内部辅助函数
+func (c *Client) decodeJSON(r io.Reader, v interface{}) error {
+	dec := json.NewDecoder(r)
+	if c.UseNumber {
+		dec.UseNumber()
+	}
+	return dec.Decode(v)
+}
 
+// checkResponse 检查写操作的响应状态码，失败时返回带有 Caddy 错误信息的 APIError - 内部辅助函数
+func (c *Client) checkResponse(resp *http.Response) error {
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return c.newAPIError("请求", resp)
+	}
+	return nil
+}
+
+// do 构建并发送请求，按 Retry、RetryStatuses 和 WriteRetry 策略处理失败 - 内部辅助函数
+// 请求体以字节形式传入，以便每次重试都能重新发送；调用方负责关闭响应体。
+// ctx 取消后不再重试，重试前的等待也会立即结束
+func (c *Client) do(ctx context.Context, method, url string, body []byte) (*http.Response, error) {
+	payload, compressed := c.compressBody(body)
+	var netRetries, writeRetries int
+	for {
+		var reader io.Reader
+		if body != nil {
+			reader = bytes.NewReader(payload)
+		}
+		req, err := c.newRequest(ctx, method, url, reader)
+		if err != nil {
+			return nil, err
+		}
+		if compressed {
+			req.Header.Set("Content-Encoding", "gzip")
+		}
+
+		resp, err := c.send(req, body)
+		if err != nil {
+			if ctx.Err() == nil && isRetryable(req) && netRetries < c.Retry.MaxRetries {
+				netRetries++
+				if err := sleepContext(ctx, c.Retry.delay(netRetries)); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			return nil, err
+		}
+
+		// Caddy 正在重载配置时写操作会被拒绝，稍后重试通常即可成功
+		if isWriteMethod(method) && isRetryable(req) && writeRetries < c.WriteRetry.MaxRetries && isWriteConflict(resp) {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+			writeRetries++
+			if err := sleepContext(ctx, retryDelay(resp, c.WriteRetry, writeRetries)); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		// 前置网关限流或暂时不可用，按 Retry 策略重试
+		if c.isRetryStatus(resp.StatusCode) && isRetryable(req) && netRetries < c.Retry.MaxRetries {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+			netRetries++
+			if err := sleepContext(ctx, retryDelay(resp, c.Retry, netRetries)); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		return resp, nil
+	}
+}
+
+// newRequest 构建带有公共请求头的请求 - 内部辅助函数
+func (c *Client) newRequest(ctx context.Context, method, url string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return nil, fmt.Errorf("创建 HTTP 请求失败: %w", err)
+	}
+	if c.socket != "" {
+		req.Host = unixHost
+	}
+	req.Header.Set("User-Agent", "gofastcaddy/"+Version)
+	for key, values := range c.headers {
+		req.Header[key] = values
+	}
+	if c.basicAuth != nil {
+		req.SetBasicAuth(c.basicAuth.username, c.basicAuth.password)
+	}
+	if c.authToken != "" {
+		req.Header.Set("Authorization", "Bearer "+c.authToken)
+	}
+	if etag := ifMatch(ctx); etag != "" && isWriteMethod(method) {
+		req.Header.Set("If-Match", etag)
+	}
 	if body != nil {
 		req.Header.Set("Content-Type", "application/json")
 	}
+	return req, nil
+}
+
+// GetUpstreams 获取反向代理上游的运行状态 - 对应 Caddy 的 /reverse_proxy/upstreams 端点
+func (c *Client) GetUpstreams() ([]types.UpstreamStatus, error) {
+	return c.GetUpstreamsContext(context.Background())
+}
+
+// GetUpstreamsContext 获取反向代理上游的运行状态 - 支持取消和超时的 GetUpstreams
+func (c *Client) GetUpstreamsContext(ctx context.Context) ([]types.UpstreamStatus, error) {
+	ctx, cancel := c.operationContext(ctx, readTimeout)
+	defer cancel()
 
-	resp, err := c.HTTPClient.Do(req)
+	resp, err := c.do(ctx, http.MethodGet, c.BaseURL+"/reverse_proxy/upstreams", nil)
 	if err != nil {
-		return fmt.Errorf("发送 HTTP 请求失败: %w", err)
+		return nil, requestError(ctx, "获取上游状态失败", err)
 	}
 	defer resp.Body.Close()
 
-	// 检查响应状态码
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		// 尝试读取错误信息
-		body, _ := io.ReadAll(resp.Body)
-		var errorMsg map[string]interface{}
-		if json.Unmarshal(body, &errorMsg) == nil {
-			if errStr, ok := errorMsg["error"].(string); ok {
-				return fmt.Errorf("请求失败, 状态码: %d, 错误: %s", resp.StatusCode, errStr)
-			}
-		}
-		return fmt.Errorf("请求失败, 状态码: %d", resp.StatusCode)
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.newAPIError("获取上游状态", resp)
 	}
 
-	return nil
-}
\ No newline at end of file
+	var result []types.UpstreamStatus
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("解析响应 JSON 失败: %w", err)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,167 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/youfun/gofastcaddy/pkg/clienttest"
+)
+
+func TestNormalizeBaseURL(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "", want: DefaultBaseURL},
+		{in: "  ", want: DefaultBaseURL},
+		{in: "http://caddy:2020", want: "http://caddy:2020"},
+		{in: "http://caddy:2020/", want: "http://caddy:2020"},
+		{in: "http://caddy:2020//", want: "http://caddy:2020"},
+		{in: "caddy:2020", want: "http://caddy:2020"},
+		{in: "caddy:2020/", want: "http://caddy:2020"},
+		{in: "https://admin.example.com/", want: "https://admin.example.com"},
+		{in: "tcp/caddy:2020", want: "http://caddy:2020"},
+		{in: ":2019", want: "http://localhost:2019"},
+		{in: "localhost:2019", want: "http://localhost:2019"},
+		{in: "unix//run/caddy/admin.sock", want: "unix//run/caddy/admin.sock"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			if got := NormalizeBaseURL(tt.in); got != tt.want {
+				t.Errorf("NormalizeBaseURL(%q) = %q, 期望 %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewClientBaseURL(t *testing.T) {
+	tests := []struct {
+		name  string
+		admin string // CADDY_ADMIN
+		env   string // CADDY_ADMIN_URL
+		opts  []Option
+		want  string
+	}{
+		{name: "默认地址", want: DefaultBaseURL},
+		{name: "环境变量", env: "caddy:2020/", want: "http://caddy:2020"},
+		{name: "WithBaseURL 优先于环境变量", env: "caddy:2020", opts: []Option{WithBaseURL("admin:2021/")}, want: "http://admin:2021"},
+		{name: "CADDY_ADMIN 优先于 CADDY_ADMIN_URL", admin: "tcp/caddy:2019", env: "caddy:2020", want: "http://caddy:2019"},
+		{name: "CADDY_ADMIN 省略主机", admin: ":2019", want: "http://localhost:2019"},
+		{name: "CADDY_ADMIN unix 套接字", admin: "unix//run/caddy/admin.sock", want: unixBaseURL},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CADDY_ADMIN", tt.admin)
+			t.Setenv("CADDY_ADMIN_URL", tt.env)
+			if got := NewClient(tt.opts...).BaseURL; got != tt.want {
+				t.Errorf("BaseURL = %s, 期望 %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeleteConfig(t *testing.T) {
+	const initial = `{"apps":{"http":{"servers":{"srv0":{"routes":[{"@id":"a"},{"@id":"b"},{"@id":"c"}]}}},"tls":{"automation":{"policies":[]}}}}`
+	tests := []struct {
+		name     string
+		path     string
+		want     string // 删除后的配置
+		notFound bool
+	}{
+		{
+			name: "嵌套路径",
+			path: "/apps/tls/automation",
+			want: `{"apps":{"http":{"servers":{"srv0":{"routes":[{"@id":"a"},{"@id":"b"},{"@id":"c"}]}}},"tls":{}}}`,
+		},
+		{
+			name: "数组下标",
+			path: "/apps/http/servers/srv0/routes/1",
+			want: `{"apps":{"http":{"servers":{"srv0":{"routes":[{"@id":"a"},{"@id":"c"}]}}},"tls":{"automation":{"policies":[]}}}}`,
+		},
+		{name: "不存在的键", path: "/apps/pki", want: initial, notFound: true},
+		{name: "超出范围的下标", path: "/apps/http/servers/srv0/routes/5", want: initial, notFound: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := clienttest.NewFakeCaddy(initial)
+			defer fake.Close()
+
+			err := NewClientWithURL(fake.URL).DeleteConfig(tt.path)
+			if tt.notFound != IsNotFound(err) || (!tt.notFound && err != nil) {
+				t.Fatalf("err = %v, 期望不存在 %v", err, tt.notFound)
+			}
+			if got := fake.ConfigJSON(); got != tt.want {
+				t.Errorf("配置 = %s\n期望 = %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeleteNoContent(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+	c := NewClientWithURL(server.URL)
+	if err := c.DeleteConfig("/apps/tls"); err != nil {
+		t.Errorf("DeleteConfig: %v", err)
+	}
+	if err := c.DeleteByID("app"); err != nil {
+		t.Errorf("DeleteByID: %v", err)
+	}
+}
+
+func TestAppendConfig(t *testing.T) {
+	const initial = `{"apps":{"http":{"servers":{"srv0":{"routes":[{"@id":"wildcard-example.com","handle":[{"handler":"subroute","routes":[{"@id":"a"}]}]}]}}}}}`
+	items := []map[string]interface{}{{"@id": "b"}, {"@id": "c"}}
+	tests := []struct {
+		name    string
+		byID    bool
+		path    string
+		items   interface{}
+		request string // 期望发送的请求，为空表示不应发送
+	}{
+		{name: "配置路径", path: "/apps/http/servers/srv0/routes/0/handle/0/routes", items: items,
+			request: "POST /config/apps/http/servers/srv0/routes/0/handle/0/routes/.../"},
+		{name: "以斜杠结尾的配置路径", path: "/apps/http/servers/srv0/routes/0/handle/0/routes/", items: items,
+			request: "POST /config/apps/http/servers/srv0/routes/0/handle/0/routes/.../"},
+		{name: "已带展开后缀", path: "/apps/http/servers/srv0/routes/0/handle/0/routes/...", items: items,
+			request: "POST /config/apps/http/servers/srv0/routes/0/handle/0/routes/.../"},
+		{name: "ID 路径", byID: true, path: "wildcard-example.com/handle/0/routes", items: items,
+			request: "POST /id/wildcard-example.com/handle/0/routes/.../"},
+		{name: "以斜杠结尾的 ID 路径", byID: true, path: "wildcard-example.com/handle/0/routes//", items: items,
+			request: "POST /id/wildcard-example.com/handle/0/routes/.../"},
+		{name: "拒绝非数组", path: "/apps/http/servers/srv0/routes/0/handle/0/routes", items: map[string]interface{}{"@id": "b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := clienttest.NewFakeCaddy(initial)
+			defer fake.Close()
+			c := NewClientWithURL(fake.URL)
+
+			var err error
+			if tt.byID {
+				err = c.AppendByID(tt.items, tt.path)
+			} else {
+				err = c.AppendConfig(tt.items, tt.path)
+			}
+			if tt.request == "" {
+				if err == nil || len(fake.Requests()) != 0 {
+					t.Fatalf("err = %v, 请求 = %v, 期望在发送前拒绝", err, fake.Requests())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := fake.Requests(); len(got) != 1 || got[0] != tt.request {
+				t.Errorf("请求 = %v, 期望 [%s]", got, tt.request)
+			}
+			if want := `"routes":[{"@id":"a"},{"@id":"b"},{"@id":"c"}]`; !strings.Contains(fake.ConfigJSON(), want) {
+				t.Errorf("配置 = %s, 期望包含 %s", fake.ConfigJSON(), want)
+			}
+		})
+	}
+}
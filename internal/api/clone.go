@@ -0,0 +1,59 @@
+package api
+
+import "sync"
+
+// Clone 返回客户端的副本 - 用于在共用连接池的前提下为部分调用增加中间件、修改超时等
+// 副本与原客户端共用 HTTP 传输层、试运行记录器、指标回调和多步写操作的锁 (LockWrites)，
+// 中间件、自定义请求头、重试状态码等列表各自独立：在副本上调用 Use、SetTimeout 不影响原客户端，反之亦然
+func (c *Client) Clone() *Client {
+	return &Client{
+		BaseURL:               c.BaseURL,
+		HTTPClient:            c.httpClient(),
+		Retry:                 c.Retry,
+		RetryStatuses:         append([]int(nil), c.RetryStatuses...),
+		WriteRetry:            c.WriteRetry,
+		ReadTimeout:           c.ReadTimeout,
+		WriteTimeout:          c.WriteTimeout,
+		LoadTimeout:           c.LoadTimeout,
+		MaxBodySize:           c.MaxBodySize,
+		UseNumber:             c.UseNumber,
+		VerifyWrites:          c.VerifyWrites,
+		Marshal:               c.Marshal,
+		Debug:                 c.Debug,
+		DebugBodyLimit:        c.DebugBodyLimit,
+		DebugIndent:           c.DebugIndent,
+		Logger:                c.Logger,
+		CompressRequests:      c.CompressRequests,
+		CompressThreshold:     c.CompressThreshold,
+		RedactedKeys:          append([]string(nil), c.RedactedKeys...),
+		Instrumentation:       c.Instrumentation,
+		DryRun:                c.DryRun,
+		DeleteGrace:           c.DeleteGrace,
+		OptimisticConcurrency: c.OptimisticConcurrency,
+		Provenance:            c.Provenance,
+		UpdateTimestamps:      c.UpdateTimestamps,
+		OptimisticRetries:     c.OptimisticRetries,
+
+		proxy:           c.proxy,
+		timeout:         c.timeout,
+		socket:          c.socket,
+		tlsConfig:       c.tlsConfig,
+		tlsErr:          c.tlsErr,
+		maxIdleConns:    c.maxIdleConns,
+		idleConnTimeout: c.idleConnTimeout,
+		keepAlive:       c.keepAlive,
+		sharedWriteMu:   c.writeLock(),
+		middleware:      append([]Middleware(nil), c.middleware...),
+		headers:         c.headers.Clone(),
+		authToken:       c.authToken,
+		basicAuth:       c.basicAuth,
+	}
+}
+
+// writeLock 返回多步写操作的锁，克隆的客户端返回与原客户端共用的锁 - 内部辅助函数
+func (c *Client) writeLock() *sync.Mutex {
+	if c.sharedWriteMu != nil {
+		return c.sharedWriteMu
+	}
+	return &c.writeMu
+}
@@ -0,0 +1,111 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// tagMiddleware 为请求添加 X-Tag 头的中间件 - 测试辅助函数
+func tagMiddleware(tag string) Middleware {
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			req.Header.Add("X-Tag", tag)
+			return next(req)
+		}
+	}
+}
+
+func TestCloneIsIndependent(t *testing.T) {
+	var mu sync.Mutex
+	var seen [][]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		seen = append(seen, r.Header.Values("X-Tag"))
+		mu.Unlock()
+		w.Write([]byte("{}"))
+	}))
+	defer server.Close()
+
+	// 原客户端的中间件切片留有余量，append 共用底层数组时副本的中间件会覆盖原客户端的
+	middleware := make([]Middleware, 0, 4)
+	middleware = append(middleware, tagMiddleware("base"))
+	original := NewClientWithURL(server.URL, WithMiddleware(middleware...), WithHeader("X-Custom", "a"))
+
+	tests := []struct {
+		name   string
+		client func() *Client
+		want   []string
+	}{
+		{
+			name: "副本增加中间件",
+			client: func() *Client {
+				clone := original.Clone()
+				clone.Use(tagMiddleware("clone"))
+				return clone
+			},
+			want: []string{"base", "clone"},
+		},
+		{
+			name:   "原客户端不受影响",
+			client: func() *Client { return original },
+			want:   []string{"base"},
+		},
+		{
+			name: "另一个副本",
+			client: func() *Client {
+				clone := original.Clone()
+				clone.Use(tagMiddleware("other"))
+				return clone
+			},
+			want: []string{"base", "other"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mu.Lock()
+			seen = nil
+			mu.Unlock()
+			if _, err := tt.client().GetConfig("/"); err != nil {
+				t.Fatal(err)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if len(seen) != 1 || !equalStrings(seen[0], tt.want) {
+				t.Errorf("X-Tag = %v, 期望 %v", seen, tt.want)
+			}
+		})
+	}
+
+	clone := original.Clone()
+	clone.SetTimeout(time.Second)
+	clone.headers.Set("X-Custom", "b")
+	if original.HTTPClient.Timeout == time.Second {
+		t.Error("副本的 SetTimeout 修改了原客户端")
+	}
+	if got := original.headers.Get("X-Custom"); got != "a" {
+		t.Errorf("原客户端的请求头 = %q", got)
+	}
+
+	// 副本与原客户端共用写锁
+	unlock := original.LockWrites()
+	if clone.writeLock().TryLock() {
+		t.Error("副本没有共用原客户端的写锁")
+	}
+	unlock()
+}
+
+// equalStrings 比较两个字符串切片 - 测试辅助函数
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,40 @@
+package api
+
+import (
+	"bytes"
+	"compress/gzip"
+)
+
+// DefaultCompressThreshold 请求体压缩的默认最小长度（字节），更小的请求体压缩收益低于开销
+const DefaultCompressThreshold = 8 << 10
+
+// WithRequestCompression 对不小于 DefaultCompressThreshold 的请求体使用 gzip 压缩，
+// 并设置 Content-Encoding: gzip；用于通过 LoadConfig 推送包含大量路由的完整配置。
+// 需要管理端点 (或其前置代理) 支持解压请求体，开启前请先确认
+func WithRequestCompression() Option {
+	return func(c *Client) {
+		c.CompressRequests = true
+	}
+}
+
+// compressBody 按 CompressRequests 和 CompressThreshold 压缩请求体 - 内部辅助函数
+// 返回实际发送的内容以及是否进行了压缩；压缩失败时按原样发送
+func (c *Client) compressBody(body []byte) ([]byte, bool) {
+	threshold := c.CompressThreshold
+	if threshold <= 0 {
+		threshold = DefaultCompressThreshold
+	}
+	if !c.CompressRequests || len(body) < threshold {
+		return body, false
+	}
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(body); err != nil {
+		return body, false
+	}
+	if err := zw.Close(); err != nil {
+		return body, false
+	}
+	return buf.Bytes(), true
+}
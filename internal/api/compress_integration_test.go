@@ -0,0 +1,77 @@
+//go:build integration
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/youfun/gofastcaddy/internal/utils"
+)
+
+// TestRequestCompressionRoundTrip 以 gzip 压缩的请求体向真实的 Caddy 加载配置并读回
+// 运行方式: CADDY_ADMIN=localhost:2019 go test -tags integration ./internal/api
+// 测试会临时加载一个监听 127.0.0.1 随机端口的服务器，结束时恢复原配置
+func TestRequestCompressionRoundTrip(t *testing.T) {
+	if utils.GetAdminAddress() == "" {
+		t.Skipf("未设置 %s 或 %s，跳过集成测试", utils.AdminEnv, utils.AdminURLEnv)
+	}
+
+	var encodings []string
+	record := func(next RoundTripFunc) RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			if req.Method == http.MethodPost && strings.HasSuffix(req.URL.Path, "/load") {
+				encodings = append(encodings, req.Header.Get("Content-Encoding"))
+			}
+			return next(req)
+		}
+	}
+	c := NewClient(WithRequestCompression(), WithMiddleware(record))
+
+	original, err := c.GetConfig("/")
+	if err != nil {
+		t.Fatalf("读取原配置失败: %v", err)
+	}
+	t.Cleanup(func() {
+		if original == nil {
+			original = map[string]interface{}{}
+		}
+		if err := c.LoadConfig(original); err != nil {
+			t.Errorf("恢复原配置失败: %v", err)
+		}
+	})
+
+	cfg := map[string]interface{}{}
+	for key, value := range original {
+		cfg[key] = value
+	}
+	routes := make([]interface{}, 0, 200)
+	for i := 0; i < 200; i++ {
+		routes = append(routes, map[string]interface{}{
+			"@id":    fmt.Sprintf("gofastcaddy-integration-%d", i),
+			"match":  []interface{}{map[string]interface{}{"host": []interface{}{fmt.Sprintf("app%d.integration.test", i)}}},
+			"handle": []interface{}{map[string]interface{}{"handler": "static_response", "body": "ok"}},
+		})
+	}
+	cfg["apps"] = map[string]interface{}{"http": map[string]interface{}{"servers": map[string]interface{}{
+		"gofastcaddy_integration": map[string]interface{}{"listen": []interface{}{"127.0.0.1:0"}, "routes": routes},
+	}}}
+
+	if err := c.LoadConfig(cfg); err != nil {
+		t.Fatalf("加载压缩的配置失败: %v", err)
+	}
+	if len(encodings) != 1 || encodings[0] != "gzip" {
+		t.Fatalf("/load 请求的 Content-Encoding = %v, 期望 gzip", encodings)
+	}
+
+	route, err := c.GetByID("gofastcaddy-integration-199")
+	if err != nil {
+		t.Fatalf("读回路由失败: %v", err)
+	}
+	handle, _ := route["handle"].([]interface{})
+	if len(handle) != 1 || handle[0].(map[string]interface{})["body"] != "ok" {
+		t.Errorf("读回的路由 = %v", route)
+	}
+}
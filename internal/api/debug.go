@@ -0,0 +1,146 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"time"
+)
+
+// DefaultDebugBodyLimit 调试事件中请求体和响应体的默认截断长度（字节）
+const DefaultDebugBodyLimit = 4096
+
+// DebugEvent 一次管理 API 调用的调试信息
+type DebugEvent struct {
+	Method       string        // 请求方法
+	URL          string        // 请求 URL
+	RequestBody  []byte        // 请求体 (按 RedactedKeys 脱敏、按 DebugBodyLimit 截断；流式上传时为空)
+	Status       int           // 响应状态码 (请求失败时为 0)
+	ResponseBody []byte        // 响应体 (按 RedactedKeys 脱敏、按 DebugBodyLimit 截断)
+	Duration     time.Duration // 从发送请求到收到响应头的耗时
+	Err          error         // 传输层错误
+}
+
+// DebugHook 管理 API 调用的调试回调 - 每次请求（包括重试和失败的请求）调用一次
+type DebugHook func(DebugEvent)
+
+// WithDebugHook 为每次管理 API 调用设置调试回调
+// limit 为请求体和响应体的截断长度，0 表示使用 DefaultDebugBodyLimit；回调不会改变请求的结果
+func WithDebugHook(hook DebugHook, limit int) Option {
+	return func(c *Client) {
+		c.Debug = hook
+		c.DebugBodyLimit = limit
+	}
+}
+
+// RequestLogger 管理 API 调用的日志回调 - 每次请求（包括重试和失败的请求）调用一次
+// 只包含请求方法、URL、状态码 (请求失败时为 0)、耗时和传输层错误，不包含可能带有令牌的请求体
+type RequestLogger func(method, url string, status int, dur time.Duration, err error)
+
+// WithLogger 为每次管理 API 调用设置日志回调，便于接入 slog、zap 等日志库
+// 与 WithDebugHook 相互独立，可同时使用
+func WithLogger(logger RequestLogger) Option {
+	return func(c *Client) {
+		c.Logger = logger
+	}
+}
+
+// WithDebugIndent 开启或关闭调试事件中请求体的缩进输出
+// 只影响 DebugHook 收到的内容，发送给 Caddy 的请求体保持紧凑格式；需要与 WithDebugHook 一起使用
+func WithDebugIndent(enabled bool) Option {
+	return func(c *Client) {
+		c.DebugIndent = enabled
+	}
+}
+
+// LogDebugHook 返回将调试事件写入 logger 的回调 - logger 为 nil 时使用标准日志
+func LogDebugHook(logger *log.Logger) DebugHook {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return func(e DebugEvent) {
+		if e.Err != nil {
+			logger.Printf("caddy admin %s %s 失败 (%s): %v 请求: %s", e.Method, e.URL, e.Duration, e.Err, e.RequestBody)
+			return
+		}
+		logger.Printf("caddy admin %s %s -> %d (%s) 请求: %s 响应: %s",
+			e.Method, e.URL, e.Status, e.Duration, e.RequestBody, e.ResponseBody)
+	}
+}
+
+// transmit 发送请求并在设置了 Logger 或 Debug 时报告请求 - 内部辅助函数
+// 请求经过 Use 注册的中间件；试运行模式下交给 DryRun 处理，不会发送
+// 设置了 Debug 时响应体会被完整读取后替换为内存副本，调用方照常读取和关闭
+func (c *Client) transmit(req *http.Request, body []byte) (*http.Response, error) {
+	if c.Debug == nil && c.Logger == nil {
+		return c.roundTrip(req, body)
+	}
+
+	start := time.Now()
+	resp, err := c.roundTrip(req, body)
+	duration := time.Since(start)
+	if c.Logger != nil {
+		status := 0
+		if resp != nil {
+			status = resp.StatusCode
+		}
+		c.Logger(req.Method, req.URL.String(), status, duration, err)
+	}
+	if c.Debug == nil {
+		return resp, err
+	}
+
+	event := DebugEvent{
+		Method:      req.Method,
+		URL:         req.URL.String(),
+		RequestBody: c.truncateDebug(c.redact(c.indentDebug(body))),
+		Duration:    duration,
+		Err:         err,
+	}
+	if resp != nil {
+		event.Status = resp.StatusCode
+		data, readErr := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		resp.Body = io.NopCloser(io.MultiReader(bytes.NewReader(data), errReader{readErr}))
+		event.ResponseBody = c.truncateDebug(c.redact(data))
+	}
+	c.Debug(event)
+	return resp, err
+}
+
+// indentDebug 在设置了 DebugIndent 时缩进调试用的 JSON 请求体，非 JSON 内容保持不变 - 内部辅助函数
+func (c *Client) indentDebug(data []byte) []byte {
+	if !c.DebugIndent || len(data) == 0 {
+		return data
+	}
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, data, "", "  "); err != nil {
+		return data
+	}
+	return buf.Bytes()
+}
+
+// truncateDebug 按 DebugBodyLimit 截断调试用的请求体或响应体 - 内部辅助函数
+func (c *Client) truncateDebug(data []byte) []byte {
+	limit := c.DebugBodyLimit
+	if limit <= 0 {
+		limit = DefaultDebugBodyLimit
+	}
+	if len(data) <= limit {
+		return data
+	}
+	return append(append([]byte(nil), data[:limit]...), "..."...)
+}
+
+// errReader 在读取完缓存的响应体后返回原始的读取错误 - 内部类型
+type errReader struct{ err error }
+
+// Read 返回原始的读取错误，没有错误时返回 io.EOF
+func (r errReader) Read([]byte) (int, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+	return 0, io.EOF
+}
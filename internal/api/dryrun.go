@@ -0,0 +1,118 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// RecordedRequest 试运行模式下记录的写请求
+type RecordedRequest struct {
+	Method string // 请求方法
+	URL    string // 请求 URL
+	Body   []byte // 请求体 (DELETE 时为空)
+}
+
+// ReadResponder 试运行模式下读请求的响应 - 返回状态码和响应体
+type ReadResponder func(req *http.Request) (int, []byte)
+
+// DryRunRecorder 试运行记录器 - 记录写请求而不发送给 Caddy，可在多个 goroutine 中使用
+type DryRunRecorder struct {
+	// Read 读请求 (GET) 的响应 (nil 表示返回 200 和 null，即配置路径为空)
+	Read ReadResponder
+
+	mu       sync.Mutex
+	requests []RecordedRequest
+}
+
+// WithDryRun 开启试运行模式：写请求 (POST、PUT、PATCH、DELETE) 记录到 recorder 并返回 200，
+// 不会发送给 Caddy；读请求返回 recorder.Read 给出的响应。recorder 为 nil 时创建新的记录器。
+// 用于预览或在没有 Caddy 的环境中测试 fastcaddy 的调用，如断言 AddReverseProxy 只发送了一个 POST
+func WithDryRun(recorder *DryRunRecorder) Option {
+	return func(c *Client) {
+		if recorder == nil {
+			recorder = &DryRunRecorder{}
+		}
+		c.DryRun = recorder
+	}
+}
+
+// ReadThrough 返回将读请求转发给客户端 c 的 ReadResponder
+// 用于在线上配置的基础上试运行：读取的是 c 连接的 Caddy 的真实配置，写请求仍只记录
+func ReadThrough(c *Client) ReadResponder {
+	return func(req *http.Request) (int, []byte) {
+		resp, err := c.do(req.Context(), http.MethodGet, req.URL.String(), nil)
+		if err != nil {
+			data, _ := json.Marshal(map[string]string{"error": err.Error()})
+			return http.StatusBadGateway, data
+		}
+		defer resp.Body.Close()
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			data, _ = json.Marshal(map[string]string{"error": err.Error()})
+			return http.StatusBadGateway, data
+		}
+		return resp.StatusCode, data
+	}
+}
+
+// RecordedRequests 返回试运行模式下记录的写请求，按发送顺序排列；未开启试运行时返回 nil
+func (c *Client) RecordedRequests() []RecordedRequest {
+	if c.DryRun == nil {
+		return nil
+	}
+	return c.DryRun.Requests()
+}
+
+// Requests 返回已记录的写请求副本，按发送顺序排列
+func (r *DryRunRecorder) Requests() []RecordedRequest {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]RecordedRequest(nil), r.requests...)
+}
+
+// Reset 清空已记录的请求
+func (r *DryRunRecorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests = nil
+}
+
+// roundTrip 记录写请求或返回读请求的预设响应 - 内部辅助函数
+func (r *DryRunRecorder) roundTrip(req *http.Request, body []byte) *http.Response {
+	status, data := http.StatusOK, []byte("null")
+	if req.Method == http.MethodGet {
+		if r.Read != nil {
+			status, data = r.Read(req)
+		}
+	} else {
+		if body == nil && req.Body != nil {
+			body, _ = io.ReadAll(req.Body)
+		}
+		r.mu.Lock()
+		r.requests = append(r.requests, RecordedRequest{
+			Method: req.Method,
+			URL:    req.URL.String(),
+			Body:   append([]byte(nil), body...),
+		})
+		r.mu.Unlock()
+		data = nil
+	}
+	if req.Body != nil {
+		req.Body.Close()
+	}
+
+	return &http.Response{
+		Status:        http.StatusText(status),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+		Body:          io.NopCloser(bytes.NewReader(data)),
+		ContentLength: int64(len(data)),
+		Request:       req,
+	}
+}
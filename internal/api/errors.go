@@ -0,0 +1,155 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// APIError 管理 API 返回的非成功响应 - 可通过 errors.As 提取
+type APIError struct {
+	Op         string // 操作描述，如 "获取配置"
+	StatusCode int    // HTTP 状态码
+	Method     string // 请求方法
+	URL        string // 请求 URL
+	Message    string // Caddy 响应中的 error 字段 (无法解析时为空)
+	Body       []byte // 原始响应体
+	Module     string // Caddy 报告缺失的模块 ID，如 "dns.providers.cloudflare" (未识别时为空)
+}
+
+// ErrModuleNotAvailable 配置引用了当前 Caddy 二进制中没有编译进去的模块
+// Caddy 在加载失败后会回滚到原配置；可通过 errors.Is 判断，通过 MissingModule 取得模块 ID
+var ErrModuleNotAvailable = errors.New("Caddy 缺少所需的模块")
+
+// modulePatterns 从 Caddy 错误信息中提取缺失模块 ID 的规则
+var modulePatterns = []*regexp.Regexp{
+	regexp.MustCompile(`unknown module: ([A-Za-z0-9_.\-]+)`),
+	regexp.MustCompile(`module not registered: ([A-Za-z0-9_.\-]+)`),
+}
+
+// Is 使 errors.Is 能够匹配 ErrModuleNotAvailable
+func (e *APIError) Is(target error) bool {
+	return target == ErrModuleNotAvailable && e.Module != ""
+}
+
+// MissingModule 返回错误中 Caddy 报告缺失的模块 ID，无法识别时返回空字符串
+func MissingModule(err error) string {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Module
+	}
+	return ""
+}
+
+// Error 返回可读的错误信息
+// 包含实际请求的 URL，便于排查 GetConfigURL、GetIDURL 规范化路径 (如末尾斜杠) 后导致的 404
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("%s失败, 状态码: %d", e.Op, e.StatusCode)
+	if e.URL != "" {
+		msg += ", url: " + e.URL
+	}
+	if e.Message != "" {
+		msg += ", 错误: " + e.Message
+	}
+	return msg
+}
+
+// DecodeError 响应内容无法解码到调用方提供的目标 - 通常是目标类型与配置结构不匹配
+type DecodeError struct {
+	URL    string // 请求 URL
+	Target string // 目标类型，如 "*types.Route"
+	Err    error  // 底层的解码错误
+}
+
+// Error 返回可读的错误信息
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("无法将 %s 的响应解码为 %s: %v", e.URL, e.Target, e.Err)
+}
+
+// Unwrap 返回底层的解码错误
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// IsNotFound 判断错误是否为 404 响应
+func IsNotFound(err error) bool {
+	return hasStatus(err, http.StatusNotFound)
+}
+
+// IsConflict 判断错误是否为 409 响应
+func IsConflict(err error) bool {
+	return hasStatus(err, http.StatusConflict)
+}
+
+// IsBadRequest 判断错误是否为 400 响应 (通常是 Caddy 拒绝了配置内容)
+func IsBadRequest(err error) bool {
+	return hasStatus(err, http.StatusBadRequest)
+}
+
+// IsInvalidPath 判断错误是否为 Caddy 因路径中间的键不存在而返回的 400 (invalid traversal path)
+// 例如配置为空时读取 /apps/tls/automation；对读取而言等同于路径不存在
+func IsInvalidPath(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		return false
+	}
+	return strings.Contains(apiErr.Message, "invalid traversal path") ||
+		strings.Contains(string(apiErr.Body), "invalid traversal path")
+}
+
+// StatusCode 返回错误对应的 HTTP 状态码，非 APIError 时返回 0
+func StatusCode(err error) int {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode
+	}
+	return 0
+}
+
+// hasStatus 判断错误是否为指定状态码的 APIError - 内部辅助函数
+func hasStatus(err error, status int) bool {
+	return StatusCode(err) == status
+}
+
+// maxPlainMessage 非 JSON 响应体写入 Message 的最大长度
+const maxPlainMessage = 512
+
+// newAPIError 根据非成功响应构造 APIError，读取并解析响应体 - 内部辅助函数
+// Message 和 Body 中敏感键的值按 RedactedKeys 脱敏
+func (c *Client) newAPIError(op string, resp *http.Response) *APIError {
+	body, _ := io.ReadAll(resp.Body)
+	apiErr := &APIError{
+		Op:         op,
+		StatusCode: resp.StatusCode,
+		Body:       c.redact(body),
+	}
+	if resp.Request != nil {
+		apiErr.Method = resp.Request.Method
+		apiErr.URL = resp.Request.URL.String()
+	}
+
+	var errorMsg map[string]interface{}
+	if json.Unmarshal(body, &errorMsg) == nil {
+		if errStr, ok := errorMsg["error"].(string); ok {
+			apiErr.Message = errStr
+		}
+	} else if text := strings.TrimSpace(string(body)); text != "" {
+		// 非 JSON 响应 (如前置代理返回的错误页) 保留原文，避免只剩状态码
+		if len(text) > maxPlainMessage {
+			text = text[:maxPlainMessage] + "..."
+		}
+		apiErr.Message = text
+	}
+	apiErr.Message = c.redactString(apiErr.Message)
+	for _, pattern := range modulePatterns {
+		if match := pattern.FindStringSubmatch(apiErr.Message); match != nil {
+			apiErr.Module = match[1]
+			break
+		}
+	}
+	return apiErr
+}
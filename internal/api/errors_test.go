@@ -0,0 +1,135 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAPIErrorAs(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		call     func(c *Client) error
+		method   string
+		path     string
+		notFound bool
+		conflict bool
+	}{
+		{
+			name:     "GetConfig 404",
+			status:   http.StatusNotFound,
+			call:     func(c *Client) error { _, err := c.GetConfig("/apps/http"); return err },
+			method:   http.MethodGet,
+			path:     "/config/apps/http/",
+			notFound: true,
+		},
+		{
+			name:     "GetByID 404",
+			status:   http.StatusNotFound,
+			call:     func(c *Client) error { _, err := c.GetByID("missing"); return err },
+			method:   http.MethodGet,
+			path:     "/id/missing/",
+			notFound: true,
+		},
+		{
+			name:     "DeleteByID 409",
+			status:   http.StatusConflict,
+			call:     func(c *Client) error { return c.DeleteByID("app") },
+			method:   http.MethodDelete,
+			path:     "/id/app/",
+			conflict: true,
+		},
+		{
+			name:   "PutConfig 500",
+			status: http.StatusInternalServerError,
+			call: func(c *Client) error {
+				return c.PutConfig(map[string]interface{}{}, "/apps/tls", http.MethodPut)
+			},
+			method: http.MethodPut,
+			path:   "/config/apps/tls/",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				fmt.Fprintf(w, `{"error":"status %d"}`, tt.status)
+			}))
+			defer server.Close()
+
+			// 包装后仍可提取
+			err := fmt.Errorf("调用方: %w", tt.call(NewClientWithURL(server.URL)))
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("errors.As 失败: %v", err)
+			}
+			if apiErr.StatusCode != tt.status || apiErr.Method != tt.method || apiErr.URL != server.URL+tt.path {
+				t.Errorf("APIError = %d %s %s, 期望 %d %s %s", apiErr.StatusCode, apiErr.Method, apiErr.URL, tt.status, tt.method, server.URL+tt.path)
+			}
+			if want := fmt.Sprintf("status %d", tt.status); apiErr.Message != want {
+				t.Errorf("Message = %q, 期望 %q", apiErr.Message, want)
+			}
+			if !strings.Contains(err.Error(), apiErr.Message) {
+				t.Errorf("错误信息中缺少 Caddy 的错误: %v", err)
+			}
+			if IsNotFound(err) != tt.notFound || IsConflict(err) != tt.conflict || StatusCode(err) != tt.status {
+				t.Errorf("IsNotFound = %v, IsConflict = %v, StatusCode = %d", IsNotFound(err), IsConflict(err), StatusCode(err))
+			}
+		})
+	}
+}
+
+func TestErrorHelpersOnOtherErrors(t *testing.T) {
+	err := errors.New("connection refused")
+	if IsNotFound(err) || IsConflict(err) || StatusCode(err) != 0 || IsNotFound(nil) {
+		t.Error("非 APIError 不应匹配任何状态码")
+	}
+}
+
+func TestAPIErrorFixtures(t *testing.T) {
+	tests := []struct {
+		fixture string // testdata 中 Caddy (或前置代理) 的原始响应体
+		status  int
+		module  string // 期望识别出的缺失模块
+		message string // 期望 Message 中包含的内容
+	}{
+		{fixture: "missing_dns_module.json", status: http.StatusBadRequest, module: "dns.providers.cloudflare", message: "loading DNS provider module"},
+		{fixture: "unregistered_handler.json", status: http.StatusBadRequest, module: "http.handlers.rate_limit", message: "server srv0: setting up route handlers"},
+		{fixture: "invalid_listen.json", status: http.StatusBadRequest, message: "address already in use"},
+		{fixture: "proxy_502.html", status: http.StatusBadGateway, message: "502 Bad Gateway"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			body, err := os.ReadFile(filepath.Join("testdata", tt.fixture))
+			if err != nil {
+				t.Fatal(err)
+			}
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write(body)
+			}))
+			defer server.Close()
+
+			err = NewClientWithURL(server.URL).LoadConfig(map[string]interface{}{"apps": map[string]interface{}{}})
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("err = %v, 期望 APIError", err)
+			}
+			if !strings.Contains(apiErr.Message, tt.message) || !strings.Contains(err.Error(), tt.message) {
+				t.Errorf("错误信息 = %q, 期望包含 %q", err.Error(), tt.message)
+			}
+			if got := MissingModule(err); got != tt.module {
+				t.Errorf("MissingModule = %q, 期望 %q", got, tt.module)
+			}
+			if errors.Is(err, ErrModuleNotAvailable) != (tt.module != "") {
+				t.Errorf("errors.Is(ErrModuleNotAvailable) = %v", errors.Is(err, ErrModuleNotAvailable))
+			}
+		})
+	}
+}
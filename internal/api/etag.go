@@ -0,0 +1,83 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// DefaultOptimisticRetries 乐观并发冲突 (412) 时重新执行读取-修改-写回的默认次数
+const DefaultOptimisticRetries = 3
+
+// ifMatchKey If-Match 请求头在 ctx 中的键
+type ifMatchKey struct{}
+
+// WithOptimisticConcurrency 开启基于 ETag/If-Match 的乐观并发控制
+// 开启后 NestedSetConfig 等读取-修改-写回的操作在写回时携带读取到的 ETag，
+// 配置在此期间被其他进程修改时 Caddy 返回 412，操作会重新读取并重试
+func WithOptimisticConcurrency(enabled bool) Option {
+	return func(c *Client) {
+		c.OptimisticConcurrency = enabled
+	}
+}
+
+// WithOptimisticRetries 开启乐观并发控制，并设置冲突 (412) 时的最大重试次数
+// n <= 0 时使用 DefaultOptimisticRetries
+func WithOptimisticRetries(n int) Option {
+	return func(c *Client) {
+		c.OptimisticConcurrency = true
+		c.OptimisticRetries = n
+	}
+}
+
+// MaxOptimisticRetries 返回乐观并发冲突时的最大重试次数
+func (c *Client) MaxOptimisticRetries() int {
+	if c.OptimisticRetries <= 0 {
+		return DefaultOptimisticRetries
+	}
+	return c.OptimisticRetries
+}
+
+// ContextWithIfMatch 为 ctx 中发出的写请求附加 If-Match 请求头
+// 与各方法的 Context 变体一起使用；etag 为空时不附加
+func ContextWithIfMatch(ctx context.Context, etag string) context.Context {
+	return context.WithValue(ctx, ifMatchKey{}, etag)
+}
+
+// IsPreconditionFailed 判断错误是否为 412 响应 (If-Match 与当前配置的 ETag 不一致)
+func IsPreconditionFailed(err error) bool {
+	return hasStatus(err, http.StatusPreconditionFailed)
+}
+
+// GetConfigWithETag 获取指定路径的配置及其 ETag
+func (c *Client) GetConfigWithETag(path string) (map[string]interface{}, string, error) {
+	return c.GetConfigWithETagContext(context.Background(), path)
+}
+
+// GetConfigWithETagContext 支持取消和超时的 GetConfigWithETag
+func (c *Client) GetConfigWithETagContext(ctx context.Context, path string) (map[string]interface{}, string, error) {
+	ctx, cancel := c.operationContext(ctx, readTimeout)
+	defer cancel()
+
+	resp, err := c.do(ctx, http.MethodGet, c.GetConfigURL(path), nil)
+	if err != nil {
+		return nil, "", requestError(ctx, "获取配置失败", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", c.newAPIError("获取配置", resp)
+	}
+
+	var result map[string]interface{}
+	if err := c.decodeJSON(resp.Body, &result); err != nil {
+		return nil, "", fmt.Errorf("解析响应 JSON 失败: %w", err)
+	}
+	return result, resp.Header.Get("Etag"), nil
+}
+
+// ifMatch 读取 ctx 中的 If-Match 值 - 内部辅助函数
+func ifMatch(ctx context.Context) string {
+	etag, _ := ctx.Value(ifMatchKey{}).(string)
+	return etag
+}
@@ -0,0 +1,42 @@
+package api
+
+import (
+	"net/http"
+	"time"
+)
+
+// Instrumentation 管理 API 调用的指标回调 - 每次请求（包括重试和失败的请求）调用一次 OnRequest 和 OnResponse
+// path 为请求的 URL 路径 (如 /config/apps/http、/id/example.com)；实现需要支持并发调用
+type Instrumentation interface {
+	// OnRequest 在发送请求前调用
+	OnRequest(method, path string)
+	// OnResponse 在收到响应头或请求失败后调用，请求失败时 status 为 0
+	OnResponse(method, path string, status int, d time.Duration)
+}
+
+// WithInstrumentation 为每次管理 API 调用设置指标回调 - 见 metrics.Collector 提供的内存实现
+// 与 WithLogger、WithDebugHook 相互独立，可同时使用
+func WithInstrumentation(inst Instrumentation) Option {
+	return func(c *Client) {
+		c.Instrumentation = inst
+	}
+}
+
+// send 发送请求并在设置了 Instrumentation 时报告请求 - 内部辅助函数
+// 所有管理 API 请求都经由 send 发送
+func (c *Client) send(req *http.Request, body []byte) (*http.Response, error) {
+	if c.Instrumentation == nil {
+		return c.transmit(req, body)
+	}
+
+	method, path := req.Method, req.URL.Path
+	c.Instrumentation.OnRequest(method, path)
+	start := time.Now()
+	resp, err := c.transmit(req, body)
+	status := 0
+	if resp != nil {
+		status = resp.StatusCode
+	}
+	c.Instrumentation.OnResponse(method, path, status, time.Since(start))
+	return resp, err
+}
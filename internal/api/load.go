@@ -0,0 +1,76 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// LoadURL 返回 /load 端点的完整 URL
+func (c *Client) LoadURL() string {
+	return c.BaseURL + "/load"
+}
+
+// LoadConfig 通过 /load 端点原子地替换整个 Caddy 配置
+// cfg 可以是 types.CaddyConfig、map[string]interface{} 或任何可序列化为 JSON 的值；
+// 配置校验失败时 Caddy 保留原配置，返回的 APIError 中带有 Caddy 的原始错误信息
+func (c *Client) LoadConfig(cfg interface{}) error {
+	return c.LoadConfigContext(context.Background(), cfg)
+}
+
+// LoadConfigContext 支持取消和超时的 LoadConfig
+func (c *Client) LoadConfigContext(ctx context.Context, cfg interface{}) error {
+	if cfg == nil {
+		return fmt.Errorf("配置不能为空")
+	}
+	body, err := c.marshal(cfg)
+	if err != nil {
+		return fmt.Errorf("序列化配置失败: %w", err)
+	}
+	return c.LoadRawContext(ctx, body)
+}
+
+// LoadRaw 通过 /load 端点加载已序列化的完整 JSON 配置 - 内容按原样发送
+// 发送前检查 JSON 是否合法，避免把截断的文件交给 Caddy
+func (c *Client) LoadRaw(body []byte) error {
+	return c.LoadRawContext(context.Background(), body)
+}
+
+// LoadRawContext 支持取消和超时的 LoadRaw
+func (c *Client) LoadRawContext(ctx context.Context, body []byte) error {
+	ctx, cancel := c.operationContext(ctx, loadTimeout)
+	defer cancel()
+
+	if !json.Valid(body) {
+		return fmt.Errorf("配置不是合法的 JSON")
+	}
+	if c.MaxBodySize > 0 && int64(len(body)) > c.MaxBodySize {
+		return bodyTooLarge(int64(len(body)), c.MaxBodySize)
+	}
+
+	resp, err := c.do(ctx, http.MethodPost, c.LoadURL(), body)
+	if err != nil {
+		return requestError(ctx, "加载配置失败", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return c.newAPIError("加载配置", resp)
+	}
+	return nil
+}
+
+// LoadConfigFromReader 通过 /load 端点以流的方式加载 r 中的完整配置
+// 数据不经过解码，适合从文件部署体积很大的配置；请求体无法重放，因此不进行重试
+func (c *Client) LoadConfigFromReader(r io.Reader) error {
+	return c.LoadConfigFromReaderContext(context.Background(), r)
+}
+
+// LoadConfigFromReaderContext 支持取消和超时的 LoadConfigFromReader
+func (c *Client) LoadConfigFromReaderContext(ctx context.Context, r io.Reader) error {
+	ctx, cancel := c.operationContext(ctx, loadTimeout)
+	defer cancel()
+
+	return c.stream(ctx, http.MethodPost, c.LoadURL(), r)
+}
@@ -0,0 +1,86 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	const raw = "{\"apps\": {\"http\": {}}}\n"
+	tests := []struct {
+		name     string
+		load     func(c *Client) error
+		wantBody string // 为空表示不应发送请求
+		wantErr  bool
+	}{
+		{
+			name:     "LoadConfig",
+			load:     func(c *Client) error { return c.LoadConfig(map[string]interface{}{"apps": map[string]interface{}{}}) },
+			wantBody: `{"apps":{}}`,
+		},
+		{
+			name:     "LoadRaw 按原样发送",
+			load:     func(c *Client) error { return c.LoadRaw([]byte(raw)) },
+			wantBody: raw,
+		},
+		{
+			name:    "LoadRaw 拒绝不合法的 JSON",
+			load:    func(c *Client) error { return c.LoadRaw([]byte(`{"apps":`)) },
+			wantErr: true,
+		},
+		{
+			name:    "LoadConfig 拒绝空配置",
+			load:    func(c *Client) error { return c.LoadConfig(nil) },
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests []string
+			var body, contentType string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests = append(requests, r.Method+" "+r.URL.Path)
+				contentType = r.Header.Get("Content-Type")
+				data, _ := io.ReadAll(r.Body)
+				body = string(data)
+			}))
+			defer server.Close()
+
+			err := tt.load(NewClientWithURL(server.URL))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, 期望错误 %v", err, tt.wantErr)
+			}
+			if tt.wantBody == "" {
+				if len(requests) != 0 {
+					t.Errorf("不应发送请求: %v", requests)
+				}
+				return
+			}
+			if len(requests) != 1 || requests[0] != "POST /load" {
+				t.Errorf("请求 = %v, 期望 [POST /load]", requests)
+			}
+			if contentType != "application/json" {
+				t.Errorf("Content-Type = %q", contentType)
+			}
+			if body != tt.wantBody {
+				t.Errorf("请求体 = %q, 期望 %q", body, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestLoadRejected(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":"loading config: unknown module"}`, http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	err := NewClientWithURL(server.URL).LoadRaw([]byte(`{}`))
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Fatalf("err = %v, 期望 400 的 APIError", err)
+	}
+}
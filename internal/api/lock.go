@@ -0,0 +1,16 @@
+package api
+
+// LockWrites 获取客户端的写锁，返回释放函数 - 用于保护“读取-修改-写回”的多步操作
+//
+// 单个请求在 Caddy 端是原子的，并发的读写请求无需加锁；但先读取再整体写回的操作
+// (如 NestedSetConfig、先删除再添加的 AddReverseProxy) 在多个 goroutine 同时执行时
+// 可能互相覆盖。共享同一客户端的所有管理器在这些操作中持有该锁，因此它们之间串行执行，
+// 其他请求不受影响；Clone 得到的副本也共用该锁。该锁不可重入，持有期间不要再调用同样加锁的方法。
+//
+// 锁只在本进程内生效，不能防止其他进程或控制器同时修改配置。
+// BaseURL、HTTPClient 等字段应在开始使用客户端之前设置，之后不应再修改
+func (c *Client) LockWrites() func() {
+	mu := c.writeLock()
+	mu.Lock()
+	return mu.Unlock
+}
@@ -0,0 +1,49 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// MarshalFunc 请求体的序列化函数
+type MarshalFunc func(v interface{}) ([]byte, error)
+
+// WithMarshaler 使用自定义的请求体序列化函数 (如缩进输出或按键排序的规范化编码)
+// 作用于所有写操作和 LoadConfig 的请求体；marshal 为 nil 时使用默认的 CompactMarshal
+func WithMarshaler(marshal MarshalFunc) Option {
+	return func(c *Client) {
+		c.Marshal = marshal
+	}
+}
+
+// CompactMarshal 默认的请求体序列化函数 - 紧凑输出，不转义 HTML 字符 (<、>、&)
+// 保证配置中的正则表达式和模板原样发送
+func CompactMarshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// IndentMarshal 缩进两个空格的序列化函数 - 适用于需要人工审阅请求体的场景
+func IndentMarshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// marshal 使用客户端设置的序列化函数编码请求体 - 内部辅助函数
+func (c *Client) marshal(v interface{}) ([]byte, error) {
+	if c.Marshal != nil {
+		return c.Marshal(v)
+	}
+	return CompactMarshal(v)
+}
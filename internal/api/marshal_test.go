@@ -0,0 +1,79 @@
+package api
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMarshaler(t *testing.T) {
+	value := map[string]interface{}{"handler": "static_response", "body": "<a href=\"/\">首页</a> & 更多"}
+	tests := []struct {
+		name    string
+		marshal MarshalFunc
+		want    string
+	}{
+		{
+			name: "默认紧凑输出且不转义 HTML",
+			want: `{"body":"<a href=\"/\">首页</a> & 更多","handler":"static_response"}`,
+		},
+		{
+			name:    "缩进输出",
+			marshal: IndentMarshal,
+			want:    "{\n  \"body\": \"<a href=\\\"/\\\">首页</a> & 更多\",\n  \"handler\": \"static_response\"\n}",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var wire []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				wire = append(wire, string(body))
+			}))
+			defer server.Close()
+
+			// 中间件 (如审计日志) 看到的请求体
+			var audited []string
+			audit := func(next RoundTripFunc) RoundTripFunc {
+				return func(req *http.Request) (*http.Response, error) {
+					body, _ := io.ReadAll(req.Body)
+					audited = append(audited, string(body))
+					req.Body = io.NopCloser(bytes.NewReader(body))
+					return next(req)
+				}
+			}
+			c := NewClientWithURL(server.URL, WithMarshaler(tt.marshal), WithMiddleware(audit))
+			if err := c.PostConfig(value, "/apps/http/servers/srv0/routes/0/handle"); err != nil {
+				t.Fatal(err)
+			}
+			if err := c.LoadConfig(value); err != nil {
+				t.Fatal(err)
+			}
+
+			// 试运行记录的请求体与实际发送的相同
+			recorder := &DryRunRecorder{}
+			dry := NewClientWithURL(server.URL, WithMarshaler(tt.marshal), WithDryRun(recorder))
+			if err := dry.PostConfig(value, "/apps/http/servers/srv0/routes/0/handle"); err != nil {
+				t.Fatal(err)
+			}
+			if err := dry.LoadConfig(value); err != nil {
+				t.Fatal(err)
+			}
+
+			recorded := recorder.Requests()
+			if len(wire) != 2 || len(audited) != 2 || len(recorded) != 2 {
+				t.Fatalf("发送 %d 个, 审计 %d 个, 记录 %d 个请求, 期望各 2 个", len(wire), len(audited), len(recorded))
+			}
+			for i := range wire {
+				if wire[i] != tt.want {
+					t.Errorf("发送的请求体 = %s\n期望 = %s", wire[i], tt.want)
+				}
+				if audited[i] != wire[i] || string(recorded[i].Body) != wire[i] {
+					t.Errorf("审计 %q 或记录 %q 与发送的 %q 不同", audited[i], recorded[i].Body, wire[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package api
+
+import "net/http"
+
+// RoundTripFunc 发送单个管理 API 请求的函数
+type RoundTripFunc func(req *http.Request) (*http.Response, error)
+
+// Middleware 管理 API 请求的中间件 - 包装 next 返回新的发送函数
+// 中间件看到的是已构建完成的请求 (含请求头和请求体)，可以修改请求、检查响应和错误，
+// 也可以不调用 next 直接返回响应或错误
+type Middleware func(next RoundTripFunc) RoundTripFunc
+
+// Use 注册中间件，按注册顺序由外向内包装每次管理 API 请求 (包括重试的请求)
+// 应在发送请求前调用，不能与正在进行的请求并发调用
+func (c *Client) Use(middleware ...Middleware) {
+	c.middleware = append(c.middleware, middleware...)
+}
+
+// WithMiddleware 注册管理 API 请求的中间件 - 见 Client.Use
+// 用于注入链路追踪请求头、为内部网关签名请求等
+func WithMiddleware(middleware ...Middleware) Option {
+	return func(c *Client) {
+		c.Use(middleware...)
+	}
+}
+
+// roundTrip 经过中间件发送请求 - 内部辅助函数
+// 最内层为 HTTP 客户端，试运行模式下为 DryRun 记录器
+func (c *Client) roundTrip(req *http.Request, body []byte) (*http.Response, error) {
+	next := RoundTripFunc(c.httpClient().Do)
+	if c.DryRun != nil {
+		next = func(req *http.Request) (*http.Response, error) {
+			return c.DryRun.roundTrip(req, body), nil
+		}
+	}
+	for i := len(c.middleware) - 1; i >= 0; i-- {
+		next = c.middleware[i](next)
+	}
+	return next(req)
+}
@@ -0,0 +1,280 @@
+package api
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Option NewClient 的可选配置
+type Option func(*Client)
+
+// WithBaseURL 设置管理端点地址，优先于 CADDY_ADMIN_URL 环境变量
+func WithBaseURL(baseURL string) Option {
+	return func(c *Client) {
+		c.BaseURL = NormalizeBaseURL(baseURL)
+	}
+}
+
+// WithTimeout 设置单个请求的超时时间 (默认 30 秒)
+// 在所有选项应用之后生效，因此与 WithHTTPClient 的先后顺序无关
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.timeout = &timeout
+	}
+}
+
+// WithHTTPClient 使用自定义的 HTTP 客户端
+// 代理选项只对 *http.Transport 类型的传输层生效；传输层为空时会创建默认传输层的副本
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		if httpClient != nil {
+			c.HTTPClient = httpClient
+		}
+	}
+}
+
+// WithHeader 为每个请求附加自定义请求头，可多次使用
+// 同名请求头以最后一次设置为准；与 WithAuthToken 同时设置 Authorization 时以 WithAuthToken 为准
+func WithHeader(key, value string) Option {
+	return func(c *Client) {
+		if c.headers == nil {
+			c.headers = make(http.Header)
+		}
+		c.headers.Set(key, value)
+	}
+}
+
+// SetHeader 在创建客户端之后设置附加到每个请求的请求头，value 为空时删除该请求头
+// 请求体的 Content-Type 仍由客户端自动设置；不应与其他请求并发调用
+func (c *Client) SetHeader(key, value string) {
+	if value == "" {
+		c.headers.Del(key)
+		return
+	}
+	WithHeader(key, value)(c)
+}
+
+// WithAuthToken 为每个请求附加 Authorization: Bearer <token> 请求头
+// 用于管理端点位于要求认证的反向代理之后的场景
+func WithAuthToken(token string) Option {
+	return func(c *Client) {
+		c.authToken = token
+	}
+}
+
+// basicAuth HTTP Basic 认证信息 - 内部类型
+type basicAuth struct {
+	username string
+	password string
+}
+
+// WithBasicAuth 为每个请求附加 HTTP Basic 认证请求头
+// 用于管理端点位于要求 Basic 认证的反向代理之后的场景；与 WithAuthToken 同时设置时以 WithAuthToken 为准。
+// 认证信息只出现在请求头中，不会写入错误信息、Logger 或 DebugHook 的内容
+func WithBasicAuth(username, password string) Option {
+	return func(c *Client) {
+		c.basicAuth = &basicAuth{username: username, password: password}
+	}
+}
+
+// SetBasicAuth 在创建客户端之后设置 HTTP Basic 认证信息，username 和 password 都为空时不再发送
+// 对之后的所有请求生效；不应与其他请求并发调用
+func (c *Client) SetBasicAuth(username, password string) {
+	if username == "" && password == "" {
+		c.basicAuth = nil
+		return
+	}
+	WithBasicAuth(username, password)(c)
+}
+
+// WithUseNumber 将读取到的配置中的数字解码为 json.Number，见 Client.UseNumber
+func WithUseNumber() Option {
+	return func(c *Client) {
+		c.UseNumber = true
+	}
+}
+
+// WithDeleteGrace 开启路由的删除保护：routes.Manager.DeleteByID 删除前保存完整路由，
+// d 时间内可通过 UndoDelete 恢复到原来的位置；回收站默认只在内存中，见 routes.Manager.RecycleBinFile
+func WithDeleteGrace(d time.Duration) Option {
+	return func(c *Client) {
+		c.DeleteGrace = d
+	}
+}
+
+// WithProvenance 开启或关闭路由来源信息
+// 开启后 AddReverseProxy 等操作在创建的路由上以 fastcaddy_meta 变量记录库版本、创建时间和操作名称，
+// 便于运维在原始 JSON 中辨认受管路由；状态哈希和漂移检测会忽略这些信息
+func WithProvenance(enabled bool) Option {
+	return func(c *Client) {
+		c.Provenance = enabled
+	}
+}
+
+// WithUpdateTimestamps 开启或关闭路由的最后修改时间
+// 开启后创建路由、UpdateRoute、SetRouteNote 等修改处理器链的操作在同一请求中以 fastcaddy_updated_at 变量
+// 记录 RFC3339 格式的 UTC 时间；状态哈希和漂移检测会忽略该信息
+func WithUpdateTimestamps(enabled bool) Option {
+	return func(c *Client) {
+		c.UpdateTimestamps = enabled
+	}
+}
+
+// WithTransport 使用自定义的传输层 (如带有自定义拨号器的 *http.Transport)
+// 只替换 HTTP 客户端的传输层，超时等客户端设置保持不变；在 WithHTTPClient 之后使用时作用于该客户端
+func WithTransport(transport http.RoundTripper) Option {
+	return func(c *Client) {
+		if transport == nil {
+			return
+		}
+		httpClient := *c.HTTPClient
+		httpClient.Transport = transport
+		c.HTTPClient = &httpClient
+	}
+}
+
+// WithIdleConns 设置传输层的空闲连接池：最大空闲连接数和空闲连接的保留时长
+// 值为 0 时保持传输层原有的设置；只对 *http.Transport 类型的传输层生效
+func WithIdleConns(maxIdle int, idleTimeout time.Duration) Option {
+	return func(c *Client) {
+		c.maxIdleConns = maxIdle
+		c.idleConnTimeout = idleTimeout
+	}
+}
+
+// DefaultMaxIdleConnsPerHost 客户端自建传输层的每主机最大空闲连接数
+// 并发批量添加路由时复用连接，避免每次请求重新建立连接 (对 https 管理端点尤为明显)
+const DefaultMaxIdleConnsPerHost = 16
+
+// WithMaxIdleConns 设置最大空闲连接数 (同时作为每个主机的上限)，0 表示使用 DefaultMaxIdleConnsPerHost
+// 只对 *http.Transport 类型的传输层生效
+func WithMaxIdleConns(n int) Option {
+	return func(c *Client) {
+		c.maxIdleConns = n
+	}
+}
+
+// WithIdleConnTimeout 设置空闲连接的保留时长，0 表示保持传输层原有的设置
+// 只对 *http.Transport 类型的传输层生效
+func WithIdleConnTimeout(d time.Duration) Option {
+	return func(c *Client) {
+		c.idleConnTimeout = d
+	}
+}
+
+// WithKeepAlive 设置 TCP 连接的 keep-alive 探测间隔，负值表示禁用
+// 通过 VPN 等会静默丢弃空闲连接的网络访问管理端点时，缩短间隔可以尽早发现断开的连接；
+// 使用 unix 套接字时无效
+func WithKeepAlive(interval time.Duration) Option {
+	return func(c *Client) {
+		c.keepAlive = &interval
+	}
+}
+
+// WithTLSConfig 使用自定义 TLS 配置连接 https:// 管理端点
+// 用于信任私有 CA 或出示客户端证书 (mTLS)；只对 *http.Transport 类型的传输层生效
+func WithTLSConfig(config *tls.Config) Option {
+	return func(c *Client) {
+		c.tlsConfig = config
+		c.tlsErr = nil
+	}
+}
+
+// proxyFunc 代理选择函数 - 与 http.Transport.Proxy 的签名相同
+type proxyFunc func(*http.Request) (*url.URL, error)
+
+// WithProxyFromEnvironment 按 HTTP_PROXY、HTTPS_PROXY、NO_PROXY 环境变量选择代理（默认行为）
+//
+// 代理选项的优先级：多个代理选项同时出现时以最后一个为准；
+// 不设置任何代理选项时等同于 WithProxyFromEnvironment
+func WithProxyFromEnvironment() Option {
+	return func(c *Client) {
+		c.proxy = http.ProxyFromEnvironment
+	}
+}
+
+// WithProxyURL 管理 API 的所有请求都经过指定代理，忽略代理环境变量
+// 支持 http、https 和 socks5 代理；地址无效时每次请求都会返回解析错误
+func WithProxyURL(proxyURL string) Option {
+	return func(c *Client) {
+		u, err := url.Parse(proxyURL)
+		if err == nil && (u.Scheme == "" || u.Host == "") {
+			err = fmt.Errorf("缺少 scheme 或主机")
+		}
+		if err != nil {
+			parseErr := fmt.Errorf("无效的代理地址 %q: %v", proxyURL, err)
+			c.proxy = func(*http.Request) (*url.URL, error) { return nil, parseErr }
+			return
+		}
+		c.proxy = http.ProxyURL(u)
+	}
+}
+
+// WithNoProxy 强制直连管理端点，忽略代理环境变量
+func WithNoProxy() Option {
+	return func(c *Client) {
+		c.proxy = func(*http.Request) (*url.URL, error) { return nil, nil }
+	}
+}
+
+// applyTransport 将代理、TLS、连接池和 unix 套接字设置应用到 HTTP 客户端的传输层 - 内部辅助函数
+// 传输层为空时基于 http.DefaultTransport 创建副本并将每主机空闲连接数提高到 DefaultMaxIdleConnsPerHost，
+// 不会修改全局默认传输层；WithHTTPClient 提供的 *http.Transport 在有设置需要应用时先 Clone，
+// 并使用新的 http.Client，调用方的客户端和传输层保持不变；
+// 自定义的非 *http.Transport 传输层保持不变
+func (c *Client) applyTransport() {
+	var transport *http.Transport
+	switch t := c.HTTPClient.Transport.(type) {
+	case nil:
+		transport = http.DefaultTransport.(*http.Transport).Clone()
+		transport.Proxy = http.ProxyFromEnvironment
+		// 客户端只访问一个管理端点，默认的每主机 2 个空闲连接在并发批量写入时会不断新建连接
+		transport.MaxIdleConnsPerHost = DefaultMaxIdleConnsPerHost
+	case *http.Transport:
+		if !c.hasTransportOptions() {
+			return
+		}
+		transport = t.Clone()
+	default:
+		return
+	}
+	client := *c.HTTPClient
+	client.Transport = transport
+	c.HTTPClient = &client
+
+	if c.proxy != nil {
+		transport.Proxy = c.proxy
+	}
+	if c.tlsConfig != nil {
+		transport.TLSClientConfig = c.tlsConfig
+	}
+	if c.tlsErr != nil {
+		transport.DialTLSContext = failingTLSDialer(c.tlsErr)
+	}
+	if c.maxIdleConns > 0 {
+		transport.MaxIdleConns = c.maxIdleConns
+		transport.MaxIdleConnsPerHost = c.maxIdleConns
+	}
+	if c.idleConnTimeout > 0 {
+		transport.IdleConnTimeout = c.idleConnTimeout
+	}
+	if c.keepAlive != nil {
+		dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: *c.keepAlive}
+		transport.DialContext = dialer.DialContext
+	}
+	if c.socket != "" {
+		// 套接字连接不经过代理，请求 URL 中的主机名只是占位符
+		transport.Proxy = nil
+		transport.DialContext = unixDialer(c.socket)
+	}
+}
+
+// hasTransportOptions 判断是否设置了需要修改传输层的选项 - 内部辅助函数
+func (c *Client) hasTransportOptions() bool {
+	return c.proxy != nil || c.tlsConfig != nil || c.tlsErr != nil || c.maxIdleConns > 0 ||
+		c.idleConnTimeout > 0 || c.keepAlive != nil || c.socket != ""
+}
@@ -0,0 +1,119 @@
+package api
+
+import (
+	"crypto/tls"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/youfun/gofastcaddy/pkg/clienttest"
+)
+
+func TestTransportOptionsDoNotMutateCallerClient(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+	}{
+		{name: "TLS", opts: []Option{WithTLSConfig(&tls.Config{ServerName: "admin"})}},
+		{name: "代理", opts: []Option{WithNoProxy()}},
+		{name: "连接池", opts: []Option{WithMaxIdleConns(7)}},
+		{name: "超时", opts: []Option{WithTimeout(3 * time.Second)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transport := &http.Transport{MaxIdleConns: 1}
+			shared := &http.Client{Transport: transport, Timeout: time.Minute}
+
+			c := NewClientWithURL("http://localhost:2019", append([]Option{WithHTTPClient(shared)}, tt.opts...)...)
+
+			// Clone 会初始化原传输层的 HTTP/2 设置，这里只检查选项对应的字段
+			if (transport.TLSClientConfig != nil && transport.TLSClientConfig.ServerName != "") ||
+				transport.Proxy != nil || transport.MaxIdleConns != 1 {
+				t.Errorf("调用方的传输层被修改: %+v", transport)
+			}
+			if shared.Transport != transport || shared.Timeout != time.Minute {
+				t.Errorf("调用方的客户端被修改")
+			}
+			if c.HTTPClient == shared {
+				t.Errorf("需要修改设置时应使用新的 http.Client")
+			}
+		})
+	}
+}
+
+func TestSharedClientKeptWithoutTransportOptions(t *testing.T) {
+	shared := &http.Client{Transport: &http.Transport{}}
+	c := NewClientWithURL("http://localhost:2019", WithHTTPClient(shared))
+	if c.HTTPClient != shared {
+		t.Errorf("没有传输层设置时应直接使用调用方的客户端")
+	}
+}
+
+func TestDefaultTransportUntouched(t *testing.T) {
+	before := http.DefaultTransport.(*http.Transport).MaxIdleConnsPerHost
+	NewClientWithURL("http://localhost:2019", WithMaxIdleConns(50), WithTLSConfig(&tls.Config{}))
+	if after := http.DefaultTransport.(*http.Transport); after.MaxIdleConnsPerHost != before || after.MaxIdleConns == 50 {
+		t.Errorf("http.DefaultTransport 被修改")
+	}
+}
+
+func TestRequestHeaders(t *testing.T) {
+	tests := []struct {
+		name  string
+		opts  []Option
+		setup func(c *Client)
+		want  string // 期望每个请求携带的 Authorization
+	}{
+		{name: "WithAuthToken", opts: []Option{WithAuthToken("secret")}, want: "Bearer secret"},
+		{name: "WithHeader", opts: []Option{WithHeader("Authorization", "Bearer header")}, want: "Bearer header"},
+		{name: "SetHeader", setup: func(c *Client) { c.SetHeader("Authorization", "Bearer rotated") }, want: "Bearer rotated"},
+		{
+			name:  "SetHeader 删除请求头",
+			opts:  []Option{WithHeader("Authorization", "Bearer old")},
+			setup: func(c *Client) { c.SetHeader("Authorization", "") },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := clienttest.NewFakeCaddy(`{"apps":{"http":{"servers":{"srv0":{"routes":[{"@id":"a"},{"@id":"b"}]}}}}}`)
+			defer fake.Close()
+
+			var seen []string
+			record := func(next RoundTripFunc) RoundTripFunc {
+				return func(req *http.Request) (*http.Response, error) {
+					seen = append(seen, req.Method+" "+req.Header.Get("Authorization")+" "+req.Header.Get("Content-Type"))
+					return next(req)
+				}
+			}
+			c := NewClientWithURL(fake.URL, append(tt.opts, WithMiddleware(record))...)
+			if tt.setup != nil {
+				tt.setup(c)
+			}
+
+			if _, err := c.GetConfig("/apps"); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := c.GetByID("a"); err != nil {
+				t.Fatal(err)
+			}
+			if err := c.PostConfig(map[string]interface{}{"@id": "c"}, "/apps/http/servers/srv0/routes"); err != nil {
+				t.Fatal(err)
+			}
+			if err := c.DeleteByID("b"); err != nil {
+				t.Fatal(err)
+			}
+
+			// 有请求体时仍自动设置 Content-Type
+			want := []string{
+				"GET " + tt.want + " ",
+				"GET " + tt.want + " ",
+				"POST " + tt.want + " application/json",
+				"DELETE " + tt.want + " ",
+			}
+			if !reflect.DeepEqual(seen, want) {
+				t.Errorf("请求头 = %q\n期望 = %q", seen, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// DefaultAdminPollInterval WaitForAdmin 的默认轮询间隔
+const DefaultAdminPollInterval = 250 * time.Millisecond
+
+// Ping 检查管理端点是否可达 - 对 /config/ 发送一次 GET 请求
+// 建议在 SetupCaddy 之前调用，尽早给出包含管理端点地址的错误
+func (c *Client) Ping() error {
+	return c.PingContext(context.Background())
+}
+
+// PingContext 支持取消和超时的 Ping - 不重试，失败时立即返回
+func (c *Client) PingContext(ctx context.Context) error {
+	ctx, cancel := c.operationContext(ctx, readTimeout)
+	defer cancel()
+
+	req, err := c.newRequest(ctx, http.MethodGet, c.GetConfigURL("/"), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.send(req, nil)
+	if err != nil {
+		return fmt.Errorf("无法连接 Caddy 管理端点 %s (Caddy 是否已启动? 可通过 %s 或 %s 环境变量指定地址): %w",
+			c.adminAddress(), AdminEnv, AdminURLEnv, err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Caddy 管理端点 %s 返回异常状态码: %d", c.adminAddress(), resp.StatusCode)
+	}
+	return nil
+}
+
+// WaitForAdmin 轮询 Ping 直到管理端点可用，用于 Caddy 尚未绑定管理端口时（如作为 sidecar 启动）
+// interval 为 0 时使用 DefaultAdminPollInterval；ctx 取消或超时时返回包含最后一次 Ping 错误的信息
+func (c *Client) WaitForAdmin(ctx context.Context, interval time.Duration) error {
+	if interval <= 0 {
+		interval = DefaultAdminPollInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		err := c.PingContext(ctx)
+		if err == nil {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("等待 Caddy 管理端点就绪超时: %w (最后一次错误: %v)", ctx.Err(), err)
+		case <-ticker.C:
+		}
+	}
+}
+
+// adminAddress 返回用于错误信息的管理端点地址 - 内部辅助函数
+func (c *Client) adminAddress() string {
+	if c.socket != "" {
+		return UnixSocketPrefix + c.socket
+	}
+	return c.BaseURL
+}
@@ -0,0 +1,94 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// delayedAdmin 在 delay 之后才开始监听的管理端点，之前的连接被拒绝 - 测试辅助函数
+func delayedAdmin(t *testing.T, delay time.Duration) string {
+	t.Helper()
+	// 先占用再释放一个端口，得到当前无人监听的地址
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	started := make(chan struct{})
+	timer := time.AfterFunc(delay, func() {
+		defer close(started)
+		l, err := net.Listen("tcp", addr)
+		if err != nil {
+			t.Errorf("监听 %s 失败: %v", addr, err)
+			return
+		}
+		server.Listener = l
+		server.Start()
+	})
+	t.Cleanup(func() {
+		if !timer.Stop() {
+			<-started
+		}
+		server.Close()
+	})
+	return "http://" + addr
+}
+
+func TestWaitForAdmin(t *testing.T) {
+	tests := []struct {
+		name    string
+		delay   time.Duration
+		timeout time.Duration
+		wantErr bool
+	}{
+		{name: "延迟启动后就绪", delay: 100 * time.Millisecond, timeout: 2 * time.Second},
+		{name: "超时前未就绪", delay: time.Hour, timeout: 100 * time.Millisecond, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewClientWithURL(delayedAdmin(t, tt.delay))
+			if err := c.Ping(); err == nil {
+				t.Fatal("启动前 Ping 应失败")
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), tt.timeout)
+			defer cancel()
+			err := c.WaitForAdmin(ctx, 10*time.Millisecond)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, 期望错误 %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), "无法连接 Caddy 管理端点") {
+					t.Errorf("错误信息 = %v", err)
+				}
+				return
+			}
+			if err := c.Ping(); err != nil {
+				t.Errorf("就绪后 Ping: %v", err)
+			}
+		})
+	}
+}
+
+func TestPingStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	err := NewClientWithURL(server.URL).Ping()
+	if err == nil || !strings.Contains(err.Error(), server.URL) || !strings.Contains(err.Error(), "503") {
+		t.Errorf("err = %v, 期望包含管理端点地址和状态码", err)
+	}
+}
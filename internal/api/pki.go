@@ -0,0 +1,61 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// DefaultCAID Caddy 内部 CA 的默认 ID (AddTLSInternalConfig 使用的 CA)
+const DefaultCAID = "local"
+
+// CAInfo Caddy PKI 应用中证书颁发机构的信息 - 对应 GET /pki/ca/<id> 的响应
+type CAInfo struct {
+	ID                      string `json:"id"`                       // CA ID
+	Name                    string `json:"name"`                     // CA 名称
+	RootCommonName          string `json:"root_common_name"`         // 根证书的通用名称
+	IntermediateCommonName  string `json:"intermediate_common_name"` // 中间证书的通用名称
+	RootCertificate         string `json:"root_certificate"`         // PEM 格式的根证书
+	IntermediateCertificate string `json:"intermediate_certificate"` // PEM 格式的中间证书
+}
+
+// PKIURL 返回 PKI 端点下指定 CA 的完整 URL
+func (c *Client) PKIURL(caID string) string {
+	return fmt.Sprintf("%s/pki/ca/%s", c.BaseURL, caID)
+}
+
+// GetCACertificate 获取内部 CA 的根证书、中间证书和元数据 - caID 为空时使用 "local"
+// PKI 应用尚未初始化 (还没有签发过内部证书) 时返回明确的错误，仍可通过 IsNotFound 识别
+func (c *Client) GetCACertificate(caID string) (CAInfo, error) {
+	return c.GetCACertificateContext(context.Background(), caID)
+}
+
+// GetCACertificateContext 支持取消和超时的 GetCACertificate
+func (c *Client) GetCACertificateContext(ctx context.Context, caID string) (CAInfo, error) {
+	ctx, cancel := c.operationContext(ctx, readTimeout)
+	defer cancel()
+
+	if caID == "" {
+		caID = DefaultCAID
+	}
+	resp, err := c.do(ctx, http.MethodGet, c.PKIURL(caID), nil)
+	if err != nil {
+		return CAInfo{}, requestError(ctx, "获取 CA 证书失败", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		err := c.newAPIError("获取 CA 证书", resp)
+		if IsNotFound(err) {
+			return CAInfo{}, fmt.Errorf("CA %s 不存在, PKI 应用可能尚未初始化 (需要先配置并使用内部证书): %w", caID, err)
+		}
+		return CAInfo{}, err
+	}
+
+	var info CAInfo
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return CAInfo{}, fmt.Errorf("解析响应 JSON 失败: %w", err)
+	}
+	return info, nil
+}
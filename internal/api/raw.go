@@ -0,0 +1,140 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/youfun/gofastcaddy/pkg/types"
+)
+
+// GetRawConfig 获取指定路径配置的原始 JSON 字节 - 不做解码，保留 Caddy 返回的内容和键顺序
+func (c *Client) GetRawConfig(path string) ([]byte, error) {
+	return c.GetRawConfigContext(context.Background(), path)
+}
+
+// GetRawConfigContext 获取指定路径配置的原始 JSON 字节 - 支持取消和超时的 GetRawConfig
+func (c *Client) GetRawConfigContext(ctx context.Context, path string) ([]byte, error) {
+	return c.getRaw(ctx, "获取配置", c.GetConfigURL(path))
+}
+
+// GetRawByID 通过 ID 获取配置的原始 JSON 字节
+func (c *Client) GetRawByID(path string) ([]byte, error) {
+	return c.GetRawByIDContext(context.Background(), path)
+}
+
+// GetRawByIDContext 通过 ID 获取配置的原始 JSON 字节 - 支持取消和超时的 GetRawByID
+func (c *Client) GetRawByIDContext(ctx context.Context, path string) ([]byte, error) {
+	return c.getRaw(ctx, "获取 ID 配置", c.GetIDURL(path))
+}
+
+// GetFullConfig 获取完整配置并解码为 types.CaddyConfig
+// Caddy 未加载配置时返回 null，此时得到 Apps 为空的配置
+func (c *Client) GetFullConfig() (*types.CaddyConfig, error) {
+	return c.GetFullConfigContext(context.Background())
+}
+
+// GetFullConfigContext 获取完整配置 - 支持取消和超时的 GetFullConfig
+func (c *Client) GetFullConfigContext(ctx context.Context) (*types.CaddyConfig, error) {
+	data, err := c.GetRawConfigContext(ctx, "/")
+	if err != nil {
+		return nil, err
+	}
+	cfg := &types.CaddyConfig{}
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("解析配置 JSON 失败: %w", err)
+	}
+	return cfg, nil
+}
+
+// GetApp 获取指定应用 (如 http、tls) 的原始 JSON 配置 - 只读取该应用的子树
+func (c *Client) GetApp(name string) (json.RawMessage, error) {
+	return c.GetAppContext(context.Background(), name)
+}
+
+// GetAppContext 获取指定应用的原始 JSON 配置 - 支持取消和超时的 GetApp
+func (c *Client) GetAppContext(ctx context.Context, name string) (json.RawMessage, error) {
+	if name == "" {
+		return nil, fmt.Errorf("应用名称不能为空")
+	}
+	data, err := c.GetRawConfigContext(ctx, "/apps/"+name)
+	if err != nil {
+		return nil, err
+	}
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || bytes.Equal(data, []byte("null")) {
+		return nil, fmt.Errorf("应用 %s 未配置", name)
+	}
+	return json.RawMessage(data), nil
+}
+
+// GetConfigInto 获取指定路径的配置并直接解码到 dest - dest 须为指针
+// 结构不匹配时返回 *DecodeError
+func (c *Client) GetConfigInto(path string, dest interface{}) error {
+	return c.GetConfigIntoContext(context.Background(), path, dest)
+}
+
+// GetConfigIntoContext 获取配置并解码到 dest - 支持取消和超时的 GetConfigInto
+func (c *Client) GetConfigIntoContext(ctx context.Context, path string, dest interface{}) error {
+	return c.getInto(ctx, "获取配置", c.GetConfigURL(path), dest)
+}
+
+// GetByIDInto 通过 ID 获取配置并直接解码到 dest - dest 须为指针
+// 结构不匹配时返回 *DecodeError
+func (c *Client) GetByIDInto(path string, dest interface{}) error {
+	return c.GetByIDIntoContext(context.Background(), path, dest)
+}
+
+// GetByIDIntoContext 通过 ID 获取配置并解码到 dest - 支持取消和超时的 GetByIDInto
+func (c *Client) GetByIDIntoContext(ctx context.Context, path string, dest interface{}) error {
+	return c.getInto(ctx, "获取 ID 配置", c.GetIDURL(path), dest)
+}
+
+// getInto 发送 GET 请求并将响应体解码到 dest - 内部辅助函数
+func (c *Client) getInto(ctx context.Context, op, url string, dest interface{}) error {
+	ctx, cancel := c.operationContext(ctx, readTimeout)
+	defer cancel()
+
+	resp, err := c.do(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return requestError(ctx, op+"失败", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return c.newAPIError(op, resp)
+	}
+
+	if err := c.decodeJSON(resp.Body, dest); err != nil {
+		if ctx.Err() != nil {
+			return requestError(ctx, "读取响应失败", err)
+		}
+		return &DecodeError{URL: url, Target: fmt.Sprintf("%T", dest), Err: err}
+	}
+	return nil
+}
+
+// getRaw 发送 GET 请求并返回原始响应体 - 内部辅助函数
+func (c *Client) getRaw(ctx context.Context, op, url string) ([]byte, error) {
+	ctx, cancel := c.operationContext(ctx, readTimeout)
+	defer cancel()
+
+	resp, err := c.do(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, requestError(ctx, op+"失败", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.newAPIError(op, resp)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, requestError(ctx, "读取响应失败", err)
+	}
+	return body, nil
+}
@@ -0,0 +1,47 @@
+package api
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// rawConfig 键顺序和空白都与 encoding/json 的输出不同，解码后再编码无法得到相同的字节
+const rawConfig = "{\"apps\": {\"tls\": {}, \"http\": {\"servers\": {\"srv0\": {\"routes\": [{\"@id\": \"app\", \"handle\": []}]}}}}}\n"
+
+func TestGetRawConfig(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/config/", "/id/app/":
+			w.Write([]byte(rawConfig))
+		default:
+			http.Error(w, `{"error":"unknown object ID"}`, http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	c := NewClientWithURL(server.URL)
+
+	tests := []struct {
+		name string
+		get  func() ([]byte, error)
+	}{
+		{name: "GetRawConfig", get: func() ([]byte, error) { return c.GetRawConfig("/") }},
+		{name: "GetRawByID", get: func() ([]byte, error) { return c.GetRawByID("app") }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := tt.get()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(data, []byte(rawConfig)) {
+				t.Errorf("返回 = %q\n期望 = %q", data, rawConfig)
+			}
+		})
+	}
+
+	if _, err := c.GetRawByID("missing"); !IsNotFound(err) {
+		t.Errorf("不存在的 ID: err = %v, 期望不存在错误", err)
+	}
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Redacted 替换敏感值的占位符
+const Redacted = "[REDACTED]"
+
+// DefaultRedactedKeys 默认脱敏的 JSON 键 - Cloudflare 令牌、证书私钥和 basic auth 密码
+var DefaultRedactedKeys = []string{"api_token", "key", "password"}
+
+// WithRedactedKeys 设置需要脱敏的 JSON 键，替换 DefaultRedactedKeys；不传参数表示关闭脱敏
+// 这些键的字符串值在错误信息 (APIError 的 Message 和 Body) 和调试事件的请求体、响应体中
+// 被替换为 Redacted；键名不区分大小写。发送给 Caddy 的请求体不受影响
+func WithRedactedKeys(keys ...string) Option {
+	return func(c *Client) {
+		c.RedactedKeys = append([]string{}, keys...)
+		c.redactPattern = nil
+	}
+}
+
+// redact 将 data 中敏感键的字符串值替换为 Redacted - 内部辅助函数
+// 按文本匹配 "key": "value" 形式，因此同样适用于错误信息中内嵌的 JSON 片段
+func (c *Client) redact(data []byte) []byte {
+	pattern := c.redactRegexp()
+	if pattern == nil || len(data) == 0 {
+		return data
+	}
+	return pattern.ReplaceAll(data, []byte("${1}${2}"+Redacted+"${2}"))
+}
+
+// redactString redact 的字符串版本 - 内部辅助函数
+func (c *Client) redactString(s string) string {
+	return string(c.redact([]byte(s)))
+}
+
+// isRedactedKey 判断 JSON 键是否需要脱敏 - 内部辅助函数
+func (c *Client) isRedactedKey(key string) bool {
+	for _, k := range c.redactedKeys() {
+		if strings.EqualFold(k, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactedKeys 返回生效的脱敏键 - 内部辅助函数
+func (c *Client) redactedKeys() []string {
+	if c.RedactedKeys == nil {
+		return DefaultRedactedKeys
+	}
+	return c.RedactedKeys
+}
+
+// redactRegexp 返回匹配敏感键值对的正则表达式，按需编译并缓存 - 内部辅助函数
+func (c *Client) redactRegexp() *regexp.Regexp {
+	c.redactMu.Lock()
+	defer c.redactMu.Unlock()
+	if c.redactPattern != nil {
+		return c.redactPattern
+	}
+	keys := c.redactedKeys()
+	if len(keys) == 0 {
+		return nil
+	}
+	quoted := make([]string, len(keys))
+	for i, key := range keys {
+		quoted[i] = regexp.QuoteMeta(key)
+	}
+	// 匹配 "key": "value"，值中允许转义字符；嵌在 JSON 字符串中、引号被转义为 \" 的键值对同样匹配，
+	// 替换时保留原有的引号形式，保证响应体仍是合法的 JSON
+	c.redactPattern = regexp.MustCompile(fmt.Sprintf(`(?i)(\\?"(?:%s)\\?"\s*:\s*)(\\?")(?:[^"\\]|\\[^"])*\\?"`, strings.Join(quoted, "|")))
+	return c.redactPattern
+}
@@ -0,0 +1,170 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy 重试策略 - 控制失败请求的重试次数和退避时间
+// 零值表示不重试
+type RetryPolicy struct {
+	MaxRetries int           // 最大重试次数（不含首次请求）
+	Backoff    time.Duration // 首次重试前的等待时间，之后每次翻倍
+	MaxBackoff time.Duration // 单次等待时间上限 (0 表示不限制)
+}
+
+// delay 计算第 attempt 次重试（从 1 开始）前的等待时间
+func (p RetryPolicy) delay(attempt int) time.Duration {
+	d := p.Backoff
+	for i := 1; i < attempt; i++ {
+		d *= 2
+		if p.MaxBackoff > 0 && d >= p.MaxBackoff {
+			break
+		}
+	}
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+	return d
+}
+
+// isWriteMethod 判断是否为修改配置的写方法 - 内部辅助函数
+func isWriteMethod(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch:
+		return true
+	}
+	return false
+}
+
+// ConfigChangingMessage Caddy 在另一次配置变更进行中时返回的错误信息
+const ConfigChangingMessage = "config is currently being updated"
+
+// isRetryable 判断请求失败后能否安全地重新发送 - 内部辅助函数
+// 请求可能已被 Caddy 处理而只是响应丢失，因此只重试重复发送结果相同的请求：GET、PUT、PATCH，
+// 以及带有 If-Match 的 POST (配置已被修改时重试会得到 412 而不是重复追加)；
+// 其他 POST 会重复追加数组元素，DELETE 重试会对已删除的路径返回 404，都不重试
+func isRetryable(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodPatch:
+		return true
+	case http.MethodPost:
+		return req.Header.Get("If-Match") != ""
+	}
+	return false
+}
+
+// isWriteConflict 判断写操作响应是否表示 Caddy 正忙于重载配置 - 内部辅助函数
+// 409、503 或错误响应体中包含 ConfigChangingMessage 时返回 true；
+// 检查响应体后会恢复响应体，调用方照常读取
+func isWriteConflict(resp *http.Response) bool {
+	if resp.StatusCode == http.StatusConflict || resp.StatusCode == http.StatusServiceUnavailable {
+		return true
+	}
+	if resp.StatusCode < 400 {
+		return false
+	}
+	data, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(io.MultiReader(bytes.NewReader(data), errReader{err}))
+	return bytes.Contains(data, []byte(ConfigChangingMessage))
+}
+
+// WithWriteRetry 设置配置变更冲突的重试策略，见 Client.WriteRetry
+// 并行执行 SetupCaddy 等多步操作时，Caddy 可能因另一次变更尚未完成而拒绝请求；零值策略表示不重试
+func WithWriteRetry(policy RetryPolicy) Option {
+	return func(c *Client) {
+		c.WriteRetry = policy
+	}
+}
+
+// WithRetry 设置传输层错误和 RetryStatuses 中状态码的重试策略，见 Client.Retry
+func WithRetry(policy RetryPolicy) Option {
+	return func(c *Client) {
+		c.Retry = policy
+	}
+}
+
+// WithRetryStatuses 设置需要重试的响应状态码，如 429 或 502、503、504
+// 重试次数和退避时间沿用 Retry 策略，与传输层错误共用同一个计数；
+// 429 或 503 响应带有 Retry-After 头时按其指示等待，而不是按退避时间。
+// 与传输层错误相同，只重试可以安全重复发送的请求，见 Client.Retry
+func WithRetryStatuses(codes ...int) Option {
+	return func(c *Client) {
+		c.RetryStatuses = append([]int(nil), codes...)
+	}
+}
+
+// isRetryStatus 判断响应状态码是否在 RetryStatuses 中 - 内部辅助函数
+func (c *Client) isRetryStatus(status int) bool {
+	for _, code := range c.RetryStatuses {
+		if code == status {
+			return true
+		}
+	}
+	return false
+}
+
+// retryAfter 解析 429 或 503 响应的 Retry-After 头，支持秒数和 HTTP 日期两种格式 - 内部辅助函数
+// 没有该头、格式无效或状态码不适用时返回 false
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusServiceUnavailable {
+		return 0, false
+	}
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		d := time.Until(date)
+		if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+	return 0, false
+}
+
+// retryDelay 计算响应重试前的等待时间，优先使用 Retry-After - 内部辅助函数
+func retryDelay(resp *http.Response, policy RetryPolicy, attempt int) time.Duration {
+	if d, ok := retryAfter(resp); ok {
+		return d
+	}
+	return policy.delay(attempt)
+}
+
+// sleepContext 等待指定时间，ctx 取消时提前返回其错误 - 内部辅助函数
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// requestError 包装请求错误 - 内部辅助函数
+// ctx 已取消或超时时返回包装后的上下文错误，便于调用方用 errors.Is 判断，而不是笼统的请求失败
+func requestError(ctx context.Context, msg string, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		if t, ok := ctx.Value(timeoutKey{}).(operationTimeout); ok && errors.Is(ctxErr, context.DeadlineExceeded) {
+			return fmt.Errorf("%s: 操作在 %s 后超时 (%s): %w", msg, t.d, t.name, ctxErr)
+		}
+		return fmt.Errorf("请求已中止: %w", ctxErr)
+	}
+	return fmt.Errorf("%s: %w", msg, err)
+}
@@ -0,0 +1,330 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/youfun/gofastcaddy/pkg/clienttest"
+)
+
+func TestTransportRetryOnlyForRepeatableRequests(t *testing.T) {
+	tests := []struct {
+		name     string
+		call     func(ctx context.Context, c *Client) error
+		ifMatch  bool
+		requests int  // 经过故障注入器的请求数
+		ok       bool // 重试后是否成功
+	}{
+		{
+			name:     "GET 重试",
+			call:     func(ctx context.Context, c *Client) error { _, err := c.GetConfigContext(ctx, "/apps"); return err },
+			requests: 2,
+			ok:       true,
+		},
+		{
+			name: "PUT 重试",
+			call: func(ctx context.Context, c *Client) error {
+				return c.CreateConfigContext(ctx, map[string]interface{}{}, "/apps/tls")
+			},
+			requests: 2,
+			ok:       true,
+		},
+		{
+			name: "PATCH 重试",
+			call: func(ctx context.Context, c *Client) error {
+				return c.PatchConfigContext(ctx, []string{"b"}, "/apps/list")
+			},
+			requests: 2,
+			ok:       true,
+		},
+		{
+			name:     "POST 不重试",
+			call:     func(ctx context.Context, c *Client) error { return c.PostConfigContext(ctx, "b", "/apps/list") },
+			requests: 1,
+		},
+		{
+			name:     "带 If-Match 的 POST 重试",
+			call:     func(ctx context.Context, c *Client) error { return c.PostConfigContext(ctx, "b", "/apps/list") },
+			ifMatch:  true,
+			requests: 2,
+			ok:       true,
+		},
+		{
+			name:     "DELETE 不重试",
+			call:     func(ctx context.Context, c *Client) error { return c.DeleteConfigContext(ctx, "/apps/list") },
+			requests: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := clienttest.NewFakeCaddy(`{"apps":{"list":["a"]}}`)
+			defer fake.Close()
+
+			ctx := context.Background()
+			if tt.ifMatch {
+				_, etag, err := NewClientWithURL(fake.URL).GetConfigWithETag("/")
+				if err != nil {
+					t.Fatal(err)
+				}
+				ctx = ContextWithIfMatch(ctx, etag)
+			}
+
+			faults := clienttest.NewFaultInjector(nil).FailNth(1)
+			c := NewClientWithURL(fake.URL,
+				WithHTTPClient(&http.Client{Transport: faults}),
+				WithRetry(RetryPolicy{MaxRetries: 3}),
+			)
+			err := tt.call(ctx, c)
+			if (err == nil) != tt.ok {
+				t.Errorf("错误 = %v, 期望成功 = %v", err, tt.ok)
+			}
+			if got := faults.Requests(); got != tt.requests {
+				t.Errorf("请求数 = %d, 期望 %d", got, tt.requests)
+			}
+		})
+	}
+}
+
+func TestRetryFlakyNetwork(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy RetryPolicy
+		failed int // 失败的调用数
+	}{
+		{name: "重试后全部成功", policy: RetryPolicy{MaxRetries: 2}},
+		{name: "不重试时部分失败", failed: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := clienttest.NewFakeCaddy(`{"apps":{}}`)
+			defer fake.Close()
+			c := NewClientWithURL(fake.URL,
+				WithHTTPClient(&http.Client{Transport: clienttest.FlakyNetwork(nil)}),
+				WithRetry(tt.policy),
+			)
+
+			failed := 0
+			for i := 0; i < 9; i++ {
+				if _, err := c.GetConfig("/apps"); err != nil {
+					failed++
+				}
+			}
+			if failed != tt.failed {
+				t.Errorf("失败的调用数 = %d, 期望 %d", failed, tt.failed)
+			}
+		})
+	}
+}
+
+func TestPostNotRetriedAfterLostResponse(t *testing.T) {
+	fake := clienttest.NewFakeCaddy(`{"apps":{"list":["a"]}}`)
+	defer fake.Close()
+	// 请求已被管理端点处理，但响应在返回途中丢失
+	var requests int32
+	lostResponse := func(next RoundTripFunc) RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			atomic.AddInt32(&requests, 1)
+			resp, err := next(req)
+			if err != nil {
+				return nil, err
+			}
+			resp.Body.Close()
+			return nil, errors.New("connection reset by peer")
+		}
+	}
+	c := NewClientWithURL(fake.URL, WithMiddleware(lostResponse), WithRetry(RetryPolicy{MaxRetries: 3}))
+
+	if err := c.PostConfig("b", "/apps/list"); err == nil {
+		t.Fatal("期望返回网络错误")
+	}
+	if got := atomic.LoadInt32(&requests); got != 1 {
+		t.Errorf("请求数 = %d, 期望 1", got)
+	}
+	if got, want := fake.ConfigJSON(), `{"apps":{"list":["a","b"]}}`; got != want {
+		t.Errorf("配置 = %s, 期望 %s (不能重复追加)", got, want)
+	}
+}
+
+func TestWriteRetry(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		method   string
+		fails    int  // 返回冲突的请求数
+		requests int  // 期望的请求数
+		ok       bool // 重试后是否成功
+	}{
+		{name: "409 重试", status: http.StatusConflict, method: http.MethodPatch, fails: 2, requests: 3, ok: true},
+		{name: "503 重试", status: http.StatusServiceUnavailable, method: http.MethodPut, fails: 2, requests: 3, ok: true},
+		{name: "配置更新中重试", status: http.StatusInternalServerError, body: `{"error":"` + ConfigChangingMessage + `"}`, method: http.MethodPatch, fails: 1, requests: 2, ok: true},
+		{name: "超过重试次数后放弃", status: http.StatusConflict, method: http.MethodPatch, fails: 10, requests: 3},
+		{name: "其他 500 不重试", status: http.StatusInternalServerError, body: `{"error":"boom"}`, method: http.MethodPatch, fails: 1, requests: 1},
+		{name: "POST 不重试", status: http.StatusConflict, method: http.MethodPost, fails: 1, requests: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if atomic.AddInt32(&requests, 1) <= int32(tt.fails) {
+					w.WriteHeader(tt.status)
+					w.Write([]byte(tt.body))
+				}
+			}))
+			defer server.Close()
+			c := NewClientWithURL(server.URL, WithWriteRetry(RetryPolicy{MaxRetries: 2, Backoff: time.Millisecond}))
+
+			err := c.PutConfig(map[string]interface{}{}, "/apps/tls", tt.method)
+			if (err == nil) != tt.ok {
+				t.Errorf("错误 = %v, 期望成功 = %v", err, tt.ok)
+			}
+			if got := atomic.LoadInt32(&requests); got != int32(tt.requests) {
+				t.Errorf("请求数 = %d, 期望 %d", got, tt.requests)
+			}
+		})
+	}
+}
+
+func TestWriteRetryConflictHandling(t *testing.T) {
+	busy := `{"error":"` + ConfigChangingMessage + `"}`
+	tests := []struct {
+		name     string
+		policy   RetryPolicy
+		method   string
+		fails    int // 返回冲突的请求数
+		requests int32
+		wantErr  string // 期望错误信息包含的内容 (为空表示期望成功)
+	}{
+		{name: "默认不重试", method: http.MethodPatch, fails: 2, requests: 1, wantErr: ConfigChangingMessage},
+		{name: "PATCH 冲突两次后成功", policy: RetryPolicy{MaxRetries: 3, Backoff: time.Millisecond}, method: http.MethodPatch, fails: 2, requests: 3},
+		{name: "DELETE 不重试", policy: RetryPolicy{MaxRetries: 3, Backoff: time.Millisecond}, method: http.MethodDelete, fails: 2, requests: 1, wantErr: ConfigChangingMessage},
+		{name: "放弃后仍返回 Caddy 的错误信息", policy: RetryPolicy{MaxRetries: 1, Backoff: time.Millisecond}, method: http.MethodPatch, fails: 5, requests: 2, wantErr: ConfigChangingMessage},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if atomic.AddInt32(&requests, 1) <= int32(tt.fails) {
+					http.Error(w, busy, http.StatusInternalServerError)
+				}
+			}))
+			defer server.Close()
+			c := NewClientWithURL(server.URL, WithWriteRetry(tt.policy))
+
+			var err error
+			if tt.method == http.MethodDelete {
+				err = c.DeleteByID("app")
+			} else {
+				err = c.PatchConfig(map[string]interface{}{}, "/apps/tls")
+			}
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("err = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("err = %v, 期望包含 %q", err, tt.wantErr)
+			}
+			if got := atomic.LoadInt32(&requests); got != tt.requests {
+				t.Errorf("请求数 = %d, 期望 %d", got, tt.requests)
+			}
+		})
+	}
+}
+
+func TestRetryStatuses(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []int
+		status   int   // 第一次请求的响应状态码，之后返回 200
+		want     int32 // 期望的请求数
+	}{
+		{name: "502 在列表中时重试", statuses: []int{502, 503, 504}, status: http.StatusBadGateway, want: 2},
+		{name: "429 不在列表中时不重试", statuses: []int{502, 503, 504}, status: http.StatusTooManyRequests, want: 1},
+		{name: "429 在列表中时重试", statuses: []int{429}, status: http.StatusTooManyRequests, want: 2},
+		{name: "未设置状态码时不重试", status: http.StatusBadGateway, want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if atomic.AddInt32(&requests, 1) == 1 {
+					w.WriteHeader(tt.status)
+					return
+				}
+				w.Write([]byte(`{}`))
+			}))
+			defer server.Close()
+
+			c := NewClientWithURL(server.URL,
+				WithRetry(RetryPolicy{MaxRetries: 2, Backoff: time.Millisecond}),
+				WithRetryStatuses(tt.statuses...),
+			)
+			_, err := c.GetConfig("/apps")
+			if (err == nil) != (tt.want == 2) {
+				t.Errorf("err = %v", err)
+			}
+			if got := atomic.LoadInt32(&requests); got != tt.want {
+				t.Errorf("请求数 = %d, 期望 %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	c := NewClientWithURL(server.URL,
+		WithRetry(RetryPolicy{MaxRetries: 1, Backoff: time.Millisecond}),
+		WithRetryStatuses(http.StatusTooManyRequests),
+	)
+	start := time.Now()
+	if _, err := c.GetConfig("/apps"); err != nil {
+		t.Fatal(err)
+	}
+	// 按 Retry-After 等待 1 秒，而不是 1 毫秒的退避时间
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("重试前只等待了 %v, 期望至少 1s", elapsed)
+	}
+	if got := atomic.LoadInt32(&requests); got != 2 {
+		t.Errorf("请求数 = %d, 期望 2", got)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	policy := RetryPolicy{Backoff: 10 * time.Millisecond}
+	tests := []struct {
+		name       string
+		status     int
+		retryAfter string
+		want       time.Duration
+	}{
+		{name: "秒数", status: http.StatusTooManyRequests, retryAfter: "3", want: 3 * time.Second},
+		{name: "503 的秒数", status: http.StatusServiceUnavailable, retryAfter: "2", want: 2 * time.Second},
+		{name: "已过去的日期", status: http.StatusTooManyRequests, retryAfter: "Mon, 02 Jan 2006 15:04:05 GMT", want: 0},
+		{name: "无效值使用退避时间", status: http.StatusTooManyRequests, retryAfter: "soon", want: 10 * time.Millisecond},
+		{name: "负数使用退避时间", status: http.StatusTooManyRequests, retryAfter: "-1", want: 10 * time.Millisecond},
+		{name: "502 忽略 Retry-After", status: http.StatusBadGateway, retryAfter: "3", want: 10 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{StatusCode: tt.status, Header: http.Header{"Retry-After": []string{tt.retryAfter}}}
+			if got := retryDelay(resp, policy, 1); got != tt.want {
+				t.Errorf("retryDelay = %v, 期望 %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+// DefaultShutdownPollInterval WaitForShutdown 轮询管理端点的默认间隔
+const DefaultShutdownPollInterval = 200 * time.Millisecond
+
+// StopURL 返回 /stop 端点的完整 URL
+func (c *Client) StopURL() string {
+	return c.BaseURL + "/stop"
+}
+
+// Stop 通过 /stop 端点让 Caddy 优雅退出
+// Caddy 在响应前后可能直接关闭连接，请求发出后出现的连接重置或 EOF 视为成功；
+// 请求不重试，避免对已经退出的进程重复发送
+func (c *Client) Stop() error {
+	return c.StopContext(context.Background())
+}
+
+// StopContext 支持取消和超时的 Stop
+func (c *Client) StopContext(ctx context.Context) error {
+	ctx, cancel := c.operationContext(ctx, writeTimeout)
+	defer cancel()
+
+	req, err := c.newRequest(ctx, http.MethodPost, c.StopURL(), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.send(req, nil)
+	if err != nil {
+		if isShutdownReset(err) {
+			return nil
+		}
+		return requestError(ctx, "停止 Caddy 失败", err)
+	}
+	defer resp.Body.Close()
+	return c.checkResponse(resp)
+}
+
+// WaitForShutdown 轮询管理端点直到不再响应，用于在 Stop 之后等待进程真正退出
+// ctx 取消或超时时返回 ctx 的错误
+func (c *Client) WaitForShutdown(ctx context.Context) error {
+	ticker := time.NewTicker(DefaultShutdownPollInterval)
+	defer ticker.Stop()
+	for {
+		req, err := c.newRequest(ctx, http.MethodGet, c.GetConfigURL("/"), nil)
+		if err != nil {
+			return err
+		}
+		resp, err := c.send(req, nil)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return nil
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// isShutdownReset 判断错误是否为 Caddy 退出时关闭连接造成的 - 内部辅助函数
+func isShutdownReset(err error) bool {
+	return errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET)
+}
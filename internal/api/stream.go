@@ -0,0 +1,118 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ErrBodyTooLarge 请求体超过 MaxBodySize
+var ErrBodyTooLarge = errors.New("请求体超过大小上限")
+
+// PutConfigFromReader 将 r 中的 JSON 以流的方式写入指定配置路径
+// 适用于体积很大的配置：数据不经过解码和重新序列化，也不会整体读入内存。
+// method 只接受 POST、PUT、PATCH；请求体无法重放，因此不进行重试
+func (c *Client) PutConfigFromReader(r io.Reader, path, method string) error {
+	return c.PutConfigFromReaderContext(context.Background(), r, path, method)
+}
+
+// PutConfigFromReaderContext 支持取消和超时的 PutConfigFromReader
+func (c *Client) PutConfigFromReaderContext(ctx context.Context, r io.Reader, path, method string) error {
+	ctx, cancel := c.operationContext(ctx, loadTimeout)
+	defer cancel()
+
+	m, err := writeMethod(method)
+	if err != nil {
+		return err
+	}
+	return c.stream(ctx, m, c.GetConfigURL(path), r)
+}
+
+// stream 以流的方式发送请求体，不重试 - 内部辅助函数
+func (c *Client) stream(ctx context.Context, method, url string, r io.Reader) error {
+	body := r
+	var limited *limitReader
+	if c.MaxBodySize > 0 {
+		limited = &limitReader{r: r, remaining: c.MaxBodySize, limit: c.MaxBodySize}
+		body = limited
+	}
+
+	req, err := c.newRequest(ctx, method, url, body)
+	if err != nil {
+		return err
+	}
+	resp, err := c.send(req, nil)
+	if limited != nil && limited.exceeded {
+		if resp != nil {
+			resp.Body.Close()
+		}
+		return bodyTooLarge(limited.limit+1, limited.limit)
+	}
+	if err != nil {
+		return requestError(ctx, "发送 HTTP 请求失败", err)
+	}
+	defer resp.Body.Close()
+	return c.checkResponse(resp)
+}
+
+// ValidateJSONStream 逐个读取 JSON 记号校验 r 是否为单个合法的 JSON 值
+// 与 json.Valid 不同，不需要将数据整体读入内存；可配合 io.TeeReader 在上传前预检
+func ValidateJSONStream(r io.Reader) error {
+	dec := json.NewDecoder(r)
+	depth := 0
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			if depth != 0 {
+				return fmt.Errorf("JSON 不完整")
+			}
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("JSON 格式错误: %w", err)
+		}
+		if delim, ok := tok.(json.Delim); ok {
+			if strings.ContainsRune("[{", rune(delim)) {
+				depth++
+			} else {
+				depth--
+			}
+		}
+		if depth == 0 && dec.More() {
+			return fmt.Errorf("JSON 包含多个顶层值")
+		}
+	}
+}
+
+// limitReader 超过上限时返回错误的 Reader - 内部辅助类型
+type limitReader struct {
+	r         io.Reader
+	remaining int64
+	limit     int64
+	exceeded  bool
+}
+
+func (l *limitReader) Read(p []byte) (int, error) {
+	if l.remaining < 0 {
+		l.exceeded = true
+		return 0, ErrBodyTooLarge
+	}
+	if int64(len(p)) > l.remaining+1 {
+		p = p[:l.remaining+1]
+	}
+	n, err := l.r.Read(p)
+	l.remaining -= int64(n)
+	if l.remaining < 0 {
+		l.exceeded = true
+		return n, ErrBodyTooLarge
+	}
+	return n, err
+}
+
+// bodyTooLarge 生成请求体过大的错误 - 内部辅助函数
+func bodyTooLarge(size, limit int64) error {
+	return fmt.Errorf("%w: 至少 %d 字节, 上限 %d 字节", ErrBodyTooLarge, size, limit)
+}
@@ -0,0 +1,73 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// largeConfig 生成包含 n 个路由的配置 JSON - 测试辅助函数
+func largeConfig(n int) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(`{"apps":{"http":{"servers":{"srv0":{"listen":[":443"],"routes":[`)
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		fmt.Fprintf(&buf, `{"@id":"app%d.example.com","match":[{"host":["app%d.example.com"]}],`+
+			`"handle":[{"handler":"reverse_proxy","upstreams":[{"dial":"10.0.%d.%d:8080"}]}],"terminal":true}`,
+			i, i, i/256%256, i%256)
+	}
+	buf.WriteString(`]}}}}}`)
+	return buf.Bytes()
+}
+
+// BenchmarkPutLargeConfig 比较先解码再序列化写入 (PutConfig) 与流式写入 (PutConfigFromReader) 大配置的内存分配
+// 管理端点只丢弃请求体，分配次数和字节数基本只来自客户端
+func BenchmarkPutLargeConfig(b *testing.B) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+	}))
+	defer server.Close()
+	c := NewClientWithURL(server.URL)
+	data := largeConfig(20000)
+
+	b.Run("解码后PutConfig", func(b *testing.B) {
+		b.ReportAllocs()
+		b.SetBytes(int64(len(data)))
+		for i := 0; i < b.N; i++ {
+			var cfg map[string]interface{}
+			if err := json.Unmarshal(data, &cfg); err != nil {
+				b.Fatal(err)
+			}
+			if err := c.PutConfig(cfg, "/", http.MethodPost); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("PutConfigFromReader", func(b *testing.B) {
+		b.ReportAllocs()
+		b.SetBytes(int64(len(data)))
+		for i := 0; i < b.N; i++ {
+			if err := c.PutConfigFromReader(bytes.NewReader(data), "/", http.MethodPost); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("校验后PutConfigFromReader", func(b *testing.B) {
+		b.ReportAllocs()
+		b.SetBytes(int64(len(data)))
+		for i := 0; i < b.N; i++ {
+			if err := ValidateJSONStream(bytes.NewReader(data)); err != nil {
+				b.Fatal(err)
+			}
+			if err := c.PutConfigFromReader(bytes.NewReader(data), "/", http.MethodPost); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,89 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// 操作超时的类别
+const (
+	readTimeout  = "ReadTimeout"
+	writeTimeout = "WriteTimeout"
+	loadTimeout  = "LoadTimeout"
+)
+
+// operationTimeout 记录在 ctx 中的操作超时信息 - 用于在错误中说明是哪个超时触发
+type operationTimeout struct {
+	name string
+	d    time.Duration
+}
+
+// timeoutKey operationTimeout 在 ctx 中的键
+type timeoutKey struct{}
+
+// WithReadTimeout 设置读操作 (GetConfig、GetByID、HasPath 等) 的默认超时
+func WithReadTimeout(d time.Duration) Option {
+	return func(c *Client) {
+		c.ReadTimeout = d
+	}
+}
+
+// WithWriteTimeout 设置写操作 (POST、PUT、PATCH、DELETE) 的默认超时
+func WithWriteTimeout(d time.Duration) Option {
+	return func(c *Client) {
+		c.WriteTimeout = d
+	}
+}
+
+// WithLoadTimeout 设置大体积配置上传 (PutConfigFromReader) 的默认超时
+func WithLoadTimeout(d time.Duration) Option {
+	return func(c *Client) {
+		c.LoadTimeout = d
+	}
+}
+
+// SetTimeout 修改单个请求的总超时 (HTTPClient.Timeout)，0 表示不限制
+// 修改的是 HTTP 客户端的副本，通过 WithHTTPClient 共享的客户端不受影响；
+// 已在进行中的请求沿用原有的超时，可以与其他请求并发调用
+func (c *Client) SetTimeout(d time.Duration) {
+	c.httpClientMu.Lock()
+	defer c.httpClientMu.Unlock()
+	httpClient := http.Client{}
+	if c.HTTPClient != nil {
+		httpClient = *c.HTTPClient
+	}
+	httpClient.Timeout = d
+	c.HTTPClient = &httpClient
+}
+
+// httpClient 返回当前使用的 HTTP 客户端 - 内部辅助函数
+// 与 SetTimeout 并发时返回替换前或替换后的客户端之一
+func (c *Client) httpClient() *http.Client {
+	c.httpClientMu.RLock()
+	defer c.httpClientMu.RUnlock()
+	return c.HTTPClient
+}
+
+// operationContext 按操作类别为 ctx 附加默认超时 - 内部辅助函数
+// 调用方的 ctx 已带截止时间或该类别未设置超时时原样返回，调用方的设置始终优先
+func (c *Client) operationContext(ctx context.Context, name string) (context.Context, context.CancelFunc) {
+	var d time.Duration
+	switch name {
+	case readTimeout:
+		d = c.ReadTimeout
+	case writeTimeout:
+		d = c.WriteTimeout
+	case loadTimeout:
+		d = c.LoadTimeout
+	}
+	if d <= 0 {
+		return ctx, func() {}
+	}
+	if _, ok := ctx.Deadline(); ok {
+		return ctx, func() {}
+	}
+
+	ctx = context.WithValue(ctx, timeoutKey{}, operationTimeout{name: name, d: d})
+	return context.WithTimeout(ctx, d)
+}
@@ -0,0 +1,91 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/youfun/gofastcaddy/pkg/clienttest"
+)
+
+func TestReadTimeoutSlowAdmin(t *testing.T) {
+	tests := []struct {
+		name    string
+		timeout time.Duration
+		wantErr bool
+	}{
+		{name: "超时", timeout: 20 * time.Millisecond, wantErr: true},
+		{name: "未超时", timeout: time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := clienttest.NewFakeCaddy(`{"apps":{}}`)
+			defer fake.Close()
+			c := NewClientWithURL(fake.URL,
+				WithHTTPClient(&http.Client{Transport: clienttest.SlowAdmin(nil, 100*time.Millisecond)}),
+				WithReadTimeout(tt.timeout),
+			)
+			_, err := c.GetConfig("/apps")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, 期望错误 %v", err, tt.wantErr)
+			}
+			if tt.wantErr && !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("err = %v, 期望 context.DeadlineExceeded", err)
+			}
+		})
+	}
+}
+
+func TestSetTimeout(t *testing.T) {
+	tests := []struct {
+		name    string
+		shared  *http.Client // 通过 WithHTTPClient 共享的客户端 (nil 表示使用默认客户端)
+		timeout time.Duration
+	}{
+		{name: "默认客户端", timeout: time.Second},
+		{name: "共享客户端", shared: &http.Client{Timeout: time.Minute}, timeout: time.Second},
+		{name: "清除超时", shared: &http.Client{Timeout: time.Minute}, timeout: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts []Option
+			if tt.shared != nil {
+				opts = append(opts, WithHTTPClient(tt.shared))
+			}
+			c := NewClientWithURL("http://localhost:2019", opts...)
+			c.SetTimeout(tt.timeout)
+			if got := c.httpClient().Timeout; got != tt.timeout {
+				t.Errorf("超时 = %v, 期望 %v", got, tt.timeout)
+			}
+			if tt.shared != nil && tt.shared.Timeout != time.Minute {
+				t.Errorf("共享客户端的超时被修改为 %v", tt.shared.Timeout)
+			}
+		})
+	}
+}
+
+// 需要 go test -race 才能发现数据竞争
+func TestSetTimeoutConcurrentWithRequests(t *testing.T) {
+	fake := clienttest.NewFakeCaddy(`{"apps":{}}`)
+	defer fake.Close()
+	c := NewClientWithURL(fake.URL)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if _, err := c.GetConfig("/apps"); err != nil {
+				t.Error(err)
+			}
+		}()
+		go func(i int) {
+			defer wg.Done()
+			c.SetTimeout(time.Duration(i+1) * time.Second)
+		}(i)
+	}
+	wg.Wait()
+}
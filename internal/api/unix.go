@@ -0,0 +1,33 @@
+package api
+
+import (
+	"context"
+	"net"
+)
+
+// UnixSocketPrefix 管理端点地址中表示 unix 套接字的前缀 - 与 Caddy admin.listen 的写法一致
+const UnixSocketPrefix = "unix/"
+
+// unixBaseURL 通过 unix 套接字连接时用于构造请求 URL 的占位地址
+const unixBaseURL = "http://unix"
+
+// unixHost 通过 unix 套接字连接时发送的 Host 请求头
+// Caddy 对套接字上的管理端点只接受空、127.0.0.1 或 ::1 的 Host，占位地址的 Host: unix 会被拒绝 (403)
+const unixHost = "127.0.0.1"
+
+// WithUnixSocket 通过 unix 套接字连接管理端点，如 /run/caddy/admin.sock
+// 请求 URL 使用占位地址 http://unix 构造，GetConfigURL 和 GetIDURL 的路径保持不变，Host 请求头为 127.0.0.1；
+// 优先于 WithBaseURL 和 CADDY_ADMIN_URL
+func WithUnixSocket(path string) Option {
+	return func(c *Client) {
+		c.socket = path
+	}
+}
+
+// unixDialer 返回总是连接到指定 unix 套接字的拨号函数 - 内部辅助函数
+func unixDialer(socket string) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, _, _ string) (net.Conn, error) {
+		var d net.Dialer
+		return d.DialContext(ctx, "unix", socket)
+	}
+}
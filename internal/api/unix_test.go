@@ -0,0 +1,82 @@
+package api
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/youfun/gofastcaddy/pkg/clienttest"
+)
+
+// newSocketCaddy 在 unix 套接字上提供假管理端点，与 Caddy 相同拒绝其他 Host 的请求 - 测试辅助函数
+func newSocketCaddy(t *testing.T, initial string) (string, *clienttest.FakeCaddy) {
+	t.Helper()
+	fake := clienttest.NewFakeCaddy(initial)
+	t.Cleanup(fake.Close)
+
+	socket := filepath.Join(t.TempDir(), "admin.sock")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Skipf("无法监听 unix 套接字: %v", err)
+	}
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Host != "" && r.Host != "127.0.0.1" && r.Host != "::1" {
+			http.Error(w, "host not allowed: "+r.Host, http.StatusForbidden)
+			return
+		}
+		fake.Server.Config.Handler.ServeHTTP(w, r)
+	}))
+	server.Listener.Close()
+	server.Listener = listener
+	server.Start()
+	t.Cleanup(server.Close)
+	return socket, fake
+}
+
+func TestUnixSocket(t *testing.T) {
+	const initial = `{"apps":{"http":{"servers":{"srv0":{"routes":[{"@id":"a"},{"@id":"b"}]}}}}}`
+	tests := []struct {
+		name   string
+		client func(socket string) *Client
+	}{
+		{name: "unix/ 地址", client: func(socket string) *Client { return NewClientWithURL(UnixSocketPrefix + socket) }},
+		{name: "WithUnixSocket", client: func(socket string) *Client {
+			return NewClientWithURL("http://localhost:2019", WithUnixSocket(socket))
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			socket, fake := newSocketCaddy(t, initial)
+			c := tt.client(socket)
+			if c.BaseURL != unixBaseURL {
+				t.Errorf("BaseURL = %s, 期望 %s", c.BaseURL, unixBaseURL)
+			}
+
+			if _, err := c.GetConfig("/apps/http/servers/srv0"); err != nil {
+				t.Fatalf("GET 失败: %v", err)
+			}
+			if err := c.PostConfig(map[string]interface{}{"@id": "c"}, "/apps/http/servers/srv0/routes"); err != nil {
+				t.Fatalf("POST 失败: %v", err)
+			}
+			if err := c.DeleteByID("a"); err != nil {
+				t.Fatalf("DeleteByID 失败: %v", err)
+			}
+			want := []string{
+				"GET /config/apps/http/servers/srv0/",
+				"POST /config/apps/http/servers/srv0/routes/",
+				"DELETE /id/a/",
+			}
+			if got := fake.Requests(); len(got) != len(want) {
+				t.Fatalf("请求 = %v, 期望 %v", got, want)
+			} else {
+				for i := range want {
+					if got[i] != want[i] {
+						t.Errorf("第 %d 个请求 = %s, 期望 %s", i, got[i], want[i])
+					}
+				}
+			}
+		})
+	}
+}
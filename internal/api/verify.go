@@ -0,0 +1,154 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ErrWriteVerificationFailed 写入后读回的配置与发送的内容不一致
+var ErrWriteVerificationFailed = errors.New("写入校验失败")
+
+// WriteVerificationError 写入校验失败的详情 - errors.Is(err, ErrWriteVerificationFailed) 为 true
+type WriteVerificationError struct {
+	Method string      // 写操作的 HTTP 方法
+	URL    string      // 写入的 URL
+	Path   string      // 第一处不一致的位置（相对于 URL 的 JSON 路径）
+	Sent   interface{} // 该位置发送的值
+	Stored interface{} // 该位置读回的值
+}
+
+// Error 返回可读的错误信息
+func (e *WriteVerificationError) Error() string {
+	return fmt.Sprintf("%s: %s %s 在 %s 处不一致, 发送: %v, 读回: %v",
+		ErrWriteVerificationFailed, e.Method, e.URL, e.Path, e.Sent, e.Stored)
+}
+
+// Is 使 errors.Is 能够匹配 ErrWriteVerificationFailed
+func (e *WriteVerificationError) Is(target error) bool {
+	return target == ErrWriteVerificationFailed
+}
+
+// verifyKey 单次调用的写入校验设置在 ctx 中的键
+type verifyKey struct{}
+
+// WithVerifyWrites 每次 POST/PUT/PATCH 成功后读回目标路径，确认存储的值与发送的内容等价
+// 键顺序不影响比较；DELETE 和带 "..." 展开的写入不做校验。默认关闭，每次写入会多一次 GET 请求
+func WithVerifyWrites() Option {
+	return func(c *Client) {
+		c.VerifyWrites = true
+	}
+}
+
+// ContextWithVerifyWrites 为单次调用覆盖 Client.VerifyWrites 设置
+// 与各方法的 Context 变体一起使用，如 c.PatchByIDContext(ContextWithVerifyWrites(ctx, true), ...)
+func ContextWithVerifyWrites(ctx context.Context, enabled bool) context.Context {
+	return context.WithValue(ctx, verifyKey{}, enabled)
+}
+
+// shouldVerify 判断本次写入是否需要校验 - 内部辅助函数
+func (c *Client) shouldVerify(ctx context.Context, method, url string) bool {
+	if method == http.MethodDelete || strings.Contains(url, "/...") {
+		return false
+	}
+	if enabled, ok := ctx.Value(verifyKey{}).(bool); ok {
+		return enabled
+	}
+	return c.VerifyWrites
+}
+
+// verifyWrite 读回目标路径并与发送的内容比较 - 内部辅助函数
+// POST 到数组时 Caddy 追加元素，此时与数组的最后一个元素比较
+func (c *Client) verifyWrite(ctx context.Context, method, url string, body []byte) error {
+	resp, err := c.do(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return requestError(ctx, "读回写入的配置失败", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return c.newAPIError("读回写入的配置", resp)
+	}
+
+	var sent, stored interface{}
+	if err := decodeNumbers(bytes.NewReader(body), &sent); err != nil {
+		return fmt.Errorf("解析发送的内容失败: %w", err)
+	}
+	dec := json.NewDecoder(resp.Body)
+	dec.UseNumber()
+	if err := dec.Decode(&stored); err != nil {
+		return fmt.Errorf("解析读回的配置失败: %w", err)
+	}
+
+	path, sentValue, storedValue, equal := firstDifference("", sent, stored)
+	if equal {
+		return nil
+	}
+	if items, ok := stored.([]interface{}); ok && method == http.MethodPost && len(items) > 0 {
+		if reflect.DeepEqual(sent, items[len(items)-1]) {
+			return nil
+		}
+	}
+	if path == "" {
+		path = "/"
+	}
+	if c.isRedactedKey(path[strings.LastIndex(path, "/")+1:]) {
+		sentValue, storedValue = Redacted, Redacted
+	}
+	return &WriteVerificationError{Method: method, URL: url, Path: path, Sent: sentValue, Stored: storedValue}
+}
+
+// decodeNumbers 以 json.Number 解码 JSON，保证数字比较不受 float64 精度影响 - 内部辅助函数
+func decodeNumbers(r *bytes.Reader, v interface{}) error {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	return dec.Decode(v)
+}
+
+// firstDifference 查找两个 JSON 值的第一处不一致 - 内部辅助函数
+// 对象按键的字典序比较，返回不一致的位置和两边的值
+func firstDifference(path string, a, b interface{}) (string, interface{}, interface{}, bool) {
+	switch av := a.(type) {
+	case map[string]interface{}:
+		bv, ok := b.(map[string]interface{})
+		if !ok {
+			return path, a, b, false
+		}
+		keys := make([]string, 0, len(av)+len(bv))
+		for k := range av {
+			keys = append(keys, k)
+		}
+		for k := range bv {
+			if _, ok := av[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if p, x, y, equal := firstDifference(path+"/"+k, av[k], bv[k]); !equal {
+				return p, x, y, false
+			}
+		}
+		return "", nil, nil, true
+	case []interface{}:
+		bv, ok := b.([]interface{})
+		if !ok || len(av) != len(bv) {
+			return path, a, b, false
+		}
+		for i := range av {
+			if p, x, y, equal := firstDifference(fmt.Sprintf("%s/%d", path, i), av[i], bv[i]); !equal {
+				return p, x, y, false
+			}
+		}
+		return "", nil, nil, true
+	}
+	if reflect.DeepEqual(a, b) {
+		return "", nil, nil, true
+	}
+	return path, a, b, false
+}
@@ -0,0 +1,131 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/youfun/gofastcaddy/pkg/clienttest"
+)
+
+// racingWriter 在每次 PATCH/POST 成功后由另一个客户端把 path 改为 value，模拟并发写入方 - 测试辅助函数
+func racingWriter(fake *clienttest.FakeCaddy, path string, value interface{}) Middleware {
+	other := NewClientWithURL(fake.URL)
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			resp, err := next(req)
+			if err == nil && req.Method != http.MethodGet {
+				if err := other.PatchConfig(value, path); err != nil {
+					return nil, err
+				}
+			}
+			return resp, err
+		}
+	}
+}
+
+func TestVerifyWrites(t *testing.T) {
+	const initial = `{"apps":{"http":{"servers":{"srv0":{"listen":[":80"],"routes":[{"@id":"app","handle":[{"handler":"reverse_proxy","upstreams":[{"dial":"app:80"}]}]}]}}}}}`
+	upstreams := []interface{}{map[string]interface{}{"dial": "new:80"}}
+
+	tests := []struct {
+		name     string
+		verify   bool  // Client.VerifyWrites
+		override *bool // ContextWithVerifyWrites (nil 表示不覆盖)
+		race     bool  // 写入后另一个写入方修改同一位置
+		write    func(*Client, context.Context) error
+		wantPath string // 期望报告的不一致位置 (为空表示期望成功)
+	}{
+		{
+			name:   "写入一致",
+			verify: true,
+			write: func(c *Client, ctx context.Context) error {
+				return c.PatchByIDContext(ctx, upstreams, "app/handle/0/upstreams")
+			},
+		},
+		{
+			name:   "POST 追加到数组时与最后一个元素比较",
+			verify: true,
+			write: func(c *Client, ctx context.Context) error {
+				return c.PostConfigContext(ctx, ":443", "/apps/http/servers/srv0/listen")
+			},
+		},
+		{
+			name:   "并发写入方覆盖了写入",
+			verify: true,
+			race:   true,
+			write: func(c *Client, ctx context.Context) error {
+				return c.PatchByIDContext(ctx, upstreams, "app/handle/0/upstreams")
+			},
+			wantPath: "/0/dial",
+		},
+		{
+			name: "默认不校验",
+			race: true,
+			write: func(c *Client, ctx context.Context) error {
+				return c.PatchByIDContext(ctx, upstreams, "app/handle/0/upstreams")
+			},
+		},
+		{
+			name:     "单次调用开启校验",
+			override: boolPtr(true),
+			race:     true,
+			write: func(c *Client, ctx context.Context) error {
+				return c.PatchByIDContext(ctx, upstreams, "app/handle/0/upstreams")
+			},
+			wantPath: "/0/dial",
+		},
+		{
+			name:     "单次调用关闭校验",
+			verify:   true,
+			override: boolPtr(false),
+			race:     true,
+			write: func(c *Client, ctx context.Context) error {
+				return c.PatchByIDContext(ctx, upstreams, "app/handle/0/upstreams")
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := clienttest.NewFakeCaddy(initial)
+			defer fake.Close()
+
+			var opts []Option
+			if tt.verify {
+				opts = append(opts, WithVerifyWrites())
+			}
+			if tt.race {
+				racer := []interface{}{map[string]interface{}{"dial": "racer:80"}}
+				opts = append(opts, WithMiddleware(racingWriter(fake, "/apps/http/servers/srv0/routes/0/handle/0/upstreams", racer)))
+			}
+			ctx := context.Background()
+			if tt.override != nil {
+				ctx = ContextWithVerifyWrites(ctx, *tt.override)
+			}
+
+			err := tt.write(NewClientWithURL(fake.URL, opts...), ctx)
+			if tt.wantPath == "" {
+				if err != nil {
+					t.Fatalf("err = %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrWriteVerificationFailed) {
+				t.Fatalf("err = %v, 期望 ErrWriteVerificationFailed", err)
+			}
+			var verr *WriteVerificationError
+			if !errors.As(err, &verr) {
+				t.Fatalf("err = %T", err)
+			}
+			if verr.Path != tt.wantPath || verr.Sent != "new:80" || verr.Stored != "racer:80" {
+				t.Errorf("不一致 = %s 发送 %v 读回 %v, 期望 %s 发送 new:80 读回 racer:80", verr.Path, verr.Sent, verr.Stored, tt.wantPath)
+			}
+		})
+	}
+}
+
+// boolPtr 返回 b 的指针 - 测试辅助函数
+func boolPtr(b bool) *bool {
+	return &b
+}
@@ -0,0 +1,77 @@
+package config
+
+import (
+	"reflect"
+	"sort"
+	"strconv"
+)
+
+// ChangeType 配置差异类型
+type ChangeType string
+
+// 配置差异类型
+const (
+	ChangeAdded    ChangeType = "added"    // 新增的值
+	ChangeRemoved  ChangeType = "removed"  // 删除的值
+	ChangeModified ChangeType = "modified" // 修改的值
+)
+
+// Change 两份配置之间的单个差异
+type Change struct {
+	Type   ChangeType  `json:"type"`             // 差异类型
+	Path   string      `json:"path"`             // 配置路径，如 /apps/http/servers/srv0/routes/0
+	Before interface{} `json:"before,omitempty"` // 原值 (新增时省略)
+	After  interface{} `json:"after,omitempty"`  // 新值 (删除时省略)
+}
+
+// Diff 比较两份 JSON 配置，返回按路径排序的差异列表
+// 对象按键、数组按下标递归比较，类型不同的值视为整体修改
+func Diff(before, after interface{}) []Change {
+	var changes []Change
+	diffValue(&changes, nil, before, after)
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+	return changes
+}
+
+// diffValue 递归比较单个值 - 内部辅助函数
+func diffValue(changes *[]Change, keys []string, before, after interface{}) {
+	switch b := before.(type) {
+	case map[string]interface{}:
+		if a, ok := after.(map[string]interface{}); ok {
+			for key, bv := range b {
+				if av, ok := a[key]; ok {
+					diffValue(changes, append(keys, key), bv, av)
+				} else {
+					*changes = append(*changes, Change{Type: ChangeRemoved, Path: KeysToPath(append(keys, key)...), Before: bv})
+				}
+			}
+			for key, av := range a {
+				if _, ok := b[key]; !ok {
+					*changes = append(*changes, Change{Type: ChangeAdded, Path: KeysToPath(append(keys, key)...), After: av})
+				}
+			}
+			return
+		}
+	case []interface{}:
+		if a, ok := after.([]interface{}); ok {
+			for i := 0; i < len(b) || i < len(a); i++ {
+				path := append(keys, strconv.Itoa(i))
+				switch {
+				case i >= len(a):
+					*changes = append(*changes, Change{Type: ChangeRemoved, Path: KeysToPath(path...), Before: b[i]})
+				case i >= len(b):
+					*changes = append(*changes, Change{Type: ChangeAdded, Path: KeysToPath(path...), After: a[i]})
+				default:
+					diffValue(changes, path, b[i], a[i])
+				}
+			}
+			return
+		}
+	}
+
+	if !reflect.DeepEqual(before, after) {
+		*changes = append(*changes, Change{Type: ChangeModified, Path: KeysToPath(keys...), Before: before, After: after})
+	}
+}
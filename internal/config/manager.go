@@ -1,6 +1,9 @@
 package config
 
 import (
+	"context"
+	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/youfun/gofastcaddy/internal/api"
@@ -13,8 +16,13 @@ type Manager struct {
 
 // NewManager 创建新的配置管理器
 func NewManager() *Manager {
+	return NewManagerWithClient(api.NewClient())
+}
+
+// NewManagerWithClient 使用指定的 API 客户端创建配置管理器
+func NewManagerWithClient(client *api.Client) *Manager {
 	return &Manager{
-		client: api.NewClient(),
+		client: client,
 	}
 }
 
@@ -72,23 +80,42 @@ func KeysToPath(keys ...string) string {
 }
 
 // NestedSetConfig 在配置中设置嵌套值 - 对应 Python 的 nested_setcfg(value, *keys) 函数
-// 获取当前配置，更新嵌套值，然后保存回去
+// 获取当前配置，更新嵌套值，然后保存回去；整个过程持有客户端的写锁。
+// 客户端开启 OptimisticConcurrency 时写回携带读取到的 ETag，
+// 配置被其他进程修改 (412) 时重新读取并重试，次数见 api.Client.MaxOptimisticRetries
 func (m *Manager) NestedSetConfig(value interface{}, keys ...string) error {
-	// 获取当前配置
-	config, err := m.client.GetConfig("/")
-	if err != nil {
-		return err
-	}
+	defer m.client.LockWrites()()
 
-	// 在配置中设置嵌套值
-	updatedConfig := NestedSetDict(config, value, keys...)
+	if !m.client.OptimisticConcurrency {
+		// 获取当前配置
+		config, err := m.client.GetConfig("/")
+		if err != nil {
+			return err
+		}
 
-	// 保存更新后的配置
-	return m.client.PutConfig(updatedConfig, "/", "POST")
+		// 在配置中设置嵌套值并保存
+		return m.client.PostConfig(NestedSetDict(config, value, keys...), "/")
+	}
+
+	var err error
+	retries := m.client.MaxOptimisticRetries()
+	for attempt := 0; attempt <= retries; attempt++ {
+		config, etag, getErr := m.client.GetConfigWithETag("/")
+		if getErr != nil {
+			return getErr
+		}
+		ctx := api.ContextWithIfMatch(context.Background(), etag)
+		err = m.client.PostConfigContext(ctx, NestedSetDict(config, value, keys...), "/")
+		if !api.IsPreconditionFailed(err) {
+			return err
+		}
+	}
+	return fmt.Errorf("配置持续被并发修改, 重试 %d 次后放弃: %w", retries, err)
 }
 
 // InitPath 初始化配置路径 - 对应 Python 的 init_path(path, skip) 函数
-// 逐步创建路径中的每个层级，跳过指定数量的初始层级
+// 逐步创建路径中的每个层级，跳过指定数量的初始层级；
+// 每个层级都以 POST 写入空对象，已存在的层级会被清空。需要保留现有配置时使用 EnsurePath
 func (m *Manager) InitPath(path string, skip int) error {
 	keys := PathToKeys(path)
 	var currentKeys []string
@@ -105,8 +132,39 @@ func (m *Manager) InitPath(path string, skip int) error {
 		// 为当前路径创建空配置
 		currentPath := KeysToPath(currentKeys...)
 		emptyConfig := make(map[string]interface{})
+		if err := m.client.PostConfig(emptyConfig, currentPath); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// EnsurePath 确保配置路径的每个层级都存在 - 参数含义同 InitPath
+// 已存在的层级保持不变，只为缺失的层级以 PUT 创建空对象；
+// 检查与创建之间被其他进程抢先创建 (409) 时视为已存在，可在多个控制器中同时运行
+func (m *Manager) EnsurePath(path string, skip int) error {
+	keys := PathToKeys(path)
+	var currentKeys []string
 
-		if err := m.client.PutConfig(emptyConfig, currentPath, "POST"); err != nil {
+	for i, key := range keys {
+		currentKeys = append(currentKeys, key)
+		if i < skip {
+			continue
+		}
+
+		// 当前层级已存在则跳过，避免覆盖其中的配置
+		currentPath := KeysToPath(currentKeys...)
+		exists, err := m.client.PathExists(currentPath)
+		if err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+
+		emptyConfig := make(map[string]interface{})
+		if err := m.client.CreateConfig(emptyConfig, currentPath); err != nil && !api.IsConflict(err) {
 			return err
 		}
 	}
@@ -114,6 +172,26 @@ func (m *Manager) InitPath(path string, skip int) error {
 	return nil
 }
 
+// DeletePath 删除指定配置路径的值
+func (m *Manager) DeletePath(path string) error {
+	return m.client.DeleteConfig(path)
+}
+
+// ListApps 列出当前配置中的全部应用名称，按字典序排列
+// 包括不由 fastcaddy 管理的应用，便于查看配置中还有哪些其他内容
+func (m *Manager) ListApps() ([]string, error) {
+	apps, err := m.client.GetConfig("/apps")
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(apps))
+	for name := range apps {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
 // GetClient 获取底层 API 客户端 - 提供对原始 API 的访问
 func (m *Manager) GetClient() *api.Client {
 	return m.client
@@ -0,0 +1,189 @@
+package config
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/youfun/gofastcaddy/internal/api"
+	"github.com/youfun/gofastcaddy/pkg/clienttest"
+)
+
+func TestInitAndEnsurePath(t *testing.T) {
+	tests := []struct {
+		name    string
+		initial string
+		ensure  bool
+		skip    int
+		want    string
+	}{
+		{
+			name:    "EnsurePath 创建缺失层级",
+			initial: `{}`,
+			ensure:  true,
+			want:    `{"apps":{"tls":{"automation":{}}}}`,
+		},
+		{
+			name:    "EnsurePath 保留已有配置",
+			initial: `{"apps":{"http":{"servers":{}},"tls":{"certificates":{}}}}`,
+			ensure:  true,
+			want:    `{"apps":{"http":{"servers":{}},"tls":{"automation":{},"certificates":{}}}}`,
+		},
+		{
+			name:    "EnsurePath 跳过前缀",
+			initial: `{"apps":{}}`,
+			ensure:  true,
+			skip:    1,
+			want:    `{"apps":{"tls":{"automation":{}}}}`,
+		},
+		{
+			name:    "InitPath 清空已有层级",
+			initial: `{"apps":{"http":{"servers":{}}}}`,
+			want:    `{"apps":{"tls":{"automation":{}}}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := clienttest.NewFakeCaddy(tt.initial)
+			defer fake.Close()
+			m := NewManagerWithClient(api.NewClientWithURL(fake.URL))
+
+			var err error
+			if tt.ensure {
+				err = m.EnsurePath("/apps/tls/automation", tt.skip)
+			} else {
+				err = m.InitPath("/apps/tls/automation", tt.skip)
+			}
+			if err != nil {
+				t.Fatalf("初始化路径: %v", err)
+			}
+			if got := fake.ConfigJSON(); got != tt.want {
+				t.Errorf("配置 = %s\n期望 = %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnsurePathUnreachable(t *testing.T) {
+	fake := clienttest.NewFakeCaddy(`{}`)
+	fake.Close()
+	m := NewManagerWithClient(api.NewClientWithURL(fake.URL))
+	if err := m.EnsurePath("/apps/tls", 0); err == nil {
+		t.Fatal("管理端点不可达时应返回错误")
+	}
+}
+
+func TestNestedSetConfig(t *testing.T) {
+	tests := []struct {
+		name       string
+		initial    string
+		optimistic bool
+		want       string
+	}{
+		{
+			name:    "设置嵌套值并保留其他配置",
+			initial: `{"admin":{"listen":"localhost:2019"},"apps":{"http":{}}}`,
+			want:    `{"admin":{"listen":"localhost:2019"},"apps":{"http":{"grace_period":"5s"}}}`,
+		},
+		{
+			name:    "创建缺失层级",
+			initial: `{}`,
+			want:    `{"apps":{"http":{"grace_period":"5s"}}}`,
+		},
+		{
+			name:       "乐观并发",
+			initial:    `{"apps":{"http":{"grace_period":"1s"}}}`,
+			optimistic: true,
+			want:       `{"apps":{"http":{"grace_period":"5s"}}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := clienttest.NewFakeCaddy(tt.initial)
+			defer fake.Close()
+			m := NewManagerWithClient(api.NewClientWithURL(fake.URL, api.WithOptimisticConcurrency(tt.optimistic)))
+
+			if err := m.NestedSetConfig("5s", "apps", "http", "grace_period"); err != nil {
+				t.Fatal(err)
+			}
+			if got := fake.ConfigJSON(); got != tt.want {
+				t.Errorf("配置 = %s\n期望 = %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeletePath(t *testing.T) {
+	fake := clienttest.NewFakeCaddy(`{"apps":{"http":{"servers":{"srv0":{"listen":[":80",":443"]}}}}}`)
+	defer fake.Close()
+	m := NewManagerWithClient(api.NewClientWithURL(fake.URL))
+
+	if err := m.DeletePath("/apps/http/servers/srv0/listen/0"); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := fake.ConfigJSON(), `{"apps":{"http":{"servers":{"srv0":{"listen":[":443"]}}}}}`; got != want {
+		t.Errorf("配置 = %s\n期望 = %s", got, want)
+	}
+	if err := m.DeletePath("/apps/tls"); !api.IsNotFound(err) {
+		t.Errorf("删除不存在的路径: err = %v, 期望不存在错误", err)
+	}
+}
+
+func TestNestedSetConfigLargeIntegers(t *testing.T) {
+	// 9007199254740993 = 2^53+1，float64 无法精确表示
+	const initial = `{"apps":{"http":{"servers":{"srv0":{"max_header_bytes":9007199254740993,"idle_timeout":9007199254740993}}}}}`
+	tests := []struct {
+		name    string
+		options []api.Option
+		want    string
+	}{
+		{
+			name:    "UseNumber 按原样写回",
+			options: []api.Option{api.WithUseNumber()},
+			want:    `{"apps":{"http":{"servers":{"srv0":{"idle_timeout":9007199254740993,"max_header_bytes":9007199254740993}}}},"logging":{}}`,
+		},
+		{
+			name: "默认解码为 float64 丢失精度",
+			want: `{"apps":{"http":{"servers":{"srv0":{"idle_timeout":9007199254740992,"max_header_bytes":9007199254740992}}}},"logging":{}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 保存原始请求体的管理端点，不经过解码
+			stored := []byte(initial)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodGet {
+					w.Write(stored)
+					return
+				}
+				stored, _ = io.ReadAll(r.Body)
+			}))
+			defer server.Close()
+
+			m := NewManagerWithClient(api.NewClientWithURL(server.URL, tt.options...))
+			if err := m.NestedSetConfig(map[string]interface{}{}, "logging"); err != nil {
+				t.Fatal(err)
+			}
+			if got := string(bytes.TrimSpace(stored)); got != tt.want {
+				t.Errorf("写回 = %s\n期望 = %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestListApps(t *testing.T) {
+	fake := clienttest.NewFakeCaddy(`{"apps":{"tls":{},"layer4":{},"http":{"servers":{}},"events":{}}}`)
+	defer fake.Close()
+	m := NewManagerWithClient(api.NewClientWithURL(fake.URL))
+
+	apps, err := m.ListApps()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := strings.Join(apps, ","), "events,http,layer4,tls"; got != want {
+		t.Errorf("ListApps = %s, 期望 %s", got, want)
+	}
+}
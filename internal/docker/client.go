@@ -0,0 +1,116 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// 常量定义 - Docker Engine API 连接参数
+const (
+	DockerHostEnv     = "DOCKER_HOST"                 // Docker 守护进程地址环境变量
+	DefaultDockerHost = "unix:///var/run/docker.sock" // 默认 Docker 守护进程地址
+)
+
+// Container 运行中容器的摘要信息 - 对应 Docker API 的 /containers/json 响应
+type Container struct {
+	ID              string            `json:"Id"`
+	Names           []string          `json:"Names"`
+	Labels          map[string]string `json:"Labels"`
+	NetworkSettings struct {
+		Networks map[string]struct {
+			IPAddress         string `json:"IPAddress"`
+			GlobalIPv6Address string `json:"GlobalIPv6Address"`
+		} `json:"Networks"`
+	} `json:"NetworkSettings"`
+}
+
+// Client Docker Engine API 的最小客户端 - 只使用标准库，不引入 Docker SDK 依赖
+type Client struct {
+	BaseURL    string       // 请求 URL 前缀 (unix 套接字时为 http://docker)
+	HTTPClient *http.Client // HTTP 客户端
+}
+
+// NewClient 根据 DOCKER_HOST 创建 Docker 客户端
+// 支持 unix:///path/to/docker.sock 和 tcp://host:port 两种格式
+func NewClient() (*Client, error) {
+	host := os.Getenv(DockerHostEnv)
+	if host == "" {
+		host = DefaultDockerHost
+	}
+	return NewClientWithHost(host)
+}
+
+// NewClientWithHost 使用指定的守护进程地址创建 Docker 客户端
+func NewClientWithHost(host string) (*Client, error) {
+	u, err := url.Parse(host)
+	if err != nil {
+		return nil, fmt.Errorf("解析 Docker 地址失败: %w", err)
+	}
+
+	switch u.Scheme {
+	case "unix":
+		socket := u.Path
+		transport := &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", socket)
+			},
+		}
+		return &Client{
+			BaseURL:    "http://docker",
+			HTTPClient: &http.Client{Transport: transport, Timeout: 30 * time.Second},
+		}, nil
+	case "tcp", "http":
+		return &Client{
+			BaseURL:    "http://" + u.Host,
+			HTTPClient: &http.Client{Timeout: 30 * time.Second},
+		}, nil
+	default:
+		return nil, fmt.Errorf("不支持的 Docker 地址: %s", host)
+	}
+}
+
+// ListContainers 列出带有指定标签的运行中容器
+func (c *Client) ListContainers(ctx context.Context, label string) ([]Container, error) {
+	filters, err := json.Marshal(map[string][]string{"label": {label}})
+	if err != nil {
+		return nil, fmt.Errorf("序列化过滤条件失败: %w", err)
+	}
+
+	reqURL := fmt.Sprintf("%s/containers/json?filters=%s", c.BaseURL, url.QueryEscape(string(filters)))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("创建 Docker 请求失败: %w", err)
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("查询 Docker 容器失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("查询 Docker 容器失败, 状态码: %d", resp.StatusCode)
+	}
+
+	var containers []Container
+	if err := json.NewDecoder(resp.Body).Decode(&containers); err != nil {
+		return nil, fmt.Errorf("解析 Docker 响应失败: %w", err)
+	}
+	return containers, nil
+}
+
+// Name 返回容器名称（去掉 Docker 添加的前导斜杠）
+func (c Container) Name() string {
+	if len(c.Names) == 0 {
+		return c.ID
+	}
+	return strings.TrimPrefix(c.Names[0], "/")
+}
@@ -0,0 +1,147 @@
+// Package migrations 提供对旧版本 fastcaddy 写入的配置进行规范化的迁移
+// 每个迁移都是幂等的：对已迁移的配置再次执行不会产生任何修改
+package migrations
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Migration 单个配置迁移
+type Migration struct {
+	Version int    // 迁移版本号，按升序执行
+	Name    string // 迁移名称
+
+	// Apply 原地修改配置树，返回被修改的路径列表；配置已符合要求时返回空列表
+	// 无法安全迁移时返回错误，此时配置树可能已被部分修改，调用方应丢弃该配置树
+	Apply func(node interface{}, path string) ([]string, error)
+}
+
+// Change 迁移产生的一项修改
+type Change struct {
+	Version   int    `json:"version"`   // 迁移版本号
+	Migration string `json:"migration"` // 迁移名称
+	Path      string `json:"path"`      // 被修改的配置路径
+}
+
+// registry 已注册的迁移，按版本号排列
+var registry = []Migration{
+	{Version: 1, Name: "normalize null match arrays", Apply: normalizeNullMatch},
+	{Version: 2, Name: "lowercase managed IDs", Apply: lowercaseIDs},
+}
+
+// All 返回全部迁移，按版本号升序排列
+func All() []Migration {
+	migrations := append([]Migration(nil), registry...)
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations
+}
+
+// Run 依次对配置树执行全部迁移并返回修改列表 - 配置树会被原地修改
+// root 为配置树在完整配置中的路径前缀，用于生成可读的修改路径；任一迁移失败时返回错误
+func Run(tree map[string]interface{}, root string) ([]Change, error) {
+	var changes []Change
+	for _, m := range All() {
+		paths, err := m.Apply(tree, root)
+		if err != nil {
+			return nil, fmt.Errorf("迁移 %d (%s) 失败: %w", m.Version, m.Name, err)
+		}
+		for _, path := range paths {
+			changes = append(changes, Change{Version: m.Version, Migration: m.Name, Path: path})
+		}
+	}
+	return changes, nil
+}
+
+// normalizeNullMatch 删除路由中值为 null 的 match 字段 - 旧版本在没有匹配条件时输出 "match": null
+func normalizeNullMatch(node interface{}, path string) ([]string, error) {
+	var changed []string
+	walk(node, path, func(obj map[string]interface{}, path string) {
+		_, hasHandle := obj["handle"]
+		if match, ok := obj["match"]; ok && match == nil && hasHandle {
+			delete(obj, "match")
+			changed = append(changed, path+"/match")
+		}
+	})
+	return changed, nil
+}
+
+// lowercaseIDs 将本库生成的 @id 规范化为小写 - 旧版本直接以原始主机名作为路由 ID
+// 只处理本库生成的路由 ID：与路由自身的某个 host 匹配项相同 (如 AddReverseProxy、AddSubReverseProxy)，
+// 或为 wildcard-<域名> 且匹配 *.<域名> (AddWildcardRoute)，比较时不区分大小写；其他 ID 保持不变。
+// 小写后的 ID 与配置中已有的 ID 冲突时返回错误，不做任何修改
+func lowercaseIDs(node interface{}, path string) ([]string, error) {
+	type rename struct {
+		obj  map[string]interface{}
+		path string
+		id   string
+	}
+	var renames []rename
+	ids := make(map[string]string) // @id -> 所在路径
+	walk(node, path, func(obj map[string]interface{}, path string) {
+		id, ok := obj["@id"].(string)
+		if !ok {
+			return
+		}
+		ids[id] = path
+		if lower := strings.ToLower(id); lower != id && generatedID(obj, lower) {
+			renames = append(renames, rename{obj: obj, path: path, id: lower})
+		}
+	})
+
+	for _, r := range renames {
+		if other, ok := ids[r.id]; ok {
+			return nil, fmt.Errorf("%s 的 @id 规范化为 %q 后与 %s 冲突", r.path, r.id, other)
+		}
+		ids[r.id] = r.path
+	}
+
+	var changed []string
+	for _, r := range renames {
+		r.obj["@id"] = r.id
+		changed = append(changed, r.path+"/@id")
+	}
+	return changed, nil
+}
+
+// generatedID 判断小写后的 ID 是否符合本库为路由生成 ID 的规则 - 内部辅助函数
+func generatedID(route map[string]interface{}, id string) bool {
+	if _, ok := route["handle"]; !ok {
+		return false
+	}
+	matchers, _ := route["match"].([]interface{})
+	for _, raw := range matchers {
+		matcher, _ := raw.(map[string]interface{})
+		hosts, _ := matcher["host"].([]interface{})
+		for _, h := range hosts {
+			host, _ := h.(string)
+			host = strings.ToLower(host)
+			if host == id || (strings.HasPrefix(host, "*.") && "wildcard-"+host[2:] == id) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// walk 深度优先遍历配置树中的每个对象 - 内部辅助函数
+// 对象的键按字典序遍历，保证修改列表的顺序稳定
+func walk(node interface{}, path string, visit func(map[string]interface{}, string)) {
+	switch v := node.(type) {
+	case map[string]interface{}:
+		visit(v, path)
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			walk(v[key], path+"/"+key, visit)
+		}
+	case []interface{}:
+		for i, item := range v {
+			walk(item, fmt.Sprintf("%s/%d", path, i), visit)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package migrations
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// decode 解析测试用的配置树 - 测试辅助函数
+func decode(t *testing.T, data string) map[string]interface{} {
+	t.Helper()
+	var tree map[string]interface{}
+	if err := json.Unmarshal([]byte(data), &tree); err != nil {
+		t.Fatalf("解析配置失败: %v", err)
+	}
+	return tree
+}
+
+func TestMigrations(t *testing.T) {
+	tests := []struct {
+		name    string
+		before  string
+		after   string
+		changed []string
+		err     string
+	}{
+		{
+			name:    "null match",
+			before:  `{"routes":[{"match":null,"handle":[{"handler":"file_server"}]}]}`,
+			after:   `{"routes":[{"handle":[{"handler":"file_server"}]}]}`,
+			changed: []string{"/routes/0/match"},
+		},
+		{
+			name:    "主机名路由 ID",
+			before:  `{"routes":[{"@id":"App.Example.com","match":[{"host":["App.Example.com"]}],"handle":[]}]}`,
+			after:   `{"routes":[{"@id":"app.example.com","match":[{"host":["App.Example.com"]}],"handle":[]}]}`,
+			changed: []string{"/routes/0/@id"},
+		},
+		{
+			name:    "通配符路由 ID",
+			before:  `{"routes":[{"@id":"wildcard-Example.com","match":[{"host":["*.example.com"]}],"handle":[]}]}`,
+			after:   `{"routes":[{"@id":"wildcard-example.com","match":[{"host":["*.example.com"]}],"handle":[]}]}`,
+			changed: []string{"/routes/0/@id"},
+		},
+		{
+			name:   "用户自定义 ID 保持不变",
+			before: `{"routes":[{"@id":"MyRoute","match":[{"host":["example.com"]}],"handle":[]}],"policy":{"@id":"TLS-Policy"}}`,
+			after:  `{"routes":[{"@id":"MyRoute","match":[{"host":["example.com"]}],"handle":[]}],"policy":{"@id":"TLS-Policy"}}`,
+		},
+		{
+			name:   "小写后与现有 ID 冲突",
+			before: `{"routes":[{"@id":"Example.com","match":[{"host":["Example.com"]}],"handle":[]},{"@id":"example.com","match":[{"host":["example.com"]}],"handle":[]}]}`,
+			err:    "冲突",
+		},
+		{
+			name:   "已迁移的配置",
+			before: `{"routes":[{"@id":"example.com","match":[{"host":["example.com"]}],"handle":[]}]}`,
+			after:  `{"routes":[{"@id":"example.com","match":[{"host":["example.com"]}],"handle":[]}]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tree := decode(t, tt.before)
+			changes, err := Run(tree, "")
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("错误 = %v, 期望包含 %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Run: %v", err)
+			}
+			if want := decode(t, tt.after); !reflect.DeepEqual(tree, want) {
+				got, _ := json.Marshal(tree)
+				t.Errorf("迁移结果 = %s\n期望 = %s", got, tt.after)
+			}
+			var paths []string
+			for _, c := range changes {
+				paths = append(paths, c.Path)
+			}
+			if !reflect.DeepEqual(paths, tt.changed) {
+				t.Errorf("修改路径 = %v, 期望 %v", paths, tt.changed)
+			}
+
+			// 迁移是幂等的
+			again, err := Run(tree, "")
+			if err != nil || len(again) != 0 {
+				t.Errorf("再次迁移 = %v, %v, 期望没有修改", again, err)
+			}
+		})
+	}
+}
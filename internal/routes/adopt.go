@@ -0,0 +1,49 @@
+package routes
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/youfun/gofastcaddy/internal/api"
+)
+
+// AdoptRoute 为已存在但没有 @id 的路由分配 @id，使其可以通过本库管理
+// 只写入路由的 @id 字段，不会修改匹配器和处理器。写入前重新读取该位置的路由，确认其 host 匹配器
+// 仍包含 host (路由列表可能已被其他进程修改)，并以读取时的 ETag 作为 If-Match 写入，
+// 期间配置发生变化时返回错误而不是给错误的路由分配 @id；路由已有 @id 或 id 已被占用时同样返回错误
+func (m *Manager) AdoptRoute(server string, index int, host, id string) error {
+	if id == "" {
+		return fmt.Errorf("路由 ID 不能为空")
+	}
+
+	defer m.client.LockWrites()()
+
+	taken, err := m.client.IDExists(id)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return fmt.Errorf("ID %s 已被其他配置使用", id)
+	}
+
+	routePath := fmt.Sprintf("%s/%s/routes/%d", ServersPath, server, index)
+	route, etag, err := m.client.GetConfigWithETag(routePath)
+	if err != nil {
+		return fmt.Errorf("读取路由 %s 失败: %w", routePath, err)
+	}
+	if route == nil {
+		return fmt.Errorf("路由 %s 不存在", routePath)
+	}
+	if existing, ok := route["@id"].(string); ok && existing != "" {
+		return fmt.Errorf("路由 %s 已有 ID %s", routePath, existing)
+	}
+	if hosts, _ := routeHosts(route); !containsHost(hosts, host) {
+		return fmt.Errorf("路由 %s 不再匹配主机名 %s, 路由列表可能已被修改", routePath, host)
+	}
+
+	err = m.client.PostConfigContext(api.ContextWithIfMatch(context.Background(), etag), id, routePath+"/@id")
+	if api.IsPreconditionFailed(err) {
+		return fmt.Errorf("路由 %s 在读取后被修改, 未分配 ID: %w", routePath, err)
+	}
+	return err
+}
@@ -0,0 +1,76 @@
+package routes
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/youfun/gofastcaddy/internal/api"
+	"github.com/youfun/gofastcaddy/pkg/clienttest"
+)
+
+func TestAdoptRoute(t *testing.T) {
+	const config = `{"apps":{"http":{"servers":{"srv0":{"listen":[":443"],"routes":[` +
+		`{"match":[{"host":["a.example.com"]}],"handle":[{"handler":"reverse_proxy","upstreams":[{"dial":"a:80"}]}]},` +
+		`{"@id":"b.example.com","match":[{"host":["b.example.com"]}],"handle":[{"handler":"reverse_proxy","upstreams":[{"dial":"b:80"}]}]}` +
+		`]}}}}}`
+	// 在 AdoptRoute 读取路由之后、写入之前插入一个路由，模拟其他进程的并发修改
+	const shifted = `{"apps":{"http":{"servers":{"srv0":{"listen":[":443"],"routes":[` +
+		`{"match":[{"host":["new.example.com"]}],"handle":[{"handler":"static_response"}]},` +
+		`{"match":[{"host":["a.example.com"]}],"handle":[{"handler":"reverse_proxy","upstreams":[{"dial":"a:80"}]}]}` +
+		`]}}}}}`
+
+	tests := []struct {
+		name    string
+		index   int
+		host    string
+		id      string
+		modify  bool // 读取后修改配置
+		wantErr string
+	}{
+		{name: "接管", index: 0, host: "a.example.com", id: "a.example.com"},
+		{name: "主机名不匹配", index: 0, host: "c.example.com", id: "c.example.com", wantErr: "不再匹配主机名"},
+		{name: "已有 ID", index: 1, host: "b.example.com", id: "b2", wantErr: "已有 ID b.example.com"},
+		{name: "ID 已被占用", index: 0, host: "a.example.com", id: "b.example.com", wantErr: "已被其他配置使用"},
+		{name: "路由不存在", index: 5, host: "a.example.com", id: "a.example.com", wantErr: "读取路由"},
+		{name: "读取后被修改", index: 0, host: "a.example.com", id: "a.example.com", modify: true, wantErr: "在读取后被修改"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := clienttest.NewFakeCaddy(config)
+			defer fake.Close()
+			var opts []api.Option
+			if tt.modify {
+				opts = append(opts, api.WithMiddleware(func(next api.RoundTripFunc) api.RoundTripFunc {
+					return func(req *http.Request) (*http.Response, error) {
+						resp, err := next(req)
+						if req.Method == http.MethodGet && strings.Contains(req.URL.Path, "/routes/0") {
+							fake.SetConfig(shifted)
+						}
+						return resp, err
+					}
+				}))
+			}
+			m := NewManagerWithClient(api.NewClientWithURL(fake.URL, opts...))
+
+			err := m.AdoptRoute(DefaultServerName, tt.index, tt.host, tt.id)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("错误 = %v, 期望包含 %q", err, tt.wantErr)
+				}
+				if tt.modify {
+					if routes := serverRoutes(t, fake); routes[0]["@id"] != nil || routes[1]["@id"] != nil {
+						t.Errorf("并发修改后仍写入了 @id: %s", fake.ConfigJSON())
+					}
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if route := getRoute(t, m, tt.id); route["@id"] != tt.id {
+				t.Errorf("路由 = %v", route)
+			}
+		})
+	}
+}
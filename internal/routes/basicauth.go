@@ -0,0 +1,260 @@
+package routes
+
+import (
+	"fmt"
+	"sort"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// BasicAuthHandler Caddy 认证处理器名称
+const BasicAuthHandler = "authentication"
+
+// DefaultBasicAuthRealm 新建认证处理器时默认使用的 realm
+const DefaultBasicAuthRealm = "restricted"
+
+// BasicAuthOptions basic auth 账户管理选项
+type BasicAuthOptions struct {
+	RemoveHandlerWhenEmpty bool // 删除最后一个用户时移除整个认证处理器（默认返回错误）
+
+	// Realm 浏览器登录框中显示的 realm (为空表示保持现有设置，新建认证处理器时使用 DefaultBasicAuthRealm)
+	Realm string
+}
+
+// SetBasicAuthAccounts 整体替换路由的 basic auth 账户列表
+// accounts 为用户名到明文密码的映射，密码在客户端使用 bcrypt 哈希后再发送给 Caddy；
+// 路由上尚无认证处理器时会插入到处理器链最前面。已有认证处理器时只替换账户列表，
+// hash、realm 等其他设置保持不变；已有设置使用 bcrypt 以外的哈希算法时返回错误
+func (m *Manager) SetBasicAuthAccounts(routeID string, accounts map[string]string) error {
+	return m.SetBasicAuthAccountsWithOptions(routeID, accounts, BasicAuthOptions{})
+}
+
+// SetBasicAuthAccountsWithOptions 按选项整体替换路由的 basic auth 账户列表 - 见 SetBasicAuthAccounts
+func (m *Manager) SetBasicAuthAccountsWithOptions(routeID string, accounts map[string]string, opts BasicAuthOptions) error {
+	if len(accounts) == 0 {
+		return fmt.Errorf("账户列表不能为空")
+	}
+
+	// 按用户名排序，保证生成的配置稳定
+	usernames := make([]string, 0, len(accounts))
+	for username := range accounts {
+		usernames = append(usernames, username)
+	}
+	sort.Strings(usernames)
+
+	var list []interface{}
+	for _, username := range usernames {
+		account, err := newBasicAuthAccount(username, accounts[username])
+		if err != nil {
+			return err
+		}
+		list = append(list, account)
+	}
+
+	defer m.client.LockWrites()()
+
+	handler, index, err := m.basicAuthHandler(routeID)
+	if err != nil {
+		return err
+	}
+	return m.saveBasicAuthHandler(routeID, index, handler, list, opts.Realm)
+}
+
+// AddBasicAuthUser 向路由的 basic auth 账户列表追加一个用户
+// 用户名已存在时返回错误，不会覆盖原有密码；认证处理器的其他设置同 SetBasicAuthAccounts
+func (m *Manager) AddBasicAuthUser(routeID, username, password string) error {
+	return m.AddBasicAuthUserWithOptions(routeID, username, password, BasicAuthOptions{})
+}
+
+// AddBasicAuthUserWithOptions 按选项向路由的 basic auth 账户列表追加一个用户 - 见 AddBasicAuthUser
+func (m *Manager) AddBasicAuthUserWithOptions(routeID, username, password string, opts BasicAuthOptions) error {
+	account, err := newBasicAuthAccount(username, password)
+	if err != nil {
+		return err
+	}
+
+	defer m.client.LockWrites()()
+
+	handler, index, err := m.basicAuthHandler(routeID)
+	if err != nil {
+		return err
+	}
+
+	accounts := basicAuthAccounts(handler)
+	for _, existing := range accounts {
+		if acct, ok := existing.(map[string]interface{}); ok && acct["username"] == username {
+			return fmt.Errorf("用户 %s 已存在", username)
+		}
+	}
+
+	return m.saveBasicAuthHandler(routeID, index, handler, append(accounts, account), opts.Realm)
+}
+
+// RemoveBasicAuthUser 从路由的 basic auth 账户列表删除一个用户
+// 删除最后一个用户时，按 opts.RemoveHandlerWhenEmpty 移除认证处理器或返回错误
+func (m *Manager) RemoveBasicAuthUser(routeID, username string, opts BasicAuthOptions) error {
+	defer m.client.LockWrites()()
+
+	handler, index, err := m.basicAuthHandler(routeID)
+	if err != nil {
+		return err
+	}
+	if handler == nil {
+		return fmt.Errorf("路由 %s 未配置 basic auth", routeID)
+	}
+
+	var remaining []interface{}
+	found := false
+	for _, existing := range basicAuthAccounts(handler) {
+		if acct, ok := existing.(map[string]interface{}); ok && acct["username"] == username {
+			found = true
+			continue
+		}
+		remaining = append(remaining, existing)
+	}
+	if !found {
+		return fmt.Errorf("用户 %s 不存在", username)
+	}
+
+	if len(remaining) == 0 {
+		if !opts.RemoveHandlerWhenEmpty {
+			return fmt.Errorf("不能删除路由 %s 的最后一个 basic auth 用户", routeID)
+		}
+		return m.client.DeleteByID(fmt.Sprintf("%s/handle/%d", routeID, index))
+	}
+
+	return m.saveBasicAuthHandler(routeID, index, handler, remaining, opts.Realm)
+}
+
+// ListBasicAuthUsers 列出路由的 basic auth 用户名 - 不返回密码哈希
+func (m *Manager) ListBasicAuthUsers(routeID string) ([]string, error) {
+	handler, _, err := m.basicAuthHandler(routeID)
+	if err != nil {
+		return nil, err
+	}
+
+	var usernames []string
+	for _, existing := range basicAuthAccounts(handler) {
+		if acct, ok := existing.(map[string]interface{}); ok {
+			if username, ok := acct["username"].(string); ok {
+				usernames = append(usernames, username)
+			}
+		}
+	}
+	sort.Strings(usernames)
+	return usernames, nil
+}
+
+// basicAuthHandler 读取路由上的认证处理器及其在处理器链中的位置 - 内部辅助函数
+// 路由上没有认证处理器时返回 nil
+func (m *Manager) basicAuthHandler(routeID string) (map[string]interface{}, int, error) {
+	route, err := m.client.GetByID(routeID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	handlers, _ := route["handle"].([]interface{})
+	for i, raw := range handlers {
+		if handler, ok := raw.(map[string]interface{}); ok && handler["handler"] == BasicAuthHandler {
+			return handler, i, nil
+		}
+	}
+	return nil, -1, nil
+}
+
+// saveBasicAuthHandler 写入认证处理器 - 内部辅助函数
+// existing 为现有的认证处理器 (nil 表示新建)，只替换其中的账户列表，realm 非空时同时替换 realm；
+// index 为 -1 时在处理器链最前面插入新的处理器，否则替换原位置的处理器；
+// 开启 NormalizeHandlerOrder 时插入、开启 UpdateTimestamps 时插入和替换都会整体写入处理器链，见 saveHandlers
+func (m *Manager) saveBasicAuthHandler(routeID string, index int, existing map[string]interface{}, accounts []interface{}, realm string) error {
+	handler, err := basicAuthConfig(existing, accounts, realm)
+	if err != nil {
+		return fmt.Errorf("路由 %s: %w", routeID, err)
+	}
+
+	if index >= 0 && !m.client.UpdateTimestamps {
+		return m.client.PatchByID(handler, fmt.Sprintf("%s/handle/%d", routeID, index))
+	}
+	if index < 0 && !m.NormalizeHandlerOrder && !m.client.UpdateTimestamps {
+		return m.client.CreateByID(handler, routeID+"/handle/0")
+	}
+
+	// 整体写入处理器链，使其余处理器按规范顺序排列或同时更新最后修改时间
+	handlers, err := m.routeHandlers(routeID)
+	if err != nil {
+		return err
+	}
+	if index < 0 {
+		return m.saveHandlers(routeID, append([]interface{}{handler}, handlers...))
+	}
+	handlers[index] = handler
+	return m.saveHandlers(routeID, handlers)
+}
+
+// basicAuthConfig 生成认证处理器配置 - 内部辅助函数
+// 复制 existing 的各层设置 (不修改 existing)，只替换 http_basic 的账户列表和非空的 realm；
+// 新建时使用 bcrypt 哈希和 DefaultBasicAuthRealm。账户密码总是 bcrypt 哈希，
+// 因此现有设置使用其他哈希算法时返回错误
+func basicAuthConfig(existing map[string]interface{}, accounts []interface{}, realm string) (map[string]interface{}, error) {
+	handler := map[string]interface{}{"handler": BasicAuthHandler}
+	for key, value := range existing {
+		handler[key] = value
+	}
+	providers := map[string]interface{}{}
+	if current, ok := handler["providers"].(map[string]interface{}); ok {
+		for key, value := range current {
+			providers[key] = value
+		}
+	}
+	httpBasic := map[string]interface{}{}
+	if current, ok := providers["http_basic"].(map[string]interface{}); ok {
+		for key, value := range current {
+			httpBasic[key] = value
+		}
+	}
+
+	hash, _ := httpBasic["hash"].(map[string]interface{})
+	if hash == nil {
+		httpBasic["hash"] = map[string]interface{}{"algorithm": "bcrypt"}
+	} else if algorithm, _ := hash["algorithm"].(string); algorithm != "bcrypt" {
+		return nil, fmt.Errorf("basic auth 使用 %q 哈希算法, 无法写入 bcrypt 哈希的密码", algorithm)
+	}
+	if realm != "" {
+		httpBasic["realm"] = realm
+	} else if _, ok := httpBasic["realm"]; !ok && existing == nil {
+		httpBasic["realm"] = DefaultBasicAuthRealm
+	}
+	httpBasic["accounts"] = accounts
+	providers["http_basic"] = httpBasic
+	handler["providers"] = providers
+	return handler, nil
+}
+
+// basicAuthAccounts 提取认证处理器中的账户列表 - 内部辅助函数
+func basicAuthAccounts(handler map[string]interface{}) []interface{} {
+	providers, _ := handler["providers"].(map[string]interface{})
+	httpBasic, _ := providers["http_basic"].(map[string]interface{})
+	accounts, _ := httpBasic["accounts"].([]interface{})
+	return accounts
+}
+
+// newBasicAuthAccount 校验并生成单个账户配置 - 内部辅助函数
+// Caddy 2.6+ 可直接使用 bcrypt 哈希字符串作为 password 字段
+func newBasicAuthAccount(username, password string) (map[string]interface{}, error) {
+	if username == "" {
+		return nil, fmt.Errorf("用户名不能为空")
+	}
+	if password == "" {
+		return nil, fmt.Errorf("用户 %s 的密码不能为空", username)
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, fmt.Errorf("生成用户 %s 的密码哈希失败: %w", username, err)
+	}
+
+	return map[string]interface{}{
+		"username": username,
+		"password": string(hash),
+	}, nil
+}
@@ -0,0 +1,119 @@
+package routes
+
+import (
+	"strings"
+	"testing"
+)
+
+// basicAuthRoute 带认证处理器的路由配置 - 测试辅助函数
+func basicAuthRoute(httpBasic string) string {
+	return `{"apps":{"http":{"servers":{"srv0":{"listen":[":443"],"routes":[{"@id":"app","match":[{"host":["app.example.com"]}],"handle":[` +
+		`{"handler":"authentication","providers":{"http_basic":` + httpBasic + `}},` +
+		`{"handler":"reverse_proxy","upstreams":[{"dial":"app:80"}]}]}]}}}}}`
+}
+
+// httpBasicConfig 读取路由认证处理器的 http_basic 设置 - 测试辅助函数
+func httpBasicConfig(t *testing.T, m *Manager, id string) map[string]interface{} {
+	t.Helper()
+	handler, _, err := m.basicAuthHandler(id)
+	if err != nil {
+		t.Fatalf("读取认证处理器: %v", err)
+	}
+	providers, _ := handler["providers"].(map[string]interface{})
+	httpBasic, _ := providers["http_basic"].(map[string]interface{})
+	return httpBasic
+}
+
+func TestBasicAuthPreservesProviderSettings(t *testing.T) {
+	tests := []struct {
+		name      string
+		initial   string
+		update    func(m *Manager) error
+		wantErr   string
+		wantHash  string
+		wantRealm interface{}
+		wantUsers []string
+	}{
+		{
+			name:      "新建时使用默认 bcrypt 哈希和 realm",
+			initial:   `{"apps":{"http":{"servers":{"srv0":{"listen":[":443"],"routes":[{"@id":"app","handle":[{"handler":"reverse_proxy","upstreams":[{"dial":"app:80"}]}]}]}}}}}`,
+			update:    func(m *Manager) error { return m.AddBasicAuthUser("app", "alice", "secret") },
+			wantHash:  `{"algorithm":"bcrypt"}`,
+			wantRealm: DefaultBasicAuthRealm,
+			wantUsers: []string{"alice"},
+		},
+		{
+			name:    "新建时使用选项中的 realm",
+			initial: `{"apps":{"http":{"servers":{"srv0":{"listen":[":443"],"routes":[{"@id":"app","handle":[{"handler":"reverse_proxy","upstreams":[{"dial":"app:80"}]}]}]}}}}}`,
+			update: func(m *Manager) error {
+				return m.SetBasicAuthAccountsWithOptions("app", map[string]string{"alice": "secret"}, BasicAuthOptions{Realm: "admin"})
+			},
+			wantHash:  `{"algorithm":"bcrypt"}`,
+			wantRealm: "admin",
+			wantUsers: []string{"alice"},
+		},
+		{
+			name:      "追加用户时保留哈希参数和 realm",
+			initial:   basicAuthRoute(`{"hash":{"algorithm":"bcrypt","cost":12},"realm":"staff","accounts":[{"username":"bob","password":"hash"}]}`),
+			update:    func(m *Manager) error { return m.AddBasicAuthUser("app", "alice", "secret") },
+			wantHash:  `{"algorithm":"bcrypt","cost":12}`,
+			wantRealm: "staff",
+			wantUsers: []string{"alice", "bob"},
+		},
+		{
+			name:      "现有配置没有 realm 时不补默认值",
+			initial:   basicAuthRoute(`{"hash":{"algorithm":"bcrypt"},"accounts":[{"username":"bob","password":"hash"}]}`),
+			update:    func(m *Manager) error { return m.SetBasicAuthAccounts("app", map[string]string{"alice": "secret"}) },
+			wantHash:  `{"algorithm":"bcrypt"}`,
+			wantRealm: nil,
+			wantUsers: []string{"alice"},
+		},
+		{
+			name:    "删除用户时可以修改 realm",
+			initial: basicAuthRoute(`{"hash":{"algorithm":"bcrypt"},"realm":"staff","accounts":[{"username":"alice","password":"hash"},{"username":"bob","password":"hash"}]}`),
+			update: func(m *Manager) error {
+				return m.RemoveBasicAuthUser("app", "bob", BasicAuthOptions{Realm: "admin"})
+			},
+			wantHash:  `{"algorithm":"bcrypt"}`,
+			wantRealm: "admin",
+			wantUsers: []string{"alice"},
+		},
+		{
+			name:      "其他哈希算法时返回错误",
+			initial:   basicAuthRoute(`{"hash":{"algorithm":"argon2id"},"realm":"staff","accounts":[{"username":"bob","password":"hash"}]}`),
+			update:    func(m *Manager) error { return m.AddBasicAuthUser("app", "alice", "secret") },
+			wantErr:   "argon2id",
+			wantHash:  `{"algorithm":"argon2id"}`,
+			wantRealm: "staff",
+			wantUsers: []string{"bob"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, _ := newTestManager(t, tt.initial)
+			err := tt.update(m)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, 期望包含 %q", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("更新 basic auth: %v", err)
+			}
+
+			httpBasic := httpBasicConfig(t, m, "app")
+			if got := encodeJSON(httpBasic["hash"]); got != tt.wantHash {
+				t.Errorf("hash = %s, 期望 %s", got, tt.wantHash)
+			}
+			if httpBasic["realm"] != tt.wantRealm {
+				t.Errorf("realm = %v, 期望 %v", httpBasic["realm"], tt.wantRealm)
+			}
+			users, err := m.ListBasicAuthUsers("app")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.Join(users, ",") != strings.Join(tt.wantUsers, ",") {
+				t.Errorf("users = %v, 期望 %v", users, tt.wantUsers)
+			}
+		})
+	}
+}
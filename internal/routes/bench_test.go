@@ -0,0 +1,82 @@
+package routes
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/youfun/gofastcaddy/internal/api"
+	"github.com/youfun/gofastcaddy/pkg/clienttest"
+)
+
+// BenchmarkAddSubReverseProxy500 向假管理端点添加 500 个子域名反向代理，dials/op 为每轮新建的连接数
+// 比较 Go 默认的每主机 2 个空闲连接与 DefaultMaxIdleConnsPerHost (16 个 goroutine 并发添加)，以及一次请求的批量添加。
+// 追加子路由持有写锁串行执行，请求之间连接总会归还连接池，两种连接池都只建立一个连接；
+// 大量添加子路由时吞吐量的提升来自 BatchAddSubReverseProxy 减少的请求数
+func BenchmarkAddSubReverseProxy500(b *testing.B) {
+	const subdomains, workers = 500, 16
+	tests := []struct {
+		name  string
+		conns int  // 每主机空闲连接数，0 表示使用 DefaultMaxIdleConnsPerHost
+		batch bool // 使用 BatchAddSubReverseProxy
+	}{
+		{name: "Go默认连接池", conns: 2},
+		{name: "默认连接池"},
+		{name: "批量添加", batch: true},
+	}
+	for _, tt := range tests {
+		b.Run(tt.name, func(b *testing.B) {
+			var dials int64
+			var dialer net.Dialer
+			transport := &http.Transport{DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+				atomic.AddInt64(&dials, 1)
+				return dialer.DialContext(ctx, network, addr)
+			}}
+
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				fake := clienttest.NewFakeCaddy(emptyServer)
+				m := NewManagerWithClient(api.NewClientWithURL(fake.URL,
+					api.WithTransport(transport), api.WithMaxIdleConns(tt.conns)))
+				if err := m.AddWildcardRoute("example.com"); err != nil {
+					b.Fatal(err)
+				}
+				b.StartTimer()
+
+				if tt.batch {
+					entries := make([]SubProxySpec, subdomains)
+					for n := range entries {
+						entries[n] = SubProxySpec{Subdomain: fmt.Sprintf("app%d", n), Ports: []string{"8080"}}
+					}
+					if err := m.BatchAddSubReverseProxy("example.com", entries); err != nil {
+						b.Fatal(err)
+					}
+				} else {
+					var wg sync.WaitGroup
+					next := int64(-1)
+					for w := 0; w < workers; w++ {
+						wg.Add(1)
+						go func() {
+							defer wg.Done()
+							for n := atomic.AddInt64(&next, 1); n < subdomains; n = atomic.AddInt64(&next, 1) {
+								if err := m.AddSubReverseProxy("example.com", fmt.Sprintf("app%d", n), []string{"8080"}, ""); err != nil {
+									b.Error(err)
+								}
+							}
+						}()
+					}
+					wg.Wait()
+				}
+
+				b.StopTimer()
+				fake.Close()
+				b.StartTimer()
+			}
+			b.ReportMetric(float64(atomic.LoadInt64(&dials))/float64(b.N), "dials/op")
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package routes
+
+import (
+	"github.com/youfun/gofastcaddy/pkg/types"
+)
+
+// MetricsVarName 路由指标名称写入的 vars 键名
+const MetricsVarName = "route_name"
+
+// RouteBuilder 路由构建器 - 以链式调用组装 types.Route
+// 处理器按添加顺序排列，MetricsName 设置的 vars 处理器始终位于最前面
+type RouteBuilder struct {
+	route       types.Route
+	metricsName string
+}
+
+// NewRouteBuilder 创建指定 ID 的路由构建器 - 路由默认为终端路由
+func NewRouteBuilder(id string) *RouteBuilder {
+	return &RouteBuilder{
+		route: types.Route{
+			ID:       id,
+			Terminal: true,
+		},
+	}
+}
+
+// Host 添加主机名匹配条件
+func (b *RouteBuilder) Host(hosts ...string) *RouteBuilder {
+	b.route.Match = append(b.route.Match, types.RouteMatch{Host: hosts})
+	return b
+}
+
+// Path 添加路径匹配条件
+func (b *RouteBuilder) Path(paths ...string) *RouteBuilder {
+	b.route.Match = append(b.route.Match, types.RouteMatch{Path: paths})
+	return b
+}
+
+// Handle 追加处理器
+func (b *RouteBuilder) Handle(handler types.Handler) *RouteBuilder {
+	b.route.Handle = append(b.route.Handle, handler)
+	return b
+}
+
+// Match 添加由注册的匹配器模块构成的匹配条件
+func (b *RouteBuilder) Match(matchers ...types.MatcherModule) *RouteBuilder {
+	b.route.Match = append(b.route.Match, types.RouteMatch{Modules: matchers})
+	return b
+}
+
+// Handler 追加由注册的处理器模块构成的处理器
+func (b *RouteBuilder) Handler(module types.HandlerModule) *RouteBuilder {
+	return b.Handle(types.Handler{
+		Handler: module.CaddyHandler(),
+		Module:  module,
+	})
+}
+
+// ReverseProxy 追加反向代理处理器
+func (b *RouteBuilder) ReverseProxy(dials ...string) *RouteBuilder {
+	var upstreams []types.Upstream
+	for _, dial := range dials {
+		upstreams = append(upstreams, types.Upstream{Dial: dial})
+	}
+	return b.Handle(types.Handler{
+		Handler:   "reverse_proxy",
+		Upstreams: upstreams,
+	})
+}
+
+// Terminal 设置是否为终端路由
+func (b *RouteBuilder) Terminal(terminal bool) *RouteBuilder {
+	b.route.Terminal = terminal
+	return b
+}
+
+// MetricsName 设置路由的指标名称 - 以 vars 处理器写入 route_name 变量
+//
+// Caddy 内置的 HTTP 指标（2.5+ 的 servers.<name>.metrics，2.10+ 为 http 应用的 metrics）
+// 只带 server 和 handler 标签，不会把 vars 变量作为 Prometheus 标签输出；
+// 该变量可通过 {http.vars.route_name} 占位符在访问日志中引用，
+// 或由读取 vars 的指标插件转换为标签，从而在大量路由中区分各自的请求序列。
+// 通配符路由要求第一个处理器为 subroute，不要为其设置指标名称
+func (b *RouteBuilder) MetricsName(name string) *RouteBuilder {
+	b.metricsName = name
+	return b
+}
+
+// Build 生成路由配置
+func (b *RouteBuilder) Build() types.Route {
+	route := b.route
+	route.Handle = append([]types.Handler(nil), b.route.Handle...)
+	if b.metricsName != "" {
+		vars := types.Handler{
+			Handler: "vars",
+			Extra:   map[string]interface{}{MetricsVarName: b.metricsName},
+		}
+		route.Handle = append([]types.Handler{vars}, route.Handle...)
+	}
+	return route
+}
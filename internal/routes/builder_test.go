@@ -0,0 +1,85 @@
+package routes
+
+import (
+	"testing"
+
+	"github.com/youfun/gofastcaddy/pkg/types"
+)
+
+func TestRouteBuilderMetricsName(t *testing.T) {
+	proxy := types.Handler{Handler: "reverse_proxy", Upstreams: []types.Upstream{{Dial: "app:80"}}}
+	tests := []struct {
+		name    string
+		builder *RouteBuilder
+		want    string // 生成的处理器链
+	}{
+		{
+			name:    "未设置指标名称",
+			builder: NewRouteBuilder("app").Host("app.example.com").Handle(proxy),
+			want:    `[{"handler":"reverse_proxy","upstreams":[{"dial":"app:80"}]}]`,
+		},
+		{
+			name:    "vars 处理器位于最前面",
+			builder: NewRouteBuilder("app").Host("app.example.com").Handle(proxy).MetricsName("app"),
+			want:    `[{"handler":"vars","route_name":"app"},{"handler":"reverse_proxy","upstreams":[{"dial":"app:80"}]}]`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			route := tt.builder.Build()
+			if got := encodeJSON(route.Handle); got != tt.want {
+				t.Errorf("处理器 = %s\n期望 = %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDescribeReportsMetricsName(t *testing.T) {
+	m, _ := newTestManager(t, emptyServer)
+	route := NewRouteBuilder("app").
+		Host("app.example.com").
+		Handle(types.Handler{Handler: "reverse_proxy", Upstreams: []types.Upstream{{Dial: "app:80"}}}).
+		MetricsName("app-route").
+		Build()
+	if err := m.AddRoute(route); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.SetRouteNote("app", "生产环境"); err != nil {
+		t.Fatal(err)
+	}
+
+	desc, err := m.Describe("app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if desc.MetricsName != "app-route" || desc.Note != "生产环境" {
+		t.Errorf("MetricsName = %q, Note = %q", desc.MetricsName, desc.Note)
+	}
+}
+
+// rateLimitHandler 未在 types 中注册的自定义处理器模块 - 测试用
+type rateLimitHandler struct {
+	Rate string `json:"rate"`
+}
+
+func (rateLimitHandler) CaddyHandler() string { return "rate_limit" }
+
+// countryMatcher 自定义匹配器模块 - 测试用
+type countryMatcher []string
+
+func (countryMatcher) CaddyMatcher() string { return "country" }
+
+func TestRouteBuilderCustomModules(t *testing.T) {
+	route := NewRouteBuilder("api").
+		Host("api.example.com").
+		Match(countryMatcher{"CN", "US"}).
+		Handler(&rateLimitHandler{Rate: "10r/s"}).
+		ReverseProxy("api:80").
+		Build()
+
+	want := `{"@id":"api","match":[{"host":["api.example.com"]},{"country":["CN","US"]}],` +
+		`"handle":[{"handler":"rate_limit","rate":"10r/s"},{"handler":"reverse_proxy","upstreams":[{"dial":"api:80"}]}],"terminal":true}`
+	if got := encodeJSON(route); got != want {
+		t.Errorf("路由 = %s\n期望 = %s", got, want)
+	}
+}
@@ -0,0 +1,188 @@
+package routes
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/youfun/gofastcaddy/internal/docker"
+	"github.com/youfun/gofastcaddy/pkg/types"
+)
+
+// DockerRoutePrefix Docker 同步生成的路由 ID 前缀 - 用于识别需要清理的路由
+const DockerRoutePrefix = "docker-"
+
+// SyncFromDocker 根据 Docker 容器标签同步反向代理路由
+// 查询带有 <labelPrefix>.host 标签的运行中容器，使用 <labelPrefix>.port 作为上游端口，
+// 为每个容器创建或更新路由，并删除对应容器已消失的路由。
+// 上游地址默认使用容器第一个网络的 IP (没有 IPv4 地址时使用 IPv6 地址)，可通过 <labelPrefix>.upstream 标签覆盖。
+// 单个容器或路由出错时继续处理其余的容器，最后返回汇总的错误
+func (m *Manager) SyncFromDocker(ctx context.Context, labelPrefix string) error {
+	cli, err := docker.NewClient()
+	if err != nil {
+		return err
+	}
+	return m.SyncFromDockerClient(ctx, cli, labelPrefix)
+}
+
+// SyncFromDockerClient 使用指定的 Docker 客户端同步反向代理路由 - 语义同 SyncFromDocker
+func (m *Manager) SyncFromDockerClient(ctx context.Context, cli *docker.Client, labelPrefix string) error {
+	labelPrefix = strings.TrimSuffix(labelPrefix, ".")
+	if labelPrefix == "" {
+		return fmt.Errorf("标签前缀不能为空")
+	}
+
+	containers, err := cli.ListContainers(ctx, labelPrefix+".host")
+	if err != nil {
+		return err
+	}
+
+	// 根据容器标签计算期望的路由：主机名 -> 上游地址
+	// 单个容器的错误不影响其他容器；出错的主机名保留现有路由，避免因标签写错而删除正在使用的路由
+	var failed []string
+	desired := make(map[string]string)
+	conflicted := make(map[string]bool)
+	for _, c := range containers {
+		host := c.Labels[labelPrefix+".host"]
+		if host == "" {
+			continue
+		}
+		dial, err := containerDial(c, labelPrefix)
+		if err != nil {
+			failed = append(failed, err.Error())
+			conflicted[host] = true
+			continue
+		}
+		if existing, ok := desired[host]; ok && existing != dial {
+			failed = append(failed, fmt.Sprintf("主机 %s 被多个容器声明", host))
+			conflicted[host] = true
+			continue
+		}
+		desired[host] = dial
+	}
+	for host := range conflicted {
+		delete(desired, host)
+	}
+
+	current, err := m.dockerRoutes()
+	if err != nil {
+		return err
+	}
+
+	// 删除容器已消失的路由
+	for id := range current {
+		host := strings.TrimPrefix(id, DockerRoutePrefix)
+		if _, ok := desired[host]; ok || conflicted[host] {
+			continue
+		}
+		if err := m.client.DeleteByID(id); err != nil {
+			failed = append(failed, fmt.Sprintf("删除路由 %s 失败: %v", id, err))
+		}
+	}
+
+	// 创建新路由，上游变化的路由先删除再添加
+	for host, dial := range desired {
+		id := DockerRoutePrefix + host
+		if existing, ok := current[id]; ok {
+			if existing == dial {
+				continue
+			}
+			if err := m.client.DeleteByID(id); err != nil {
+				failed = append(failed, fmt.Sprintf("删除路由 %s 失败: %v", id, err))
+				continue
+			}
+		}
+
+		route := types.Route{
+			ID: id,
+			Match: []types.RouteMatch{
+				{
+					Host: []string{host},
+				},
+			},
+			Handle: []types.Handler{
+				{
+					Handler:   "reverse_proxy",
+					Upstreams: []types.Upstream{{Dial: dial}},
+				},
+			},
+			Terminal: true,
+		}
+		if err := m.AddRoute(route); err != nil {
+			failed = append(failed, fmt.Sprintf("添加路由 %s 失败: %v", id, err))
+		}
+	}
+
+	if len(failed) > 0 {
+		sort.Strings(failed)
+		return fmt.Errorf("%d 个容器或路由同步失败: %s", len(failed), strings.Join(failed, "; "))
+	}
+	return nil
+}
+
+// dockerRoutes 读取当前由 Docker 同步生成的路由及其上游地址 - 内部辅助函数
+func (m *Manager) dockerRoutes() (map[string]string, error) {
+	result := make(map[string]string)
+
+	serverPath := strings.TrimSuffix(RoutesPath, "/routes")
+	server, err := m.client.GetConfig(serverPath)
+	if err != nil {
+		return nil, err
+	}
+
+	routes, _ := server["routes"].([]interface{})
+	for _, raw := range routes {
+		route, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		id, _ := route["@id"].(string)
+		if !strings.HasPrefix(id, DockerRoutePrefix) {
+			continue
+		}
+
+		var dial string
+		handlers, _ := route["handle"].([]interface{})
+		if len(handlers) > 0 {
+			handler, _ := handlers[0].(map[string]interface{})
+			upstreams, _ := handler["upstreams"].([]interface{})
+			if len(upstreams) > 0 {
+				upstream, _ := upstreams[0].(map[string]interface{})
+				dial, _ = upstream["dial"].(string)
+			}
+		}
+		result[id] = dial
+	}
+	return result, nil
+}
+
+// containerDial 根据容器标签和网络信息计算上游地址 - 内部辅助函数
+func containerDial(c docker.Container, labelPrefix string) (string, error) {
+	if upstream := c.Labels[labelPrefix+".upstream"]; upstream != "" {
+		return upstream, nil
+	}
+
+	port := c.Labels[labelPrefix+".port"]
+	if port == "" {
+		return "", fmt.Errorf("容器 %s 缺少 %s.port 标签", c.Name(), labelPrefix)
+	}
+
+	// 按网络名排序，保证多网络容器每次选择相同的地址
+	names := make([]string, 0, len(c.NetworkSettings.Networks))
+	for name := range c.NetworkSettings.Networks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		network := c.NetworkSettings.Networks[name]
+		if network.IPAddress != "" {
+			return net.JoinHostPort(network.IPAddress, port), nil
+		}
+		if network.GlobalIPv6Address != "" {
+			return net.JoinHostPort(network.GlobalIPv6Address, port), nil
+		}
+	}
+	return "", fmt.Errorf("容器 %s 没有可用的网络地址", c.Name())
+}
@@ -0,0 +1,69 @@
+package routes
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/youfun/gofastcaddy/internal/docker"
+)
+
+func TestSyncFromDockerClient(t *testing.T) {
+	const containers = `[
+		{"Id":"1","Names":["/a"],"Labels":{"fc.host":"a.example.com","fc.port":"8080"},"NetworkSettings":{"Networks":{"bridge":{"IPAddress":"172.17.0.2"}}}},
+		{"Id":"2","Names":["/b"],"Labels":{"fc.host":"b.example.com","fc.port":"80"},"NetworkSettings":{"Networks":{"v6":{"GlobalIPv6Address":"fd00::2"}}}},
+		{"Id":"3","Names":["/c"],"Labels":{"fc.host":"c.example.com"},"NetworkSettings":{"Networks":{"bridge":{"IPAddress":"172.17.0.3"}}}},
+		{"Id":"4","Names":["/d1"],"Labels":{"fc.host":"d.example.com","fc.port":"80"},"NetworkSettings":{"Networks":{"bridge":{"IPAddress":"172.17.0.4"}}}},
+		{"Id":"5","Names":["/d2"],"Labels":{"fc.host":"d.example.com","fc.port":"80"},"NetworkSettings":{"Networks":{"bridge":{"IPAddress":"172.17.0.5"}}}},
+		{"Id":"6","Names":["/e"],"Labels":{"fc.host":"e.example.com","fc.upstream":"e-svc:9000"}}
+	]`
+	dockerAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(containers))
+	}))
+	defer dockerAPI.Close()
+	cli, err := docker.NewClientWithHost(strings.Replace(dockerAPI.URL, "http://", "tcp://", 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// c、d 的容器标签有误，现有路由应保留；gone 的容器已消失，路由应删除
+	const config = `{"apps":{"http":{"servers":{"srv0":{"listen":[":443"],"routes":[` +
+		`{"@id":"docker-c.example.com","match":[{"host":["c.example.com"]}],"handle":[{"handler":"reverse_proxy","upstreams":[{"dial":"172.17.0.3:80"}]}]},` +
+		`{"@id":"docker-d.example.com","match":[{"host":["d.example.com"]}],"handle":[{"handler":"reverse_proxy","upstreams":[{"dial":"172.17.0.4:80"}]}]},` +
+		`{"@id":"docker-gone.example.com","match":[{"host":["gone.example.com"]}],"handle":[{"handler":"reverse_proxy","upstreams":[{"dial":"172.17.0.9:80"}]}]}` +
+		`]}}}}}`
+	m, _ := newTestManager(t, config)
+
+	err = m.SyncFromDockerClient(context.Background(), cli, "fc")
+	if err == nil {
+		t.Fatal("期望返回汇总的错误")
+	}
+	for _, want := range []string{"2 个容器或路由同步失败", "缺少 fc.port 标签", "主机 d.example.com 被多个容器声明"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("错误 %q 缺少 %q", err, want)
+		}
+	}
+
+	current, err := m.dockerRoutes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		id   string
+		dial string // 为空表示路由不存在
+	}{
+		{"docker-a.example.com", "172.17.0.2:8080"},
+		{"docker-b.example.com", "[fd00::2]:80"},
+		{"docker-c.example.com", "172.17.0.3:80"},
+		{"docker-d.example.com", "172.17.0.4:80"},
+		{"docker-e.example.com", "e-svc:9000"},
+		{"docker-gone.example.com", ""},
+	}
+	for _, tt := range tests {
+		if got, ok := current[tt.id]; got != tt.dial || ok != (tt.dial != "") {
+			t.Errorf("%s: 上游 = %q (存在 %v), 期望 %q", tt.id, got, ok, tt.dial)
+		}
+	}
+}
@@ -0,0 +1,353 @@
+package routes
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// LinkHint 单个 Link 预加载提示 - 对应 Link 响应头中的一项
+type LinkHint struct {
+	URI string // 资源地址，如 /static/app.css
+	Rel string // 关系类型，如 preload、preconnect
+	As  string // 资源类型，如 style、script (rel 为 preload 时必填)
+}
+
+// EarlyHintsOptions Link 提示设置选项
+type EarlyHintsOptions struct {
+	Push bool // 同时配置 push 处理器推送同源的 preload 资源 (push 是 Caddy 标准模块，只对 HTTP/2 连接生效)
+}
+
+// 允许的 rel 取值
+var linkRels = []string{"preload", "modulepreload", "preconnect", "prefetch", "dns-prefetch"}
+
+// 允许的 as 取值
+var linkAsValues = []string{"audio", "document", "embed", "fetch", "font", "image", "object", "script", "style", "track", "video", "worker"}
+
+// String 格式化为 Link 头的值，如 </app.css>; rel=preload; as=style
+func (h LinkHint) String() string {
+	value := fmt.Sprintf("<%s>; rel=%s", h.URI, h.Rel)
+	if h.As != "" {
+		value += "; as=" + h.As
+	}
+	if h.As == "font" {
+		value += "; crossorigin" // 字体预加载必须使用 CORS 模式，否则浏览器会重复请求
+	}
+	return value
+}
+
+// AddEarlyHints 向路由追加 Link 预加载提示 - 已存在的相同提示不会重复添加
+// 代理到支持 103 Early Hints 的上游时，Caddy 会原样转发上游的 103 响应；
+// 这里添加的 Link 头随最终响应发出，浏览器据此提前加载资源
+func (m *Manager) AddEarlyHints(routeID string, links []LinkHint) error {
+	return m.AddEarlyHintsWithOptions(routeID, links, EarlyHintsOptions{})
+}
+
+// AddEarlyHintsWithOptions 按选项向路由追加 Link 预加载提示
+func (m *Manager) AddEarlyHintsWithOptions(routeID string, links []LinkHint, opts EarlyHintsOptions) error {
+	if len(links) == 0 {
+		return fmt.Errorf("Link 提示列表不能为空")
+	}
+	if err := validateLinkHints(links); err != nil {
+		return err
+	}
+
+	defer m.client.LockWrites()()
+
+	handlers, err := m.routeHandlers(routeID)
+	if err != nil {
+		return err
+	}
+
+	var existing []string
+	if i := findLinkHeadersHandler(handlers); i >= 0 {
+		existing = linkHeaderValues(handlers[i].(map[string]interface{}))
+	}
+	values := linkValues(links, existing)
+	return m.saveEarlyHints(routeID, handlers, values, pushTargets(links, opts))
+}
+
+// ReplaceEarlyHints 整体替换路由的 Link 预加载提示
+func (m *Manager) ReplaceEarlyHints(routeID string, links []LinkHint, opts EarlyHintsOptions) error {
+	if len(links) == 0 {
+		return fmt.Errorf("Link 提示列表不能为空, 删除请使用 RemoveEarlyHints")
+	}
+	if err := validateLinkHints(links); err != nil {
+		return err
+	}
+
+	defer m.client.LockWrites()()
+
+	handlers, err := m.routeHandlers(routeID)
+	if err != nil {
+		return err
+	}
+	values := linkValues(links, nil)
+	handlers = removeHandlers(handlers, findPushHandler)
+	return m.saveEarlyHints(routeID, handlers, values, pushTargets(links, opts))
+}
+
+// RemoveEarlyHints 删除路由上的 Link 预加载提示和 push 处理器
+func (m *Manager) RemoveEarlyHints(routeID string) error {
+	defer m.client.LockWrites()()
+
+	handlers, err := m.routeHandlers(routeID)
+	if err != nil {
+		return err
+	}
+
+	remaining := removeHandlers(handlers, findPushHandler)
+	removed := len(remaining) < len(handlers)
+	if i := findLinkHeadersHandler(remaining); i >= 0 {
+		headers := copyHandler(remaining[i].(map[string]interface{}))
+		setResponseLink(headers, nil)
+		if isEmptyHeadersHandler(headers) {
+			remaining = removeHandlers(remaining, findLinkHeadersHandler)
+		} else {
+			remaining = append([]interface{}(nil), remaining...)
+			remaining[i] = headers
+		}
+		removed = true
+	}
+	if !removed {
+		return fmt.Errorf("路由 %s 未配置 Link 提示", routeID)
+	}
+	return m.saveHandlers(routeID, remaining)
+}
+
+// routeHandlers 读取路由的处理器链 - 内部辅助函数
+func (m *Manager) routeHandlers(routeID string) ([]interface{}, error) {
+	route, err := m.client.GetByID(routeID)
+	if err != nil {
+		return nil, err
+	}
+	handlers, _ := route["handle"].([]interface{})
+	return handlers, nil
+}
+
+// saveEarlyHints 写入 Link 头处理器和 push 处理器 - 内部辅助函数
+// Link 值合并到已有的 headers 处理器中 (优先选择已设置 Link 的，其次是无条件的 headers 处理器)，
+// 该处理器的其他请求头、响应头设置保持不变；没有可用的 headers 处理器时在处理器链最前面插入新的，
+// 保证在终端处理器写响应之前生效
+func (m *Manager) saveEarlyHints(routeID string, handlers []interface{}, values []string, targets []string) error {
+	if i := findMergeableHeadersHandler(handlers); i >= 0 {
+		headers := copyHandler(handlers[i].(map[string]interface{}))
+		setResponseLink(headers, values)
+		handlers[i] = headers
+	} else {
+		headers := map[string]interface{}{"handler": "headers"}
+		setResponseLink(headers, values)
+		handlers = append([]interface{}{headers}, handlers...)
+	}
+
+	if len(targets) > 0 {
+		var resources []interface{}
+		for _, target := range targets {
+			resources = append(resources, map[string]interface{}{"target": target})
+		}
+		push := map[string]interface{}{
+			"handler":   "push",
+			"resources": resources,
+		}
+		if i := findPushHandler(handlers); i >= 0 {
+			handlers[i] = push
+		} else {
+			handlers = append([]interface{}{push}, handlers...)
+		}
+	}
+
+	return m.saveHandlers(routeID, handlers)
+}
+
+// validateLinkHints 校验 Link 提示的 URI、rel 和 as - 内部辅助函数
+func validateLinkHints(links []LinkHint) error {
+	for _, link := range links {
+		if link.URI == "" || strings.ContainsAny(link.URI, "<>\" \t\r\n") {
+			return fmt.Errorf("无效的 Link URI: %q", link.URI)
+		}
+		if _, err := url.Parse(link.URI); err != nil {
+			return fmt.Errorf("无效的 Link URI %q: %w", link.URI, err)
+		}
+		rel := strings.ToLower(link.Rel)
+		if !containsFold(linkRels, rel) {
+			return fmt.Errorf("不支持的 rel 值: %q", link.Rel)
+		}
+		if link.As != "" && !containsFold(linkAsValues, link.As) {
+			return fmt.Errorf("不支持的 as 值: %q", link.As)
+		}
+		if (rel == "preload" || rel == "prefetch") && link.As == "" {
+			return fmt.Errorf("rel=%s 的 Link 提示 %s 必须指定 as", rel, link.URI)
+		}
+	}
+	return nil
+}
+
+// linkValues 生成去重后的 Link 头取值，existing 中的值保持在前 - 内部辅助函数
+func linkValues(links []LinkHint, existing []string) []string {
+	values := append([]string(nil), existing...)
+	for _, link := range links {
+		link.Rel = strings.ToLower(link.Rel)
+		link.As = strings.ToLower(link.As)
+		value := link.String()
+		if !containsFold(values, value) {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// pushTargets 选出需要推送的同源 preload 资源 - 内部辅助函数
+func pushTargets(links []LinkHint, opts EarlyHintsOptions) []string {
+	if !opts.Push {
+		return nil
+	}
+	var targets []string
+	for _, link := range links {
+		if strings.EqualFold(link.Rel, "preload") && strings.HasPrefix(link.URI, "/") && !strings.HasPrefix(link.URI, "//") {
+			if !containsFold(targets, link.URI) {
+				targets = append(targets, link.URI)
+			}
+		}
+	}
+	return targets
+}
+
+// linkHeaderValues 提取 headers 处理器中设置的 Link 值 - 内部辅助函数
+func linkHeaderValues(handler map[string]interface{}) []string {
+	response, _ := handler["response"].(map[string]interface{})
+	set, _ := response["set"].(map[string]interface{})
+	raw, _ := set["Link"].([]interface{})
+	var values []string
+	for _, v := range raw {
+		if s, ok := v.(string); ok {
+			values = append(values, s)
+		}
+	}
+	return values
+}
+
+// findLinkHeadersHandler 查找设置 Link 响应头的 headers 处理器 - 内部辅助函数
+func findLinkHeadersHandler(handlers []interface{}) int {
+	for i, raw := range handlers {
+		handler, ok := raw.(map[string]interface{})
+		if !ok || handler["handler"] != "headers" {
+			continue
+		}
+		response, _ := handler["response"].(map[string]interface{})
+		set, _ := response["set"].(map[string]interface{})
+		if _, ok := set["Link"]; ok {
+			return i
+		}
+	}
+	return -1
+}
+
+// findMergeableHeadersHandler 查找可以写入 Link 值的 headers 处理器 - 内部辅助函数
+// 优先返回已设置 Link 的处理器，其次是第一个没有 response.require 条件的 headers 处理器
+func findMergeableHeadersHandler(handlers []interface{}) int {
+	if i := findLinkHeadersHandler(handlers); i >= 0 {
+		return i
+	}
+	for i, raw := range handlers {
+		handler, ok := raw.(map[string]interface{})
+		if !ok || handler["handler"] != "headers" {
+			continue
+		}
+		response, _ := handler["response"].(map[string]interface{})
+		if _, conditional := response["require"]; !conditional {
+			return i
+		}
+	}
+	return -1
+}
+
+// setResponseLink 设置 headers 处理器的 response.set.Link，values 为空时删除 - 内部辅助函数
+// 调用方需传入可以修改的副本 (见 copyHandler)
+func setResponseLink(handler map[string]interface{}, values []string) {
+	response, _ := handler["response"].(map[string]interface{})
+	if response == nil {
+		response = map[string]interface{}{}
+	}
+	set, _ := response["set"].(map[string]interface{})
+	if set == nil {
+		set = map[string]interface{}{}
+	}
+	if len(values) > 0 {
+		set["Link"] = values
+	} else {
+		delete(set, "Link")
+	}
+	if len(set) > 0 {
+		response["set"] = set
+	} else {
+		delete(response, "set")
+	}
+	if len(response) > 0 {
+		handler["response"] = response
+	} else {
+		delete(handler, "response")
+	}
+}
+
+// isEmptyHeadersHandler 判断 headers 处理器是否已没有任何请求头或响应头操作 - 内部辅助函数
+func isEmptyHeadersHandler(handler map[string]interface{}) bool {
+	for key := range handler {
+		if key != "handler" {
+			return false
+		}
+	}
+	return true
+}
+
+// copyHandler 复制处理器的 request、response 两层结构，修改副本不影响原处理器 - 内部辅助函数
+func copyHandler(handler map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(handler))
+	for key, value := range handler {
+		if section, ok := value.(map[string]interface{}); ok && (key == "request" || key == "response") {
+			copied := make(map[string]interface{}, len(section))
+			for k, v := range section {
+				if ops, ok := v.(map[string]interface{}); ok {
+					inner := make(map[string]interface{}, len(ops))
+					for name, value := range ops {
+						inner[name] = value
+					}
+					v = inner
+				}
+				copied[k] = v
+			}
+			value = copied
+		}
+		result[key] = value
+	}
+	return result
+}
+
+// findPushHandler 查找 push 处理器 - 内部辅助函数
+func findPushHandler(handlers []interface{}) int {
+	for i, raw := range handlers {
+		if handler, ok := raw.(map[string]interface{}); ok && handler["handler"] == "push" {
+			return i
+		}
+	}
+	return -1
+}
+
+// removeHandlers 删除 find 找到的处理器 - 内部辅助函数
+func removeHandlers(handlers []interface{}, find func([]interface{}) int) []interface{} {
+	i := find(handlers)
+	if i < 0 {
+		return handlers
+	}
+	result := append([]interface{}(nil), handlers[:i]...)
+	return append(result, handlers[i+1:]...)
+}
+
+// containsFold 不区分大小写检查切片是否包含指定值 - 内部辅助函数
+func containsFold(items []string, value string) bool {
+	for _, item := range items {
+		if strings.EqualFold(item, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,52 @@
+package routes
+
+import "testing"
+
+func TestEarlyHintsMergeIntoHeadersHandler(t *testing.T) {
+	tests := []struct {
+		name   string
+		handle string // 路由原有的处理器链
+		want   string // 添加 Link 提示后的处理器链
+	}{
+		{
+			name:   "没有 headers 处理器",
+			handle: `[{"handler":"reverse_proxy","upstreams":[{"dial":"app:80"}]}]`,
+			want:   `[{"handler":"headers","response":{"set":{"Link":["</app.css>; rel=preload; as=style"]}}},{"handler":"reverse_proxy","upstreams":[{"dial":"app:80"}]}]`,
+		},
+		{
+			name:   "合并到已有的安全响应头",
+			handle: `[{"handler":"headers","request":{"delete":["X-Debug"]},"response":{"set":{"X-Frame-Options":["DENY"]}}},{"handler":"reverse_proxy","upstreams":[{"dial":"app:80"}]}]`,
+			want:   `[{"handler":"headers","request":{"delete":["X-Debug"]},"response":{"set":{"Link":["</app.css>; rel=preload; as=style"],"X-Frame-Options":["DENY"]}}},{"handler":"reverse_proxy","upstreams":[{"dial":"app:80"}]}]`,
+		},
+		{
+			name:   "有条件的 headers 处理器不合并",
+			handle: `[{"handler":"headers","response":{"require":{"status_code":[404]},"set":{"Cache-Control":["no-store"]}}},{"handler":"reverse_proxy","upstreams":[{"dial":"app:80"}]}]`,
+			want:   `[{"handler":"headers","response":{"set":{"Link":["</app.css>; rel=preload; as=style"]}}},{"handler":"headers","response":{"require":{"status_code":[404]},"set":{"Cache-Control":["no-store"]}}},{"handler":"reverse_proxy","upstreams":[{"dial":"app:80"}]}]`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, _ := newTestManager(t, `{"apps":{"http":{"servers":{"srv0":{"listen":[":443"],"routes":[{"@id":"app","match":[{"host":["app.example.com"]}],"handle":`+tt.handle+`}]}}}}}`)
+			if err := m.AddEarlyHints("app", []LinkHint{{URI: "/app.css", Rel: "preload", As: "style"}}); err != nil {
+				t.Fatalf("AddEarlyHints: %v", err)
+			}
+			if handle := encodeJSON(getRoute(t, m, "app")["handle"]); handle != tt.want {
+				t.Errorf("处理器链 = %s\n期望 = %s", handle, tt.want)
+			}
+		})
+	}
+}
+
+func TestRemoveEarlyHintsKeepsOtherHeaders(t *testing.T) {
+	m, _ := newTestManager(t, `{"apps":{"http":{"servers":{"srv0":{"listen":[":443"],"routes":[{"@id":"app","handle":[{"handler":"headers","response":{"set":{"X-Frame-Options":["DENY"]}}},{"handler":"file_server"}]}]}}}}}`)
+	if err := m.AddEarlyHints("app", []LinkHint{{URI: "/app.js", Rel: "preload", As: "script"}}); err != nil {
+		t.Fatalf("AddEarlyHints: %v", err)
+	}
+	if err := m.RemoveEarlyHints("app"); err != nil {
+		t.Fatalf("RemoveEarlyHints: %v", err)
+	}
+	want := `[{"handler":"headers","response":{"set":{"X-Frame-Options":["DENY"]}}},{"handler":"file_server"}]`
+	if handle := encodeJSON(getRoute(t, m, "app")["handle"]); handle != want {
+		t.Errorf("处理器链 = %s\n期望 = %s", handle, want)
+	}
+}
@@ -0,0 +1,136 @@
+package routes
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/youfun/gofastcaddy/internal/api"
+	"github.com/youfun/gofastcaddy/pkg/types"
+)
+
+// GeoIP 访问限制相关常量
+const (
+	DefaultGeoIPDatabase = "/usr/share/GeoIP/GeoLite2-Country.mmdb" // 默认的 MaxMind 国家数据库路径
+	GeoDenyRoutePrefix   = "geo-deny-"                              // 拒绝路由的 ID 前缀
+)
+
+// GeoDenyRouteID 返回保护指定路由的国家拒绝路由 ID
+func GeoDenyRouteID(routeID string) string {
+	return GeoDenyRoutePrefix + routeID
+}
+
+// RestrictByCountry 拒绝来自指定国家的客户端访问路由 - 需要 Caddy 编译包含 geoip 匹配器插件
+// 在受保护路由之前插入一条拒绝路由：匹配条件为受保护路由的每组匹配条件再加上 GeoIPMatcher，
+// 处理器为返回 denyStatus (0 表示 403) 的 static_response。已存在拒绝路由时整体替换，
+// 因此也用于更新国家列表；只支持默认服务器上的顶层路由。
+// 国家代码不区分大小写；数据库路径见 Manager.GeoIPDatabase
+func (m *Manager) RestrictByCountry(routeID string, denyCountries []string, denyStatus int) error {
+	if len(denyCountries) == 0 {
+		return fmt.Errorf("拒绝的国家列表不能为空, 删除限制请使用 UnrestrictByCountry")
+	}
+	if denyStatus == 0 {
+		denyStatus = http.StatusForbidden
+	}
+	if denyStatus < 400 || denyStatus > 599 {
+		return fmt.Errorf("无效的拒绝状态码: %d", denyStatus)
+	}
+
+	matcher := types.GeoIPMatcher{DBPath: m.GeoIPDatabase}
+	if matcher.DBPath == "" {
+		matcher.DBPath = DefaultGeoIPDatabase
+	}
+	for _, code := range denyCountries {
+		matcher.DenyCountries = append(matcher.DenyCountries, strings.ToUpper(strings.TrimSpace(code)))
+	}
+	if err := matcher.Validate(); err != nil {
+		return err
+	}
+
+	defer m.client.LockWrites()()
+
+	protected, err := m.GetRoute(routeID)
+	if err != nil {
+		return err
+	}
+	deny := geoDenyRoute(routeID, protected, matcher, denyStatus)
+
+	denyID := GeoDenyRouteID(routeID)
+	exists, err := m.client.IDExists(denyID)
+	if err != nil {
+		return err
+	}
+	if exists {
+		err = m.client.PatchByID(deny, denyID)
+	} else {
+		var index int
+		if index, err = m.topLevelIndex(routeID); err != nil {
+			return err
+		}
+		err = m.client.CreateConfig(deny, fmt.Sprintf("%s/%d", RoutesPath, index))
+	}
+	if api.MissingModule(err) == "http.matchers."+types.GeoIPMatcherModule {
+		return fmt.Errorf("当前 Caddy 未包含 geoip 匹配器模块, 请使用 "+
+			"xcaddy build --with github.com/porech/caddy-maxmind-geolocation 构建后重试: %w", err)
+	}
+	return err
+}
+
+// UnrestrictByCountry 删除 RestrictByCountry 为路由添加的国家拒绝路由 - 不存在时不做任何操作
+func (m *Manager) UnrestrictByCountry(routeID string) error {
+	denyID := GeoDenyRouteID(routeID)
+	exists, err := m.client.IDExists(denyID)
+	if err != nil || !exists {
+		return err
+	}
+	return m.client.DeleteByID(denyID)
+}
+
+// topLevelIndex 查找路由在默认服务器路由列表中的位置 - 内部辅助函数
+func (m *Manager) topLevelIndex(routeID string) (int, error) {
+	server, err := m.client.GetConfig(strings.TrimSuffix(RoutesPath, "/routes"))
+	if err != nil {
+		return 0, err
+	}
+	routes, _ := server["routes"].([]interface{})
+	for i, raw := range routes {
+		if route, ok := raw.(map[string]interface{}); ok && route["@id"] == routeID {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("路由 %s 不是默认服务器的顶层路由", routeID)
+}
+
+// geoDenyRoute 构建国家拒绝路由 - 内部辅助函数
+// 匹配条件集合之间为“或”、集合内为“与”，因此在每组条件中加入 geoip 匹配器；
+// 受保护路由没有匹配条件时拒绝路由只按国家匹配
+func geoDenyRoute(routeID string, protected types.Route, matcher types.GeoIPMatcher, status int) types.Route {
+	var match []types.RouteMatch
+	for _, set := range protected.Match {
+		var modules []types.MatcherModule
+		for _, module := range set.Modules {
+			if module.CaddyMatcher() != types.GeoIPMatcherModule {
+				modules = append(modules, module)
+			}
+		}
+		set.Modules = append(modules, matcher)
+		match = append(match, set)
+	}
+	if len(match) == 0 {
+		match = []types.RouteMatch{{Modules: []types.MatcherModule{matcher}}}
+	}
+
+	return types.Route{
+		ID:    GeoDenyRouteID(routeID),
+		Match: match,
+		Handle: []types.Handler{
+			{
+				Handler: "static_response",
+				Extra: map[string]interface{}{
+					"status_code": status,
+				},
+			},
+		},
+		Terminal: true,
+	}
+}
@@ -0,0 +1,266 @@
+package routes
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/youfun/gofastcaddy/pkg/types"
+)
+
+// HTTPSRedirectRoutePrefix ExemptHostsFromHTTPSRedirect 创建的重定向路由的 @id 前缀，后接服务器名称
+const HTTPSRedirectRoutePrefix = "https-redirect-"
+
+// Caddy 的默认端口 - 对应 apps.http 的 http_port、https_port
+const (
+	defaultHTTPPort  = 80
+	defaultHTTPSPort = 443
+)
+
+// HTTPSRedirectRouteID 返回服务器的 HTTPS 重定向路由 ID
+func HTTPSRedirectRouteID(serverName string) string {
+	return HTTPSRedirectRoutePrefix + serverName
+}
+
+// ExemptHostsFromHTTPSRedirect 使主机在 HTTP 端口的请求不再被重定向到 HTTPS，其他主机的重定向保持不变
+//
+// Caddy 只能按服务器关闭自动重定向 (automatic_https.disable_redirects)，因此这里关闭服务器的自动重定向，
+// 并在服务器路由列表最前面维护一个等价的重定向路由，通过 not host 匹配器排除被豁免的主机。
+// 与 automatic_https.skip 不同，被豁免的主机仍由自动 HTTPS 申请和续期证书。
+// 列表按增量合并并去重；重复调用不会产生修改。服务器需要监听 HTTP 端口，否则返回错误
+func (m *Manager) ExemptHostsFromHTTPSRedirect(serverName string, hosts []string) error {
+	defer m.client.LockWrites()()
+
+	server, err := m.httpsServer(serverName)
+	if err != nil {
+		return err
+	}
+	ports, err := m.httpPorts()
+	if err != nil {
+		return err
+	}
+	if !listensOnPort(server, ports.http) {
+		return fmt.Errorf("服务器 %s 没有监听 HTTP 端口 %d, 无法豁免 HTTPS 重定向", serverName, ports.http)
+	}
+
+	exempt, found := redirectExemptHosts(server, serverName)
+	merged := append([]string(nil), exempt...)
+	for _, host := range hosts {
+		host = strings.ToLower(strings.TrimSpace(host))
+		if host != "" && !containsFold(merged, host) {
+			merged = append(merged, host)
+		}
+	}
+	if found && len(merged) == len(exempt) {
+		return nil
+	}
+
+	if err := m.setDisableRedirects(serverName, server, true); err != nil {
+		return err
+	}
+	routeID := HTTPSRedirectRouteID(serverName)
+	if found {
+		return m.client.PatchByID(redirectMatch(merged), routeID+"/match")
+	}
+	return m.client.CreateConfig(httpsRedirectRoute(routeID, merged, ports.https), fmt.Sprintf("%s/%s/routes/0", ServersPath, serverName))
+}
+
+// UnexemptHosts 取消主机的 HTTPS 重定向豁免
+// 没有被豁免的主机时删除重定向路由，并恢复服务器的自动重定向
+func (m *Manager) UnexemptHosts(serverName string, hosts []string) error {
+	defer m.client.LockWrites()()
+
+	server, err := m.httpsServer(serverName)
+	if err != nil {
+		return err
+	}
+	exempt, found := redirectExemptHosts(server, serverName)
+	if !found {
+		return nil
+	}
+
+	var remaining []string
+	for _, host := range exempt {
+		if !containsFold(hosts, host) {
+			remaining = append(remaining, host)
+		}
+	}
+	if len(remaining) == len(exempt) {
+		return nil
+	}
+	routeID := HTTPSRedirectRouteID(serverName)
+	if len(remaining) > 0 {
+		return m.client.PatchByID(redirectMatch(remaining), routeID+"/match")
+	}
+	if err := m.client.DeleteByID(routeID); err != nil {
+		return err
+	}
+	return m.setDisableRedirects(serverName, server, false)
+}
+
+// ListRedirectExemptHosts 返回服务器上被豁免 HTTPS 重定向的主机
+func (m *Manager) ListRedirectExemptHosts(serverName string) ([]string, error) {
+	server, err := m.httpsServer(serverName)
+	if err != nil {
+		return nil, err
+	}
+	hosts, _ := redirectExemptHosts(server, serverName)
+	return hosts, nil
+}
+
+// httpsServer 读取服务器配置 - 内部辅助函数
+func (m *Manager) httpsServer(serverName string) (map[string]interface{}, error) {
+	server, err := m.client.GetConfig(fmt.Sprintf("%s/%s", ServersPath, serverName))
+	if err != nil {
+		return nil, err
+	}
+	if server == nil {
+		return nil, fmt.Errorf("服务器 %s 不存在", serverName)
+	}
+	return server, nil
+}
+
+// appPorts HTTP 应用的 HTTP 和 HTTPS 端口
+type appPorts struct {
+	http  int
+	https int
+}
+
+// httpPorts 读取 HTTP 应用的 http_port 和 https_port，未设置时使用 Caddy 的默认端口 - 内部辅助函数
+func (m *Manager) httpPorts() (appPorts, error) {
+	app, err := m.client.GetConfig(strings.TrimSuffix(ServersPath, "/servers"))
+	if err != nil {
+		return appPorts{}, err
+	}
+	ports := appPorts{http: defaultHTTPPort, https: defaultHTTPSPort}
+	if port, ok := jsonInt(app["http_port"]); ok {
+		ports.http = port
+	}
+	if port, ok := jsonInt(app["https_port"]); ok {
+		ports.https = port
+	}
+	return ports, nil
+}
+
+// setDisableRedirects 设置服务器的 automatic_https.disable_redirects - 内部辅助函数
+// automatic_https 中的其他设置保持不变
+func (m *Manager) setDisableRedirects(serverName string, server map[string]interface{}, disable bool) error {
+	path := fmt.Sprintf("%s/%s/automatic_https", ServersPath, serverName)
+	automatic, hasAutomatic := server["automatic_https"].(map[string]interface{})
+	current, _ := automatic["disable_redirects"].(bool)
+	switch {
+	case current == disable:
+		return nil
+	case !disable:
+		return m.client.DeleteConfig(path + "/disable_redirects")
+	case !hasAutomatic:
+		return m.client.PostConfig(map[string]interface{}{"disable_redirects": true}, path)
+	default:
+		return m.client.PostConfig(true, path+"/disable_redirects")
+	}
+}
+
+// httpsRedirectRoute 生成重定向路由：HTTP 请求 (被豁免的主机除外) 以 308 重定向到 HTTPS - 内部辅助函数
+// 与 Caddy 自动重定向的行为相同，HTTPS 端口不是 443 时 Location 中带上端口
+func httpsRedirectRoute(id string, exempt []string, httpsPort int) types.Route {
+	location := "https://{http.request.host}{http.request.uri}"
+	if httpsPort != defaultHTTPSPort {
+		location = fmt.Sprintf("https://{http.request.host}:%d{http.request.uri}", httpsPort)
+	}
+	return types.Route{
+		ID:    id,
+		Match: redirectMatch(exempt),
+		Handle: []types.Handler{{
+			Handler: "static_response",
+			Extra: map[string]interface{}{
+				"status_code": 308,
+				"headers":     map[string]interface{}{"Location": []string{location}},
+			},
+		}},
+		Terminal: true,
+	}
+}
+
+// redirectMatch 生成匹配 HTTP 请求且排除被豁免主机的匹配器 - 内部辅助函数
+func redirectMatch(exempt []string) []types.RouteMatch {
+	return []types.RouteMatch{{Extra: map[string]interface{}{
+		"protocol": "http",
+		"not":      []interface{}{map[string]interface{}{"host": exempt}},
+	}}}
+}
+
+// redirectExemptHosts 读取服务器重定向路由中被豁免的主机 - 内部辅助函数
+// 第二个返回值表示重定向路由是否存在
+func redirectExemptHosts(server map[string]interface{}, serverName string) ([]string, bool) {
+	routeID := HTTPSRedirectRouteID(serverName)
+	routes, _ := server["routes"].([]interface{})
+	for _, raw := range routes {
+		route, _ := raw.(map[string]interface{})
+		if id, _ := route["@id"].(string); id != routeID {
+			continue
+		}
+		matchers, _ := route["match"].([]interface{})
+		if len(matchers) == 0 {
+			return nil, true
+		}
+		matcher, _ := matchers[0].(map[string]interface{})
+		not, _ := matcher["not"].([]interface{})
+		var hosts []string
+		for _, rawNot := range not {
+			notMatcher, _ := rawNot.(map[string]interface{})
+			values, _ := notMatcher["host"].([]interface{})
+			for _, value := range values {
+				if host, ok := value.(string); ok {
+					hosts = append(hosts, host)
+				}
+			}
+		}
+		return hosts, true
+	}
+	return nil, false
+}
+
+// listensOnPort 检查服务器是否监听指定端口 - 内部辅助函数
+// 支持 Caddy 的监听地址写法，如 :80、0.0.0.0:80、tcp/[::]:80 和端口范围 :80-81
+func listensOnPort(server map[string]interface{}, port int) bool {
+	listen, _ := server["listen"].([]interface{})
+	for _, raw := range listen {
+		addr, _ := raw.(string)
+		if i := strings.Index(addr, "/"); i >= 0 {
+			addr = addr[i+1:]
+		}
+		_, portRange, err := net.SplitHostPort(addr)
+		if err != nil {
+			continue
+		}
+		first, last, isRange := strings.Cut(portRange, "-")
+		start, err := strconv.Atoi(first)
+		if err != nil {
+			continue
+		}
+		end := start
+		if isRange {
+			if end, err = strconv.Atoi(last); err != nil {
+				continue
+			}
+		}
+		if port >= start && port <= end {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonInt 读取 JSON 数字形式的整数 - 内部辅助函数
+func jsonInt(value interface{}) (int, bool) {
+	switch v := value.(type) {
+	case float64:
+		return int(v), true
+	case json.Number:
+		n, err := v.Int64()
+		return int(n), err == nil
+	}
+	return 0, false
+}
@@ -0,0 +1,169 @@
+package routes
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestExemptHostsFromHTTPSRedirect(t *testing.T) {
+	const hostRoute = `{"@id":"a.example.com","match":[{"host":["a.example.com"]}],"handle":[{"handler":"reverse_proxy","upstreams":[{"dial":"app:80"}]}]}`
+	server := func(listen, extra string) string {
+		return `{"apps":{"http":{` + extra + `"servers":{"srv0":{"listen":` + listen +
+			`,"automatic_https":{"skip_certificates":["internal.example.com"]},"routes":[` + hostRoute + `]}}}}}`
+	}
+
+	tests := []struct {
+		name       string
+		config     string
+		exempt     [][]string // 依次调用 ExemptHostsFromHTTPSRedirect
+		unexempt   []string   // 随后调用 UnexemptHosts (nil 表示不调用)
+		wantErr    string
+		wantHosts  []string // 重定向路由中被豁免的主机 (nil 表示路由不存在)
+		wantDisRed bool     // 期望的 disable_redirects
+		location   string   // 期望的重定向地址 (为空表示不检查)
+	}{
+		{
+			name:       "监听 HTTP 和 HTTPS 端口",
+			config:     server(`[":80",":443"]`, ""),
+			exempt:     [][]string{{"A.example.com"}},
+			wantHosts:  []string{"a.example.com"},
+			wantDisRed: true,
+			location:   "https://{http.request.host}{http.request.uri}",
+		},
+		{
+			name:       "增量合并",
+			config:     server(`[":80",":443"]`, ""),
+			exempt:     [][]string{{"a.example.com"}, {"b.example.com", "a.example.com"}},
+			wantHosts:  []string{"a.example.com", "b.example.com"},
+			wantDisRed: true,
+		},
+		{
+			name:    "只监听 HTTPS 端口",
+			config:  server(`[":443"]`, ""),
+			exempt:  [][]string{{"a.example.com"}},
+			wantErr: "没有监听 HTTP 端口 80",
+		},
+		{
+			name:    "非 443 的 HTTPS 端口不算 HTTP 端口",
+			config:  server(`[":8443"]`, ""),
+			exempt:  [][]string{{"a.example.com"}},
+			wantErr: "没有监听 HTTP 端口 80",
+		},
+		{
+			name:       "自定义端口",
+			config:     server(`["tcp/0.0.0.0:8080-8081","[::]:8443"]`, `"http_port":8081,"https_port":8443,`),
+			exempt:     [][]string{{"a.example.com"}},
+			wantHosts:  []string{"a.example.com"},
+			wantDisRed: true,
+			location:   "https://{http.request.host}:8443{http.request.uri}",
+		},
+		{
+			name:       "取消部分豁免",
+			config:     server(`[":80",":443"]`, ""),
+			exempt:     [][]string{{"a.example.com", "b.example.com"}},
+			unexempt:   []string{"b.example.com"},
+			wantHosts:  []string{"a.example.com"},
+			wantDisRed: true,
+		},
+		{
+			name:     "取消全部豁免时恢复自动重定向",
+			config:   server(`[":80",":443"]`, ""),
+			exempt:   [][]string{{"a.example.com"}},
+			unexempt: []string{"a.example.com"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, fake := newTestManager(t, tt.config)
+			var err error
+			for _, hosts := range tt.exempt {
+				if err = m.ExemptHostsFromHTTPSRedirect(DefaultServerName, hosts); err != nil {
+					break
+				}
+			}
+			if err == nil && tt.unexempt != nil {
+				err = m.UnexemptHosts(DefaultServerName, tt.unexempt)
+			}
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("错误 = %v, 期望包含 %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var config struct {
+				Apps struct {
+					HTTP struct {
+						Servers map[string]struct {
+							AutomaticHTTPS map[string]interface{} `json:"automatic_https"`
+							Routes         []json.RawMessage      `json:"routes"`
+						} `json:"servers"`
+					} `json:"http"`
+				} `json:"apps"`
+			}
+			if err := json.Unmarshal([]byte(fake.ConfigJSON()), &config); err != nil {
+				t.Fatal(err)
+			}
+			srv := config.Apps.HTTP.Servers[DefaultServerName]
+			if disable, _ := srv.AutomaticHTTPS["disable_redirects"].(bool); disable != tt.wantDisRed {
+				t.Errorf("disable_redirects = %v, 期望 %v", disable, tt.wantDisRed)
+			}
+			if _, ok := srv.AutomaticHTTPS["skip_certificates"]; !ok {
+				t.Error("automatic_https 的其他设置丢失")
+			}
+			if _, ok := srv.AutomaticHTTPS["skip"]; ok {
+				t.Error("不应使用 automatic_https.skip")
+			}
+
+			hosts, err := m.ListRedirectExemptHosts(DefaultServerName)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.Join(hosts, ",") != strings.Join(tt.wantHosts, ",") {
+				t.Errorf("被豁免的主机 = %v, 期望 %v", hosts, tt.wantHosts)
+			}
+			if tt.wantHosts == nil {
+				if len(srv.Routes) != 1 {
+					t.Errorf("重定向路由未删除: %d 个路由", len(srv.Routes))
+				}
+				return
+			}
+			// 重定向路由必须在主机路由之前
+			var first map[string]interface{}
+			json.Unmarshal(srv.Routes[0], &first)
+			if first["@id"] != HTTPSRedirectRouteID(DefaultServerName) {
+				t.Fatalf("第一个路由 = %s", srv.Routes[0])
+			}
+			if tt.location != "" && !strings.Contains(string(srv.Routes[0]), `"Location":["`+tt.location+`"]`) {
+				t.Errorf("重定向路由 = %s, 期望 Location %s", srv.Routes[0], tt.location)
+			}
+		})
+	}
+}
+
+func TestJSONInt(t *testing.T) {
+	tests := []struct {
+		name   string
+		value  interface{}
+		want   int
+		wantOK bool
+	}{
+		{name: "float64", value: float64(8080), want: 8080, wantOK: true},
+		{name: "json.Number", value: json.Number("8443"), want: 8443, wantOK: true},
+		{name: "非整数的 json.Number", value: json.Number("1.5"), wantOK: false},
+		{name: "字符串", value: "80", wantOK: false},
+		{name: "缺失", value: nil, wantOK: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := jsonInt(tt.value)
+			if ok != tt.wantOK || (ok && got != tt.want) {
+				t.Errorf("jsonInt(%v) = %d, %v, 期望 %d, %v", tt.value, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
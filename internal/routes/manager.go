@@ -1,8 +1,10 @@
 package routes
 
 import (
+	"encoding/json"
 	"fmt"
 	"strconv"
+	"strings"
 
 	"github.com/youfun/gofastcaddy/internal/api"
 	"github.com/youfun/gofastcaddy/internal/config"
@@ -12,81 +14,196 @@ import (
 // 常量定义 - 服务器和路由配置路径
 const (
 	ServersPath = "/apps/http/servers"
-	RoutesPath  = ServersPath + "/srv0/routes"
+	RoutesPath  = ServersPath + "/" + DefaultServerName + "/routes"
+
+	DefaultServerName = "srv0" // SetupCaddy 默认创建的服务器名称
 )
 
 // Manager 路由管理器 - 处理路由相关配置
 type Manager struct {
 	client        *api.Client
 	configManager *config.Manager
+
+	// SortWildcardChildren 为 true 时，AddSubReverseProxy 写入后通配符路由的子路由按 @id 排序，
+	// 使不同添加顺序产生相同的配置
+	SortWildcardChildren bool
+
+	// NormalizeHandlerOrder 为 true 时，修改处理器链的辅助方法 (SetEarlyHints、SetRouteNote 等) 写入前
+	// 按规范顺序重排处理器链，见 OrderHandlers；默认保持处理器链原样，便于需要自定义顺序的场景
+	NormalizeHandlerOrder bool
+
+	// OnHandlerReorder 开启 NormalizeHandlerOrder 后处理器发生移动时调用，用于记录重排情况
+	OnHandlerReorder func(routeID string, moves []HandlerMove)
+
+	// GeoIPDatabase RestrictByCountry 使用的 MaxMind 国家数据库路径 (为空表示 DefaultGeoIPDatabase)
+	GeoIPDatabase string
+
+	// RecycleBinFile 非空时回收站同时保存到该文件，进程重启后仍可在保留期限内恢复，见 UndoDelete
+	RecycleBinFile string
+
+	// Warn 警告回调 (nil 表示忽略警告)
+	Warn func(msg string)
+
+	bin recycleBin // 已删除路由的回收站，客户端设置了 DeleteGrace 时使用
 }
 
 // NewManager 创建新的路由管理器
 func NewManager() *Manager {
+	return NewManagerWithClient(api.NewClient())
+}
+
+// NewManagerWithClient 使用指定的 API 客户端创建路由管理器
+func NewManagerWithClient(client *api.Client) *Manager {
 	return &Manager{
-		client:        api.NewClient(),
-		configManager: config.NewManager(),
+		client:        client,
+		configManager: config.NewManagerWithClient(client),
+	}
+}
+
+// warn 通过 Warn 回调输出警告，未设置回调时忽略 - 内部辅助函数
+func (m *Manager) warn(msg string) {
+	if m.Warn != nil {
+		m.Warn(msg)
 	}
 }
 
 // InitRoutes 初始化 HTTP 路由配置 - 对应 Python 的 init_routes(srv_name, skip) 函数
-// 创建基础的 HTTP 服务器和路由配置
+// 创建基础的 HTTP 服务器和路由配置；服务器路径已存在时不做任何修改，
+// 管理端点不可达时返回错误而不是当作不存在
 func (m *Manager) InitRoutes(serverName string, skip int) error {
-	// 如果服务器路径已存在，直接返回
-	if m.client.HasPath(ServersPath) {
-		return nil
+	// 初始化 http 应用路径
+	if err := m.configManager.EnsurePath(strings.TrimSuffix(ServersPath, "/servers"), skip); err != nil {
+		return err
 	}
 
-	// 初始化服务器路径
-	if err := m.configManager.InitPath(ServersPath, skip); err != nil {
+	server := NewHTTPServer()
+	if err := server.Validate(); err != nil {
 		return err
 	}
 
-	// 创建基础 HTTP 服务器配置
-	serverConfig := types.HTTPServer{
-		Listen:    []string{":80", ":443"}, // 监听 HTTP 和 HTTPS 端口
-		Routes:    []types.Route{},         // 空路由列表
-		Protocols: []string{"h1", "h2"},    // 支持 HTTP/1.1 和 HTTP/2
-	}
+	// 服务器路径不存在时才创建
+	servers := map[string]interface{}{serverName: server}
+	_, err := m.client.PutConfigIfAbsent(servers, ServersPath)
+	return err
+}
 
-	// 设置服务器配置
-	serverPath := fmt.Sprintf("%s/%s", ServersPath, serverName)
-	return m.client.PutConfig(serverConfig, serverPath, "POST")
+// GetRoute 获取指定 ID 的路由 - 直接解码为 types.Route
+func (m *Manager) GetRoute(id string) (types.Route, error) {
+	var route types.Route
+	if err := m.client.GetByIDInto(id, &route); err != nil {
+		return types.Route{}, err
+	}
+	return route, nil
 }
 
 // AddRoute 添加路由规则 - 对应 Python 的 add_route(route) 函数
-// 将路由配置添加到 Caddy 服务器
+// 将路由配置添加到 Caddy 服务器；发送前通过 types.Route.Validate 校验路由结构
 func (m *Manager) AddRoute(route types.Route) error {
-	return m.client.PutConfig(route, RoutesPath, "POST")
+	if err := route.Validate(); err != nil {
+		return err
+	}
+	return m.client.PostConfig(route, RoutesPath)
+}
+
+// UpdateRoute 替换指定 ID 的现有路由 - 使用 PATCH，不会像 POST 那样追加出重复的路由
+// 路由不存在时返回错误；route.ID 会被设置为 id，保证替换后仍可通过同一 ID 访问
+func (m *Manager) UpdateRoute(id string, route types.Route) error {
+	route.ID = id
+	if err := route.Validate(); err != nil {
+		return err
+	}
+
+	defer m.client.LockWrites()()
+
+	m.preserveMetadata(id, &route)
+	m.stampUpdated(&route)
+	return m.client.PatchByID(route, id)
 }
 
 // DeleteByID 删除指定 ID 的路由 - 对应 Python 的 del_id(id) 函数
-// 通过路由 ID 删除特定路由
+// 通过路由 ID 删除特定路由；客户端设置了 DeleteGrace 时先将路由放入回收站，
+// 保留期限内可通过 UndoDelete 恢复
 func (m *Manager) DeleteByID(id string) error {
+	if m.client.DeleteGrace > 0 {
+		defer m.client.LockWrites()()
+	}
+	return m.deleteRoute(id)
+}
+
+// deleteRoute 删除指定 ID 的路由，客户端设置了 DeleteGrace 时先放入回收站 - 内部辅助函数
+// 调用方在设置了 DeleteGrace 时需要持有写锁
+func (m *Manager) deleteRoute(id string) error {
+	if m.client.DeleteGrace > 0 {
+		if err := m.saveDeleted(id); err != nil {
+			return fmt.Errorf("删除前保存路由 %s 失败: %w", id, err)
+		}
+	}
 	return m.client.DeleteByID(id)
 }
 
 // AddReverseProxy 添加反向代理路由 - 对应 Python 的 add_reverse_proxy(from_host, to_url) 函数
 // 创建从指定主机到目标 URL 的反向代理
 func (m *Manager) AddReverseProxy(fromHost, toURL string) error {
+	// 创建反向代理路由配置，校验通过后才删除现有路由
+	route := ReverseProxyRoute(fromHost, toURL)
+	if err := route.Validate(); err != nil {
+		return err
+	}
+
+	defer m.client.LockWrites()()
+
 	// 如果已存在相同主机的路由，先删除
-	if m.client.HasID(fromHost) {
-		if err := m.client.DeleteByID(fromHost); err != nil {
+	exists, err := m.client.IDExists(route.ID)
+	if err != nil {
+		return err
+	}
+	if exists {
+		if err := m.client.DeleteByID(route.ID); err != nil {
 			return fmt.Errorf("删除现有路由失败: %w", err)
 		}
 	}
 
-	// 创建反向代理路由配置
-	route := types.Route{
-		ID: fromHost,
+	m.stampProvenance(&route, "AddReverseProxy")
+	m.stampUpdated(&route)
+
+	// 添加路由
+	return m.AddRoute(route)
+}
+
+// NewHTTPServer 返回 InitRoutes 创建的基础 HTTP 服务器配置 - 纯构建函数，不访问管理 API
+// 监听 HTTP 和 HTTPS 端口，路由列表为空
+func NewHTTPServer() types.HTTPServer {
+	return types.HTTPServer{
+		Listen:    []string{":80", ":443"}, // 监听 HTTP 和 HTTPS 端口
+		Routes:    []types.Route{},         // 空路由列表
+		Protocols: []string{"h1", "h2"},    // 支持 HTTP/1.1 和 HTTP/2
+	}
+}
+
+// HostRouteID 返回以主机名命名的路由 @id - 主机名转为小写
+// Caddy 的主机名匹配不区分大小写，统一小写避免同一主机因大小写不同产生两个路由 (见 migrations 的 lowercase managed IDs)
+func HostRouteID(host string) string {
+	return strings.ToLower(host)
+}
+
+// WildcardRouteID 返回 AddWildcardRoute 为 domain 创建的通配符路由 @id
+func WildcardRouteID(domain string) string {
+	return WildcardRoutePrefix + HostRouteID(domain)
+}
+
+// ReverseProxyRoute 返回 AddReverseProxy 创建的反向代理路由 - 纯构建函数，不访问管理 API
+// 路由 @id 为小写的主机名 (见 HostRouteID)；多个上游时按 Caddy 默认的负载均衡策略分发
+func ReverseProxyRoute(fromHost string, dials ...string) types.Route {
+	upstreams := make([]types.Upstream, 0, len(dials))
+	for _, dial := range dials {
+		upstreams = append(upstreams, types.Upstream{Dial: dial})
+	}
+	return types.Route{
+		ID: HostRouteID(fromHost),
 		Handle: []types.Handler{
 			{
-				Handler: "reverse_proxy",
-				Upstreams: []types.Upstream{
-					{
-						Dial: toURL,
-					},
-				},
+				Handler:   "reverse_proxy",
+				Upstreams: upstreams,
 			},
 		},
 		Match: []types.RouteMatch{
@@ -96,9 +213,6 @@ func (m *Manager) AddReverseProxy(fromHost, toURL string) error {
 		},
 		Terminal: true, // 设置为终端路由
 	}
-
-	// 添加路由
-	return m.AddRoute(route)
 }
 
 // AddWildcardRoute 添加通配符子域名路由 - 对应 Python 的 add_wildcard_route(domain) 函数
@@ -106,7 +220,7 @@ func (m *Manager) AddReverseProxy(fromHost, toURL string) error {
 func (m *Manager) AddWildcardRoute(domain string) error {
 	// 创建通配符路由配置
 	route := types.Route{
-		ID: fmt.Sprintf("wildcard-%s", domain),
+		ID: WildcardRouteID(domain),
 		Match: []types.RouteMatch{
 			{
 				Host: []string{fmt.Sprintf("*.%s", domain)}, // 通配符匹配
@@ -120,6 +234,8 @@ func (m *Manager) AddWildcardRoute(domain string) error {
 		},
 		Terminal: true,
 	}
+	m.stampProvenance(&route, "AddWildcardRoute")
+	m.stampUpdated(&route)
 
 	// 添加路由
 	return m.AddRoute(route)
@@ -128,8 +244,75 @@ func (m *Manager) AddWildcardRoute(domain string) error {
 // AddSubReverseProxy 添加子域名反向代理 - 对应 Python 的 add_sub_reverse_proxy 函数
 // 为通配符域名下的特定子域名添加反向代理，支持多端口
 func (m *Manager) AddSubReverseProxy(domain, subdomain string, ports []string, host string) error {
-	wildcardID := fmt.Sprintf("wildcard-%s", domain)
-	routeID := fmt.Sprintf("%s.%s", subdomain, domain)
+	wildcardID := WildcardRouteID(domain)
+
+	// 创建子路由配置
+	newRoute := SubReverseProxyRoute(domain, subdomain, ports, host)
+	if err := newRoute.Validate(); err != nil {
+		return err
+	}
+	m.stampProvenance(&newRoute, "AddSubReverseProxy")
+	m.stampUpdated(&newRoute)
+
+	if m.SortWildcardChildren {
+		return m.addSortedSubroute(wildcardID, newRoute)
+	}
+
+	// 将子路由追加到通配符路由的处理器中；持有写锁，避免与清理空通配符路由的检查交错
+	defer m.client.LockWrites()()
+	return m.client.AppendByID([]types.Route{newRoute}, wildcardID+"/handle/0/routes")
+}
+
+// SubProxySpec 批量添加子域名反向代理时的单个子域名 - 字段含义同 AddSubReverseProxy 的参数
+type SubProxySpec struct {
+	Subdomain string   // 子域名 (不含通配符域名部分)
+	Ports     []string // 上游端口列表
+	Host      string   // 上游主机 (为空表示 localhost)
+}
+
+// BatchAddSubReverseProxy 批量添加子域名反向代理 - 语义同逐个调用 AddSubReverseProxy
+// 所有子路由先逐个校验 (见 types.Route.Validate)，再在一次请求中追加到通配符路由，Caddy 要么全部应用要么全部拒绝；
+// 设置了 SortWildcardChildren 时读取现有子路由后整体排序写入
+func (m *Manager) BatchAddSubReverseProxy(domain string, entries []SubProxySpec) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	seen := make(map[string]bool, len(entries))
+	batch := make([]types.Route, 0, len(entries))
+	for _, entry := range entries {
+		if entry.Subdomain == "" {
+			return fmt.Errorf("子域名不能为空")
+		}
+		if len(entry.Ports) == 0 {
+			return fmt.Errorf("子域名 %s 没有上游端口", entry.Subdomain)
+		}
+		if seen[entry.Subdomain] {
+			return fmt.Errorf("子域名 %s 重复", entry.Subdomain)
+		}
+		seen[entry.Subdomain] = true
+
+		route := SubReverseProxyRoute(domain, entry.Subdomain, entry.Ports, entry.Host)
+		if err := route.Validate(); err != nil {
+			return err
+		}
+		m.stampProvenance(&route, "BatchAddSubReverseProxy")
+		m.stampUpdated(&route)
+		batch = append(batch, route)
+	}
+
+	wildcardID := WildcardRouteID(domain)
+	if m.SortWildcardChildren {
+		return m.addSortedSubroute(wildcardID, batch...)
+	}
+	defer m.client.LockWrites()()
+	return m.client.AppendByID(batch, wildcardID+"/handle/0/routes")
+}
+
+// SubReverseProxyRoute 返回 AddSubReverseProxy 创建的子路由 - 纯构建函数，不访问管理 API
+// 路由 @id 为小写的 subdomain.domain (见 HostRouteID)；host 为空时使用 localhost
+func SubReverseProxyRoute(domain, subdomain string, ports []string, host string) types.Route {
+	routeID := HostRouteID(fmt.Sprintf("%s.%s", subdomain, domain))
 
 	// 如果 host 为空，默认使用 localhost
 	if host == "" {
@@ -144,8 +327,7 @@ func (m *Manager) AddSubReverseProxy(domain, subdomain string, ports []string, h
 		})
 	}
 
-	// 创建子路由配置
-	newRoute := types.Route{
+	return types.Route{
 		ID: routeID,
 		Match: []types.RouteMatch{
 			{
@@ -159,11 +341,6 @@ func (m *Manager) AddSubReverseProxy(domain, subdomain string, ports []string, h
 			},
 		},
 	}
-
-	// 将子路由添加到通配符路由的处理器中
-	// 这里使用 "..." 语法来追加到现有路由列表
-	subroutePath := fmt.Sprintf("%s/handle/0/routes/...", wildcardID)
-	return m.client.PutByID([]types.Route{newRoute}, subroutePath, "POST")
 }
 
 // AddSubReverseProxyWithPorts 添加子域名反向代理（支持单个端口或端口列表）
@@ -192,6 +369,8 @@ func (m *Manager) AddSubReverseProxyWithPorts(domain, subdomain string, ports in
 				portList = append(portList, strconv.Itoa(p))
 			case float64: // JSON 数字默认解析为 float64
 				portList = append(portList, strconv.Itoa(int(p)))
+			case json.Number:
+				portList = append(portList, p.String())
 			}
 		}
 	default:
@@ -0,0 +1,360 @@
+package routes
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/youfun/gofastcaddy/internal/api"
+	"github.com/youfun/gofastcaddy/pkg/clienttest"
+	"github.com/youfun/gofastcaddy/pkg/types"
+)
+
+// emptyServer 只有一个空服务器的配置 - 测试辅助常量
+const emptyServer = `{"apps":{"http":{"servers":{"srv0":{"listen":[":80",":443"],"routes":[]}}}}}`
+
+// newTestManager 创建连接假管理端点的路由管理器 - 测试辅助函数
+func newTestManager(t *testing.T, initial string) (*Manager, *clienttest.FakeCaddy) {
+	t.Helper()
+	fake := clienttest.NewFakeCaddy(initial)
+	t.Cleanup(fake.Close)
+	return NewManagerWithClient(api.NewClientWithURL(fake.URL)), fake
+}
+
+// getRoute 读取指定 @id 的路由 - 测试辅助函数
+func getRoute(t *testing.T, m *Manager, id string) map[string]interface{} {
+	t.Helper()
+	route, err := m.client.GetByID(id)
+	if err != nil {
+		t.Fatalf("读取路由 %s: %v", id, err)
+	}
+	return route
+}
+
+// serverRoutes 返回默认服务器的顶层路由 - 测试辅助函数
+func serverRoutes(t *testing.T, fake *clienttest.FakeCaddy) []map[string]interface{} {
+	t.Helper()
+	var config struct {
+		Apps struct {
+			HTTP struct {
+				Servers map[string]struct {
+					Routes []map[string]interface{} `json:"routes"`
+				} `json:"servers"`
+			} `json:"http"`
+		} `json:"apps"`
+	}
+	if err := json.Unmarshal([]byte(fake.ConfigJSON()), &config); err != nil {
+		t.Fatalf("解析配置失败: %v", err)
+	}
+	return config.Apps.HTTP.Servers[DefaultServerName].Routes
+}
+
+// encodeJSON 以不转义 HTML 字符的方式序列化，便于与期望的 JSON 文本比较 - 测试辅助函数
+func encodeJSON(v interface{}) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(v)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// handlerNames 返回路由处理器链中各处理器的名称 - 测试辅助函数
+func handlerNames(route map[string]interface{}) []string {
+	var names []string
+	handlers, _ := route["handle"].([]interface{})
+	for _, raw := range handlers {
+		handler, _ := raw.(map[string]interface{})
+		name, _ := handler["handler"].(string)
+		names = append(names, name)
+	}
+	return names
+}
+
+func TestMetadataKeepsWildcardSubrouteFirst(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(m *Manager) error
+	}{
+		{
+			name:  "SetRouteNote",
+			setup: func(m *Manager) error { return m.SetRouteNote("wildcard-example.com", "共享入口") },
+		},
+		{
+			name: "UpdateRoute 保留备注",
+			setup: func(m *Manager) error {
+				if err := m.SetRouteNote("wildcard-example.com", "共享入口"); err != nil {
+					return err
+				}
+				return m.UpdateRoute("wildcard-example.com", types.Route{
+					ID:       "wildcard-example.com",
+					Match:    []types.RouteMatch{{Host: []string{"*.example.com"}}},
+					Handle:   []types.Handler{{Handler: "subroute", Routes: []types.Route{}}},
+					Terminal: true,
+				})
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, _ := newTestManager(t, emptyServer)
+			if err := m.AddWildcardRoute("example.com"); err != nil {
+				t.Fatalf("AddWildcardRoute: %v", err)
+			}
+			if err := tt.setup(m); err != nil {
+				t.Fatalf("setup: %v", err)
+			}
+			if err := m.AddSubReverseProxy("example.com", "app", []string{"8080"}, ""); err != nil {
+				t.Fatalf("AddSubReverseProxy: %v", err)
+			}
+
+			route := getRoute(t, m, "wildcard-example.com")
+			names := handlerNames(route)
+			if len(names) != 2 || names[0] != "subroute" || names[1] != "vars" {
+				t.Fatalf("处理器链 = %v, 期望 [subroute vars]", names)
+			}
+			if note, err := m.GetRouteNote("wildcard-example.com"); err != nil || note != "共享入口" {
+				t.Errorf("GetRouteNote = %q, %v", note, err)
+			}
+			if _, err := m.client.GetByID("app.example.com"); err != nil {
+				t.Errorf("子路由未写入: %v", err)
+			}
+		})
+	}
+}
+
+func TestRouteIDsAreLowercase(t *testing.T) {
+	m, fake := newTestManager(t, emptyServer)
+	if err := m.AddReverseProxy("App.Example.com", "localhost:8080"); err != nil {
+		t.Fatalf("AddReverseProxy: %v", err)
+	}
+	// 大小写不同的同一主机替换原路由而不是新增
+	if err := m.AddReverseProxy("app.example.com", "localhost:9090"); err != nil {
+		t.Fatalf("AddReverseProxy: %v", err)
+	}
+	if err := m.AddWildcardRoute("Example.com"); err != nil {
+		t.Fatalf("AddWildcardRoute: %v", err)
+	}
+	if err := m.AddSubReverseProxy("Example.com", "API", []string{"8081"}, ""); err != nil {
+		t.Fatalf("AddSubReverseProxy: %v", err)
+	}
+
+	for _, id := range []string{"app.example.com", "wildcard-example.com", "api.example.com"} {
+		getRoute(t, m, id)
+	}
+	routes := serverRoutes(t, fake)
+	if len(routes) != 2 {
+		t.Errorf("顶层路由数量 = %d, 期望 2", len(routes))
+	}
+}
+
+func TestUpdateRouteKeepsUnmodeledFields(t *testing.T) {
+	tests := []struct {
+		name   string
+		route  string
+		mutate func(route *types.Route)
+		want   string
+	}{
+		{
+			name:   "修改上游时保留 group 和 max_requests",
+			route:  `{"@id":"app","group":"apps","match":[{"host":["app.example.com"]}],"handle":[{"handler":"reverse_proxy","upstreams":[{"dial":"old:80","max_requests":10}]}],"terminal":true}`,
+			mutate: func(route *types.Route) { route.Handle[0].Upstreams[0].Dial = "new:80" },
+			want:   `{"@id":"app","group":"apps","handle":[{"handler":"reverse_proxy","upstreams":[{"dial":"new:80","max_requests":10}]}],"match":[{"host":["app.example.com"]}],"terminal":true}`,
+		},
+		{
+			name:   "不修改时原样写回",
+			route:  `{"@id":"app","group":"apps","match":[{"host":["app.example.com"]}],"handle":[{"handler":"static_response","body":"ok"}],"terminal":true}`,
+			mutate: func(route *types.Route) {},
+			want:   `{"@id":"app","group":"apps","handle":[{"body":"ok","handler":"static_response"}],"match":[{"host":["app.example.com"]}],"terminal":true}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, _ := newTestManager(t, `{"apps":{"http":{"servers":{"srv0":{"listen":[":443"],"routes":[`+tt.route+`]}}}}}`)
+			route, err := m.GetRoute("app")
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.mutate(&route)
+			if err := m.UpdateRoute("app", route); err != nil {
+				t.Fatalf("UpdateRoute: %v", err)
+			}
+			if got := encodeJSON(getRoute(t, m, "app")); got != tt.want {
+				t.Errorf("路由 = %s\n期望 = %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBatchAddSubReverseProxyValidatesEachRoute(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []SubProxySpec
+		wantErr string
+		want    []string // 写入后通配符路由的子路由 ID
+	}{
+		{
+			name:    "全部有效",
+			entries: []SubProxySpec{{Subdomain: "a", Ports: []string{"8001"}}, {Subdomain: "b", Ports: []string{"8002"}, Host: "app"}},
+			want:    []string{"a.example.com", "b.example.com"},
+		},
+		{
+			name:    "上游地址包含空白字符",
+			entries: []SubProxySpec{{Subdomain: "a", Ports: []string{"8001"}}, {Subdomain: "b", Ports: []string{"80 81"}}},
+			wantErr: "b.example.com",
+		},
+		{
+			name:    "上游主机包含空白字符",
+			entries: []SubProxySpec{{Subdomain: "a", Ports: []string{"8001"}, Host: "my host"}},
+			wantErr: "a.example.com",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, fake := newTestManager(t, emptyServer)
+			if err := m.AddWildcardRoute("example.com"); err != nil {
+				t.Fatal(err)
+			}
+			writes := len(fake.Requests())
+
+			err := m.BatchAddSubReverseProxy("example.com", tt.entries)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, 期望包含 %q", err, tt.wantErr)
+				}
+				if requests := fake.Requests(); len(requests) != writes {
+					t.Errorf("校验失败后仍发送了请求: %v", requests[writes:])
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			wildcard := getRoute(t, m, WildcardRouteID("example.com"))
+			handle, _ := wildcard["handle"].([]interface{})
+			subroute, _ := handle[0].(map[string]interface{})
+			children, _ := subroute["routes"].([]interface{})
+			var ids []string
+			for _, raw := range children {
+				child, _ := raw.(map[string]interface{})
+				id, _ := child["@id"].(string)
+				ids = append(ids, id)
+			}
+			if strings.Join(ids, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("子路由 = %v, 期望 %v", ids, tt.want)
+			}
+		})
+	}
+}
+
+// TestAddReverseProxyConcurrent 并发添加反向代理路由时所有路由都写入且同一主机只有一个路由 (配合 -race 运行)
+func TestAddReverseProxyConcurrent(t *testing.T) {
+	m, fake := newTestManager(t, emptyServer)
+
+	const hosts, rounds = 8, 3
+	var wg sync.WaitGroup
+	errs := make(chan error, hosts*rounds)
+	for i := 0; i < hosts; i++ {
+		for j := 0; j < rounds; j++ {
+			wg.Add(1)
+			go func(i, j int) {
+				defer wg.Done()
+				errs <- m.AddReverseProxy(fmt.Sprintf("app%d.example.com", i), fmt.Sprintf("app%d:%d", i, 8080+j))
+			}(i, j)
+		}
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	count := make(map[string]int)
+	for _, route := range serverRoutes(t, fake) {
+		id, _ := route["@id"].(string)
+		count[id]++
+	}
+	for i := 0; i < hosts; i++ {
+		if id := fmt.Sprintf("app%d.example.com", i); count[id] != 1 {
+			t.Errorf("路由 %s 数量 = %d, 期望 1", id, count[id])
+		}
+	}
+	if len(count) != hosts {
+		t.Errorf("路由 = %v, 期望 %d 个", count, hosts)
+	}
+}
+
+func TestInitRoutes(t *testing.T) {
+	tests := []struct {
+		name    string
+		initial string
+		want    string // 初始化后的 srv0
+	}{
+		{
+			name:    "创建服务器",
+			initial: `{}`,
+			want:    `{"listen":[":80",":443"],"protocols":["h1","h2"],"routes":[]}`,
+		},
+		{
+			name:    "已存在的服务器保持不变",
+			initial: `{"apps":{"http":{"servers":{"srv0":{"listen":[":8443"],"routes":[{"@id":"app"}]}}}}}`,
+			want:    `{"listen":[":8443"],"routes":[{"@id":"app"}]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, fake := newTestManager(t, tt.initial)
+			// 重复初始化不改变结果
+			for i := 0; i < 2; i++ {
+				if err := m.InitRoutes(DefaultServerName, 0); err != nil {
+					t.Fatal(err)
+				}
+			}
+			server, err := m.client.GetConfig(ServersPath + "/" + DefaultServerName)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := encodeJSON(server); got != tt.want {
+				t.Errorf("服务器 = %s\n期望 = %s", got, tt.want)
+			}
+			for _, req := range fake.Requests() {
+				if strings.HasPrefix(req, "PATCH ") || strings.HasPrefix(req, "DELETE ") {
+					t.Errorf("初始化不应替换或删除配置: %s", req)
+				}
+			}
+		})
+	}
+}
+
+func TestAddAndUpdateRoute(t *testing.T) {
+	m, fake := newTestManager(t, emptyServer)
+	for _, host := range []string{"a.example.com", "b.example.com"} {
+		if err := m.AddRoute(ReverseProxyRoute(host, "app:80")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := m.UpdateRoute("a.example.com", ReverseProxyRoute("a.example.com", "app:8080")); err != nil {
+		t.Fatal(err)
+	}
+
+	var ids, dials []string
+	for _, route := range serverRoutes(t, fake) {
+		ids = append(ids, route["@id"].(string))
+		handler := route["handle"].([]interface{})[0].(map[string]interface{})
+		dials = append(dials, handler["upstreams"].([]interface{})[0].(map[string]interface{})["dial"].(string))
+	}
+	// AddRoute 追加路由，UpdateRoute 原地替换而不追加
+	if got, want := strings.Join(ids, ","), "a.example.com,b.example.com"; got != want {
+		t.Errorf("路由 = %s, 期望 %s", got, want)
+	}
+	if got, want := strings.Join(dials, ","), "app:8080,app:80"; got != want {
+		t.Errorf("上游 = %s, 期望 %s", got, want)
+	}
+	if err := m.UpdateRoute("missing.example.com", ReverseProxyRoute("missing.example.com", "app:80")); err == nil {
+		t.Error("替换不存在的路由应返回错误")
+	}
+}
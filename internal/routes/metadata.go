@@ -0,0 +1,251 @@
+package routes
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/youfun/gofastcaddy/pkg/types"
+)
+
+// 路由元数据相关常量
+// Caddy 拒绝路由上的未知字段，因此元数据保存在处理器链末尾的 vars 处理器中 (见 setRouteMetadata)，
+// 键名带有 fastcaddy_ 前缀；vars 处理器只设置请求变量，不影响请求处理
+const (
+	MetadataVarPrefix = "fastcaddy_"
+	NoteVar           = MetadataVarPrefix + "note"
+	MaxNoteSize       = 1024                                 // 备注的最大字节数
+	UpstreamGroupVar  = MetadataVarPrefix + "upstream_group" // 蓝绿发布中当前生效的上游分组名称
+)
+
+// RouteDescription 路由的可读摘要
+type RouteDescription struct {
+	ID          string   `json:"id"`                     // 路由 ID
+	Hosts       []string `json:"hosts,omitempty"`        // 匹配的主机名
+	Handlers    []string `json:"handlers"`               // 处理器名称，按执行顺序排列
+	Upstreams   []string `json:"upstreams,omitempty"`    // 反向代理的上游地址
+	MetricsName string   `json:"metrics_name,omitempty"` // 指标名称 (见 RouteBuilder.MetricsName)
+	Note        string   `json:"note,omitempty"`         // 备注
+	Group       string   `json:"group,omitempty"`        // 当前生效的上游分组名称 (见 SetUpstreamGroup)
+
+	Provenance *Provenance `json:"provenance,omitempty"` // 来源信息 (见 Provenance)
+	UpdatedAt  *time.Time  `json:"updated_at,omitempty"` // 最后修改时间 (见 UpdatedAtVar)
+}
+
+// SetRouteNote 设置路由的备注 - note 为空时删除备注
+// 控制字符会被去除（保留换行），超过 MaxNoteSize 字节时返回错误
+func (m *Manager) SetRouteNote(id, note string) error {
+	note = sanitizeNote(note)
+	if len(note) > MaxNoteSize {
+		return fmt.Errorf("备注长度 %d 字节超过上限 %d 字节", len(note), MaxNoteSize)
+	}
+
+	defer m.client.LockWrites()()
+
+	handlers, err := m.routeHandlers(id)
+	if err != nil {
+		return err
+	}
+	if findMetadataHandler(handlers) < 0 && note == "" {
+		return nil
+	}
+	return m.saveHandlers(id, setHandlersMetadata(handlers, NoteVar, note))
+}
+
+// GetRouteNote 读取路由的备注 - 未设置时返回空字符串
+func (m *Manager) GetRouteNote(id string) (string, error) {
+	handlers, err := m.routeHandlers(id)
+	if err != nil {
+		return "", err
+	}
+	return metadataValue(handlers, NoteVar), nil
+}
+
+// SetUpstreamGroup 记录路由当前生效的上游分组名称 (如蓝绿发布的 blue、green) - group 为空时删除
+// 分组名称保存在路由元数据中，LookupProxyTarget 和 Describe 会返回该名称
+func (m *Manager) SetUpstreamGroup(id, group string) error {
+	defer m.client.LockWrites()()
+
+	handlers, err := m.routeHandlers(id)
+	if err != nil {
+		return err
+	}
+	if findMetadataHandler(handlers) < 0 && group == "" {
+		return nil
+	}
+	return m.saveHandlers(id, setHandlersMetadata(handlers, UpstreamGroupVar, group))
+}
+
+// Describe 返回指定路由的摘要
+func (m *Manager) Describe(id string) (RouteDescription, error) {
+	route, err := m.client.GetByID(id)
+	if err != nil {
+		return RouteDescription{}, err
+	}
+	return describeRoute(route), nil
+}
+
+// ListManagedRoutes 列出默认服务器上所有带 @id 的顶层路由的摘要，按 ID 排序
+func (m *Manager) ListManagedRoutes() ([]RouteDescription, error) {
+	server, err := m.client.GetConfig(strings.TrimSuffix(RoutesPath, "/routes"))
+	if err != nil {
+		return nil, err
+	}
+
+	var result []RouteDescription
+	routes, _ := server["routes"].([]interface{})
+	for _, raw := range routes {
+		route, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if id, _ := route["@id"].(string); id != "" {
+			result = append(result, describeRoute(route))
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	return result, nil
+}
+
+// preserveMetadata 将现有路由的元数据 vars 处理器带到替换后的路由中 - 内部辅助函数
+// 用于整体替换路由的操作，保证备注等元数据不会因编辑而丢失
+func (m *Manager) preserveMetadata(id string, route *types.Route) {
+	handlers, err := m.routeHandlers(id)
+	if err != nil {
+		return
+	}
+	index := findMetadataHandler(handlers)
+	if index < 0 {
+		return
+	}
+	for _, h := range route.Handle {
+		for key := range h.Extra {
+			if strings.HasPrefix(key, MetadataVarPrefix) {
+				return // 新路由自带元数据，以调用方为准
+			}
+		}
+	}
+
+	vars := types.Handler{Handler: "vars", Extra: map[string]interface{}{}}
+	for key, value := range handlers[index].(map[string]interface{}) {
+		if key != "handler" {
+			vars.Extra[key] = value
+		}
+	}
+	route.Handle = append(route.Handle, vars)
+}
+
+// describeRoute 根据路由 JSON 生成摘要 - 内部辅助函数
+func describeRoute(route map[string]interface{}) RouteDescription {
+	desc := RouteDescription{Handlers: []string{}}
+	desc.ID, _ = route["@id"].(string)
+
+	matchers, _ := route["match"].([]interface{})
+	for _, raw := range matchers {
+		matcher, _ := raw.(map[string]interface{})
+		hosts, _ := matcher["host"].([]interface{})
+		for _, h := range hosts {
+			if host, ok := h.(string); ok {
+				desc.Hosts = append(desc.Hosts, host)
+			}
+		}
+	}
+
+	handlers, _ := route["handle"].([]interface{})
+	for _, raw := range handlers {
+		handler, _ := raw.(map[string]interface{})
+		name, _ := handler["handler"].(string)
+		desc.Handlers = append(desc.Handlers, name)
+		switch name {
+		case "reverse_proxy":
+			upstreams, _ := handler["upstreams"].([]interface{})
+			for _, u := range upstreams {
+				upstream, _ := u.(map[string]interface{})
+				if dial, ok := upstream["dial"].(string); ok {
+					desc.Upstreams = append(desc.Upstreams, dial)
+				}
+			}
+		case "vars":
+			if metrics, ok := handler[MetricsVarName].(string); ok {
+				desc.MetricsName = metrics
+			}
+		}
+	}
+	desc.Note = metadataValue(handlers, NoteVar)
+	desc.Group = metadataValue(handlers, UpstreamGroupVar)
+	desc.Provenance = provenanceValue(handlers)
+	if updated := updatedAtValue(handlers); !updated.IsZero() {
+		desc.UpdatedAt = &updated
+	}
+	return desc
+}
+
+// findMetadataHandler 查找保存元数据的 vars 处理器 - 内部辅助函数
+func findMetadataHandler(handlers []interface{}) int {
+	for i, raw := range handlers {
+		if handler, ok := raw.(map[string]interface{}); ok && isMetadataHandler(handler) {
+			return i
+		}
+	}
+	return -1
+}
+
+// isMetadataHandler 判断处理器是否为保存元数据的 vars 处理器 - 内部辅助函数
+func isMetadataHandler(handler map[string]interface{}) bool {
+	if handler["handler"] != "vars" {
+		return false
+	}
+	for key := range handler {
+		if strings.HasPrefix(key, MetadataVarPrefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// setHandlersMetadata 在处理器链的元数据 vars 处理器中设置字符串值，value 为空时删除 - 内部辅助函数
+// 没有元数据处理器时追加到处理器链末尾；删除后处理器不再有任何键时将其移除
+func setHandlersMetadata(handlers []interface{}, key, value string) []interface{} {
+	index := findMetadataHandler(handlers)
+	if index < 0 {
+		if value == "" {
+			return handlers
+		}
+		handlers = append(handlers, map[string]interface{}{"handler": "vars"})
+		index = len(handlers) - 1
+	}
+
+	vars := handlers[index].(map[string]interface{})
+	if value == "" {
+		delete(vars, key)
+	} else {
+		vars[key] = value
+	}
+	if len(vars) == 1 {
+		handlers = append(handlers[:index], handlers[index+1:]...)
+	}
+	return handlers
+}
+
+// metadataValue 读取元数据 vars 处理器中的字符串值 - 内部辅助函数
+func metadataValue(handlers []interface{}, key string) string {
+	index := findMetadataHandler(handlers)
+	if index < 0 {
+		return ""
+	}
+	value, _ := handlers[index].(map[string]interface{})[key].(string)
+	return value
+}
+
+// sanitizeNote 去除备注中的控制字符（保留换行）和首尾空白 - 内部辅助函数
+func sanitizeNote(note string) string {
+	note = strings.Map(func(r rune) rune {
+		if r == '\n' || !unicode.IsControl(r) {
+			return r
+		}
+		return -1
+	}, note)
+	return strings.TrimSpace(note)
+}
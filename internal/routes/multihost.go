@@ -0,0 +1,198 @@
+package routes
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/youfun/gofastcaddy/internal/utils"
+	"github.com/youfun/gofastcaddy/pkg/types"
+)
+
+// AddReverseProxyMultiHost 添加匹配多个主机名的反向代理路由 - 所有主机名共用一个路由和上游
+// 主机名经 utils.NormalizeHost 规范化 (小写、国际化域名编码为 punycode)，重复的主机名只保留一个；
+// 已存在相同 ID 的路由时先删除再添加
+func (m *Manager) AddReverseProxyMultiHost(id string, hosts []string, toURL string) error {
+	if id == "" {
+		return fmt.Errorf("路由 ID 不能为空")
+	}
+	normalized, err := normalizeHosts(hosts)
+	if err != nil {
+		return err
+	}
+
+	route := MultiHostRoute(id, normalized, toURL)
+	if err := route.Validate(); err != nil {
+		return err
+	}
+
+	defer m.client.LockWrites()()
+
+	exists, err := m.client.IDExists(id)
+	if err != nil {
+		return err
+	}
+	if exists {
+		if err := m.client.DeleteByID(id); err != nil {
+			return fmt.Errorf("删除现有路由失败: %w", err)
+		}
+	}
+
+	m.stampProvenance(&route, "AddReverseProxyMultiHost")
+	m.stampUpdated(&route)
+	return m.AddRoute(route)
+}
+
+// SetRouteHosts 替换路由 host 匹配器中的主机名 - 主机名集合与现有的相同 (不计顺序) 时不发送请求
+// 返回是否修改了配置
+func (m *Manager) SetRouteHosts(id string, hosts []string) (bool, error) {
+	normalized, err := normalizeHosts(hosts)
+	if err != nil {
+		return false, err
+	}
+
+	defer m.client.LockWrites()()
+
+	route, err := m.client.GetByID(id)
+	if err != nil {
+		return false, err
+	}
+	current, ok := routeHosts(route)
+	if !ok {
+		return false, fmt.Errorf("路由 %s 没有 host 匹配器", id)
+	}
+	if SameHosts(current, normalized) {
+		return false, nil
+	}
+	if err := m.saveRouteHosts(route, normalized, m.client.PatchByID, id); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// RemoveHost 从匹配该主机名的顶层路由中移除主机名
+// 路由还匹配其他主机名且 dropRoute 为 false 时只从 host 匹配器中移除该主机名，
+// 否则删除整个路由；没有路由匹配该主机名时返回错误
+func (m *Manager) RemoveHost(host string, dropRoute bool) error {
+	host, err := utils.NormalizeHost(host)
+	if err != nil {
+		return err
+	}
+
+	defer m.client.LockWrites()()
+
+	server, err := m.client.GetConfig(strings.TrimSuffix(RoutesPath, "/routes"))
+	if err != nil {
+		return err
+	}
+	items, _ := server["routes"].([]interface{})
+	for i, raw := range items {
+		route, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		hosts, ok := routeHosts(route)
+		if !ok || !containsHost(hosts, host) {
+			continue
+		}
+
+		id, _ := route["@id"].(string)
+		if dropRoute || len(hosts) == 1 {
+			if id != "" {
+				return m.deleteRoute(id)
+			}
+			return m.client.DeleteConfig(fmt.Sprintf("%s/%d", RoutesPath, i))
+		}
+
+		remaining := make([]string, 0, len(hosts)-1)
+		for _, h := range hosts {
+			if h != host {
+				remaining = append(remaining, h)
+			}
+		}
+		if id != "" {
+			return m.saveRouteHosts(route, remaining, m.client.PatchByID, id)
+		}
+		return m.saveRouteHosts(route, remaining, m.client.PatchConfig, fmt.Sprintf("%s/%d", RoutesPath, i))
+	}
+	return fmt.Errorf("没有路由匹配主机名 %s", host)
+}
+
+// MultiHostRoute 返回 AddReverseProxyMultiHost 创建的反向代理路由 - 纯构建函数，不访问管理 API
+// 主机名按原样写入 host 匹配器，调用方负责规范化
+func MultiHostRoute(id string, hosts []string, dials ...string) types.Route {
+	route := ReverseProxyRoute(id, dials...)
+	route.Match = []types.RouteMatch{{Host: append([]string(nil), hosts...)}}
+	return route
+}
+
+// SameHosts 判断两组主机名是否相同 - 不计顺序、大小写和重复
+func SameHosts(a, b []string) bool {
+	return strings.Join(hostSet(a), ",") == strings.Join(hostSet(b), ",")
+}
+
+// normalizeHosts 规范化并去重主机名，保持首次出现的顺序 - 内部辅助函数
+func normalizeHosts(hosts []string) ([]string, error) {
+	if len(hosts) == 0 {
+		return nil, fmt.Errorf("至少需要一个主机名")
+	}
+	seen := make(map[string]bool, len(hosts))
+	result := make([]string, 0, len(hosts))
+	for _, host := range hosts {
+		normalized, err := utils.NormalizeHost(host)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[normalized] {
+			seen[normalized] = true
+			result = append(result, normalized)
+		}
+	}
+	return result, nil
+}
+
+// hostSet 返回小写、去重并排序后的主机名 - 内部辅助函数
+func hostSet(hosts []string) []string {
+	seen := make(map[string]bool, len(hosts))
+	var result []string
+	for _, host := range hosts {
+		host = strings.ToLower(host)
+		if !seen[host] {
+			seen[host] = true
+			result = append(result, host)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+// routeHosts 读取路由第一个匹配器中的主机名 - 内部辅助函数
+// 路由没有 host 匹配器时第二个返回值为 false
+func routeHosts(route map[string]interface{}) ([]string, bool) {
+	matchers, _ := route["match"].([]interface{})
+	if len(matchers) == 0 {
+		return nil, false
+	}
+	matcher, _ := matchers[0].(map[string]interface{})
+	raw, ok := matcher["host"].([]interface{})
+	if !ok {
+		return nil, false
+	}
+	hosts := make([]string, 0, len(raw))
+	for _, h := range raw {
+		if host, ok := h.(string); ok {
+			hosts = append(hosts, host)
+		}
+	}
+	return hosts, true
+}
+
+// containsHost 检查主机名列表是否包含指定主机名 (不区分大小写) - 内部辅助函数
+func containsHost(hosts []string, host string) bool {
+	for _, h := range hosts {
+		if strings.EqualFold(h, host) {
+			return true
+		}
+	}
+	return false
+}
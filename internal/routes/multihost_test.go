@@ -0,0 +1,180 @@
+package routes
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// multiHostServer 含一个多主机名路由和一个没有 @id 的多主机名路由 - 测试辅助常量
+const multiHostServer = `{"apps":{"http":{"servers":{"srv0":{"listen":[":443"],"routes":[
+	{"@id":"shop","match":[{"host":["example.com","example.net","example.org"]}],"handle":[{"handler":"reverse_proxy","upstreams":[{"dial":"shop:80"}]}]},
+	{"match":[{"host":["a.example.com","b.example.com"]}],"handle":[{"handler":"static_response"}]},
+	{"@id":"solo","match":[{"host":["solo.example.com"]}],"handle":[{"handler":"static_response"}]}
+]}}}}}`
+
+func TestAddReverseProxyMultiHost(t *testing.T) {
+	tests := []struct {
+		name    string
+		initial string
+		hosts   []string
+		want    []string // 期望的 host 匹配器
+		wantErr bool
+	}{
+		{
+			name:    "规范化并去重",
+			initial: emptyServer,
+			hosts:   []string{"Example.com", "example.NET.", "example.com", "bücher.example"},
+			want:    []string{"example.com", "example.net", "xn--bcher-kva.example"},
+		},
+		{
+			name:    "替换相同 ID 的路由",
+			initial: multiHostServer,
+			hosts:   []string{"example.com"},
+			want:    []string{"example.com"},
+		},
+		{name: "空主机名列表", initial: emptyServer, wantErr: true},
+		{name: "无效的主机名", initial: emptyServer, hosts: []string{"example.com", "bad host"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, fake := newTestManager(t, tt.initial)
+			before := len(serverRoutes(t, fake))
+
+			err := m.AddReverseProxyMultiHost("shop", tt.hosts, "shop:8080")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, 期望错误 %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if len(serverRoutes(t, fake)) != before {
+					t.Error("校验失败时不应修改配置")
+				}
+				return
+			}
+			var shops []map[string]interface{}
+			for _, route := range serverRoutes(t, fake) {
+				if route["@id"] == "shop" {
+					shops = append(shops, route)
+				}
+			}
+			if len(shops) != 1 {
+				t.Fatalf("ID 为 shop 的路由有 %d 个, 期望 1", len(shops))
+			}
+			if hosts, _ := routeHosts(shops[0]); !reflect.DeepEqual(hosts, tt.want) {
+				t.Errorf("host 匹配器 = %v, 期望 %v", hosts, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetRouteHosts(t *testing.T) {
+	tests := []struct {
+		name    string
+		hosts   []string
+		changed bool
+		want    []string
+	}{
+		{name: "顺序和大小写不同视为相同", hosts: []string{"EXAMPLE.org", "example.com", "example.net"}, want: []string{"example.com", "example.net", "example.org"}},
+		{name: "增加主机名", hosts: []string{"example.com", "example.net", "example.org", "example.io"}, changed: true, want: []string{"example.com", "example.net", "example.org", "example.io"}},
+		{name: "减少主机名", hosts: []string{"example.net"}, changed: true, want: []string{"example.net"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, fake := newTestManager(t, multiHostServer)
+			changed, err := m.SetRouteHosts("shop", tt.hosts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if changed != tt.changed {
+				t.Errorf("changed = %v, 期望 %v", changed, tt.changed)
+			}
+			if !tt.changed {
+				for _, req := range fake.Requests() {
+					if !strings.HasPrefix(req, "GET ") {
+						t.Errorf("主机名集合相同时不应写入: %s", req)
+					}
+				}
+			}
+			if hosts, _ := routeHosts(serverRoutes(t, fake)[0]); !reflect.DeepEqual(hosts, tt.want) {
+				t.Errorf("host 匹配器 = %v, 期望 %v", hosts, tt.want)
+			}
+		})
+	}
+}
+
+func TestRemoveHost(t *testing.T) {
+	tests := []struct {
+		name      string
+		host      string
+		dropRoute bool
+		want      [][]string // 移除后各路由的 host 匹配器
+		wantErr   bool
+	}{
+		{
+			name: "只移除一个主机名",
+			host: "Example.NET",
+			want: [][]string{{"example.com", "example.org"}, {"a.example.com", "b.example.com"}, {"solo.example.com"}},
+		},
+		{
+			name:      "删除整个路由",
+			host:      "example.net",
+			dropRoute: true,
+			want:      [][]string{{"a.example.com", "b.example.com"}, {"solo.example.com"}},
+		},
+		{
+			name: "最后一个主机名时删除路由",
+			host: "solo.example.com",
+			want: [][]string{{"example.com", "example.net", "example.org"}, {"a.example.com", "b.example.com"}},
+		},
+		{
+			name: "没有 @id 的路由按位置修改",
+			host: "a.example.com",
+			want: [][]string{{"example.com", "example.net", "example.org"}, {"b.example.com"}, {"solo.example.com"}},
+		},
+		{
+			name:      "没有 @id 的路由按位置删除",
+			host:      "b.example.com",
+			dropRoute: true,
+			want:      [][]string{{"example.com", "example.net", "example.org"}, {"solo.example.com"}},
+		},
+		{
+			name:    "没有路由匹配",
+			host:    "missing.example.com",
+			want:    [][]string{{"example.com", "example.net", "example.org"}, {"a.example.com", "b.example.com"}, {"solo.example.com"}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, fake := newTestManager(t, multiHostServer)
+			if err := m.RemoveHost(tt.host, tt.dropRoute); (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, 期望错误 %v", err, tt.wantErr)
+			}
+			var got [][]string
+			for _, route := range serverRoutes(t, fake) {
+				hosts, _ := routeHosts(route)
+				got = append(got, hosts)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("路由主机名 = %v, 期望 %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSameHosts(t *testing.T) {
+	tests := []struct {
+		a, b []string
+		want bool
+	}{
+		{a: []string{"a.com", "b.com"}, b: []string{"B.com", "a.com"}, want: true},
+		{a: []string{"a.com", "a.com"}, b: []string{"a.com"}, want: true},
+		{a: []string{"a.com"}, b: []string{"a.com", "b.com"}, want: false},
+		{a: nil, b: []string{}, want: true},
+	}
+	for _, tt := range tests {
+		if got := SameHosts(tt.a, tt.b); got != tt.want {
+			t.Errorf("SameHosts(%v, %v) = %v, 期望 %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
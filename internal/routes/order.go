@@ -0,0 +1,114 @@
+package routes
+
+import "sort"
+
+// HandlerClass 处理器在规范顺序中的类别 - 数值越小越靠前
+// 规范顺序为：认证 → 修改请求 → vars/map → encode → 终端处理器
+type HandlerClass int
+
+// 处理器类别
+const (
+	ClassAuth     HandlerClass = iota + 1 // 认证，如 authentication；必须最先执行，避免未认证请求触达后续处理器
+	ClassRequest                          // 修改请求或附加响应头，如 rewrite、headers、push
+	ClassVars                             // 设置变量，如 vars、map
+	ClassEncode                           // 响应编码，如 encode；放在认证之后，避免压缩 401 响应
+	ClassTerminal                         // 写响应的终端处理器，如 reverse_proxy、static_response
+)
+
+// handlerClasses 已知处理器名称对应的类别 - 内部变量
+// 各辅助方法插入的处理器在此声明类别：SetBasicAuthAccounts 插入 authentication，
+// SetEarlyHints 插入 headers 和 push，AddReverseProxy 等创建 reverse_proxy 或 subroute
+var handlerClasses = map[string]HandlerClass{
+	BasicAuthHandler:  ClassAuth,
+	"rewrite":         ClassRequest,
+	"uri":             ClassRequest,
+	"headers":         ClassRequest,
+	"push":            ClassRequest,
+	"request_body":    ClassRequest,
+	"vars":            ClassVars,
+	"map":             ClassVars,
+	"encode":          ClassEncode,
+	"reverse_proxy":   ClassTerminal,
+	"static_response": ClassTerminal,
+	"file_server":     ClassTerminal,
+	"subroute":        ClassTerminal,
+	"error":           ClassTerminal,
+}
+
+// HandlerMove 规范化处理器顺序时的一次位置变化
+type HandlerMove struct {
+	Handler string // 处理器名称
+	From    int    // 原位置
+	To      int    // 新位置
+}
+
+// ClassifyHandler 返回处理器的类别；未知处理器和保存元数据的 vars 处理器返回 false
+func ClassifyHandler(handler map[string]interface{}) (HandlerClass, bool) {
+	if isMetadataHandler(handler) {
+		return 0, false
+	}
+	name, _ := handler["handler"].(string)
+	class, ok := handlerClasses[name]
+	return class, ok
+}
+
+// OrderHandlers 按规范顺序重排处理器链 - 纯函数，不修改传入的切片
+// 同一类别的处理器按名称排列，同名处理器保持原有相对顺序，因此辅助方法的调用顺序不影响结果；
+// 未知处理器和元数据 vars 处理器位置固定，只在它们分隔出的各段内部重排。
+// 返回重排后的处理器链和发生移动的处理器
+func OrderHandlers(handlers []interface{}) ([]interface{}, []HandlerMove) {
+	result := make([]interface{}, len(handlers))
+	copy(result, handlers)
+
+	positions := make([]int, len(handlers))
+	for i := range positions {
+		positions[i] = i
+	}
+
+	classes := make([]HandlerClass, len(handlers))
+	names := make([]string, len(handlers))
+	start := 0
+	for i := 0; i <= len(handlers); i++ {
+		if i < len(handlers) {
+			if handler, ok := handlers[i].(map[string]interface{}); ok {
+				if class, known := ClassifyHandler(handler); known {
+					classes[i] = class
+					names[i], _ = handler["handler"].(string)
+					continue
+				}
+			}
+		}
+		// 未知处理器 (或链尾) 结束当前段
+		segment := positions[start:i]
+		sort.SliceStable(segment, func(a, b int) bool {
+			x, y := segment[a], segment[b]
+			if classes[x] != classes[y] {
+				return classes[x] < classes[y]
+			}
+			return names[x] < names[y]
+		})
+		start = i + 1
+	}
+
+	var moves []HandlerMove
+	for to, from := range positions {
+		result[to] = handlers[from]
+		if to != from {
+			moves = append(moves, HandlerMove{Handler: names[from], From: from, To: to})
+		}
+	}
+	return result, moves
+}
+
+// orderForSave 在开启 NormalizeHandlerOrder 时规范化要写入的处理器链 - 内部辅助函数
+// 发生移动时调用 OnHandlerReorder
+func (m *Manager) orderForSave(routeID string, handlers []interface{}) []interface{} {
+	if !m.NormalizeHandlerOrder {
+		return handlers
+	}
+	ordered, moves := OrderHandlers(handlers)
+	if len(moves) > 0 && m.OnHandlerReorder != nil {
+		m.OnHandlerReorder(routeID, moves)
+	}
+	return ordered
+}
@@ -0,0 +1,165 @@
+package routes
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// permutations 返回 items 的全部排列 - 测试辅助函数
+func permutations(items []string) [][]string {
+	if len(items) <= 1 {
+		return [][]string{append([]string(nil), items...)}
+	}
+	var result [][]string
+	for i, item := range items {
+		rest := append(append([]string(nil), items[:i]...), items[i+1:]...)
+		for _, p := range permutations(rest) {
+			result = append(result, append([]string{item}, p...))
+		}
+	}
+	return result
+}
+
+// chainNames 返回处理器链中各处理器的名称，以逗号连接 - 测试辅助函数
+func chainNames(handlers []interface{}) string {
+	return strings.Join(handlerNames(map[string]interface{}{"handle": handlers}), ",")
+}
+
+func TestOrderHandlersPermutations(t *testing.T) {
+	const want = "authentication,headers,rewrite,vars,encode,reverse_proxy"
+	names := []string{"reverse_proxy", "encode", "vars", "rewrite", "headers", "authentication"}
+	for _, perm := range permutations(names) {
+		handlers := make([]interface{}, len(perm))
+		for i, name := range perm {
+			handlers[i] = map[string]interface{}{"handler": name}
+		}
+		ordered, _ := OrderHandlers(handlers)
+		if got := chainNames(ordered); got != want {
+			t.Fatalf("%v 规范化后 = %s, 期望 %s", perm, got, want)
+		}
+		// 规范化结果再次规范化不发生移动
+		if _, moves := OrderHandlers(ordered); len(moves) != 0 {
+			t.Fatalf("%v 规范化不是幂等的: %+v", perm, moves)
+		}
+		if got := chainNames(handlers); got != strings.Join(perm, ",") {
+			t.Fatalf("OrderHandlers 修改了传入的切片: %s", got)
+		}
+	}
+}
+
+func TestOrderHandlersFixedPositions(t *testing.T) {
+	tests := []struct {
+		name      string
+		handlers  string // 逗号分隔的处理器名称，meta 表示元数据 vars 处理器
+		want      string
+		wantMoves []HandlerMove
+	}{
+		{
+			name:      "encode 移到认证之后",
+			handlers:  "encode,authentication,reverse_proxy",
+			want:      "authentication,encode,reverse_proxy",
+			wantMoves: []HandlerMove{{Handler: "authentication", From: 1, To: 0}, {Handler: "encode", From: 0, To: 1}},
+		},
+		{
+			name:     "未知处理器分隔的段分别重排",
+			handlers: "encode,rate_limit,reverse_proxy,headers",
+			want:     "encode,rate_limit,headers,reverse_proxy",
+			wantMoves: []HandlerMove{
+				{Handler: "headers", From: 3, To: 2},
+				{Handler: "reverse_proxy", From: 2, To: 3},
+			},
+		},
+		{
+			name:     "元数据处理器位置不变",
+			handlers: "reverse_proxy,authentication,meta",
+			want:     "authentication,reverse_proxy,vars",
+			wantMoves: []HandlerMove{
+				{Handler: "authentication", From: 1, To: 0},
+				{Handler: "reverse_proxy", From: 0, To: 1},
+			},
+		},
+		{name: "已是规范顺序", handlers: "authentication,headers,encode,reverse_proxy", want: "authentication,headers,encode,reverse_proxy"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var handlers []interface{}
+			for _, name := range strings.Split(tt.handlers, ",") {
+				if name == "meta" {
+					handlers = append(handlers, map[string]interface{}{"handler": "vars", MetadataVarPrefix + "note": "x"})
+					continue
+				}
+				handlers = append(handlers, map[string]interface{}{"handler": name})
+			}
+			ordered, moves := OrderHandlers(handlers)
+			if got := chainNames(ordered); got != tt.want {
+				t.Errorf("规范化后 = %s, 期望 %s", got, tt.want)
+			}
+			if !reflect.DeepEqual(moves, tt.wantMoves) {
+				t.Errorf("移动 = %+v, 期望 %+v", moves, tt.wantMoves)
+			}
+		})
+	}
+}
+
+// bcryptHashPattern 匹配 bcrypt 哈希字符串，哈希中的盐每次随机生成
+var bcryptHashPattern = regexp.MustCompile(`\$2[aby]\$\d\d\$[./A-Za-z0-9]{53}`)
+
+func TestNormalizeHandlerOrderComposesHelpers(t *testing.T) {
+	const initial = `{"apps":{"http":{"servers":{"srv0":{"listen":[":443"],"routes":[` +
+		`{"@id":"app","match":[{"host":["app.example.com"]}],"handle":[{"handler":"reverse_proxy","upstreams":[{"dial":"app:80"}]},{"handler":"encode","encodings":{"gzip":{}}}]}` +
+		`]}}}}}`
+	helpers := map[string]func(m *Manager) error{
+		"auth": func(m *Manager) error {
+			return m.SetBasicAuthAccounts("app", map[string]string{"admin": "secret"})
+		},
+		"hints": func(m *Manager) error {
+			return m.AddEarlyHints("app", []LinkHint{{URI: "/app.css", Rel: "preload", As: "style"}})
+		},
+		"note": func(m *Manager) error { return m.SetRouteNote("app", "由测试创建") },
+	}
+
+	var first string
+	for _, order := range permutations([]string{"auth", "hints", "note"}) {
+		m, _ := newTestManager(t, initial)
+		m.NormalizeHandlerOrder = true
+		var reordered []HandlerMove
+		m.OnHandlerReorder = func(routeID string, moves []HandlerMove) {
+			reordered = append(reordered, moves...)
+		}
+		for _, name := range order {
+			if err := helpers[name](m); err != nil {
+				t.Fatalf("%v: %s: %v", order, name, err)
+			}
+		}
+
+		handle := getRoute(t, m, "app")["handle"].([]interface{})
+		// bcrypt 哈希带随机盐，比较前去掉密码
+		got := bcryptHashPattern.ReplaceAllString(encodeJSON(handle), "<hash>")
+		if first == "" {
+			first = got
+			if names := chainNames(handle); !strings.HasPrefix(names, "authentication,headers,encode,reverse_proxy") {
+				t.Fatalf("处理器顺序 = %s", names)
+			}
+		} else if got != first {
+			t.Errorf("调用顺序 %v 得到不同的处理器链:\n%s\n第一个排列:\n%s", order, got, first)
+		}
+		if len(reordered) == 0 {
+			t.Errorf("%v: 原有的 encode 在 reverse_proxy 之后，期望报告重排", order)
+		}
+	}
+}
+
+func TestHandlerOrderOptOut(t *testing.T) {
+	m, _ := newTestManager(t, `{"apps":{"http":{"servers":{"srv0":{"listen":[":443"],"routes":[`+
+		`{"@id":"app","handle":[{"handler":"reverse_proxy","upstreams":[{"dial":"app:80"}]},{"handler":"encode","encodings":{"gzip":{}}}]}`+
+		`]}}}}}`)
+	if err := m.AddEarlyHints("app", []LinkHint{{URI: "/app.css", Rel: "preload", As: "style"}}); err != nil {
+		t.Fatal(err)
+	}
+	// 默认不重排，保留调用方的自定义顺序
+	if got, want := chainNames(getRoute(t, m, "app")["handle"].([]interface{})), "headers,reverse_proxy,encode"; got != want {
+		t.Errorf("处理器顺序 = %s, 期望 %s", got, want)
+	}
+}
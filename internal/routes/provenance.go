@@ -0,0 +1,112 @@
+package routes
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/youfun/gofastcaddy/internal/api"
+	"github.com/youfun/gofastcaddy/pkg/types"
+)
+
+// ProvenanceVar 来源信息写入的 vars 键名
+const ProvenanceVar = MetadataVarPrefix + "meta"
+
+// Provenance 路由的来源信息 - 客户端开启 Provenance 时写入 fastcaddy 创建的路由
+// 用于在原始 JSON 中辨认哪些路由由 fastcaddy 管理；状态哈希和漂移检测会忽略该信息
+type Provenance struct {
+	Version   string    `json:"version"`    // 创建路由的库版本
+	CreatedAt time.Time `json:"created_at"` // 创建时间 (UTC)
+	Operation string    `json:"operation"`  // 创建路由的高级操作，如 "AddReverseProxy"
+}
+
+// stampProvenance 在客户端开启 Provenance 时为路由写入来源信息 - 内部辅助函数
+// 写入位置见 setRouteMetadata
+func (m *Manager) stampProvenance(route *types.Route, operation string) {
+	if !m.client.Provenance {
+		return
+	}
+	setRouteMetadata(route, ProvenanceVar, Provenance{
+		Version:   api.Version,
+		CreatedAt: time.Now().UTC().Truncate(time.Second),
+		Operation: operation,
+	})
+}
+
+// setRouteMetadata 在路由的元数据 vars 处理器中写入一个值 - 内部辅助函数
+// 已有元数据 vars 处理器时写入其中，否则追加到处理器链末尾：
+// vars 处理器只保存数据，放在末尾不会移动 handle/0 等已有处理器的位置 (通配符路由依赖 handle/0 的 subroute)
+func setRouteMetadata(route *types.Route, key string, value interface{}) {
+	for i, h := range route.Handle {
+		if h.Handler != "vars" || h.Module != nil {
+			continue
+		}
+		for existing := range h.Extra {
+			if strings.HasPrefix(existing, MetadataVarPrefix) {
+				route.Handle[i].Extra[key] = value
+				return
+			}
+		}
+	}
+	route.Handle = append(route.Handle, types.Handler{
+		Handler: "vars",
+		Extra:   map[string]interface{}{key: value},
+	})
+}
+
+// provenanceValue 读取元数据 vars 处理器中的来源信息，未设置时返回 nil - 内部辅助函数
+func provenanceValue(handlers []interface{}) *Provenance {
+	index := findMetadataHandler(handlers)
+	if index < 0 {
+		return nil
+	}
+	raw, ok := handlers[index].(map[string]interface{})[ProvenanceVar]
+	if !ok {
+		return nil
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil
+	}
+	var provenance Provenance
+	if json.Unmarshal(data, &provenance) != nil {
+		return nil
+	}
+	return &provenance
+}
+
+// StripProvenance 返回去除了路由来源信息和最后修改时间的配置副本，用于语义比较
+// 两者都包含时间，每次重新创建或修改路由都会变化；只含这些信息的 vars 处理器会被整体去除，
+// 其他元数据 (如备注) 保持不变。不修改传入的配置
+func StripProvenance(value interface{}) interface{} {
+	stripped, _ := stripProvenance(value)
+	return stripped
+}
+
+// stripProvenance 递归去除来源信息和最后修改时间 - 内部辅助函数
+// 第二个返回值表示该值是只剩 handler 字段的 vars 处理器，应从所在数组中删除
+func stripProvenance(value interface{}) (interface{}, bool) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		isVars := v["handler"] == "vars"
+		stripped := false
+		result := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			if isVars && (key == ProvenanceVar || key == UpdatedAtVar) {
+				stripped = true
+				continue
+			}
+			result[key], _ = stripProvenance(item)
+		}
+		return result, isVars && stripped && len(result) == 1
+	case []interface{}:
+		result := make([]interface{}, 0, len(v))
+		for _, item := range v {
+			if stripped, drop := stripProvenance(item); !drop {
+				result = append(result, stripped)
+			}
+		}
+		return result, false
+	}
+	return value, false
+}
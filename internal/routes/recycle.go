@@ -0,0 +1,271 @@
+package routes
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DeletedRoute 回收站中的路由 - DeleteByID 在删除前保存的完整路由及其位置
+type DeletedRoute struct {
+	ID        string                 `json:"id"`         // 路由 ID
+	Route     map[string]interface{} `json:"route"`      // 删除前的完整路由 JSON
+	Anchor    string                 `json:"anchor"`     // 所在数组最近的带 @id 的祖先 (为空表示从配置根开始)
+	Parent    string                 `json:"parent"`     // 所在数组相对于 Anchor 的路径，如 handle/0/routes
+	Index     int                    `json:"index"`      // 在数组中的位置
+	DeletedAt time.Time              `json:"deleted_at"` // 删除时间
+}
+
+// recycleBin 已删除路由的回收站 - 内部类型
+type recycleBin struct {
+	mu      sync.Mutex
+	loaded  bool
+	entries map[string]DeletedRoute
+}
+
+// UndoDelete 在保留期限内恢复被 DeleteByID 删除的路由，放回原来所在的数组和位置
+// 通配符子路由恢复到原通配符路由的 subroute 中；原数组已不存在 (如父路由也被删除) 时返回错误，
+// 此时可先恢复父路由再重试。原位置超出数组当前长度时追加到末尾
+func (m *Manager) UndoDelete(id string) error {
+	entry, err := m.takeDeleted(id)
+	if err != nil {
+		return err
+	}
+
+	defer m.client.LockWrites()()
+
+	parent, length, err := m.deletedParent(entry)
+	if err != nil {
+		m.keepDeleted(entry)
+		return err
+	}
+	// PUT 只能插入到已有元素的位置；原位置已在末尾之后 (如删除的是最后一个或唯一的路由) 时追加
+	switch {
+	case entry.Index < length && entry.Anchor != "":
+		err = m.client.CreateByID(entry.Route, fmt.Sprintf("%s/%d", parent, entry.Index))
+	case entry.Index < length:
+		err = m.client.CreateConfig(entry.Route, fmt.Sprintf("%s/%d", parent, entry.Index))
+	case entry.Anchor != "":
+		err = m.client.PostByID(entry.Route, parent)
+	default:
+		err = m.client.PostConfig(entry.Route, parent)
+	}
+	if err != nil {
+		m.keepDeleted(entry)
+		return fmt.Errorf("恢复路由 %s 失败: %w", id, err)
+	}
+	return nil
+}
+
+// DeletedRoutes 返回回收站中仍在保留期限内的路由
+// 回收站没有后台清理，过期条目在访问回收站 (删除、UndoDelete、DeletedRoutes) 时才被清除，
+// 包括 RecycleBinFile 中的内容
+func (m *Manager) DeletedRoutes() []DeletedRoute {
+	m.bin.mu.Lock()
+	defer m.bin.mu.Unlock()
+	m.loadBinLocked()
+	m.purgeLocked()
+
+	result := make([]DeletedRoute, 0, len(m.bin.entries))
+	for _, entry := range m.bin.entries {
+		result = append(result, entry)
+	}
+	return result
+}
+
+// saveDeleted 删除前将路由及其位置放入回收站 - 内部辅助函数
+// 路由不在配置中时不做任何操作，由随后的删除请求返回错误
+func (m *Manager) saveDeleted(id string) error {
+	cfg, err := m.client.GetConfig("/")
+	if err != nil {
+		return err
+	}
+	entry, ok := locateRoute(cfg, id)
+	if !ok {
+		return nil
+	}
+	entry.DeletedAt = time.Now()
+	m.keepDeleted(entry)
+	return nil
+}
+
+// keepDeleted 将条目写入回收站 - 内部辅助函数
+func (m *Manager) keepDeleted(entry DeletedRoute) {
+	m.bin.mu.Lock()
+	defer m.bin.mu.Unlock()
+	m.loadBinLocked()
+	m.purgeLocked()
+	m.bin.entries[entry.ID] = entry
+	m.persistLocked()
+}
+
+// takeDeleted 从回收站取出条目，过期或不存在时返回错误 - 内部辅助函数
+func (m *Manager) takeDeleted(id string) (DeletedRoute, error) {
+	m.bin.mu.Lock()
+	defer m.bin.mu.Unlock()
+	m.loadBinLocked()
+	m.purgeLocked()
+
+	entry, ok := m.bin.entries[id]
+	if !ok {
+		return DeletedRoute{}, fmt.Errorf("回收站中没有路由 %s (未开启删除保护或已超过保留期限)", id)
+	}
+	delete(m.bin.entries, id)
+	m.persistLocked()
+	return entry, nil
+}
+
+// deletedParent 返回恢复目标数组的路径及其当前长度 - 内部辅助函数
+func (m *Manager) deletedParent(entry DeletedRoute) (string, int, error) {
+	var (
+		items []interface{}
+		err   error
+	)
+	path := entry.Parent
+	if entry.Anchor != "" {
+		path = entry.Anchor + "/" + entry.Parent
+		var anchor map[string]interface{}
+		anchor, err = m.client.GetByID(entry.Anchor)
+		if err == nil {
+			value, _ := lookupKeys(anchor, strings.Split(entry.Parent, "/"))
+			items, _ = value.([]interface{})
+		}
+	} else {
+		var cfg map[string]interface{}
+		cfg, err = m.client.GetConfig("/")
+		if err == nil {
+			value, _ := lookupKeys(cfg, strings.Split(strings.Trim(entry.Parent, "/"), "/"))
+			items, _ = value.([]interface{})
+		}
+	}
+	if err != nil {
+		return "", 0, fmt.Errorf("读取路由 %s 原来所在的位置失败: %w", entry.ID, err)
+	}
+	if items == nil {
+		return "", 0, fmt.Errorf("路由 %s 原来所在的数组 %s 已不存在", entry.ID, path)
+	}
+	return path, len(items), nil
+}
+
+// purgeLocked 删除超过保留期限的条目，调用方需持有回收站的锁 - 内部辅助函数
+func (m *Manager) purgeLocked() {
+	grace := m.client.DeleteGrace
+	changed := false
+	for id, entry := range m.bin.entries {
+		if grace <= 0 || time.Since(entry.DeletedAt) > grace {
+			delete(m.bin.entries, id)
+			changed = true
+		}
+	}
+	if changed {
+		m.persistLocked()
+	}
+}
+
+// loadBinLocked 首次使用时初始化回收站，设置了 RecycleBinFile 时从文件读取 - 内部辅助函数
+func (m *Manager) loadBinLocked() {
+	if m.bin.loaded {
+		return
+	}
+	m.bin.loaded = true
+	m.bin.entries = make(map[string]DeletedRoute)
+	if m.RecycleBinFile == "" {
+		return
+	}
+	data, err := os.ReadFile(m.RecycleBinFile)
+	if err != nil {
+		return
+	}
+	var entries []DeletedRoute
+	if json.Unmarshal(data, &entries) == nil {
+		for _, entry := range entries {
+			m.bin.entries[entry.ID] = entry
+		}
+	}
+}
+
+// persistLocked 设置了 RecycleBinFile 时将回收站写入文件 - 内部辅助函数
+// 写入失败只影响进程重启后的恢复，不影响删除本身，因此忽略错误
+func (m *Manager) persistLocked() {
+	if m.RecycleBinFile == "" {
+		return
+	}
+	entries := make([]DeletedRoute, 0, len(m.bin.entries))
+	for _, entry := range m.bin.entries {
+		entries = append(entries, entry)
+	}
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return
+	}
+	os.WriteFile(m.RecycleBinFile, data, 0o600)
+}
+
+// locateRoute 在配置中查找 @id 为 id 的路由及其所在数组的位置 - 内部辅助函数
+// 位置相对于最近的带 @id 的祖先记录，父路由在服务器路由列表中移动后仍能找到
+func locateRoute(cfg map[string]interface{}, id string) (DeletedRoute, bool) {
+	var (
+		result DeletedRoute
+		found  bool
+	)
+	var walk func(value interface{}, anchor string, keys []string)
+	walk = func(value interface{}, anchor string, keys []string) {
+		if found {
+			return
+		}
+		switch v := value.(type) {
+		case map[string]interface{}:
+			if ownID, ok := v["@id"].(string); ok && ownID != "" && ownID != id {
+				anchor, keys = ownID, nil
+			}
+			for key, item := range v {
+				walk(item, anchor, append(keys[:len(keys):len(keys)], key))
+			}
+		case []interface{}:
+			for i, item := range v {
+				if route, ok := item.(map[string]interface{}); ok && route["@id"] == id {
+					result = DeletedRoute{ID: id, Route: route, Anchor: anchor, Parent: strings.Join(keys, "/"), Index: i}
+					if anchor == "" {
+						result.Parent = "/" + result.Parent
+					}
+					found = true
+					return
+				}
+				walk(item, anchor, append(keys[:len(keys):len(keys)], strconv.Itoa(i)))
+			}
+		}
+	}
+	walk(cfg, "", nil)
+	return result, found
+}
+
+// lookupKeys 沿键路径在 JSON 结构中查找值，支持对象键和数组下标 - 内部辅助函数
+func lookupKeys(value interface{}, keys []string) (interface{}, bool) {
+	current := value
+	for _, key := range keys {
+		if key == "" {
+			continue
+		}
+		switch v := current.(type) {
+		case map[string]interface{}:
+			next, ok := v[key]
+			if !ok {
+				return nil, false
+			}
+			current = next
+		case []interface{}:
+			index, err := strconv.Atoi(key)
+			if err != nil || index < 0 || index >= len(v) {
+				return nil, false
+			}
+			current = v[index]
+		default:
+			return nil, false
+		}
+	}
+	return current, true
+}
@@ -0,0 +1,96 @@
+package routes
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/youfun/gofastcaddy/internal/api"
+	"github.com/youfun/gofastcaddy/pkg/clienttest"
+)
+
+// newGraceManager 创建开启删除保护的路由管理器 - 测试辅助函数
+func newGraceManager(t *testing.T, initial string, grace time.Duration) (*Manager, *clienttest.FakeCaddy) {
+	t.Helper()
+	fake := clienttest.NewFakeCaddy(initial)
+	t.Cleanup(fake.Close)
+	return NewManagerWithClient(api.NewClientWithURL(fake.URL, api.WithDeleteGrace(grace))), fake
+}
+
+// routeIDs 返回路由列表中各路由的 @id，以逗号连接 - 测试辅助函数
+func routeIDs(routes []interface{}) string {
+	var ids []string
+	for _, raw := range routes {
+		id, _ := raw.(map[string]interface{})["@id"].(string)
+		ids = append(ids, id)
+	}
+	return strings.Join(ids, ",")
+}
+
+func TestUndoDelete(t *testing.T) {
+	const three = `{"apps":{"http":{"servers":{"srv0":{"routes":[{"@id":"a"},{"@id":"b"},{"@id":"c"}]}}}}}`
+	const nested = `{"apps":{"http":{"servers":{"srv0":{"routes":[` +
+		`{"@id":"wildcard-example.com","handle":[{"handler":"subroute","routes":[{"@id":"x"},{"@id":"y"}]}]}` +
+		`]}}}}}`
+
+	tests := []struct {
+		name    string
+		initial string
+		id      string
+		nested  bool   // 路由位于通配符路由的 subroute 中
+		want    string // 恢复后所在数组的 @id 顺序
+	}{
+		{name: "中间的路由", initial: three, id: "b", want: "a,b,c"},
+		{name: "最后一个路由", initial: three, id: "c", want: "a,b,c"},
+		{name: "唯一的路由", initial: `{"apps":{"http":{"servers":{"srv0":{"routes":[{"@id":"a"}]}}}}}`, id: "a", want: "a"},
+		{name: "通配符子路由", initial: nested, id: "y", nested: true, want: "x,y"},
+		{name: "通配符的第一个子路由", initial: nested, id: "x", nested: true, want: "x,y"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, fake := newGraceManager(t, tt.initial, time.Minute)
+			if err := m.DeleteByID(tt.id); err != nil {
+				t.Fatal(err)
+			}
+			if deleted := m.DeletedRoutes(); len(deleted) != 1 || deleted[0].ID != tt.id {
+				t.Fatalf("回收站 = %+v", deleted)
+			}
+			if err := m.UndoDelete(tt.id); err != nil {
+				t.Fatal(err)
+			}
+
+			var list []interface{}
+			for _, route := range serverRoutes(t, fake) {
+				list = append(list, route)
+			}
+			if tt.nested {
+				subroute := list[0].(map[string]interface{})["handle"].([]interface{})[0]
+				list, _ = subroute.(map[string]interface{})["routes"].([]interface{})
+			}
+			if got := routeIDs(list); got != tt.want {
+				t.Errorf("恢复后 = %s, 期望 %s", got, tt.want)
+			}
+			if len(m.DeletedRoutes()) != 0 {
+				t.Error("恢复后回收站应为空")
+			}
+		})
+	}
+}
+
+func TestUndoDeleteAfterRetention(t *testing.T) {
+	m, fake := newGraceManager(t, `{"apps":{"http":{"servers":{"srv0":{"routes":[{"@id":"a"},{"@id":"b"}]}}}}}`, 10*time.Millisecond)
+	if err := m.DeleteByID("b"); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(30 * time.Millisecond)
+
+	if deleted := m.DeletedRoutes(); len(deleted) != 0 {
+		t.Errorf("超过保留期限后回收站 = %+v", deleted)
+	}
+	if err := m.UndoDelete("b"); err == nil {
+		t.Error("超过保留期限后恢复应返回错误")
+	}
+	if got := len(serverRoutes(t, fake)); got != 1 {
+		t.Errorf("路由数 = %d, 期望 1", got)
+	}
+}
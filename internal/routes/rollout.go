@@ -0,0 +1,244 @@
+package routes
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// 发布过程的默认参数
+const (
+	DefaultRolloutTimeout      = 2 * time.Minute
+	DefaultRolloutPollInterval = time.Second
+)
+
+// 发布过程的状态
+const (
+	RolloutAdded      = "added"       // 新上游已加入路由
+	RolloutHealthy    = "healthy"     // 新上游已持续健康 HealthyFor 时长
+	RolloutUnhealthy  = "unhealthy"   // 新上游当前不健康，重新计时
+	RolloutOldRemoved = "old_removed" // 旧上游已从路由中移除
+	RolloutRolledBack = "rolled_back" // 已恢复原有上游列表
+)
+
+// RolloutEvent 发布过程中的状态变化
+type RolloutEvent struct {
+	RouteID string // 路由 ID
+	Stage   string // 状态，取值见 Rollout* 常量
+	Dial    string // 相关的上游地址
+	Err     error  // 导致回滚的错误 (仅 RolloutRolledBack)
+}
+
+// RolloutOptions 上游发布选项
+type RolloutOptions struct {
+	HealthyFor   time.Duration      // 新上游需要持续健康的时长
+	Timeout      time.Duration      // 等待新上游健康的总时长 (0 表示使用默认值)
+	PollInterval time.Duration      // 轮询上游状态的间隔 (0 表示使用默认值)
+	OnEvent      func(RolloutEvent) // 状态变化回调 (可选)
+	Group        string             // 新上游的分组名称 (可选)，移除旧上游后通过 SetUpstreamGroup 记录到路由
+
+	// Probe 额外的健康检查 (可选)，返回 nil 且上游状态健康时才计入健康时长；
+	// 例如通过数据面检查路由: func(ctx context.Context) error { _, err := fc.VerifyProxy(ctx, host, "/health"); return err }
+	Probe func(ctx context.Context) error
+}
+
+// RolloutUpstream 为现有反向代理路由滚动替换上游
+// 先追加 newDial，轮询 /reverse_proxy/upstreams 直到新上游持续健康 HealthyFor 时长，再移除 oldDial；
+// 超时或 ctx 取消时移除本次添加的 newDial。每次读取-修改-写回都持有 LockWrites 并基于最新的上游列表，
+// 不会覆盖等待期间其他调用方的修改；newDial 与 oldDial 相同或 oldDial 不在路由中时返回错误。
+// 已在处理中的请求由 Caddy 在配置重载时自行完成。
+//
+// Caddy 的上游状态端点不直接暴露主动健康检查结果，这里以“出现在列表中且 fails 为 0”判定健康，
+// 因此路由应配置被动健康检查 (fail_duration) 或主动健康检查，否则失败不会被记录
+func (m *Manager) RolloutUpstream(ctx context.Context, routeID, newDial, oldDial string, opts RolloutOptions) error {
+	if newDial == "" || oldDial == "" {
+		return fmt.Errorf("新旧上游地址不能为空")
+	}
+	if newDial == oldDial {
+		return fmt.Errorf("新旧上游地址相同: %s", newDial)
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultRolloutTimeout
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = DefaultRolloutPollInterval
+	}
+	report := func(stage, dial string, err error) {
+		if opts.OnEvent != nil {
+			opts.OnEvent(RolloutEvent{RouteID: routeID, Stage: stage, Dial: dial, Err: err})
+		}
+	}
+
+	added := false
+	err := m.editUpstreams(routeID, func(upstreams []interface{}) ([]interface{}, error) {
+		if !hasDial(upstreams, oldDial) {
+			return nil, fmt.Errorf("路由 %s 的上游中没有 %s", routeID, oldDial)
+		}
+		if hasDial(upstreams, newDial) {
+			return nil, nil
+		}
+		added = true
+		return append(append([]interface{}(nil), upstreams...), map[string]interface{}{"dial": newDial}), nil
+	})
+	if err != nil {
+		return fmt.Errorf("添加上游 %s 失败: %w", newDial, err)
+	}
+	report(RolloutAdded, newDial, nil)
+
+	if err := m.waitHealthy(ctx, newDial, opts, report); err != nil {
+		// 只移除本次添加的上游，保留等待期间其他调用方对上游列表的修改
+		if added {
+			rbErr := m.editUpstreams(routeID, func(upstreams []interface{}) ([]interface{}, error) {
+				return withoutDial(upstreams, newDial), nil
+			})
+			if rbErr != nil {
+				return fmt.Errorf("等待上游 %s 健康失败: %v; 回滚也失败: %w", newDial, err, rbErr)
+			}
+		}
+		report(RolloutRolledBack, newDial, err)
+		return fmt.Errorf("等待上游 %s 健康失败, 已回滚: %w", newDial, err)
+	}
+	report(RolloutHealthy, newDial, nil)
+
+	err = m.editUpstreams(routeID, func(upstreams []interface{}) ([]interface{}, error) {
+		if !hasDial(upstreams, newDial) {
+			return nil, fmt.Errorf("新上游 %s 已不在路由 %s 中", newDial, routeID)
+		}
+		if !hasDial(upstreams, oldDial) {
+			return nil, fmt.Errorf("路由 %s 的上游中没有 %s", routeID, oldDial)
+		}
+		return withoutDial(upstreams, oldDial), nil
+	})
+	if err != nil {
+		return fmt.Errorf("移除上游 %s 失败: %w", oldDial, err)
+	}
+	report(RolloutOldRemoved, oldDial, nil)
+
+	if opts.Group != "" {
+		if err := m.SetUpstreamGroup(routeID, opts.Group); err != nil {
+			return fmt.Errorf("记录上游分组 %s 失败: %w", opts.Group, err)
+		}
+	}
+	return nil
+}
+
+// waitHealthy 轮询上游状态直到 dial 持续健康 HealthyFor 时长 - 内部辅助函数
+func (m *Manager) waitHealthy(ctx context.Context, dial string, opts RolloutOptions, report func(string, string, error)) error {
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	var healthySince time.Time
+	ticker := time.NewTicker(opts.PollInterval)
+	defer ticker.Stop()
+	for {
+		statuses, err := m.client.GetUpstreamsContext(ctx)
+		if err != nil {
+			return err
+		}
+
+		healthy := false
+		for _, status := range statuses {
+			if status.Address == dial {
+				healthy = status.Fails == 0
+				break
+			}
+		}
+		if healthy && opts.Probe != nil {
+			healthy = opts.Probe(ctx) == nil
+		}
+
+		now := time.Now()
+		switch {
+		case !healthy:
+			if !healthySince.IsZero() {
+				report(RolloutUnhealthy, dial, nil)
+			}
+			healthySince = time.Time{}
+		case healthySince.IsZero():
+			healthySince = now
+		}
+		if !healthySince.IsZero() && now.Sub(healthySince) >= opts.HealthyFor {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// proxyUpstreams 读取路由中第一个反向代理处理器的位置和上游列表 - 内部辅助函数
+func (m *Manager) proxyUpstreams(routeID string) (int, []interface{}, error) {
+	handlers, err := m.routeHandlers(routeID)
+	if err != nil {
+		return 0, nil, err
+	}
+	for i, raw := range handlers {
+		if handler, ok := raw.(map[string]interface{}); ok && handler["handler"] == "reverse_proxy" {
+			upstreams, _ := handler["upstreams"].([]interface{})
+			return i, upstreams, nil
+		}
+	}
+	return 0, nil, fmt.Errorf("路由 %s 没有反向代理处理器", routeID)
+}
+
+// editUpstreams 在写锁内读取路由的上游列表并写回 edit 的结果 - 内部辅助函数
+// edit 返回 nil 列表且没有错误时不写入
+func (m *Manager) editUpstreams(routeID string, edit func(upstreams []interface{}) ([]interface{}, error)) error {
+	defer m.client.LockWrites()()
+
+	index, upstreams, err := m.proxyUpstreams(routeID)
+	if err != nil {
+		return err
+	}
+	updated, err := edit(upstreams)
+	if err != nil || updated == nil {
+		return err
+	}
+	return m.saveUpstreams(routeID, index, updated)
+}
+
+// saveUpstreams 写入第 index 个处理器 (反向代理) 的上游列表 - 内部辅助函数
+// 开启 UpdateTimestamps 时整体写入处理器链，使上游列表和最后修改时间在同一请求中生效
+func (m *Manager) saveUpstreams(routeID string, index int, upstreams []interface{}) error {
+	if !m.client.UpdateTimestamps {
+		return m.client.PatchByID(upstreams, fmt.Sprintf("%s/handle/%d/upstreams", routeID, index))
+	}
+	handlers, err := m.routeHandlers(routeID)
+	if err != nil {
+		return err
+	}
+	if index >= len(handlers) {
+		return fmt.Errorf("路由 %s 的处理器链已被修改", routeID)
+	}
+	proxy, ok := handlers[index].(map[string]interface{})
+	if !ok || proxy["handler"] != "reverse_proxy" {
+		return fmt.Errorf("路由 %s 的处理器链已被修改", routeID)
+	}
+	proxy["upstreams"] = upstreams
+	return m.saveHandlers(routeID, handlers)
+}
+
+// withoutDial 返回去掉指定地址后的上游列表 - 内部辅助函数
+func withoutDial(upstreams []interface{}, dial string) []interface{} {
+	remaining := []interface{}{}
+	for _, upstream := range upstreams {
+		if u, ok := upstream.(map[string]interface{}); ok && u["dial"] == dial {
+			continue
+		}
+		remaining = append(remaining, upstream)
+	}
+	return remaining
+}
+
+// hasDial 检查上游列表是否包含指定地址 - 内部辅助函数
+func hasDial(upstreams []interface{}, dial string) bool {
+	for _, upstream := range upstreams {
+		if u, ok := upstream.(map[string]interface{}); ok && u["dial"] == dial {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,217 @@
+package routes
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/youfun/gofastcaddy/internal/api"
+	"github.com/youfun/gofastcaddy/pkg/clienttest"
+)
+
+const rolloutServer = `{"apps":{"http":{"servers":{"srv0":{"listen":[":443"],"routes":[
+	{"@id":"app","match":[{"host":["app.example.com"]}],"handle":[{"handler":"reverse_proxy","upstreams":[{"dial":"old:80"}]}]}
+]}}}}}`
+
+func TestRolloutUpstreamProbe(t *testing.T) {
+	tests := []struct {
+		name    string
+		probe   func(ctx context.Context) error
+		wantErr bool
+		want    []string // 发布结束后的上游列表
+	}{
+		{name: "未设置探测", want: []string{"new:80"}},
+		{name: "探测成功", probe: func(context.Context) error { return nil }, want: []string{"new:80"}},
+		{name: "探测失败时回滚", probe: func(context.Context) error { return errors.New("502") }, wantErr: true, want: []string{"old:80"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, fake := newTestManager(t, rolloutServer)
+			fake.Handle("/reverse_proxy/upstreams", func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(`[{"address":"new:80","fails":0},{"address":"old:80","fails":0}]`))
+			})
+
+			err := m.RolloutUpstream(context.Background(), "app", "new:80", "old:80", RolloutOptions{
+				HealthyFor:   20 * time.Millisecond,
+				Timeout:      200 * time.Millisecond,
+				PollInterval: 5 * time.Millisecond,
+				Probe:        tt.probe,
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, 期望错误 %v", err, tt.wantErr)
+			}
+			_, upstreams, err := m.proxyUpstreams("app")
+			if err != nil {
+				t.Fatal(err)
+			}
+			var dials []string
+			for _, u := range upstreams {
+				dials = append(dials, u.(map[string]interface{})["dial"].(string))
+			}
+			if !reflect.DeepEqual(dials, tt.want) {
+				t.Errorf("上游 = %v, 期望 %v", dials, tt.want)
+			}
+		})
+	}
+}
+
+func TestRolloutUpstreamFaults(t *testing.T) {
+	tests := []struct {
+		name   string
+		faults func(next http.RoundTripper) *clienttest.FaultInjector
+		stages []string // 期望的发布状态序列
+	}{
+		{
+			name:   "只读管理端点时不修改路由",
+			faults: clienttest.ReadOnlyAdmin,
+		},
+		{
+			name: "读取上游状态失败时回滚",
+			faults: func(next http.RoundTripper) *clienttest.FaultInjector {
+				return clienttest.NewFaultInjector(next).StatusFor("/reverse_proxy/upstreams", http.StatusInternalServerError)
+			},
+			stages: []string{RolloutAdded, RolloutRolledBack},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := clienttest.NewFakeCaddy(rolloutServer)
+			defer fake.Close()
+			m := NewManagerWithClient(api.NewClientWithURL(fake.URL,
+				api.WithHTTPClient(&http.Client{Transport: tt.faults(nil)}),
+			))
+
+			var stages []string
+			err := m.RolloutUpstream(context.Background(), "app", "new:80", "old:80", RolloutOptions{
+				Timeout:      200 * time.Millisecond,
+				PollInterval: 5 * time.Millisecond,
+				OnEvent:      func(e RolloutEvent) { stages = append(stages, e.Stage) },
+			})
+			if err == nil {
+				t.Fatal("期望发布失败")
+			}
+			if !reflect.DeepEqual(stages, tt.stages) {
+				t.Errorf("状态 = %v, 期望 %v", stages, tt.stages)
+			}
+			// 失败后路由保持原有的上游列表
+			handle := serverRoutes(t, fake)[0]["handle"].([]interface{})
+			upstreams := handle[0].(map[string]interface{})["upstreams"]
+			if got, want := encodeJSON(upstreams), `[{"dial":"old:80"}]`; got != want {
+				t.Errorf("上游 = %s, 期望 %s", got, want)
+			}
+		})
+	}
+}
+
+func TestRolloutUpstreamRejectsInvalidDials(t *testing.T) {
+	tests := []struct {
+		name    string
+		newDial string
+		oldDial string
+	}{
+		{name: "新旧地址相同", newDial: "old:80", oldDial: "old:80"},
+		{name: "旧上游不在路由中", newDial: "new:80", oldDial: "missing:80"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, fake := newTestManager(t, rolloutServer)
+			err := m.RolloutUpstream(context.Background(), "app", tt.newDial, tt.oldDial, RolloutOptions{})
+			if err == nil {
+				t.Fatal("期望返回错误")
+			}
+			for _, req := range fake.Requests() {
+				if !strings.HasPrefix(req, "GET ") {
+					t.Errorf("不应修改配置: %s", req)
+				}
+			}
+		})
+	}
+}
+
+func TestRolloutUpstreamRollbackKeepsConcurrentChanges(t *testing.T) {
+	m, fake := newTestManager(t, rolloutServer)
+	other := NewManagerWithClient(api.NewClientWithURL(fake.URL))
+	fake.Handle("/reverse_proxy/upstreams", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"address":"new:80","fails":1}]`))
+	})
+
+	var once sync.Once
+	err := m.RolloutUpstream(context.Background(), "app", "new:80", "old:80", RolloutOptions{
+		Timeout:      50 * time.Millisecond,
+		PollInterval: 5 * time.Millisecond,
+		OnEvent: func(e RolloutEvent) {
+			if e.Stage != RolloutAdded {
+				return
+			}
+			// 等待期间其他调用方追加了一个上游
+			once.Do(func() {
+				err := other.editUpstreams("app", func(upstreams []interface{}) ([]interface{}, error) {
+					return append(upstreams, map[string]interface{}{"dial": "other:80"}), nil
+				})
+				if err != nil {
+					t.Error(err)
+				}
+			})
+		},
+	})
+	if err == nil {
+		t.Fatal("期望发布失败")
+	}
+	_, upstreams, err := m.proxyUpstreams("app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := encodeJSON(upstreams), `[{"dial":"old:80"},{"dial":"other:80"}]`; got != want {
+		t.Errorf("回滚后上游 = %s, 期望 %s", got, want)
+	}
+}
+
+func TestRolloutUpstreamHonorsContext(t *testing.T) {
+	m, fake := newTestManager(t, rolloutServer)
+	// 上游状态端点挂起，路由的读写正常
+	fake.Handle("/reverse_proxy/upstreams", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	})
+
+	start := time.Now()
+	err := m.RolloutUpstream(context.Background(), "app", "new:80", "old:80", RolloutOptions{
+		Timeout:      100 * time.Millisecond,
+		PollInterval: 5 * time.Millisecond,
+	})
+	if err == nil {
+		t.Fatal("期望发布超时")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("发布在超时后仍等待了 %v", elapsed)
+	}
+}
+
+func TestRolloutUpstreamRecordsGroup(t *testing.T) {
+	m, fake := newTestManager(t, rolloutServer)
+	fake.Handle("/reverse_proxy/upstreams", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"address":"new:80","fails":0}]`))
+	})
+
+	err := m.RolloutUpstream(context.Background(), "app", "new:80", "old:80", RolloutOptions{
+		PollInterval: 5 * time.Millisecond,
+		Group:        "green",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	target, err := m.LookupProxyTarget("app.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (ProxyTarget{Dials: []string{"new:80"}, Group: "green"}); !reflect.DeepEqual(target, want) {
+		t.Errorf("代理目标 = %+v, 期望 %+v", target, want)
+	}
+}
@@ -0,0 +1,202 @@
+package routes
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/youfun/gofastcaddy/internal/utils"
+)
+
+// GetProxyTarget 可能返回的错误 - 可通过 errors.Is 判断
+var (
+	ErrHostNotServed   = errors.New("没有路由处理该主机名")
+	ErrNotProxy        = errors.New("主机名不由反向代理处理")
+	ErrDynamicUpstream = errors.New("主机名使用动态上游")
+)
+
+// HostResolution ResolveHost 的结果 - 处理主机名请求的路由及响应请求的处理器
+type HostResolution struct {
+	Server  string                   // 服务器名称
+	Routes  []map[string]interface{} // 匹配的路由，从服务器的顶层路由到最内层 subroute 中的路由依次排列
+	Handler map[string]interface{}   // 响应请求的处理器，如 reverse_proxy、static_response
+}
+
+// ProxyTarget 主机名当前的代理目标
+type ProxyTarget struct {
+	Dials []string // 上游地址 (dial)
+	Group string   // 当前生效的上游分组名称 (见 SetUpstreamGroup)，路由没有记录时为空
+}
+
+// ResolveHost 查找处理主机名请求的路由
+// 按 Caddy 的匹配规则依次查找各服务器的路由 (默认服务器优先)，进入 subroute (包括通配符路由的子路由)，
+// 同一 group 中只有第一个匹配的路由生效。带有 host 以外匹配条件 (如路径、GeoIP) 的匹配器被视为不一定匹配而跳过。
+// 以 reverse_proxy、static_response、file_server 或 error 处理器响应请求，vars、headers 等其他处理器被跳过；
+// 没有路由处理该主机名时返回 ErrHostNotServed
+func (m *Manager) ResolveHost(host string) (HostResolution, error) {
+	host, err := utils.NormalizeHost(host)
+	if err != nil {
+		return HostResolution{}, err
+	}
+
+	servers, err := m.client.GetConfig(ServersPath)
+	if err != nil {
+		return HostResolution{}, err
+	}
+	names := make([]string, 0, len(servers))
+	for name := range servers {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if (names[i] == DefaultServerName) != (names[j] == DefaultServerName) {
+			return names[i] == DefaultServerName
+		}
+		return names[i] < names[j]
+	})
+
+	for _, name := range names {
+		server, _ := servers[name].(map[string]interface{})
+		routes, _ := server["routes"].([]interface{})
+		chain, handler, err := resolveRoutes(routes, host)
+		if errors.Is(err, ErrHostNotServed) {
+			continue
+		}
+		if err != nil {
+			return HostResolution{}, err
+		}
+		return HostResolution{Server: name, Routes: chain, Handler: handler}, nil
+	}
+	return HostResolution{}, fmt.Errorf("%w: %s", ErrHostNotServed, host)
+}
+
+// GetProxyTarget 返回主机名当前代理到的上游地址 (dial) - 查找规则见 ResolveHost
+// 没有路由处理该主机名时返回 ErrHostNotServed，由 static_response、file_server 等处理器响应时返回 ErrNotProxy，
+// 反向代理使用 dynamic_upstreams (如 SRV) 时返回 ErrDynamicUpstream。
+// RolloutUpstream 进行中的路由同时包含新旧上游，结果即为当前的完整上游列表
+func (m *Manager) GetProxyTarget(host string) ([]string, error) {
+	target, err := m.LookupProxyTarget(host)
+	return target.Dials, err
+}
+
+// LookupProxyTarget 返回主机名当前的上游地址和蓝绿发布的分组名称 - 错误同 GetProxyTarget
+// 分组名称取自匹配的路由中最内层记录了 UpstreamGroupVar 的路由
+func (m *Manager) LookupProxyTarget(host string) (ProxyTarget, error) {
+	resolution, err := m.ResolveHost(host)
+	if err != nil {
+		return ProxyTarget{}, err
+	}
+	if name, _ := resolution.Handler["handler"].(string); name != "reverse_proxy" {
+		return ProxyTarget{}, fmt.Errorf("%w: %s 由 %s 处理器响应", ErrNotProxy, host, name)
+	}
+	dials, err := proxyDials(resolution.Handler, host)
+	if err != nil {
+		return ProxyTarget{}, err
+	}
+
+	target := ProxyTarget{Dials: dials}
+	for i := len(resolution.Routes) - 1; i >= 0 && target.Group == ""; i-- {
+		handlers, _ := resolution.Routes[i]["handle"].([]interface{})
+		target.Group = metadataValue(handlers, UpstreamGroupVar)
+	}
+	return target, nil
+}
+
+// resolveRoutes 在路由列表中查找响应主机名请求的处理器及其所在的路由链 - 内部辅助函数
+// 路由列表中没有处理该主机名的处理器时返回 ErrHostNotServed
+func resolveRoutes(routes []interface{}, host string) ([]map[string]interface{}, map[string]interface{}, error) {
+	matchedGroups := make(map[string]bool)
+	for _, raw := range routes {
+		route, ok := raw.(map[string]interface{})
+		if !ok || !routeMatchesHost(route, host) {
+			continue
+		}
+		if group, _ := route["group"].(string); group != "" {
+			if matchedGroups[group] {
+				continue
+			}
+			matchedGroups[group] = true
+		}
+
+		handlers, _ := route["handle"].([]interface{})
+		for _, rawHandler := range handlers {
+			handler, _ := rawHandler.(map[string]interface{})
+			switch name, _ := handler["handler"].(string); name {
+			case "reverse_proxy", "static_response", "file_server", "error":
+				return []map[string]interface{}{route}, handler, nil
+			case "subroute":
+				children, _ := handler["routes"].([]interface{})
+				chain, responder, err := resolveRoutes(children, host)
+				if !errors.Is(err, ErrHostNotServed) {
+					return append([]map[string]interface{}{route}, chain...), responder, err
+				}
+			}
+		}
+		if terminal, _ := route["terminal"].(bool); terminal {
+			// 如通配符路由中没有匹配的子路由，Caddy 返回空响应
+			return nil, nil, fmt.Errorf("%w: %s 匹配的终端路由没有处理器响应", ErrHostNotServed, host)
+		}
+	}
+	return nil, nil, fmt.Errorf("%w: %s", ErrHostNotServed, host)
+}
+
+// proxyDials 提取反向代理处理器的上游地址 - 内部辅助函数
+func proxyDials(handler map[string]interface{}, host string) ([]string, error) {
+	if dynamic, ok := handler["dynamic_upstreams"].(map[string]interface{}); ok {
+		source, _ := dynamic["source"].(string)
+		return nil, fmt.Errorf("%w: %s 的上游由 %s 动态提供", ErrDynamicUpstream, host, source)
+	}
+	upstreams, _ := handler["upstreams"].([]interface{})
+	dials := make([]string, 0, len(upstreams))
+	for _, raw := range upstreams {
+		upstream, _ := raw.(map[string]interface{})
+		if dial, ok := upstream["dial"].(string); ok {
+			dials = append(dials, dial)
+		}
+	}
+	return dials, nil
+}
+
+// routeMatchesHost 判断路由是否一定匹配该主机名 - 内部辅助函数
+// 没有匹配器的路由匹配所有请求；匹配器集合之间为“或”关系，只含 host 条件的集合才参与判断
+func routeMatchesHost(route map[string]interface{}, host string) bool {
+	matchers, _ := route["match"].([]interface{})
+	if len(matchers) == 0 {
+		return true
+	}
+	for _, raw := range matchers {
+		matcher, _ := raw.(map[string]interface{})
+		patterns, ok := matcher["host"].([]interface{})
+		if !ok || len(matcher) != 1 {
+			continue
+		}
+		for _, p := range patterns {
+			if pattern, ok := p.(string); ok && hostMatches(pattern, host) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// hostMatches 按 Caddy host 匹配器的规则判断主机名是否匹配 - 内部辅助函数
+// 不区分大小写；"*" 匹配恰好一级标签，如 *.example.com 匹配 a.example.com 而不匹配 a.b.example.com
+func hostMatches(pattern, host string) bool {
+	if strings.EqualFold(pattern, host) {
+		return true
+	}
+	if !strings.Contains(pattern, "*") {
+		return false
+	}
+	patternLabels := strings.Split(pattern, ".")
+	hostLabels := strings.Split(host, ".")
+	if len(patternLabels) != len(hostLabels) {
+		return false
+	}
+	for i, label := range patternLabels {
+		if label != "*" && !strings.EqualFold(label, hostLabels[i]) {
+			return false
+		}
+	}
+	return true
+}
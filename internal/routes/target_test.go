@@ -0,0 +1,136 @@
+package routes
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/youfun/gofastcaddy/pkg/types"
+)
+
+func TestLookupProxyTarget(t *testing.T) {
+	tests := []struct {
+		name    string
+		setup   func(m *Manager) error
+		host    string
+		want    ProxyTarget
+		wantErr error
+	}{
+		{
+			name:  "普通反向代理",
+			setup: func(m *Manager) error { return m.AddReverseProxy("app.example.com", "localhost:8080") },
+			host:  "APP.example.com",
+			want:  ProxyTarget{Dials: []string{"localhost:8080"}},
+		},
+		{
+			name: "通配符子路由",
+			setup: func(m *Manager) error {
+				if err := m.AddWildcardRoute("example.com"); err != nil {
+					return err
+				}
+				return m.AddSubReverseProxy("example.com", "api", []string{"9000", "9001"}, "backend")
+			},
+			host: "api.example.com",
+			want: ProxyTarget{Dials: []string{"backend:9000", "backend:9001"}},
+		},
+		{
+			name: "蓝绿发布分组",
+			setup: func(m *Manager) error {
+				if err := m.AddReverseProxy("app.example.com", "green:80"); err != nil {
+					return err
+				}
+				return m.SetUpstreamGroup("app.example.com", "green")
+			},
+			host: "app.example.com",
+			want: ProxyTarget{Dials: []string{"green:80"}, Group: "green"},
+		},
+		{
+			name: "通配符中没有匹配的子路由",
+			setup: func(m *Manager) error {
+				if err := m.AddWildcardRoute("example.com"); err != nil {
+					return err
+				}
+				return m.AddSubReverseProxy("example.com", "api", []string{"9000"}, "")
+			},
+			host:    "www.example.com",
+			wantErr: ErrHostNotServed,
+		},
+		{
+			name:    "没有匹配的路由",
+			setup:   func(m *Manager) error { return m.AddReverseProxy("app.example.com", "localhost:8080") },
+			host:    "other.example.com",
+			wantErr: ErrHostNotServed,
+		},
+		{
+			name: "静态响应",
+			setup: func(m *Manager) error {
+				return m.AddRoute(NewRouteBuilder("redirect").Host("old.example.com").
+					Handle(types.Handler{Handler: "static_response", Extra: map[string]interface{}{"status_code": 301}}).Build())
+			},
+			host:    "old.example.com",
+			wantErr: ErrNotProxy,
+		},
+		{
+			name: "动态上游",
+			setup: func(m *Manager) error {
+				return m.AddRoute(NewRouteBuilder("srv").Host("srv.example.com").
+					Handle(types.Handler{Handler: "reverse_proxy", Extra: map[string]interface{}{
+						"dynamic_upstreams": map[string]interface{}{"source": "srv", "name": "_http._tcp.example.com"},
+					}}).Build())
+			},
+			host:    "srv.example.com",
+			wantErr: ErrDynamicUpstream,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, _ := newTestManager(t, emptyServer)
+			if err := tt.setup(m); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := m.LookupProxyTarget(tt.host)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, 期望 %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("代理目标 = %+v, 期望 %+v", got, tt.want)
+			}
+			if dials, err := m.GetProxyTarget(tt.host); err != nil || !reflect.DeepEqual(dials, tt.want.Dials) {
+				t.Errorf("GetProxyTarget = %v, %v", dials, err)
+			}
+		})
+	}
+}
+
+func TestResolveHostWildcardChain(t *testing.T) {
+	m, _ := newTestManager(t, emptyServer)
+	if err := m.AddWildcardRoute("example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.AddSubReverseProxy("example.com", "api", []string{"9000"}, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	resolution, err := m.ResolveHost("api.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, route := range resolution.Routes {
+		id, _ := route["@id"].(string)
+		ids = append(ids, id)
+	}
+	if want := []string{"wildcard-example.com", "api.example.com"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("路由链 = %v, 期望 %v", ids, want)
+	}
+	if resolution.Server != DefaultServerName || resolution.Handler["handler"] != "reverse_proxy" {
+		t.Errorf("服务器 = %s, 处理器 = %v", resolution.Server, resolution.Handler["handler"])
+	}
+}
@@ -0,0 +1,107 @@
+package routes
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// DeadlineHeaderVar 记录 SetProxyTimeouts 写入的截止时间请求头名称的元数据键
+const DeadlineHeaderVar = MetadataVarPrefix + "deadline_header"
+
+// ProxyTimeouts 反向代理的超时设置 - 对应 reverse_proxy http 传输层的超时字段
+// 值为 0 的超时会从配置中移除，恢复 Caddy 的默认值
+type ProxyTimeouts struct {
+	Dial           time.Duration // 连接上游的超时 (dial_timeout)
+	ResponseHeader time.Duration // 等待上游响应头的超时 (response_header_timeout)
+	Read           time.Duration // 读取上游响应的超时 (read_timeout)
+	Write          time.Duration // 向上游写请求的超时 (write_timeout)
+
+	// PropagateDeadlineHeader 设置后，通过 header_up 将 ResponseHeader 超时（毫秒）写入该请求头，
+	// 使上游在边缘已放弃等待时停止处理；ResponseHeader 为 0 时移除该请求头
+	PropagateDeadlineHeader string
+}
+
+// SetProxyTimeouts 设置路由中反向代理处理器的超时
+// 之前由本方法写入的截止时间请求头 (名称记录在 DeadlineHeaderVar 元数据中) 会随超时一起更新或移除，
+// 保证两处配置一致；其他请求头不受影响
+func (m *Manager) SetProxyTimeouts(routeID string, timeouts ProxyTimeouts) error {
+	defer m.client.LockWrites()()
+
+	handlers, err := m.routeHandlers(routeID)
+	if err != nil {
+		return err
+	}
+
+	index := -1
+	var handler map[string]interface{}
+	for i, raw := range handlers {
+		if h, ok := raw.(map[string]interface{}); ok && h["handler"] == "reverse_proxy" {
+			index, handler = i, h
+			break
+		}
+	}
+	if handler == nil {
+		return fmt.Errorf("路由 %s 没有反向代理处理器", routeID)
+	}
+
+	transport, _ := handler["transport"].(map[string]interface{})
+	if transport == nil {
+		transport = map[string]interface{}{"protocol": "http"}
+	}
+	setDuration(transport, "dial_timeout", timeouts.Dial)
+	setDuration(transport, "response_header_timeout", timeouts.ResponseHeader)
+	setDuration(transport, "read_timeout", timeouts.Read)
+	setDuration(transport, "write_timeout", timeouts.Write)
+	handler["transport"] = transport
+
+	// 删除上一次写入的截止时间请求头，再按新设置写入
+	headers, _ := handler["headers"].(map[string]interface{})
+	request, _ := headers["request"].(map[string]interface{})
+	set, _ := request["set"].(map[string]interface{})
+	if previous := metadataValue(handlers, DeadlineHeaderVar); previous != "" {
+		delete(set, previous)
+	}
+	owned := ""
+	if timeouts.PropagateDeadlineHeader != "" && timeouts.ResponseHeader > 0 {
+		if set == nil {
+			set = map[string]interface{}{}
+		}
+		owned = http.CanonicalHeaderKey(timeouts.PropagateDeadlineHeader)
+		delete(set, timeouts.PropagateDeadlineHeader)
+		set[owned] = []interface{}{strconv.FormatInt(timeouts.ResponseHeader.Milliseconds(), 10)}
+	}
+	switch {
+	case len(set) > 0:
+		if request == nil {
+			request = map[string]interface{}{}
+		}
+		request["set"] = set
+		if headers == nil {
+			headers = map[string]interface{}{}
+		}
+		headers["request"] = request
+		handler["headers"] = headers
+	case request != nil:
+		delete(request, "set")
+		if len(request) == 0 {
+			delete(headers, "request")
+		}
+		if len(headers) == 0 {
+			delete(handler, "headers")
+		}
+	}
+
+	handlers[index] = handler
+	return m.saveHandlers(routeID, setHandlersMetadata(handlers, DeadlineHeaderVar, owned))
+}
+
+// setDuration 设置或移除传输层的超时字段 - 内部辅助函数
+func setDuration(transport map[string]interface{}, key string, d time.Duration) {
+	if d <= 0 {
+		delete(transport, key)
+		return
+	}
+	transport[key] = d.String()
+}
@@ -0,0 +1,90 @@
+package routes
+
+import (
+	"time"
+
+	"github.com/youfun/gofastcaddy/pkg/types"
+)
+
+// UpdatedAtVar 最后修改时间写入的 vars 键名 - 值为 RFC3339 格式的 UTC 时间
+const UpdatedAtVar = MetadataVarPrefix + "updated_at"
+
+// GetRouteUpdatedAt 读取路由的最后修改时间 - 未记录时返回零值
+// 需要客户端开启 UpdateTimestamps，见 api.WithUpdateTimestamps
+func (m *Manager) GetRouteUpdatedAt(id string) (time.Time, error) {
+	handlers, err := m.routeHandlers(id)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return updatedAtValue(handlers), nil
+}
+
+// stampUpdated 在客户端开启 UpdateTimestamps 时为新建或整体替换的路由写入最后修改时间 - 内部辅助函数
+// 写入位置同 stampProvenance
+func (m *Manager) stampUpdated(route *types.Route) {
+	if !m.client.UpdateTimestamps {
+		return
+	}
+	setRouteMetadata(route, UpdatedAtVar, updatedAtNow())
+}
+
+// touchHandlers 在客户端开启 UpdateTimestamps 时更新处理器链中的最后修改时间 - 内部辅助函数
+// 已有元数据 vars 处理器时写入其中，否则追加到处理器链末尾
+func (m *Manager) touchHandlers(handlers []interface{}) []interface{} {
+	if !m.client.UpdateTimestamps {
+		return handlers
+	}
+	if index := findMetadataHandler(handlers); index >= 0 {
+		handlers[index].(map[string]interface{})[UpdatedAtVar] = updatedAtNow()
+		return handlers
+	}
+	return append(handlers, map[string]interface{}{"handler": "vars", UpdatedAtVar: updatedAtNow()})
+}
+
+// saveHandlers 写入路由的处理器链，并在同一请求中更新最后修改时间 - 内部辅助函数
+// 开启 NormalizeHandlerOrder 时先按规范顺序重排
+func (m *Manager) saveHandlers(routeID string, handlers []interface{}) error {
+	return m.client.PatchByID(m.touchHandlers(m.orderForSave(routeID, handlers)), routeID+"/handle")
+}
+
+// updatedAtValue 读取元数据 vars 处理器中的最后修改时间，未设置或格式无效时返回零值 - 内部辅助函数
+func updatedAtValue(handlers []interface{}) time.Time {
+	value := metadataValue(handlers, UpdatedAtVar)
+	if value == "" {
+		return time.Time{}
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// updatedAtNow 返回当前时间的 RFC3339 UTC 表示 - 内部辅助函数
+func updatedAtNow() string {
+	return time.Now().UTC().Format(time.RFC3339)
+}
+
+// saveRouteHosts 写入路由第一个匹配器中的主机名 - 内部辅助函数
+// base 为路由的路径，由 patch 决定按 @id 还是按配置路径写入；开启 UpdateTimestamps 时
+// 整体写回路由，使主机名和最后修改时间在同一请求中生效
+func (m *Manager) saveRouteHosts(route map[string]interface{}, hosts []string, patch func(data interface{}, path string) error, base string) error {
+	if !m.client.UpdateTimestamps {
+		return patch(hosts, base+"/match/0/host")
+	}
+	updated := make(map[string]interface{}, len(route))
+	for key, value := range route {
+		updated[key] = value
+	}
+	matchers := append([]interface{}(nil), route["match"].([]interface{})...)
+	matcher := make(map[string]interface{})
+	for key, value := range matchers[0].(map[string]interface{}) {
+		matcher[key] = value
+	}
+	matcher["host"] = hosts
+	matchers[0] = matcher
+	updated["match"] = matchers
+	handlers, _ := route["handle"].([]interface{})
+	updated["handle"] = m.touchHandlers(append([]interface{}(nil), handlers...))
+	return patch(updated, base)
+}
@@ -0,0 +1,126 @@
+package routes
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/youfun/gofastcaddy/internal/api"
+	"github.com/youfun/gofastcaddy/pkg/clienttest"
+)
+
+func TestPartialUpdatesTouchTimestamp(t *testing.T) {
+	const config = `{"apps":{"http":{"servers":{"srv0":{"listen":[":443"],"routes":[` +
+		`{"@id":"app","match":[{"host":["a.example.com","b.example.com"]}],"handle":[{"handler":"reverse_proxy","upstreams":[{"dial":"old:80"}]}]},` +
+		`{"match":[{"host":["c.example.com","d.example.com"]}],"handle":[{"handler":"static_response"}]}` +
+		`]}}}}}`
+
+	tests := []struct {
+		name    string
+		routeID string // 为空表示检查第二个 (没有 @id 的) 路由
+		update  func(m *Manager) error
+		check   func(t *testing.T, route map[string]interface{})
+	}{
+		{
+			name:    "SetRouteHosts",
+			routeID: "app",
+			update: func(m *Manager) error {
+				_, err := m.SetRouteHosts("app", []string{"a.example.com"})
+				return err
+			},
+			check: func(t *testing.T, route map[string]interface{}) {
+				if hosts, _ := routeHosts(route); !SameHosts(hosts, []string{"a.example.com"}) {
+					t.Errorf("主机名 = %v", hosts)
+				}
+			},
+		},
+		{
+			name:    "RemoveHost 按 ID",
+			routeID: "app",
+			update:  func(m *Manager) error { return m.RemoveHost("b.example.com", false) },
+			check: func(t *testing.T, route map[string]interface{}) {
+				if hosts, _ := routeHosts(route); !SameHosts(hosts, []string{"a.example.com"}) {
+					t.Errorf("主机名 = %v", hosts)
+				}
+			},
+		},
+		{
+			name:   "RemoveHost 按路径",
+			update: func(m *Manager) error { return m.RemoveHost("d.example.com", false) },
+			check: func(t *testing.T, route map[string]interface{}) {
+				if hosts, _ := routeHosts(route); !SameHosts(hosts, []string{"c.example.com"}) {
+					t.Errorf("主机名 = %v", hosts)
+				}
+			},
+		},
+		{
+			name:    "AddBasicAuthUser 插入",
+			routeID: "app",
+			update:  func(m *Manager) error { return m.AddBasicAuthUser("app", "alice", "secret") },
+			check: func(t *testing.T, route map[string]interface{}) {
+				if names := handlerNames(route); names[0] != BasicAuthHandler {
+					t.Errorf("处理器链 = %v", names)
+				}
+			},
+		},
+		{
+			name:    "AddBasicAuthUser 替换",
+			routeID: "app",
+			update: func(m *Manager) error {
+				if err := m.AddBasicAuthUser("app", "alice", "secret"); err != nil {
+					return err
+				}
+				return m.AddBasicAuthUser("app", "bob", "secret")
+			},
+			check: func(t *testing.T, route map[string]interface{}) {
+				handler := route["handle"].([]interface{})[0].(map[string]interface{})
+				if accounts := basicAuthAccounts(handler); len(accounts) != 2 {
+					t.Errorf("账户 = %v", accounts)
+				}
+			},
+		},
+		{
+			name:    "RolloutUpstream",
+			routeID: "app",
+			update: func(m *Manager) error {
+				return m.RolloutUpstream(context.Background(), "app", "new:80", "old:80", RolloutOptions{
+					HealthyFor:   time.Millisecond,
+					PollInterval: time.Millisecond,
+				})
+			},
+			check: func(t *testing.T, route map[string]interface{}) {
+				proxy := route["handle"].([]interface{})[0].(map[string]interface{})
+				upstreams := proxy["upstreams"].([]interface{})
+				if len(upstreams) != 1 || !hasDial(upstreams, "new:80") {
+					t.Errorf("上游 = %v", upstreams)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := clienttest.NewFakeCaddy(config)
+			defer fake.Close()
+			fake.Handle("/reverse_proxy/upstreams", func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(`[{"address":"old:80","fails":0},{"address":"new:80","fails":0}]`))
+			})
+			m := NewManagerWithClient(api.NewClientWithURL(fake.URL, api.WithUpdateTimestamps(true)))
+
+			if err := tt.update(m); err != nil {
+				t.Fatal(err)
+			}
+			var route map[string]interface{}
+			if tt.routeID != "" {
+				route = getRoute(t, m, tt.routeID)
+			} else {
+				route = serverRoutes(t, fake)[1]
+			}
+			tt.check(t, route)
+			handlers, _ := route["handle"].([]interface{})
+			if updatedAtValue(handlers).IsZero() {
+				t.Errorf("未更新最后修改时间: %s", encodeJSON(route))
+			}
+		})
+	}
+}
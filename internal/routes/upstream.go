@@ -0,0 +1,159 @@
+package routes
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultUpstreamCheckTimeout 上游连通性检查的默认拨号超时
+const DefaultUpstreamCheckTimeout = 3 * time.Second
+
+// UpstreamCheckMode 添加路由前的上游连通性检查方式
+type UpstreamCheckMode int
+
+// 上游连通性检查方式
+const (
+	UpstreamCheckOff  UpstreamCheckMode = iota // 不检查（默认）
+	UpstreamCheckWarn                          // 不可达时发出警告，仍然添加路由
+	UpstreamCheckFail                          // 不可达时返回错误，不添加路由
+)
+
+// ProxyOptions 反向代理添加选项
+type ProxyOptions struct {
+	CheckUpstreams UpstreamCheckMode            // 上游连通性检查方式
+	CheckTimeout   time.Duration                // 单个上游的拨号超时 (0 表示使用默认值)
+	Warn           func(dial string, err error) // UpstreamCheckWarn 模式下的警告回调 (nil 时使用 Manager.Warn)
+}
+
+// CheckUpstreams 并发 TCP 拨号每个上游地址并报告结果
+// 返回值以上游地址为键，可达时对应的值为 nil。拨号直接发往上游而不经过 Caddy 数据面，
+// 检查经 Caddy 代理后的可用性见 FastCaddy.VerifyProxy
+func (m *Manager) CheckUpstreams(dials []string, timeout time.Duration) map[string]error {
+	if timeout <= 0 {
+		timeout = DefaultUpstreamCheckTimeout
+	}
+
+	results := make(map[string]error, len(dials))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, dial := range dials {
+		wg.Add(1)
+		go func(dial string) {
+			defer wg.Done()
+			err := dialUpstream(dial, timeout)
+			mu.Lock()
+			results[dial] = err
+			mu.Unlock()
+		}(dial)
+	}
+	wg.Wait()
+	return results
+}
+
+// AddReverseProxyWithOptions 按选项添加反向代理路由 - 语义同 AddReverseProxy
+func (m *Manager) AddReverseProxyWithOptions(fromHost, toURL string, opts ProxyOptions) error {
+	if err := m.checkUpstreams([]string{toURL}, opts); err != nil {
+		return err
+	}
+	return m.AddReverseProxy(fromHost, toURL)
+}
+
+// AddSubReverseProxyWithOptions 按选项添加子域名反向代理 - 语义同 AddSubReverseProxy
+func (m *Manager) AddSubReverseProxyWithOptions(domain, subdomain string, ports []string, host string, opts ProxyOptions) error {
+	if host == "" {
+		host = "localhost"
+	}
+	var dials []string
+	for _, port := range ports {
+		dials = append(dials, fmt.Sprintf("%s:%s", host, port))
+	}
+	if err := m.checkUpstreams(dials, opts); err != nil {
+		return err
+	}
+	return m.AddSubReverseProxy(domain, subdomain, ports, host)
+}
+
+// checkUpstreams 按选项检查上游并处理不可达的结果 - 内部辅助函数
+func (m *Manager) checkUpstreams(dials []string, opts ProxyOptions) error {
+	if opts.CheckUpstreams == UpstreamCheckOff {
+		return nil
+	}
+
+	results := m.CheckUpstreams(dials, opts.CheckTimeout)
+	var failed []string
+	for _, dial := range dials {
+		err := results[dial]
+		if err == nil {
+			continue
+		}
+		if opts.CheckUpstreams == UpstreamCheckWarn {
+			if opts.Warn != nil {
+				opts.Warn(dial, err)
+			} else {
+				m.warn(fmt.Sprintf("上游 %s 不可达: %v", dial, err))
+			}
+			continue
+		}
+		failed = append(failed, fmt.Sprintf("%s (%v)", dial, err))
+	}
+
+	if len(failed) > 0 {
+		sort.Strings(failed)
+		return fmt.Errorf("上游不可达: %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// dialUpstream TCP 拨号单个上游地址 - 内部辅助函数
+// 接受 host:port 或带 scheme 的 URL 形式，见 upstreamAddress
+func dialUpstream(dial string, timeout time.Duration) error {
+	addr, err := upstreamAddress(dial)
+	if err != nil {
+		return err
+	}
+	conn, err := net.DialTimeout("tcp", addr, timeout)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// upstreamAddress 将上游地址转换为可拨号的 host:port - 内部辅助函数
+// URL 形式省略端口时按 scheme 使用 80 (http) 或 443 (https)；
+// 反向代理只转发到上游的根路径，因此带路径、查询参数的 URL 视为错误
+func upstreamAddress(dial string) (string, error) {
+	if !strings.Contains(dial, "://") {
+		if _, _, err := net.SplitHostPort(dial); err != nil {
+			return "", fmt.Errorf("上游地址 %s 无效: %w", dial, err)
+		}
+		return dial, nil
+	}
+
+	u, err := url.Parse(dial)
+	if err != nil {
+		return "", fmt.Errorf("上游地址 %s 无效: %w", dial, err)
+	}
+	if u.Hostname() == "" {
+		return "", fmt.Errorf("上游地址 %s 缺少主机名", dial)
+	}
+	if (u.Path != "" && u.Path != "/") || u.RawQuery != "" || u.Fragment != "" {
+		return "", fmt.Errorf("上游地址 %s 不能包含路径或查询参数", dial)
+	}
+	port := u.Port()
+	if port == "" {
+		switch strings.ToLower(u.Scheme) {
+		case "http":
+			port = "80"
+		case "https":
+			port = "443"
+		default:
+			return "", fmt.Errorf("上游地址 %s 缺少端口", dial)
+		}
+	}
+	return net.JoinHostPort(u.Hostname(), port), nil
+}
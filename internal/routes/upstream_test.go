@@ -0,0 +1,109 @@
+package routes
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUpstreamCheckWarnings(t *testing.T) {
+	tests := []struct {
+		name        string
+		optionWarn  bool // 是否设置 ProxyOptions.Warn
+		managerWarn bool // 是否设置 Manager.Warn
+		wantOption  int
+		wantManager int
+	}{
+		{name: "选项回调优先", optionWarn: true, managerWarn: true, wantOption: 1},
+		{name: "退回到 Manager.Warn", managerWarn: true, wantManager: 1},
+		{name: "未设置回调时忽略"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, _ := newTestManager(t, emptyServer)
+			var optionCalls, managerCalls []string
+			opts := ProxyOptions{CheckUpstreams: UpstreamCheckWarn, CheckTimeout: 100 * time.Millisecond}
+			if tt.optionWarn {
+				opts.Warn = func(dial string, err error) { optionCalls = append(optionCalls, dial) }
+			}
+			if tt.managerWarn {
+				m.Warn = func(msg string) { managerCalls = append(managerCalls, msg) }
+			}
+
+			// 端口 1 通常没有服务监听，拨号会被拒绝
+			if err := m.AddReverseProxyWithOptions("app.example.com", "127.0.0.1:1", opts); err != nil {
+				t.Fatal(err)
+			}
+			if len(optionCalls) != tt.wantOption || len(managerCalls) != tt.wantManager {
+				t.Errorf("选项回调 %v, Manager.Warn %v", optionCalls, managerCalls)
+			}
+			for _, msg := range managerCalls {
+				if !strings.Contains(msg, "127.0.0.1:1") {
+					t.Errorf("警告 = %q", msg)
+				}
+			}
+		})
+	}
+}
+
+func TestUpstreamAddress(t *testing.T) {
+	tests := []struct {
+		dial    string
+		want    string
+		wantErr bool
+	}{
+		{dial: "app:8080", want: "app:8080"},
+		{dial: "[::1]:8080", want: "[::1]:8080"},
+		{dial: "http://app", want: "app:80"},
+		{dial: "https://app/", want: "app:443"},
+		{dial: "HTTPS://app:8443", want: "app:8443"},
+		{dial: "http://[::1]", want: "[::1]:80"},
+		{dial: "app", wantErr: true},
+		{dial: "http://app/api", wantErr: true},
+		{dial: "http://app/?debug=1", wantErr: true},
+		{dial: "h2c://app", wantErr: true},
+		{dial: "http://", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.dial, func(t *testing.T) {
+			got, err := upstreamAddress(tt.dial)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, 期望错误 %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("upstreamAddress(%q) = %q, 期望 %q", tt.dial, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckUpstreamsURLForms(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	addr := ln.Addr().String()
+
+	tests := []struct {
+		dial      string
+		reachable bool
+	}{
+		{dial: addr, reachable: true},
+		{dial: "http://" + addr, reachable: true},
+		{dial: "http://" + addr + "/", reachable: true},
+		{dial: "http://" + addr + "/api", reachable: false},
+	}
+	m, _ := newTestManager(t, emptyServer)
+	var dials []string
+	for _, tt := range tests {
+		dials = append(dials, tt.dial)
+	}
+	results := m.CheckUpstreams(dials, time.Second)
+	for _, tt := range tests {
+		if err := results[tt.dial]; (err == nil) != tt.reachable {
+			t.Errorf("%s: err = %v, 期望可达 %v", tt.dial, err, tt.reachable)
+		}
+	}
+}
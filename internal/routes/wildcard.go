@@ -0,0 +1,208 @@
+package routes
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/youfun/gofastcaddy/internal/api"
+	"github.com/youfun/gofastcaddy/pkg/types"
+)
+
+// WildcardRoutePrefix 通配符路由 ID 前缀 - 与 AddWildcardRoute 的约定一致
+const WildcardRoutePrefix = "wildcard-"
+
+// RemoveSubOptions 子域名反向代理删除选项
+type RemoveSubOptions struct {
+	PruneEmptyWildcard bool // 删除最后一个子路由后同时删除空的通配符路由
+}
+
+// PruneOptions 空通配符路由清理选项
+type PruneOptions struct {
+	DryRun bool // 只返回会被删除的路由 ID，不修改配置
+}
+
+// RemoveSubReverseProxy 删除通配符域名下的子域名反向代理
+// 删除子路由、检查并删除空的通配符路由的整个过程持有写锁，不会删除并发添加了子路由的通配符路由；
+// 客户端设置了 DeleteGrace 时删除的路由与 DeleteByID 一样放入回收站
+func (m *Manager) RemoveSubReverseProxy(domain, subdomain string, opts RemoveSubOptions) error {
+	defer m.client.LockWrites()()
+
+	routeID := HostRouteID(fmt.Sprintf("%s.%s", subdomain, domain))
+	if err := m.deleteRoute(routeID); err != nil {
+		return fmt.Errorf("删除子路由 %s 失败: %w", routeID, err)
+	}
+	if !opts.PruneEmptyWildcard {
+		return nil
+	}
+
+	wildcardID := WildcardRouteID(domain)
+	route, err := m.client.GetByID(wildcardID)
+	if err != nil {
+		return err
+	}
+	if isEmptyWildcard(route) {
+		return m.deleteRoute(wildcardID)
+	}
+	return nil
+}
+
+// PruneEmptyWildcards 删除子路由已全部移除的通配符路由，返回被删除的路由 ID
+func (m *Manager) PruneEmptyWildcards() ([]string, error) {
+	return m.PruneEmptyWildcardsWithOptions(PruneOptions{})
+}
+
+// PruneEmptyWildcardsWithOptions 按选项删除空的通配符路由
+// 只处理 ID 以 wildcard- 开头、且处理器链恰好是一个空 subroute 的路由；
+// 附带其他处理器的通配符路由永远不会被删除。检查和删除期间持有写锁
+func (m *Manager) PruneEmptyWildcardsWithOptions(opts PruneOptions) ([]string, error) {
+	defer m.client.LockWrites()()
+
+	server, err := m.client.GetConfig(strings.TrimSuffix(RoutesPath, "/routes"))
+	if err != nil {
+		return nil, err
+	}
+
+	var pruned []string
+	routes, _ := server["routes"].([]interface{})
+	for _, raw := range routes {
+		route, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		id, _ := route["@id"].(string)
+		if strings.HasPrefix(id, WildcardRoutePrefix) && isEmptyWildcard(route) {
+			pruned = append(pruned, id)
+		}
+	}
+	sort.Strings(pruned)
+
+	if opts.DryRun {
+		return pruned, nil
+	}
+	for i, id := range pruned {
+		if err := m.deleteRoute(id); err != nil {
+			return pruned[:i], fmt.Errorf("删除通配符路由 %s 失败: %w", id, err)
+		}
+	}
+	return pruned, nil
+}
+
+// NormalizeWildcardOrder 将通配符路由的子路由按 @id 稳定排序
+// 子路由都以互不相交的主机名匹配时顺序不影响匹配结果；存在不含主机匹配的子路由时拒绝排序
+func (m *Manager) NormalizeWildcardOrder(domain string) error {
+	defer m.client.LockWrites()()
+
+	wildcardID := WildcardRouteID(domain)
+	children, err := m.wildcardChildren(wildcardID)
+	if err != nil {
+		return err
+	}
+	return m.saveSortedChildren(wildcardID, children)
+}
+
+// addSortedSubroute 添加子路由并保持子路由按 @id 排序 - 内部辅助函数
+func (m *Manager) addSortedSubroute(wildcardID string, routes ...types.Route) error {
+	defer m.client.LockWrites()()
+
+	children, err := m.wildcardChildren(wildcardID)
+	if err != nil {
+		return err
+	}
+	for _, route := range routes {
+		data, err := json.Marshal(route)
+		if err != nil {
+			return fmt.Errorf("序列化子路由失败: %w", err)
+		}
+		var child map[string]interface{}
+		if err := json.Unmarshal(data, &child); err != nil {
+			return fmt.Errorf("序列化子路由失败: %w", err)
+		}
+		children = append(children, child)
+	}
+	return m.saveSortedChildren(wildcardID, children)
+}
+
+// wildcardChildren 读取通配符路由 subroute 中的子路由 - 内部辅助函数
+func (m *Manager) wildcardChildren(wildcardID string) ([]interface{}, error) {
+	route, err := m.client.GetByID(wildcardID)
+	if err != nil {
+		return nil, err
+	}
+	handlers, _ := route["handle"].([]interface{})
+	if len(handlers) == 0 {
+		return nil, fmt.Errorf("通配符路由 %s 没有处理器", wildcardID)
+	}
+	handler, _ := handlers[0].(map[string]interface{})
+	if handler["handler"] != "subroute" {
+		return nil, fmt.Errorf("通配符路由 %s 的第一个处理器不是 subroute", wildcardID)
+	}
+	children, _ := handler["routes"].([]interface{})
+	return children, nil
+}
+
+// saveSortedChildren 校验并写入排序后的子路由 - 内部辅助函数
+// 新建的通配符路由的 subroute 还没有 routes 键，PATCH 返回 404 时改用 POST 创建
+func (m *Manager) saveSortedChildren(wildcardID string, children []interface{}) error {
+	if len(children) == 0 {
+		return nil
+	}
+	for _, raw := range children {
+		child, _ := raw.(map[string]interface{})
+		if !hostOnlyMatch(child) {
+			id, _ := child["@id"].(string)
+			return fmt.Errorf("子路由 %q 不是纯主机名匹配, 排序可能改变匹配结果", id)
+		}
+	}
+
+	sort.SliceStable(children, func(i, j int) bool {
+		a, _ := children[i].(map[string]interface{})["@id"].(string)
+		b, _ := children[j].(map[string]interface{})["@id"].(string)
+		return a < b
+	})
+	path := wildcardID + "/handle/0/routes"
+	err := m.client.PatchByID(children, path)
+	if api.IsNotFound(err) {
+		return m.client.PostByID(children, path)
+	}
+	return err
+}
+
+// hostOnlyMatch 判断子路由的每个匹配器集合是否都只含主机名匹配 - 内部辅助函数
+func hostOnlyMatch(route map[string]interface{}) bool {
+	matchers, _ := route["match"].([]interface{})
+	if len(matchers) == 0 {
+		return false
+	}
+	for _, raw := range matchers {
+		matcher, _ := raw.(map[string]interface{})
+		hosts, _ := matcher["host"].([]interface{})
+		if len(matcher) != 1 || len(hosts) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// isEmptyWildcard 判断通配符路由是否只有一个空的 subroute 处理器 - 内部辅助函数
+// 保存元数据的 vars 处理器 (备注、来源信息) 不计入
+func isEmptyWildcard(route map[string]interface{}) bool {
+	raw, _ := route["handle"].([]interface{})
+	var handlers []interface{}
+	for _, h := range raw {
+		if handler, ok := h.(map[string]interface{}); ok && isMetadataHandler(handler) {
+			continue
+		}
+		handlers = append(handlers, h)
+	}
+	if len(handlers) != 1 {
+		return false
+	}
+	handler, _ := handlers[0].(map[string]interface{})
+	if handler["handler"] != "subroute" {
+		return false
+	}
+	children, _ := handler["routes"].([]interface{})
+	return len(children) == 0
+}
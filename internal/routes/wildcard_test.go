@@ -0,0 +1,289 @@
+package routes
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/youfun/gofastcaddy/internal/api"
+	"github.com/youfun/gofastcaddy/pkg/clienttest"
+)
+
+// TestNormalizeWildcardOrderConcurrent 排序与并发添加子路由串行执行，不会丢失子路由 (配合 -race 运行)
+func TestNormalizeWildcardOrderConcurrent(t *testing.T) {
+	fake := clienttest.NewFakeCaddy(emptyServer)
+	defer fake.Close()
+	// 读请求返回后稍作延迟，放大读取与写回之间的竞争窗口
+	slowReads := func(next api.RoundTripFunc) api.RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			resp, err := next(req)
+			if req.Method == http.MethodGet {
+				time.Sleep(2 * time.Millisecond)
+			}
+			return resp, err
+		}
+	}
+	m := NewManagerWithClient(api.NewClientWithURL(fake.URL, api.WithMiddleware(slowReads)))
+	m.SortWildcardChildren = true
+	if err := m.AddWildcardRoute("example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.AddSubReverseProxy("example.com", "base", []string{"8080"}, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	const n = 8
+	var wg sync.WaitGroup
+	errs := make(chan error, 2*n)
+	for i := 0; i < n; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			errs <- m.AddSubReverseProxy("example.com", fmt.Sprintf("app%d", i), []string{"8080"}, "")
+		}(i)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 4; j++ {
+				if err := m.NormalizeWildcardOrder("example.com"); err != nil {
+					errs <- err
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	children, err := m.wildcardChildren(WildcardRouteID("example.com"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(children) != n+1 {
+		t.Errorf("子路由数 = %d, 期望 %d", len(children), n+1)
+	}
+}
+
+// TestRemoveSubReverseProxyPruneConcurrent 清理空通配符路由不会删除检查之后并发添加了子路由的通配符路由
+func TestRemoveSubReverseProxyPruneConcurrent(t *testing.T) {
+	fake := clienttest.NewFakeCaddy(emptyServer)
+	defer fake.Close()
+
+	var (
+		m      *Manager
+		armed  bool // 准备工作完成后才开始并发添加
+		once   sync.Once
+		wg     sync.WaitGroup
+		addErr error
+	)
+	// 删除子路由后读取通配符路由时，另一个调用方开始添加子路由
+	addAfterCheck := func(next api.RoundTripFunc) api.RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			resp, err := next(req)
+			if armed && req.Method == http.MethodGet && req.URL.Path == "/id/wildcard-example.com/" {
+				once.Do(func() {
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						addErr = m.AddSubReverseProxy("example.com", "b", []string{"8080"}, "")
+					}()
+					time.Sleep(20 * time.Millisecond)
+				})
+			}
+			return resp, err
+		}
+	}
+	m = NewManagerWithClient(api.NewClientWithURL(fake.URL, api.WithMiddleware(addAfterCheck)))
+	if err := m.AddWildcardRoute("example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.AddSubReverseProxy("example.com", "a", []string{"8080"}, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	armed = true
+	if err := m.RemoveSubReverseProxy("example.com", "a", RemoveSubOptions{PruneEmptyWildcard: true}); err != nil {
+		t.Fatal(err)
+	}
+	wg.Wait()
+	// 添加在清理之后执行，通配符路由已不存在，添加失败；不能出现添加成功但子路由随通配符路由一起被删除
+	exists, err := m.client.IDExists("b.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if (addErr == nil) != exists {
+		t.Errorf("添加结果 %v, 子路由存在 %v", addErr, exists)
+	}
+}
+
+func TestPruneEmptyWildcards(t *testing.T) {
+	const subroute = `{"handler":"subroute","routes":[]}`
+	tests := []struct {
+		name   string
+		route  string // 服务器中唯一的路由
+		pruned bool
+	}{
+		{name: "空的通配符路由", route: `{"@id":"wildcard-example.com","handle":[` + subroute + `]}`, pruned: true},
+		{name: "附带元数据的空通配符路由", route: `{"@id":"wildcard-example.com","handle":[{"handler":"vars","fastcaddy_note":"x"},` + subroute + `]}`, pruned: true},
+		{name: "还有子路由", route: `{"@id":"wildcard-example.com","handle":[{"handler":"subroute","routes":[{"@id":"a.example.com"}]}]}`},
+		{name: "附带其他处理器", route: `{"@id":"wildcard-example.com","handle":[{"handler":"headers"},` + subroute + `]}`},
+		{name: "附带非元数据的 vars 处理器", route: `{"@id":"wildcard-example.com","handle":[{"handler":"vars","route_name":"x"},` + subroute + `]}`},
+		{name: "ID 不是通配符前缀", route: `{"@id":"example.com","handle":[` + subroute + `]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, fake := newTestManager(t, `{"apps":{"http":{"servers":{"srv0":{"routes":[`+tt.route+`]}}}}}`)
+
+			// 试运行只返回结果，不修改配置
+			dryRun, err := m.PruneEmptyWildcardsWithOptions(PruneOptions{DryRun: true})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, req := range fake.Requests() {
+				if !strings.HasPrefix(req, "GET ") {
+					t.Errorf("试运行不应修改配置: %s", req)
+				}
+			}
+
+			pruned, err := m.PruneEmptyWildcards()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := len(pruned) == 1; got != tt.pruned || len(dryRun) != len(pruned) {
+				t.Errorf("试运行 = %v, 删除 = %v, 期望删除 %v", dryRun, pruned, tt.pruned)
+			}
+			if remaining := len(serverRoutes(t, fake)); remaining != 1-len(pruned) {
+				t.Errorf("剩余路由数 = %d", remaining)
+			}
+		})
+	}
+}
+
+func TestRemoveSubReverseProxyUndo(t *testing.T) {
+	m, _ := newGraceManager(t, emptyServer, time.Minute)
+	if err := m.AddWildcardRoute("example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.AddSubReverseProxy("example.com", "a", []string{"8080"}, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.RemoveSubReverseProxy("example.com", "a", RemoveSubOptions{PruneEmptyWildcard: true}); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(m.DeletedRoutes()); got != 2 {
+		t.Fatalf("回收站条目数 = %d, 期望 2", got)
+	}
+
+	// 先恢复通配符路由，再恢复其中的子路由
+	for _, id := range []string{"wildcard-example.com", "a.example.com"} {
+		if err := m.UndoDelete(id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if exists, err := m.client.IDExists("a.example.com"); err != nil || !exists {
+		t.Errorf("子路由未恢复: %v", err)
+	}
+}
+
+func TestSortWildcardChildrenPermutations(t *testing.T) {
+	provision := func(t *testing.T, sorted bool, add func(m *Manager) error) string {
+		m, fake := newTestManager(t, emptyServer)
+		m.SortWildcardChildren = sorted
+		if err := m.AddWildcardRoute("example.com"); err != nil {
+			t.Fatal(err)
+		}
+		if err := add(m); err != nil {
+			t.Fatal(err)
+		}
+		return fake.ConfigJSON()
+	}
+	addInOrder := func(order ...string) func(m *Manager) error {
+		return func(m *Manager) error {
+			for _, sub := range order {
+				if err := m.AddSubReverseProxy("example.com", sub, []string{"8080"}, ""); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+
+	want := provision(t, true, addInOrder("a", "b", "c"))
+	for _, order := range [][]string{{"c", "a", "b"}, {"b", "c", "a"}} {
+		if got := provision(t, true, addInOrder(order...)); got != want {
+			t.Errorf("按 %v 顺序添加后配置不同:\n%s\n%s", order, got, want)
+		}
+	}
+	batch := provision(t, true, func(m *Manager) error {
+		if err := m.AddSubReverseProxy("example.com", "b", []string{"8080"}, ""); err != nil {
+			return err
+		}
+		return m.BatchAddSubReverseProxy("example.com", []SubProxySpec{
+			{Subdomain: "c", Ports: []string{"8080"}}, {Subdomain: "a", Ports: []string{"8080"}},
+		})
+	})
+	if batch != want {
+		t.Errorf("批量添加后配置不同:\n%s\n%s", batch, want)
+	}
+	// 未开启排序时按调用顺序追加
+	if got := provision(t, false, addInOrder("c", "a", "b")); got == want {
+		t.Error("未开启排序时子路由不应被排序")
+	}
+}
+
+func TestNormalizeWildcardOrder(t *testing.T) {
+	child := func(id, match string) string {
+		return `{"@id":"` + id + `","match":[` + match + `],"handle":[{"handler":"reverse_proxy","upstreams":[{"dial":"localhost:8080"}]}]}`
+	}
+	wildcard := func(children ...string) string {
+		return `{"apps":{"http":{"servers":{"srv0":{"routes":[{"@id":"wildcard-example.com","handle":[{"handler":"subroute","routes":[` +
+			strings.Join(children, ",") + `]}]}]}}}}}`
+	}
+	tests := []struct {
+		name    string
+		initial string
+		want    string // 排序后的子路由 @id
+		wantErr bool
+	}{
+		{
+			name:    "按 @id 排序",
+			initial: wildcard(child("c.example.com", `{"host":["c.example.com"]}`), child("a.example.com", `{"host":["a.example.com"]}`), child("b.example.com", `{"host":["b.example.com"]}`)),
+			want:    "a.example.com,b.example.com,c.example.com",
+		},
+		{
+			name:    "存在路径匹配时拒绝排序",
+			initial: wildcard(child("c.example.com", `{"host":["c.example.com"]}`), child("a", `{"path":["/api/*"]}`)),
+			want:    "c.example.com,a",
+			wantErr: true,
+		},
+		{
+			name:    "主机名与路径组合匹配时拒绝排序",
+			initial: wildcard(child("c.example.com", `{"host":["c.example.com"]}`), child("a.example.com", `{"host":["a.example.com"],"path":["/api/*"]}`)),
+			want:    "c.example.com,a.example.com",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, _ := newTestManager(t, tt.initial)
+			err := m.NormalizeWildcardOrder("example.com")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, 期望错误 %v", err, tt.wantErr)
+			}
+			children, err := m.wildcardChildren("wildcard-example.com")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := routeIDs(children); got != tt.want {
+				t.Errorf("子路由 = %s, 期望 %s", got, tt.want)
+			}
+		})
+	}
+}
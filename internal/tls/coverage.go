@@ -0,0 +1,309 @@
+package tls
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/youfun/gofastcaddy/internal/utils"
+	"golang.org/x/net/publicsuffix"
+)
+
+// Cloudflare 区域查询相关常量
+const (
+	DefaultCloudflareAPI = "https://api.cloudflare.com/client/v4" // Cloudflare API 地址
+	cloudflareZonesPage  = 50                                     // 每页查询的区域数
+	cloudflareTimeout    = 15 * time.Second                       // 默认的请求超时
+)
+
+// ZoneLister 查询 API 令牌可访问的 DNS 区域 (如 example.com) - 可替换为测试实现
+type ZoneLister interface {
+	ListZones(ctx context.Context, token string) ([]string, error)
+}
+
+// CloudflareZoneLister 通过 Cloudflare API 的 /zones 接口查询令牌可访问的区域
+type CloudflareZoneLister struct {
+	BaseURL    string       // API 地址 (为空表示 DefaultCloudflareAPI)
+	HTTPClient *http.Client // HTTP 客户端 (nil 时使用 15 秒超时的默认客户端)
+}
+
+// ListZones 分页查询令牌可访问的全部区域名称
+func (l CloudflareZoneLister) ListZones(ctx context.Context, token string) ([]string, error) {
+	baseURL := l.BaseURL
+	if baseURL == "" {
+		baseURL = DefaultCloudflareAPI
+	}
+	client := l.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: cloudflareTimeout}
+	}
+
+	var zones []string
+	for page := 1; ; page++ {
+		query := url.Values{"page": {fmt.Sprint(page)}, "per_page": {fmt.Sprint(cloudflareZonesPage)}}
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(baseURL, "/")+"/zones?"+query.Encode(), nil)
+		if err != nil {
+			return nil, fmt.Errorf("创建 Cloudflare 请求失败: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("查询 Cloudflare 区域失败: %w", err)
+		}
+		var result struct {
+			Success bool `json:"success"`
+			Errors  []struct {
+				Message string `json:"message"`
+			} `json:"errors"`
+			Result []struct {
+				Name string `json:"name"`
+			} `json:"result"`
+			ResultInfo struct {
+				TotalPages int `json:"total_pages"`
+			} `json:"result_info"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("解析 Cloudflare 响应失败 (状态码 %d): %w", resp.StatusCode, err)
+		}
+		if !result.Success {
+			var messages []string
+			for _, e := range result.Errors {
+				messages = append(messages, e.Message)
+			}
+			return nil, fmt.Errorf("查询 Cloudflare 区域失败 (状态码 %d): %s", resp.StatusCode, strings.Join(messages, "; "))
+		}
+
+		for _, zone := range result.Result {
+			zones = append(zones, strings.ToLower(zone.Name))
+		}
+		if page >= result.ResultInfo.TotalPages {
+			return zones, nil
+		}
+	}
+}
+
+// AuditDNSCoverage 检查 Cloudflare 令牌是否覆盖通过 DNS 挑战申请证书的主机所在的区域
+// 只检查由 DNS 挑战策略 (颁发者配置了 challenges.dns) 负责的主机：DNS 挑战策略的主题，
+// 以及 http 应用 host 匹配器中按 Caddy 的策略选择规则落到 DNS 挑战策略上的主机。
+// 返回未被令牌覆盖的主机所在的注册域名 (按公共后缀列表得出，如 a.example.co.uk 对应 example.co.uk)，按字典序排列。
+// token 为空时从环境变量读取 (见 utils.GetCloudflareToken)
+func (m *Manager) AuditDNSCoverage(token string) ([]string, error) {
+	apps, err := m.client.GetConfig("/apps")
+	if err != nil {
+		return nil, err
+	}
+	return m.uncoveredHosts(token, dnsChallengeHosts(apps))
+}
+
+// warnDNSCoverage 在开启 CheckDNSCoverage 时检查指定主机名的区域覆盖情况并发出警告 - 内部辅助函数
+// 未指定主机名时检查全部受管主机 (同 AuditDNSCoverage)；检查失败 (如无法访问 Cloudflare) 同样只发出警告，不影响调用方的结果
+func (m *Manager) warnDNSCoverage(token string, hosts ...string) {
+	if !m.CheckDNSCoverage {
+		return
+	}
+	var uncovered []string
+	var err error
+	if len(hosts) == 0 {
+		uncovered, err = m.AuditDNSCoverage(token)
+	} else {
+		uncovered, err = m.uncoveredHosts(token, hosts)
+	}
+	switch {
+	case err != nil:
+		m.warn(fmt.Sprintf("无法检查 Cloudflare 令牌的区域覆盖: %v", err))
+	case len(uncovered) > 0:
+		m.warn(fmt.Sprintf("Cloudflare 令牌无权访问以下域名所在的区域, DNS 挑战将会失败: %s", strings.Join(uncovered, ", ")))
+	}
+}
+
+// uncoveredHosts 返回不属于令牌可访问区域的主机所在的注册域名 - 内部辅助函数
+// 占位符、IP 地址和没有注册域名的主机 (如 localhost 或公共后缀本身) 无法进行 DNS 挑战，会被忽略
+func (m *Manager) uncoveredHosts(token string, hosts []string) ([]string, error) {
+	if token == "" {
+		token = utils.GetCloudflareToken()
+	}
+	if token == "" {
+		return nil, fmt.Errorf("缺少 Cloudflare API 令牌")
+	}
+
+	lister := m.Zones
+	if lister == nil {
+		lister = CloudflareZoneLister{}
+	}
+	zones, err := lister.ListZones(context.Background(), token)
+	if err != nil {
+		return nil, err
+	}
+
+	var uncovered []string
+	for _, host := range hosts {
+		host = strings.TrimPrefix(strings.TrimSuffix(strings.ToLower(host), "."), "*.")
+		if host == "" || strings.Contains(host, "{") || isIP(host) {
+			continue
+		}
+		apex, err := publicsuffix.EffectiveTLDPlusOne(host)
+		if err != nil {
+			continue
+		}
+		if !zoneCovers(zones, host) {
+			uncovered = append(uncovered, apex)
+		}
+	}
+	sort.Strings(uncovered)
+	return dedupeSorted(uncovered), nil
+}
+
+// warn 通过 Warn 回调输出警告，未设置回调时忽略 - 内部辅助函数
+func (m *Manager) warn(msg string) {
+	if m.Warn != nil {
+		m.Warn(msg)
+	}
+}
+
+// dnsChallengeHosts 返回由 DNS 挑战策略负责的主机名 - 内部辅助函数
+// http 应用中的主机按 Caddy 的规则选择策略：第一个主题匹配的策略，没有时使用第一个不带主题的策略
+func dnsChallengeHosts(apps map[string]interface{}) []string {
+	tlsApp, _ := apps["tls"].(map[string]interface{})
+	automation, _ := tlsApp["automation"].(map[string]interface{})
+	policies, _ := automation["policies"].([]interface{})
+
+	hosts := make(map[string]bool)
+	for _, raw := range policies {
+		policy, _ := raw.(map[string]interface{})
+		if usesDNSChallenge(policy) {
+			for _, subject := range policySubjects(policy) {
+				hosts[subject] = true
+			}
+		}
+	}
+
+	routeHosts := make(map[string]bool)
+	collectHosts(apps["http"], routeHosts)
+	for host := range routeHosts {
+		if policy := governingPolicy(policies, host); policy != nil && usesDNSChallenge(policy) {
+			hosts[host] = true
+		}
+	}
+
+	list := make([]string, 0, len(hosts))
+	for host := range hosts {
+		list = append(list, host)
+	}
+	sort.Strings(list)
+	return list
+}
+
+// governingPolicy 返回负责主机证书的自动化策略，没有时返回 nil - 内部辅助函数
+func governingPolicy(policies []interface{}, host string) map[string]interface{} {
+	var catchAll map[string]interface{}
+	for _, raw := range policies {
+		policy, _ := raw.(map[string]interface{})
+		subjects := policySubjects(policy)
+		if len(subjects) == 0 {
+			if catchAll == nil {
+				catchAll = policy
+			}
+			continue
+		}
+		for _, subject := range subjects {
+			if subjectMatches(subject, host) {
+				return policy
+			}
+		}
+	}
+	return catchAll
+}
+
+// usesDNSChallenge 判断策略是否有颁发者启用了 DNS 挑战 - 内部辅助函数
+func usesDNSChallenge(policy map[string]interface{}) bool {
+	issuers, _ := policy["issuers"].([]interface{})
+	for _, raw := range issuers {
+		issuer, _ := raw.(map[string]interface{})
+		challenges, _ := issuer["challenges"].(map[string]interface{})
+		if _, ok := challenges["dns"].(map[string]interface{}); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// policySubjects 返回策略的主题，统一为小写 - 内部辅助函数
+func policySubjects(policy map[string]interface{}) []string {
+	raw, _ := policy["subjects"].([]interface{})
+	var subjects []string
+	for _, s := range raw {
+		if subject, ok := s.(string); ok {
+			subjects = append(subjects, strings.ToLower(subject))
+		}
+	}
+	return subjects
+}
+
+// subjectMatches 判断策略主题是否匹配主机名，通配符主题只匹配一级子域名 - 内部辅助函数
+func subjectMatches(subject, host string) bool {
+	if subject == host {
+		return true
+	}
+	if strings.HasPrefix(subject, "*.") {
+		if i := strings.Index(host, "."); i > 0 {
+			return host[i:] == subject[1:]
+		}
+	}
+	return false
+}
+
+// zoneCovers 判断主机名是否等于某个区域或是其子域名 - 内部辅助函数
+func zoneCovers(zones []string, host string) bool {
+	for _, zone := range zones {
+		if host == zone || strings.HasSuffix(host, "."+zone) {
+			return true
+		}
+	}
+	return false
+}
+
+// collectHosts 递归收集配置中 host 匹配器的主机名 - 内部辅助函数
+func collectHosts(value interface{}, hosts map[string]bool) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			if list, ok := item.([]interface{}); ok && key == "host" {
+				for _, h := range list {
+					if host, ok := h.(string); ok {
+						hosts[strings.ToLower(host)] = true
+					}
+				}
+				continue
+			}
+			collectHosts(item, hosts)
+		}
+	case []interface{}:
+		for _, item := range v {
+			collectHosts(item, hosts)
+		}
+	}
+}
+
+// isIP 判断主机名是否为 IP 地址 - 内部辅助函数
+func isIP(host string) bool {
+	return strings.Trim(host, "0123456789.") == "" || strings.Contains(host, ":")
+}
+
+// dedupeSorted 去除已排序切片中的重复项 - 内部辅助函数
+func dedupeSorted(values []string) []string {
+	var out []string
+	for i, v := range values {
+		if i == 0 || v != values[i-1] {
+			out = append(out, v)
+		}
+	}
+	return out
+}
@@ -0,0 +1,98 @@
+package tls
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// fakeCloudflare 返回令牌可访问 zones 的假 Cloudflare API - 测试辅助函数
+// 每页只返回一个区域，以覆盖分页逻辑
+func fakeCloudflare(t *testing.T, token string, zones ...string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/zones" || r.Header.Get("Authorization") != "Bearer "+token {
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode(map[string]interface{}{"success": false, "errors": []map[string]string{{"message": "Invalid access token"}}})
+			return
+		}
+		page := 1
+		if p := r.URL.Query().Get("page"); p != "" {
+			json.Unmarshal([]byte(p), &page)
+		}
+		var result []map[string]string
+		if page <= len(zones) {
+			result = append(result, map[string]string{"name": zones[page-1]})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success":     true,
+			"result":      result,
+			"result_info": map[string]int{"total_pages": len(zones)},
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestAuditDNSCoverage(t *testing.T) {
+	const (
+		dnsIssuer  = `{"module":"acme","challenges":{"dns":{"provider":{"name":"cloudflare","api_token":"t"}}}}`
+		httpIssuer = `{"module":"acme"}`
+	)
+	routes := func(hosts ...string) string {
+		data, _ := json.Marshal(hosts)
+		return `"http":{"servers":{"srv0":{"routes":[{"match":[{"host":` + string(data) + `}],"handle":[{"handler":"file_server"}]}]}}}`
+	}
+
+	tests := []struct {
+		name   string
+		config string
+		want   []string
+	}{
+		{
+			name:   "没有自动化策略时不使用 DNS 挑战",
+			config: `{"apps":{` + routes("app.example.org") + `}}`,
+		},
+		{
+			name:   "不带主题的 DNS 挑战策略覆盖全部路由主机",
+			config: `{"apps":{` + routes("app.example.com", "app.example.org", "b.example.org", "localhost", "10.0.0.1") + `,"tls":{"automation":{"policies":[{"issuers":[` + dnsIssuer + `]}]}}}}`,
+			want:   []string{"example.org"},
+		},
+		{
+			name:   "只检查 DNS 挑战策略负责的主机",
+			config: `{"apps":{` + routes("app.example.org", "api.example.net") + `,"tls":{"automation":{"policies":[{"subjects":["app.example.org"],"issuers":[` + httpIssuer + `]},{"subjects":["*.example.net"],"issuers":[` + dnsIssuer + `]}]}}}}`,
+			want:   []string{"example.net"},
+		},
+		{
+			name:   "按公共后缀列表得出注册域名",
+			config: `{"apps":{"tls":{"automation":{"policies":[{"subjects":["a.shop.example.co.uk","*.b.example.co.uk","dev.example.com"],"issuers":[` + dnsIssuer + `]}]}}}}`,
+			want:   []string{"example.co.uk"},
+		},
+	}
+	cloudflare := fakeCloudflare(t, "token", "example.com", "dev.example.com")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, _ := newTestManager(t, tt.config)
+			m.Zones = CloudflareZoneLister{BaseURL: cloudflare.URL}
+			got, err := m.AuditDNSCoverage("token")
+			if err != nil {
+				t.Fatalf("AuditDNSCoverage: %v", err)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("未覆盖 = %v, 期望 %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuditDNSCoverageInvalidToken(t *testing.T) {
+	cloudflare := fakeCloudflare(t, "token", "example.com")
+	m, _ := newTestManager(t, `{"apps":{"tls":{"automation":{"policies":[{"subjects":["a.example.org"],"issuers":[{"module":"acme","challenges":{"dns":{}}}]}]}}}}`)
+	m.Zones = CloudflareZoneLister{BaseURL: cloudflare.URL}
+	_, err := m.AuditDNSCoverage("wrong")
+	if err == nil || !strings.Contains(err.Error(), "Invalid access token") {
+		t.Fatalf("err = %v, 期望包含 Cloudflare 的错误信息", err)
+	}
+}
@@ -0,0 +1,211 @@
+package tls
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/youfun/gofastcaddy/pkg/types"
+)
+
+// 全局 DNS 提供商相关路径
+const (
+	TLSAppPath    = "/apps/tls"
+	GlobalDNSPath = TLSAppPath + "/dns"
+	PoliciesPath  = AutomationPath + "/policies"
+)
+
+// dnsProviders 内置的 DNS 提供商构造函数，按提供商名称索引
+var dnsProviders = map[string]func(token string) types.ACMEDNSProvider{
+	"cloudflare": func(token string) types.ACMEDNSProvider {
+		return types.ACMEDNSProvider{Name: "cloudflare", APIToken: token}
+	},
+}
+
+// NewDNSProvider 使用 API 令牌构造内置的 DNS 提供商配置
+func NewDNSProvider(name, token string) (types.ACMEDNSProvider, error) {
+	factory, ok := dnsProviders[name]
+	if !ok {
+		return types.ACMEDNSProvider{}, fmt.Errorf("不支持的 DNS 提供商: %s", name)
+	}
+	return factory(token), nil
+}
+
+// DNSProviders 返回内置的 DNS 提供商名称，按字典序排列
+func DNSProviders() []string {
+	names := make([]string, 0, len(dnsProviders))
+	for name := range dnsProviders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// GetACMEGlobalDNSConfig 获取引用全局 DNS 提供商的 ACME 颁发者配置
+// 启用 DNS 挑战但不内嵌提供商凭据，由 tls 应用的 dns 字段统一提供（Caddy 2.10+）
+func GetACMEGlobalDNSConfig() map[string]interface{} {
+	return map[string]interface{}{
+		"module": "acme",
+		"challenges": map[string]interface{}{
+			"dns": map[string]interface{}{},
+		},
+	}
+}
+
+// SetGlobalDNSProvider 设置全局 DNS 提供商
+// 新版 Caddy (2.10+) 写入 tls 应用的 dns 字段，所有 ACME 颁发者共享同一份凭据；
+// 旧版 Caddy 不认识该字段时，回退为把提供商注入到现有的每个 ACME 颁发者中
+func (m *Manager) SetGlobalDNSProvider(provider types.ACMEDNSProvider) error {
+	if provider.Name == "" {
+		return fmt.Errorf("DNS 提供商名称不能为空")
+	}
+
+	// 配置为空时先创建空的根配置，已有的其他配置保持不变
+	if _, err := m.client.PutConfigIfAbsent(map[string]interface{}{}, "/"); err != nil {
+		return err
+	}
+	if err := m.configManager.EnsurePath(TLSAppPath, 0); err != nil {
+		return err
+	}
+
+	supported, err := m.supportsGlobalDNS(provider)
+	if err != nil {
+		return err
+	}
+	if supported {
+		return nil
+	}
+	return m.injectIssuerDNSProvider(provider)
+}
+
+// supportsGlobalDNS 探测 Caddy 是否支持全局 DNS 提供商 - 兼容层
+// 直接尝试写入 tls 应用的 dns 字段：成功即说明支持（同时完成设置），
+// 旧版 Caddy 加载配置时会以 unknown field 拒绝，此时返回 false 以便回退
+func (m *Manager) supportsGlobalDNS(provider types.ACMEDNSProvider) (bool, error) {
+	if m.globalDNS != nil {
+		if !*m.globalDNS {
+			return false, nil
+		}
+		return true, m.client.PostConfig(provider, GlobalDNSPath)
+	}
+
+	err := m.client.PostConfig(provider, GlobalDNSPath)
+	if err != nil && !isUnknownFieldError(err, "dns") {
+		return false, err
+	}
+	supported := err == nil
+	m.globalDNS = &supported
+	return supported, nil
+}
+
+// injectIssuerDNSProvider 将 DNS 提供商注入所有 ACME 颁发者 - 旧版 Caddy 的回退方案
+func (m *Manager) injectIssuerDNSProvider(provider types.ACMEDNSProvider) error {
+	exists, err := m.client.PathExists(PoliciesPath)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return nil // 尚无自动化策略，无需注入
+	}
+
+	config, err := m.client.GetConfig(AutomationPath)
+	if err != nil {
+		return err
+	}
+	policies, _ := config["policies"].([]interface{})
+
+	changed := false
+	for _, rawPolicy := range policies {
+		policy, ok := rawPolicy.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		issuers, _ := policy["issuers"].([]interface{})
+		for _, rawIssuer := range issuers {
+			issuer, ok := rawIssuer.(map[string]interface{})
+			if !ok || issuer["module"] != "acme" {
+				continue
+			}
+			challenges, _ := issuer["challenges"].(map[string]interface{})
+			if challenges == nil {
+				challenges = map[string]interface{}{}
+				issuer["challenges"] = challenges
+			}
+			dns, _ := challenges["dns"].(map[string]interface{})
+			if dns == nil {
+				dns = map[string]interface{}{}
+				challenges["dns"] = dns
+			}
+			dns["provider"] = provider
+			changed = true
+		}
+	}
+
+	if !changed {
+		return nil
+	}
+	// 整体替换策略列表；对数组路径 POST 会把列表作为一个元素追加
+	return m.client.PatchConfig(policies, PoliciesPath)
+}
+
+// RemoveSubjectPolicies 删除主题完全属于 subjects 的 TLS 自动化策略
+// 同时覆盖其他主题的策略保持不变；返回被删除的策略数量
+func (m *Manager) RemoveSubjectPolicies(subjects ...string) (int, error) {
+	exists, err := m.client.PathExists(PoliciesPath)
+	if err != nil {
+		return 0, err
+	}
+	if !exists {
+		return 0, nil
+	}
+
+	config, err := m.client.GetConfig(AutomationPath)
+	if err != nil {
+		return 0, err
+	}
+	policies, _ := config["policies"].([]interface{})
+
+	var remaining []interface{}
+	removed := 0
+	for _, rawPolicy := range policies {
+		policy, _ := rawPolicy.(map[string]interface{})
+		policySubjects, _ := policy["subjects"].([]interface{})
+		if len(policySubjects) > 0 && allSubjectsIn(policySubjects, subjects) {
+			removed++
+			continue
+		}
+		remaining = append(remaining, rawPolicy)
+	}
+
+	if removed == 0 {
+		return 0, nil
+	}
+	if remaining == nil {
+		remaining = []interface{}{}
+	}
+	// 整体替换策略列表；对数组路径 POST 会把列表作为一个元素追加
+	return removed, m.client.PatchConfig(remaining, PoliciesPath)
+}
+
+// allSubjectsIn 检查策略的所有主题是否都在给定列表中 - 内部辅助函数
+func allSubjectsIn(policySubjects []interface{}, subjects []string) bool {
+	for _, raw := range policySubjects {
+		subject, _ := raw.(string)
+		found := false
+		for _, s := range subjects {
+			if s == subject {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// isUnknownFieldError 判断 Caddy 是否因为不认识某个 JSON 字段而拒绝配置 - 内部辅助函数
+func isUnknownFieldError(err error, field string) bool {
+	return strings.Contains(err.Error(), fmt.Sprintf("unknown field \"%s\"", field))
+}
@@ -0,0 +1,125 @@
+package tls
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestSetGlobalDNSProvider(t *testing.T) {
+	const policies = `{"apps":{"tls":{"automation":{"policies":[` +
+		`{"subjects":["example.com"],"issuers":[{"module":"acme"},{"module":"internal"}]},` +
+		`{"issuers":[{"module":"acme","challenges":{"http":{"disabled":true}}}]}` +
+		`]}}}}`
+	const provider = `{"api_token":"token","name":"cloudflare"}`
+	tests := []struct {
+		name    string
+		initial string
+		legacy  bool   // 模拟不认识 tls.dns 字段的旧版 Caddy
+		want    string // 写入后的 tls 应用
+	}{
+		{
+			name:    "新版写入 tls.dns",
+			initial: policies,
+			want: `{"automation":{"policies":[` +
+				`{"issuers":[{"module":"acme"},{"module":"internal"}],"subjects":["example.com"]},` +
+				`{"issuers":[{"challenges":{"http":{"disabled":true}},"module":"acme"}]}` +
+				`]},"dns":` + provider + `}`,
+		},
+		{
+			name:    "新版空配置",
+			initial: "",
+			want:    `{"dns":` + provider + `}`,
+		},
+		{
+			name:    "旧版注入每个 ACME 颁发者",
+			initial: policies,
+			legacy:  true,
+			want: `{"automation":{"policies":[` +
+				`{"issuers":[{"challenges":{"dns":{"provider":` + provider + `}},"module":"acme"},{"module":"internal"}],"subjects":["example.com"]},` +
+				`{"issuers":[{"challenges":{"dns":{"provider":` + provider + `},"http":{"disabled":true}},"module":"acme"}]}` +
+				`]}}`,
+		},
+		{
+			name:    "旧版没有自动化策略",
+			initial: `{"apps":{"tls":{}}}`,
+			legacy:  true,
+			want:    `{}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, fake := newTestManager(t, tt.initial)
+			if tt.legacy {
+				fake.Handle("/config/apps/tls/dns", func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(http.StatusBadRequest)
+					w.Write([]byte(`{"error":"loading new config: decoding module config: tls: json: unknown field \"dns\""}`))
+				})
+			}
+			p, err := NewDNSProvider("cloudflare", "token")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// 第二次调用使用缓存的探测结果，不再尝试写入 tls.dns
+			for i := 0; i < 2; i++ {
+				if err := m.SetGlobalDNSProvider(p); err != nil {
+					t.Fatal(err)
+				}
+			}
+			probes := 0
+			for _, req := range fake.Requests() {
+				if req == "POST /config/apps/tls/dns/" {
+					probes++
+				}
+			}
+			if want := map[bool]int{false: 2, true: 1}[tt.legacy]; probes != want {
+				t.Errorf("写入 tls.dns 的请求数 = %d, 期望 %d", probes, want)
+			}
+
+			got := fake.ConfigJSON()
+			if !strings.Contains(got, `"tls":`+tt.want) {
+				t.Errorf("配置 = %s\n期望 tls = %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetACMEGlobalDNSConfigHasNoCredentials(t *testing.T) {
+	issuer := GetACMEGlobalDNSConfig()
+	dns := issuer["challenges"].(map[string]interface{})["dns"].(map[string]interface{})
+	if _, ok := dns["provider"]; ok {
+		t.Errorf("引用全局 DNS 提供商的颁发者不应内嵌提供商: %v", issuer)
+	}
+}
+
+func TestRemoveSubjectPolicies(t *testing.T) {
+	const initial = `{"apps":{"tls":{"automation":{"policies":[` +
+		`{"subjects":["*.example.com"]},{"subjects":["*.example.com","example.com"]},{"subjects":["*.other.com"]},{}` +
+		`]}}}}`
+	tests := []struct {
+		name     string
+		subjects []string
+		removed  int
+		want     string // 删除后的策略列表
+	}{
+		{name: "只删除完全属于主题的策略", subjects: []string{"*.example.com"}, removed: 1, want: `[{"subjects":["*.example.com","example.com"]},{"subjects":["*.other.com"]},{}]`},
+		{name: "多个主题", subjects: []string{"*.example.com", "example.com", "*.other.com"}, removed: 3, want: `[{}]`},
+		{name: "没有匹配的策略", subjects: []string{"*.missing.com"}, want: `[{"subjects":["*.example.com"]},{"subjects":["*.example.com","example.com"]},{"subjects":["*.other.com"]},{}]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, fake := newTestManager(t, initial)
+			removed, err := m.RemoveSubjectPolicies(tt.subjects...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if removed != tt.removed {
+				t.Errorf("删除的策略数 = %d, 期望 %d", removed, tt.removed)
+			}
+			if got := fake.ConfigJSON(); !strings.Contains(got, `"policies":`+tt.want+`}`) {
+				t.Errorf("配置 = %s\n期望策略 = %s", got, tt.want)
+			}
+		})
+	}
+}
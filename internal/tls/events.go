@@ -0,0 +1,133 @@
+package tls
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/youfun/gofastcaddy/internal/api"
+	"github.com/youfun/gofastcaddy/pkg/types"
+)
+
+// 证书事件相关常量
+const (
+	EventsAppPath              = "/apps/events"
+	EventSubscriptionsPath     = EventsAppPath + "/subscriptions"
+	CertificateEventsID        = "fastcaddy-cert-events" // 由 fastcaddy 管理的证书事件订阅的 @id
+	DefaultEventHandlerTimeout = "10s"                   // 事件处理器命令的默认超时
+)
+
+// CertificateEvents Caddy tls 应用发出的证书相关事件
+var CertificateEvents = []string{
+	"cert_obtaining",        // 开始申请证书
+	"cert_obtained",         // 证书申请成功
+	"cert_failed",           // 证书申请失败
+	"cert_ocsp_revoked",     // OCSP 报告证书已吊销
+	"cached_managed_cert",   // 受管证书加载到缓存
+	"cached_unmanaged_cert", // 非受管证书加载到缓存
+}
+
+// ConfigureCertificateEvents 在证书事件发生时向 webhookURL 发送 POST 请求
+// Caddy 核心没有 HTTP 事件处理器，这里通过 exec 处理器调用 curl 发送 JSON，
+// 请求体为 {"event": "<事件名>", "identifier": "<证书主题>"}；需要 Caddy 编译包含 exec 事件处理器插件，
+// 且运行环境中有 curl。events 为空时订阅 CertificateEvents 中的全部事件
+func (m *Manager) ConfigureCertificateEvents(webhookURL string, events []string) error {
+	u, err := url.Parse(webhookURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("无效的 webhook 地址: %q", webhookURL)
+	}
+	handler := types.ExecEventHandler{
+		Handler: "exec",
+		Command: "curl",
+		Args: []string{
+			"-fsS", "-X", "POST",
+			"-H", "Content-Type: application/json",
+			"-d", `{"event":"{event.name}","identifier":"{event.data.identifier}"}`,
+			webhookURL,
+		},
+		Timeout: DefaultEventHandlerTimeout,
+	}
+	return m.SetCertificateEventHandler(handler, events)
+}
+
+// SetCertificateEventHandler 将证书事件绑定到指定的 exec 处理器
+// 由 fastcaddy 管理的订阅 (@id 为 CertificateEventsID) 已存在时整体替换，
+// events 应用中的其他订阅保持不变。events 为空时订阅 CertificateEvents 中的全部事件
+func (m *Manager) SetCertificateEventHandler(handler types.ExecEventHandler, events []string) error {
+	if handler.Command == "" {
+		return fmt.Errorf("事件处理器命令不能为空")
+	}
+	handler.Handler = "exec"
+	if len(events) == 0 {
+		events = CertificateEvents
+	}
+	if err := validateCertificateEvents(events); err != nil {
+		return err
+	}
+
+	subscription := types.EventSubscription{
+		ID:       CertificateEventsID,
+		Events:   events,
+		Modules:  []string{"tls"},
+		Handlers: []types.ExecEventHandler{handler},
+	}
+
+	defer m.client.LockWrites()()
+
+	exists, err := m.client.IDExists(CertificateEventsID)
+	if err != nil {
+		return err
+	}
+	if exists {
+		err = m.client.PatchByID(subscription, CertificateEventsID)
+	} else {
+		err = m.addEventSubscription(subscription)
+	}
+	if module := api.MissingModule(err); module == "events.handlers.exec" {
+		return fmt.Errorf("当前 Caddy 未包含 exec 事件处理器模块, 请使用 "+
+			"xcaddy build --with github.com/mholt/caddy-events-exec 构建后重试: %w", err)
+	}
+	return err
+}
+
+// RemoveCertificateEvents 删除由 fastcaddy 管理的证书事件订阅 - 不存在时不做任何操作
+// events 应用中的其他订阅保持不变
+func (m *Manager) RemoveCertificateEvents() error {
+	exists, err := m.client.IDExists(CertificateEventsID)
+	if err != nil || !exists {
+		return err
+	}
+	return m.client.DeleteByID(CertificateEventsID)
+}
+
+// addEventSubscription 追加事件订阅，events 应用或订阅列表不存在时创建 - 内部辅助函数
+func (m *Manager) addEventSubscription(subscription types.EventSubscription) error {
+	if err := m.configManager.EnsurePath(EventsAppPath, 1); err != nil {
+		return err
+	}
+	exists, err := m.client.PathExists(EventSubscriptionsPath)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return m.client.PostConfig([]types.EventSubscription{subscription}, EventSubscriptionsPath)
+	}
+	return m.client.AppendConfig([]types.EventSubscription{subscription}, EventSubscriptionsPath)
+}
+
+// validateCertificateEvents 校验事件名称是否为已知的证书事件 - 内部辅助函数
+func validateCertificateEvents(events []string) error {
+	for _, event := range events {
+		known := false
+		for _, name := range CertificateEvents {
+			if event == name {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return fmt.Errorf("未知的证书事件: %q (可选: %s)", event, strings.Join(CertificateEvents, ", "))
+		}
+	}
+	return nil
+}
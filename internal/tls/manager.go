@@ -1,6 +1,8 @@
 package tls
 
 import (
+	"fmt"
+
 	"github.com/youfun/gofastcaddy/internal/api"
 	"github.com/youfun/gofastcaddy/internal/config"
 	"github.com/youfun/gofastcaddy/pkg/types"
@@ -13,13 +15,27 @@ const AutomationPath = "/apps/tls/automation"
 type Manager struct {
 	client        *api.Client
 	configManager *config.Manager
+	globalDNS     *bool // 探测到的全局 DNS 提供商支持情况 (nil 表示尚未探测)
+
+	// CheckDNSCoverage 为 true 时 AddACMEConfig 和 EnsureDomainPolicy 成功后检查 Cloudflare 令牌
+	// 是否覆盖相关主机所在的区域，未覆盖时通过 Warn 发出警告，见 AuditDNSCoverage
+	CheckDNSCoverage bool
+	// Zones 查询令牌可访问区域的实现 (nil 表示使用 CloudflareZoneLister)
+	Zones ZoneLister
+	// Warn 警告回调 (nil 表示忽略警告)
+	Warn func(msg string)
 }
 
 // NewManager 创建新的 TLS 管理器
 func NewManager() *Manager {
+	return NewManagerWithClient(api.NewClient())
+}
+
+// NewManagerWithClient 使用指定的 API 客户端创建TLS 管理器
+func NewManagerWithClient(client *api.Client) *Manager {
 	return &Manager{
-		client:        api.NewClient(),
-		configManager: config.NewManager(),
+		client:        client,
+		configManager: config.NewManagerWithClient(client),
 	}
 }
 
@@ -43,55 +59,65 @@ func GetACMEConfig(token string) map[string]interface{} {
 	}
 }
 
-// AddTLSInternalConfig 添加内部 TLS 配置 - 对应 Python 的 add_tls_internal_config() 函数
-// 为本地开发环境配置内部证书颁发者
-func (m *Manager) AddTLSInternalConfig() error {
-	// 检查自动化路径是否已存在
-	if m.client.HasPath(AutomationPath) {
-		return nil // 已存在，无需重复配置
+// InternalPolicy 返回使用 Caddy 内部 CA 的 TLS 自动化策略 - 纯构建函数，不访问管理 API
+// 不指定 subjects 时策略覆盖所有主机
+func InternalPolicy(subjects ...string) map[string]interface{} {
+	policy := map[string]interface{}{
+		"issuers": []map[string]interface{}{
+			{
+				"module": "internal",
+			},
+		},
 	}
+	if len(subjects) > 0 {
+		policy["subjects"] = subjects
+	}
+	return policy
+}
 
-	// 创建空的根配置
-	if err := m.client.PutConfig(map[string]interface{}{}, "/", "POST"); err != nil {
+// AddTLSInternalConfig 添加内部 TLS 配置 - 对应 Python 的 add_tls_internal_config() 函数
+// 为本地开发环境配置内部证书颁发者；自动化路径已存在时不做任何修改，
+// 管理端点不可达时返回错误而不是当作不存在
+func (m *Manager) AddTLSInternalConfig() error {
+	// 配置为空时先创建空的根配置，已有配置保持不变
+	if _, err := m.client.PutConfigIfAbsent(map[string]interface{}{}, "/"); err != nil {
 		return err
 	}
 
-	// 初始化自动化路径
-	if err := m.configManager.InitPath(AutomationPath, 0); err != nil {
+	// 初始化 tls 应用路径
+	if err := m.configManager.EnsurePath(TLSAppPath, 0); err != nil {
 		return err
 	}
 
 	// 创建内部证书颁发者策略
-	policies := []map[string]interface{}{
-		{
-			"issuers": []map[string]interface{}{
-				{
-					"module": "internal",
-				},
-			},
-		},
+	automation := map[string]interface{}{
+		"policies": []map[string]interface{}{InternalPolicy()},
 	}
 
-	// 设置策略配置
-	policiesPath := AutomationPath + "/policies"
-	return m.client.PutConfig(policies, policiesPath, "POST")
+	// 自动化路径不存在时才创建
+	_, err := m.client.PutConfigIfAbsent(automation, AutomationPath)
+	return err
 }
 
 // AddACMEConfig 添加 ACME 配置 - 对应 Python 的 add_acme_config(cf_token) 函数
 // 为生产环境配置 ACME 证书颁发者（使用 Cloudflare）
 func (m *Manager) AddACMEConfig(cfToken string) error {
-	// 检查自动化路径是否已存在
-	if m.client.HasPath(AutomationPath) {
+	// 检查自动化路径是否已存在；管理端点不可达时返回错误，避免误判为不存在而覆盖配置
+	exists, err := m.client.PathExists(AutomationPath)
+	if err != nil {
+		return err
+	}
+	if exists {
 		return nil // 已存在，无需重复配置
 	}
 
-	// 创建空的根配置
-	if err := m.client.PutConfig(map[string]interface{}{}, "/", "POST"); err != nil {
+	// 配置为空时先创建空的根配置，已有的其他配置保持不变
+	if _, err := m.client.PutConfigIfAbsent(map[string]interface{}{}, "/"); err != nil {
 		return err
 	}
 
 	// 初始化自动化路径
-	if err := m.configManager.InitPath(AutomationPath, 0); err != nil {
+	if err := m.configManager.EnsurePath(AutomationPath, 0); err != nil {
 		return err
 	}
 
@@ -108,7 +134,39 @@ func (m *Manager) AddACMEConfig(cfToken string) error {
 
 	// 设置策略配置
 	policiesPath := AutomationPath + "/policies"
-	return m.client.PutConfig(policies, policiesPath, "POST")
+	if err := m.client.PostConfig(policies, policiesPath); err != nil {
+		if api.MissingModule(err) == "dns.providers.cloudflare" {
+			return fmt.Errorf("当前 Caddy 未包含 Cloudflare DNS 模块, 请使用 "+
+				"xcaddy build --with github.com/caddy-dns/cloudflare 构建后重试: %w", err)
+		}
+		return err
+	}
+
+	// 写入后确认配置确实生效，而不是在加载失败后被 Caddy 回滚
+	config, err := m.client.GetConfig(AutomationPath)
+	if err != nil {
+		return fmt.Errorf("确认 ACME 配置失败: %w", err)
+	}
+	if !hasIssuerModule(config, "acme") {
+		return fmt.Errorf("ACME 配置写入后未生效, Caddy 可能已回滚 (请检查 Caddy 日志中的模块加载错误)")
+	}
+	m.warnDNSCoverage(cfToken)
+	return nil
+}
+
+// hasIssuerModule 检查自动化配置中是否存在指定模块的颁发者 - 内部辅助函数
+func hasIssuerModule(automation map[string]interface{}, module string) bool {
+	policies, _ := automation["policies"].([]interface{})
+	for _, raw := range policies {
+		policy, _ := raw.(map[string]interface{})
+		issuers, _ := policy["issuers"].([]interface{})
+		for _, rawIssuer := range issuers {
+			if issuer, ok := rawIssuer.(map[string]interface{}); ok && issuer["module"] == module {
+				return true
+			}
+		}
+	}
+	return false
 }
 
 // SetupPKITrust 配置 PKI 证书颁发机构信任 - 对应 Python 的 setup_pki_trust(install_trust) 函数
@@ -123,7 +181,7 @@ func (m *Manager) SetupPKITrust(installTrust *bool) error {
 	pkiPath := "/apps/pki/certificate_authorities/local"
 
 	// 初始化 PKI 路径，跳过第一级 (apps)
-	if err := m.configManager.InitPath(pkiPath, 1); err != nil {
+	if err := m.configManager.EnsurePath(pkiPath, 1); err != nil {
 		return err
 	}
 
@@ -132,6 +190,6 @@ func (m *Manager) SetupPKITrust(installTrust *bool) error {
 		InstallTrust: *installTrust,
 	}
 
-	// 设置 PKI 配置
-	return m.client.PutConfig(pkiConfig, pkiPath, "POST")
+	// 替换 EnsurePath 创建的空对象
+	return m.client.PatchConfig(pkiConfig, pkiPath)
 }
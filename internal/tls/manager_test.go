@@ -0,0 +1,182 @@
+package tls
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/youfun/gofastcaddy/internal/api"
+	"github.com/youfun/gofastcaddy/pkg/clienttest"
+)
+
+// newTestManager 创建连接假管理端点的 TLS 管理器 - 测试辅助函数
+func newTestManager(t *testing.T, initial string) (*Manager, *clienttest.FakeCaddy) {
+	t.Helper()
+	fake := clienttest.NewFakeCaddy(initial)
+	t.Cleanup(fake.Close)
+	return NewManagerWithClient(api.NewClientWithURL(fake.URL)), fake
+}
+
+func TestAddTLSInternalConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		initial string
+		want    string
+	}{
+		{
+			name:    "null 根配置",
+			initial: "",
+			want:    `{"apps":{"tls":{"automation":{"policies":[{"issuers":[{"module":"internal"}]}]}}}}`,
+		},
+		{
+			name:    "空根配置",
+			initial: `{}`,
+			want:    `{"apps":{"tls":{"automation":{"policies":[{"issuers":[{"module":"internal"}]}]}}}}`,
+		},
+		{
+			name:    "已有自动化配置保持不变",
+			initial: `{"apps":{"tls":{"automation":{"policies":[{"issuers":[{"module":"acme"}]}]}}}}`,
+			want:    `{"apps":{"tls":{"automation":{"policies":[{"issuers":[{"module":"acme"}]}]}}}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, fake := newTestManager(t, tt.initial)
+			if err := m.AddTLSInternalConfig(); err != nil {
+				t.Fatalf("AddTLSInternalConfig: %v", err)
+			}
+			if got := fake.ConfigJSON(); got != tt.want {
+				t.Errorf("配置 = %s\n期望 = %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddTLSInternalConfigUnreachable(t *testing.T) {
+	m, fake := newTestManager(t, "")
+	fake.Close()
+	err := m.AddTLSInternalConfig()
+	if err == nil || strings.Contains(err.Error(), "invalid traversal path") {
+		t.Fatalf("期望连接错误，得到 %v", err)
+	}
+}
+
+func TestAddACMEConfig(t *testing.T) {
+	acme := `{"policies":[{"issuers":[{"challenges":{"dns":{"provider":{"api_token":"token","name":"cloudflare"}}},"module":"acme"}]}]}`
+	tests := []struct {
+		name    string
+		initial string
+		want    string
+	}{
+		{
+			name:    "null 根配置",
+			initial: "",
+			want:    `{"apps":{"tls":{"automation":` + acme + `}}}`,
+		},
+		{
+			name:    "空根配置",
+			initial: `{}`,
+			want:    `{"apps":{"tls":{"automation":` + acme + `}}}`,
+		},
+		{
+			name:    "保留其他应用",
+			initial: `{"apps":{"http":{"servers":{}}}}`,
+			want:    `{"apps":{"http":{"servers":{}},"tls":{"automation":` + acme + `}}}`,
+		},
+		{
+			name:    "已有自动化配置保持不变",
+			initial: `{"apps":{"tls":{"automation":{"policies":[]}}}}`,
+			want:    `{"apps":{"tls":{"automation":{"policies":[]}}}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, fake := newTestManager(t, tt.initial)
+			if err := m.AddACMEConfig("token"); err != nil {
+				t.Fatalf("AddACMEConfig: %v", err)
+			}
+			if got := fake.ConfigJSON(); got != tt.want {
+				t.Errorf("配置 = %s\n期望 = %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddACMEConfigProvisionFailure(t *testing.T) {
+	const missingModule = `{"error":"loading config: loading new config: loading tls app module: provision tls: provisioning automation policy 0: ` +
+		`loading ACME issuer module: provision tls.issuance.acme: loading DNS provider module: loading module 'cloudflare': unknown module: dns.providers.cloudflare"}`
+	tests := []struct {
+		name       string
+		handler    http.HandlerFunc
+		want       string // 期望错误信息包含的内容
+		wantModule bool
+	}{
+		{
+			name: "缺少 Cloudflare DNS 模块",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, missingModule, http.StatusBadRequest)
+			},
+			want:       "xcaddy build --with github.com/caddy-dns/cloudflare",
+			wantModule: true,
+		},
+		{
+			// Caddy 返回成功但随后读到的仍是原配置
+			name:    "写入后被回滚",
+			handler: func(w http.ResponseWriter, r *http.Request) {},
+			want:    "未生效",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, fake := newTestManager(t, `{}`)
+			fake.Handle("/config/apps/tls/automation/policies", tt.handler)
+
+			err := m.AddACMEConfig("token")
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("err = %v, 期望包含 %q", err, tt.want)
+			}
+			if errors.Is(err, api.ErrModuleNotAvailable) != tt.wantModule {
+				t.Errorf("errors.Is(ErrModuleNotAvailable) = %v, 期望 %v", !tt.wantModule, tt.wantModule)
+			}
+			if tt.wantModule && !strings.Contains(err.Error(), "unknown module: dns.providers.cloudflare") {
+				t.Errorf("错误中缺少 Caddy 的原始信息: %v", err)
+			}
+		})
+	}
+}
+
+func TestSetupPKITrust(t *testing.T) {
+	yes, no := true, false
+	tests := []struct {
+		name    string
+		initial string
+		install *bool
+		want    string
+	}{
+		{name: "nil 时不修改配置", initial: `{"apps":{}}`, want: `{"apps":{}}`},
+		{
+			name:    "创建 PKI 配置",
+			initial: `{"apps":{"http":{}}}`,
+			install: &yes,
+			want:    `{"apps":{"http":{},"pki":{"certificate_authorities":{"local":{"install_trust":true}}}}}`,
+		},
+		{
+			name:    "替换已有设置",
+			initial: `{"apps":{"pki":{"certificate_authorities":{"local":{"install_trust":true}}}}}`,
+			install: &no,
+			want:    `{"apps":{"pki":{"certificate_authorities":{"local":{"install_trust":false}}}}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, fake := newTestManager(t, tt.initial)
+			if err := m.SetupPKITrust(tt.install); err != nil {
+				t.Fatal(err)
+			}
+			if got := fake.ConfigJSON(); got != tt.want {
+				t.Errorf("配置 = %s\n期望 = %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,22 @@
+package tls
+
+import (
+	"fmt"
+	"os"
+)
+
+// ExportRootCert 将内部 CA (local) 的根证书以 PEM 格式写入 path
+// 用于在浏览器或 CI 容器中安装本地开发使用的根证书
+func (m *Manager) ExportRootCert(path string) error {
+	info, err := m.client.GetCACertificate("")
+	if err != nil {
+		return err
+	}
+	if info.RootCertificate == "" {
+		return fmt.Errorf("CA %s 没有返回根证书", info.ID)
+	}
+	if err := os.WriteFile(path, []byte(info.RootCertificate), 0644); err != nil {
+		return fmt.Errorf("写入根证书失败: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package tls
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// EnsureDomainPolicy 确保存在覆盖 domain 及 *.domain 的 Cloudflare DNS 挑战 ACME 策略
+// 已有主题完全相同的策略时不做修改并返回 false；新策略插入到第一个不带主题的兜底策略之前，
+// 保证按顺序匹配时先于兜底策略生效。整个读取-插入过程持有客户端的写锁
+func (m *Manager) EnsureDomainPolicy(domain, token string) (bool, error) {
+	if domain == "" {
+		return false, fmt.Errorf("域名不能为空")
+	}
+	if token == "" {
+		return false, fmt.Errorf("域名 %s 缺少 Cloudflare API 令牌", domain)
+	}
+	subjects := []string{domain, "*." + domain}
+
+	defer m.client.LockWrites()()
+
+	exists, err := m.client.PathExists(PoliciesPath)
+	if err != nil {
+		return false, err
+	}
+	if !exists {
+		if _, err := m.client.PutConfigIfAbsent(map[string]interface{}{}, "/"); err != nil {
+			return false, err
+		}
+		if err := m.configManager.EnsurePath(AutomationPath, 0); err != nil {
+			return false, err
+		}
+	}
+
+	var policies []interface{}
+	if exists {
+		automation, err := m.client.GetConfig(AutomationPath)
+		if err != nil {
+			return false, err
+		}
+		policies, _ = automation["policies"].([]interface{})
+	}
+
+	index := len(policies)
+	for i, raw := range policies {
+		policy, _ := raw.(map[string]interface{})
+		policySubjects, _ := policy["subjects"].([]interface{})
+		if len(policySubjects) == 0 {
+			if i < index {
+				index = i
+			}
+			continue
+		}
+		if sameSubjects(policySubjects, subjects) {
+			return false, nil
+		}
+	}
+
+	policy := map[string]interface{}{
+		"subjects": subjects,
+		"issuers":  []map[string]interface{}{GetACMEConfig(token)},
+	}
+	if !exists {
+		err = m.client.PostConfig([]interface{}{policy}, PoliciesPath)
+	} else {
+		err = m.client.CreateConfig(policy, fmt.Sprintf("%s/%d", PoliciesPath, index))
+	}
+	if err != nil {
+		return false, err
+	}
+	m.warnDNSCoverage(token, domain)
+	return true, nil
+}
+
+// sameSubjects 判断策略主题与给定主题是否为同一集合 - 内部辅助函数
+func sameSubjects(policySubjects []interface{}, subjects []string) bool {
+	var have []string
+	for _, raw := range policySubjects {
+		if s, ok := raw.(string); ok {
+			have = append(have, s)
+		}
+	}
+	want := append([]string(nil), subjects...)
+	sort.Strings(have)
+	sort.Strings(want)
+	return reflect.DeepEqual(have, want)
+}
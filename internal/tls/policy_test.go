@@ -0,0 +1,122 @@
+package tls
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestEnsureDomainPolicy(t *testing.T) {
+	tests := []struct {
+		name     string
+		initial  string
+		changed  bool
+		subjects [][]string // 写入后各策略的主题，nil 表示兜底策略
+	}{
+		{
+			name:     "null 根配置",
+			initial:  "",
+			changed:  true,
+			subjects: [][]string{{"example.com", "*.example.com"}},
+		},
+		{
+			name:     "空根配置",
+			initial:  `{}`,
+			changed:  true,
+			subjects: [][]string{{"example.com", "*.example.com"}},
+		},
+		{
+			name:     "插入到兜底策略之前",
+			initial:  `{"apps":{"tls":{"automation":{"policies":[{"issuers":[{"module":"internal"}]}]}}}}`,
+			changed:  true,
+			subjects: [][]string{{"example.com", "*.example.com"}, nil},
+		},
+		{
+			name:     "已有相同策略",
+			initial:  `{"apps":{"tls":{"automation":{"policies":[{"subjects":["*.example.com","example.com"]}]}}}}`,
+			changed:  false,
+			subjects: [][]string{{"*.example.com", "example.com"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, fake := newTestManager(t, tt.initial)
+			changed, err := m.EnsureDomainPolicy("example.com", "token")
+			if err != nil {
+				t.Fatalf("EnsureDomainPolicy: %v", err)
+			}
+			if changed != tt.changed {
+				t.Errorf("changed = %v, 期望 %v", changed, tt.changed)
+			}
+
+			var config struct {
+				Apps struct {
+					TLS struct {
+						Automation struct {
+							Policies []struct {
+								Subjects []string `json:"subjects"`
+							} `json:"policies"`
+						} `json:"automation"`
+					} `json:"tls"`
+				} `json:"apps"`
+			}
+			if err := json.Unmarshal([]byte(fake.ConfigJSON()), &config); err != nil {
+				t.Fatal(err)
+			}
+			policies := config.Apps.TLS.Automation.Policies
+			if len(policies) != len(tt.subjects) {
+				t.Fatalf("策略数量 = %d, 期望 %d: %s", len(policies), len(tt.subjects), fake.ConfigJSON())
+			}
+			for i, want := range tt.subjects {
+				if got := policies[i].Subjects; !equalStrings(got, want) {
+					t.Errorf("policies[%d].subjects = %v, 期望 %v", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestPolicyLookupOnEmptyConfig(t *testing.T) {
+	m, fake := newTestManager(t, `{}`)
+	if n, err := m.RemoveSubjectPolicies("example.com"); err != nil || n != 0 {
+		t.Errorf("RemoveSubjectPolicies = %d, %v, 期望 0, nil", n, err)
+	}
+	if err := m.RemoveCertificateEvents(); err != nil {
+		t.Errorf("RemoveCertificateEvents: %v", err)
+	}
+	if got := fake.ConfigJSON(); got != `{}` {
+		t.Errorf("配置被修改: %s", got)
+	}
+}
+
+// equalStrings 比较两个字符串切片 - 测试辅助函数
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestSetCertificateEventHandlerCreatesSubscriptions(t *testing.T) {
+	m, fake := newTestManager(t, `{"apps":{}}`)
+	if err := m.ConfigureCertificateEvents("https://hooks.example.com/cert", []string{"cert_obtained"}); err != nil {
+		t.Fatalf("ConfigureCertificateEvents: %v", err)
+	}
+	var config struct {
+		Apps struct {
+			Events struct {
+				Subscriptions []map[string]interface{} `json:"subscriptions"`
+			} `json:"events"`
+		} `json:"apps"`
+	}
+	if err := json.Unmarshal([]byte(fake.ConfigJSON()), &config); err != nil {
+		t.Fatal(err)
+	}
+	if subs := config.Apps.Events.Subscriptions; len(subs) != 1 || subs[0]["@id"] != CertificateEventsID {
+		t.Errorf("订阅 = %v", subs)
+	}
+}
@@ -11,6 +11,24 @@ const (
 	CloudflareAltEnv   = "CLOUDFLARE_API_TOKEN" // 备用 Cloudflare 令牌环境变量
 )
 
+// 常量定义 - 管理端点地址的环境变量名
+const (
+	AdminEnv    = "CADDY_ADMIN"     // caddy 命令行使用的管理端点地址环境变量
+	AdminURLEnv = "CADDY_ADMIN_URL" // fastcaddy 早期使用的管理端点地址环境变量
+)
+
+// GetAdminAddress 获取环境变量中的管理端点地址 - 未设置时返回空字符串
+// 与 caddy 命令行一致优先使用 CADDY_ADMIN，其次为 CADDY_ADMIN_URL；
+// 返回值保持原样，可以是 host:port、:port、完整 URL 或 unix//path 形式，由 api.NormalizeBaseURL 规范化
+func GetAdminAddress() string {
+	for _, env := range []string{AdminEnv, AdminURLEnv} {
+		if addr := strings.TrimSpace(os.Getenv(env)); addr != "" {
+			return addr
+		}
+	}
+	return ""
+}
+
 // GetCloudflareToken 获取 Cloudflare API 令牌
 // 从环境变量中获取 Cloudflare API 令牌，支持多个环境变量名
 func GetCloudflareToken() string {
@@ -151,4 +169,42 @@ func MergeStringMaps(maps ...map[string]string) map[string]string {
 		}
 	}
 	return result
-}
\ No newline at end of file
+}
+
+// RedactedValue 脱敏后替换敏感值使用的占位符
+const RedactedValue = "[REDACTED]"
+
+// DefaultSecretKeys 默认视为敏感信息的 JSON 键名
+// 覆盖 Cloudflare 令牌、证书私钥和 basic auth 密码等常见字段
+var DefaultSecretKeys = []string{"api_token", "key", "password", "secret"}
+
+// RedactSecrets 返回脱敏后的副本
+// 递归遍历 map 和切片，将键名属于 keys 的值替换为 RedactedValue，原数据保持不变
+func RedactSecrets(value interface{}, keys []string) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			if StringSliceContains(keys, k) && item != nil {
+				result[k] = RedactedValue
+				continue
+			}
+			result[k] = RedactSecrets(item, keys)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			result[i] = RedactSecrets(item, keys)
+		}
+		return result
+	case []map[string]interface{}:
+		result := make([]map[string]interface{}, len(v))
+		for i, item := range v {
+			result[i] = RedactSecrets(item, keys).(map[string]interface{})
+		}
+		return result
+	default:
+		return value
+	}
+}
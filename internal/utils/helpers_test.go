@@ -0,0 +1,31 @@
+package utils
+
+import "testing"
+
+func TestGetAdminAddress(t *testing.T) {
+	tests := []struct {
+		name  string
+		admin string // CADDY_ADMIN
+		url   string // CADDY_ADMIN_URL
+		want  string
+	}{
+		{name: "未设置", want: ""},
+		{name: "host:port", admin: "caddy:2019", want: "caddy:2019"},
+		{name: "省略主机", admin: ":2019", want: ":2019"},
+		{name: "完整 URL", admin: "https://admin.example.com:2019/", want: "https://admin.example.com:2019/"},
+		{name: "unix 套接字", admin: "unix//run/caddy/admin.sock", want: "unix//run/caddy/admin.sock"},
+		{name: "去掉首尾空白", admin: "  caddy:2019\n", want: "caddy:2019"},
+		{name: "CADDY_ADMIN 优先", admin: "caddy:2019", url: "http://legacy:2020", want: "caddy:2019"},
+		{name: "回退到 CADDY_ADMIN_URL", url: "http://legacy:2020", want: "http://legacy:2020"},
+		{name: "空白的 CADDY_ADMIN 视为未设置", admin: "  ", url: "http://legacy:2020", want: "http://legacy:2020"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(AdminEnv, tt.admin)
+			t.Setenv(AdminURLEnv, tt.url)
+			if got := GetAdminAddress(); got != tt.want {
+				t.Errorf("GetAdminAddress() = %q, 期望 %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// punycode 编码参数 (RFC 3492)
+const (
+	punyBase        = 36
+	punyTMin        = 1
+	punyTMax        = 26
+	punySkew        = 38
+	punyDamp        = 700
+	punyInitialBias = 72
+	punyInitialN    = 128
+	punyPrefix      = "xn--"
+	maxLabelLength  = 63
+	maxHostLength   = 253
+)
+
+// NormalizeHost 将主机名规范化为 Caddy host 匹配器使用的 ASCII 形式
+// 转为小写、去掉末尾的点，含非 ASCII 字符的标签按 punycode 编码为 xn-- 形式；
+// 允许 *.example.com 形式的通配符。主机名格式无效时返回错误
+func NormalizeHost(host string) (string, error) {
+	host = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(host)), ".")
+	if !ValidateHost(host) {
+		return "", fmt.Errorf("无效的主机名: %q", host)
+	}
+
+	labels := strings.Split(host, ".")
+	for i, label := range labels {
+		if label == "" {
+			return "", fmt.Errorf("主机名 %q 中存在空标签", host)
+		}
+		if label == "*" && i == 0 {
+			continue
+		}
+		if !isASCII(label) {
+			encoded, err := encodePunycode(label)
+			if err != nil {
+				return "", fmt.Errorf("主机名 %q 无法编码: %w", host, err)
+			}
+			label = punyPrefix + encoded
+			labels[i] = label
+		}
+		if len(label) > maxLabelLength {
+			return "", fmt.Errorf("主机名 %q 的标签 %q 超过 %d 个字符", host, label, maxLabelLength)
+		}
+		for _, r := range label {
+			if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+				return "", fmt.Errorf("主机名 %q 包含无效字符 %q", host, r)
+			}
+		}
+	}
+
+	ascii := strings.Join(labels, ".")
+	if len(ascii) > maxHostLength {
+		return "", fmt.Errorf("主机名 %q 超过 %d 个字符", host, maxHostLength)
+	}
+	return ascii, nil
+}
+
+// isASCII 判断字符串是否只包含 ASCII 字符 - 内部辅助函数
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// encodePunycode 按 RFC 3492 将单个标签编码为 punycode (不含 xn-- 前缀) - 内部辅助函数
+func encodePunycode(label string) (string, error) {
+	runes := []rune(label)
+	var out []byte
+	for _, r := range runes {
+		if r < utf8.RuneSelf {
+			out = append(out, byte(r))
+		}
+	}
+	basic := len(out)
+	handled := basic
+	if basic > 0 {
+		out = append(out, '-')
+	}
+
+	n, delta, bias := rune(punyInitialN), 0, punyInitialBias
+	for handled < len(runes) {
+		m := rune(utf8.MaxRune + 1)
+		for _, r := range runes {
+			if r >= n && r < m {
+				m = r
+			}
+		}
+		if int(m-n) > (1<<31-1-delta)/(handled+1) {
+			return "", fmt.Errorf("punycode 编码溢出")
+		}
+		delta += int(m-n) * (handled + 1)
+		n = m
+
+		for _, r := range runes {
+			if r < n {
+				delta++
+			}
+			if r != n {
+				continue
+			}
+			q := delta
+			for k := punyBase; ; k += punyBase {
+				t := k - bias
+				if t < punyTMin {
+					t = punyTMin
+				} else if t > punyTMax {
+					t = punyTMax
+				}
+				if q < t {
+					break
+				}
+				out = append(out, punyDigit(t+(q-t)%(punyBase-t)))
+				q = (q - t) / (punyBase - t)
+			}
+			out = append(out, punyDigit(q))
+			bias = punyAdapt(delta, handled+1, handled == basic)
+			delta = 0
+			handled++
+		}
+		delta++
+		n++
+	}
+	return string(out), nil
+}
+
+// punyAdapt 调整 punycode 编码的偏置 - 内部辅助函数
+func punyAdapt(delta, numPoints int, first bool) int {
+	if first {
+		delta /= punyDamp
+	} else {
+		delta /= 2
+	}
+	delta += delta / numPoints
+	k := 0
+	for delta > ((punyBase-punyTMin)*punyTMax)/2 {
+		delta /= punyBase - punyTMin
+		k += punyBase
+	}
+	return k + (punyBase-punyTMin+1)*delta/(delta+punySkew)
+}
+
+// punyDigit 将 0-35 的数值转换为 punycode 字符 - 内部辅助函数
+func punyDigit(d int) byte {
+	if d < 26 {
+		return byte('a' + d)
+	}
+	return byte('0' + d - 26)
+}
@@ -0,0 +1,37 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNormalizeHost(t *testing.T) {
+	tests := []struct {
+		host    string
+		want    string
+		wantErr bool
+	}{
+		{host: "Example.COM.", want: "example.com"},
+		{host: "bücher.example", want: "xn--bcher-kva.example"},
+		{host: "München.de", want: "xn--mnchen-3ya.de"},
+		{host: "例え.テスト", want: "xn--r8jz45g.xn--zckzah"},
+		{host: "пример.испытание", want: "xn--e1afmkfd.xn--80akhbyknj4f"},
+		{host: "*.bücher.example", want: "*.xn--bcher-kva.example"},
+		{host: "xn--bcher-kva.example", want: "xn--bcher-kva.example"},
+		{host: "a..example.com", wantErr: true},
+		{host: "bad host.com", wantErr: true},
+		{host: strings.Repeat("a", 64) + ".com", wantErr: true},
+		{host: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			got, err := NormalizeHost(tt.host)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, 期望错误 %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("NormalizeHost(%q) = %q, 期望 %q", tt.host, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package gofastcaddy
+
+import (
+	"fmt"
+
+	"github.com/youfun/gofastcaddy/internal/migrations"
+)
+
+// MigrationChange 迁移产生的一项修改 - 见 migrations.Change
+type MigrationChange = migrations.Change
+
+// MigrateManagedConfig 对受管配置范围 (ManagedApps) 执行配置迁移
+// 用于规范化旧版本 fastcaddy 写入的配置，避免与当前版本的输出之间出现永久的虚假漂移。
+// dryRun 为 true 时只返回将要进行的修改而不写入；否则逐个替换发生变化的应用
+func (fc *FastCaddy) MigrateManagedConfig(dryRun bool) ([]MigrationChange, error) {
+	state, err := fc.managedState()
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []MigrationChange
+	for _, name := range ManagedApps {
+		app, ok := state[name].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		appChanges, err := migrations.Run(app, "/apps/"+name)
+		if err != nil {
+			return changes, fmt.Errorf("迁移应用 %s 失败: %w", name, err)
+		}
+		if len(appChanges) == 0 {
+			continue
+		}
+		changes = append(changes, appChanges...)
+		if dryRun {
+			continue
+		}
+		if err := fc.API.PatchConfig(app, "/apps/"+name); err != nil {
+			return changes, fmt.Errorf("写入迁移后的应用 %s 失败: %w", name, err)
+		}
+	}
+	return changes, nil
+}
@@ -0,0 +1,163 @@
+package gofastcaddy
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/youfun/gofastcaddy/internal/routes"
+	"github.com/youfun/gofastcaddy/internal/utils"
+	"github.com/youfun/gofastcaddy/pkg/types"
+)
+
+// DefaultOnboardConcurrency OnboardDomains 的默认并发数
+const DefaultOnboardConcurrency = 4
+
+// RootRedirectPrefix 根域名重定向路由的 ID 前缀
+const RootRedirectPrefix = "redirect-"
+
+// OnboardOptions 批量接入域名的选项
+type OnboardOptions struct {
+	Token          string // 默认的 Cloudflare API 令牌，为空时读取 CLOUDFLARE_API_TOKEN
+	RedirectRootTo string // 非空时将根域名重定向到该子域名，如 "www"
+	Concurrency    int    // 同时处理的域名数 (0 表示 DefaultOnboardConcurrency)
+}
+
+// OnboardStatus 单个域名的接入结果
+type OnboardStatus string
+
+// 域名接入结果
+const (
+	OnboardCreated OnboardStatus = "created" // 至少新建了一项配置
+	OnboardExisted OnboardStatus = "existed" // 所有配置均已存在，未做修改
+	OnboardFailed  OnboardStatus = "failed"  // 接入失败，见 Err
+)
+
+// OnboardResult 单个域名的接入结果
+type OnboardResult struct {
+	Status OnboardStatus `json:"status"`
+	Err    error         `json:"-"`
+}
+
+// OnboardDomains 批量接入顶级域名
+// 每个域名确保存在通配符路由、覆盖 domain 和 *.domain 的 Cloudflare DNS 挑战 TLS 策略，
+// 以及可选的根域名重定向。令牌优先取 perDomainTokens[domain]，否则使用 defaults.Token。
+// 域名之间并发处理，单个域名失败不影响其他域名；对已接入的域名重复执行不做任何修改
+func (fc *FastCaddy) OnboardDomains(domains []string, perDomainTokens map[string]string, defaults OnboardOptions) map[string]OnboardResult {
+	concurrency := defaults.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultOnboardConcurrency
+	}
+	if defaults.Token == "" {
+		defaults.Token = utils.GetCloudflareToken()
+	}
+
+	// 去重并排序，保证处理顺序稳定
+	unique := make(map[string]bool)
+	for _, domain := range domains {
+		if domain = strings.TrimSpace(domain); domain != "" {
+			unique[domain] = true
+		}
+	}
+	sorted := make([]string, 0, len(unique))
+	for domain := range unique {
+		sorted = append(sorted, domain)
+	}
+	sort.Strings(sorted)
+
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		results = make(map[string]OnboardResult, len(sorted))
+		sem     = make(chan struct{}, concurrency)
+	)
+	for _, domain := range sorted {
+		token := perDomainTokens[domain]
+		if token == "" {
+			token = defaults.Token
+		}
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(domain, token string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			result := OnboardResult{Status: OnboardExisted}
+			created, err := fc.onboardDomain(domain, token, defaults)
+			switch {
+			case err != nil:
+				result = OnboardResult{Status: OnboardFailed, Err: err}
+			case created:
+				result.Status = OnboardCreated
+			}
+
+			mu.Lock()
+			results[domain] = result
+			mu.Unlock()
+		}(domain, token)
+	}
+	wg.Wait()
+	return results
+}
+
+// onboardDomain 接入单个域名，返回是否新建了任何配置 - 内部辅助函数
+func (fc *FastCaddy) onboardDomain(domain, token string, opts OnboardOptions) (bool, error) {
+	created := false
+
+	policyCreated, err := fc.TLS.EnsureDomainPolicy(domain, token)
+	if err != nil {
+		return created, fmt.Errorf("配置 TLS 策略失败: %w", err)
+	}
+	created = created || policyCreated
+
+	wildcardID := routes.WildcardRouteID(domain)
+	exists, err := fc.API.IDExists(wildcardID)
+	if err != nil {
+		return created, err
+	}
+	if !exists {
+		if err := fc.Routes.AddWildcardRoute(domain); err != nil {
+			return created, fmt.Errorf("添加通配符路由失败: %w", err)
+		}
+		created = true
+	}
+
+	if opts.RedirectRootTo == "" {
+		return created, nil
+	}
+	redirectID := RootRedirectPrefix + domain
+	exists, err = fc.API.IDExists(redirectID)
+	if err != nil {
+		return created, err
+	}
+	if !exists {
+		target := fmt.Sprintf("https://%s.%s{http.request.uri}", opts.RedirectRootTo, domain)
+		if err := fc.Routes.AddRoute(rootRedirectRoute(redirectID, domain, target)); err != nil {
+			return created, fmt.Errorf("添加根域名重定向失败: %w", err)
+		}
+		created = true
+	}
+	return created, nil
+}
+
+// rootRedirectRoute 构建根域名的永久重定向路由 - 内部辅助函数
+func rootRedirectRoute(id, domain, target string) types.Route {
+	return types.Route{
+		ID:    id,
+		Match: []types.RouteMatch{{Host: []string{domain}}},
+		Handle: []types.Handler{
+			{
+				Handler: "static_response",
+				Extra: map[string]interface{}{
+					"status_code": 308,
+					"headers": map[string]interface{}{
+						"Location": []string{target},
+					},
+				},
+			},
+		},
+		Terminal: true,
+	}
+}
@@ -0,0 +1,250 @@
+package gofastcaddy
+
+import (
+	"context"
+	"crypto/tls"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/youfun/gofastcaddy/internal/api"
+)
+
+// Option New 的可选配置 - 见 api.Option
+type Option = api.Option
+
+// WithBaseURL 设置管理端点地址，优先于 CADDY_ADMIN_URL 环境变量
+func WithBaseURL(baseURL string) Option {
+	return api.WithBaseURL(baseURL)
+}
+
+// WithTimeout 设置单个请求的超时时间 (默认 30 秒)
+func WithTimeout(timeout time.Duration) Option {
+	return api.WithTimeout(timeout)
+}
+
+// WithHTTPClient 使用自定义的 HTTP 客户端
+func WithHTTPClient(httpClient *http.Client) Option {
+	return api.WithHTTPClient(httpClient)
+}
+
+// WithProxyFromEnvironment 按代理环境变量选择代理（默认行为）
+func WithProxyFromEnvironment() Option {
+	return api.WithProxyFromEnvironment()
+}
+
+// WithProxyURL 管理 API 的所有请求都经过指定代理
+func WithProxyURL(proxyURL string) Option {
+	return api.WithProxyURL(proxyURL)
+}
+
+// WithNoProxy 强制直连管理端点，忽略代理环境变量
+func WithNoProxy() Option {
+	return api.WithNoProxy()
+}
+
+// WithUnixSocket 通过 unix 套接字连接管理端点
+func WithUnixSocket(path string) Option {
+	return api.WithUnixSocket(path)
+}
+
+// WithHeader 为每个请求附加自定义请求头
+func WithHeader(key, value string) Option {
+	return api.WithHeader(key, value)
+}
+
+// WithAuthToken 为每个请求附加 Bearer 令牌
+func WithAuthToken(token string) Option {
+	return api.WithAuthToken(token)
+}
+
+// WithBasicAuth 为每个请求附加 HTTP Basic 认证请求头
+func WithBasicAuth(username, password string) Option {
+	return api.WithBasicAuth(username, password)
+}
+
+// WithTransport 使用自定义的传输层
+func WithTransport(transport http.RoundTripper) Option {
+	return api.WithTransport(transport)
+}
+
+// WithIdleConns 设置最大空闲连接数和空闲连接的保留时长
+func WithIdleConns(maxIdle int, idleTimeout time.Duration) Option {
+	return api.WithIdleConns(maxIdle, idleTimeout)
+}
+
+// WithMaxIdleConns 设置最大空闲连接数
+func WithMaxIdleConns(n int) Option {
+	return api.WithMaxIdleConns(n)
+}
+
+// WithIdleConnTimeout 设置空闲连接的保留时长
+func WithIdleConnTimeout(d time.Duration) Option {
+	return api.WithIdleConnTimeout(d)
+}
+
+// WithKeepAlive 设置 TCP keep-alive 探测间隔，负值表示禁用
+func WithKeepAlive(interval time.Duration) Option {
+	return api.WithKeepAlive(interval)
+}
+
+// WithTLSConfig 使用自定义 TLS 配置连接 https:// 管理端点 (私有 CA、mTLS 客户端证书)
+func WithTLSConfig(config *tls.Config) Option {
+	return api.WithTLSConfig(config)
+}
+
+// AdminTLSOptions 连接 https:// 管理端点的 TLS 设置 (客户端证书、CA、ServerName) - 见 api.AdminTLSOptions
+type AdminTLSOptions = api.AdminTLSOptions
+
+// WithAdminTLS 按 opts 生成 TLS 配置连接 https:// 管理端点，所有子管理器共享同一传输层
+func WithAdminTLS(opts AdminTLSOptions) Option {
+	return api.WithAdminTLS(opts)
+}
+
+// WithVerifyWrites 每次写入成功后读回并校验存储的配置，见 api.WithVerifyWrites
+func WithVerifyWrites() Option {
+	return api.WithVerifyWrites()
+}
+
+// ErrWriteVerificationFailed 写入校验失败 - 见 api.WriteVerificationError
+var ErrWriteVerificationFailed = api.ErrWriteVerificationFailed
+
+// ContextWithVerifyWrites 为单次调用开启或关闭写入校验
+func ContextWithVerifyWrites(ctx context.Context, enabled bool) context.Context {
+	return api.ContextWithVerifyWrites(ctx, enabled)
+}
+
+// WithOptimisticConcurrency 读取-修改-写回时使用 ETag/If-Match 检测其他进程的并发修改
+func WithOptimisticConcurrency(enabled bool) Option {
+	return api.WithOptimisticConcurrency(enabled)
+}
+
+// WithOptimisticRetries 开启乐观并发控制并设置冲突时的最大重试次数
+func WithOptimisticRetries(n int) Option {
+	return api.WithOptimisticRetries(n)
+}
+
+// WithMarshaler 使用自定义的请求体序列化函数，见 api.CompactMarshal 和 api.IndentMarshal
+func WithMarshaler(marshal api.MarshalFunc) Option {
+	return api.WithMarshaler(marshal)
+}
+
+// WithDebugLogging 将每次管理 API 调用的方法、URL、状态码、耗时和截断后的请求体/响应体写入 logger
+// logger 为 nil 时使用标准日志；作用于所有子管理器共享的 API 客户端
+func WithDebugLogging(logger *log.Logger) Option {
+	return api.WithDebugHook(api.LogDebugHook(logger), 0)
+}
+
+// WithDebugIndent 在调试输出中缩进 JSON 请求体，不影响发送给 Caddy 的内容 - 见 api.WithDebugIndent
+func WithDebugIndent(enabled bool) Option {
+	return api.WithDebugIndent(enabled)
+}
+
+// RequestLogger 管理 API 调用的日志回调 - 见 api.RequestLogger
+type RequestLogger = api.RequestLogger
+
+// WithLogger 为每次管理 API 调用设置日志回调，不记录请求体
+func WithLogger(logger RequestLogger) Option {
+	return api.WithLogger(logger)
+}
+
+// WithRequestCompression 使用 gzip 压缩较大的请求体，需要管理端点支持解压 - 见 api.WithRequestCompression
+func WithRequestCompression() Option {
+	return api.WithRequestCompression()
+}
+
+// WithRedactedKeys 设置在错误信息和调试日志中脱敏的 JSON 键，默认为 api_token、key、password
+func WithRedactedKeys(keys ...string) Option {
+	return api.WithRedactedKeys(keys...)
+}
+
+// RoundTripFunc 发送单个管理 API 请求的函数 - 见 api.RoundTripFunc
+type RoundTripFunc = api.RoundTripFunc
+
+// Middleware 管理 API 请求的中间件 - 见 api.Middleware
+type Middleware = api.Middleware
+
+// WithMiddleware 注册管理 API 请求的中间件 - 见 api.WithMiddleware
+func WithMiddleware(middleware ...Middleware) Option {
+	return api.WithMiddleware(middleware...)
+}
+
+// Instrumentation 管理 API 调用的指标回调 - 见 api.Instrumentation
+type Instrumentation = api.Instrumentation
+
+// WithInstrumentation 为每次管理 API 调用设置指标回调 - 见 api.WithInstrumentation
+func WithInstrumentation(inst Instrumentation) Option {
+	return api.WithInstrumentation(inst)
+}
+
+// RecordedRequest 试运行模式下记录的写请求 - 见 api.RecordedRequest
+type RecordedRequest = api.RecordedRequest
+
+// DryRunRecorder 试运行记录器 - 见 api.DryRunRecorder
+type DryRunRecorder = api.DryRunRecorder
+
+// ReadResponder 试运行模式下读请求的响应 - 见 api.ReadResponder
+type ReadResponder = api.ReadResponder
+
+// ReadThrough 将试运行的读请求转发给线上客户端 - 见 api.ReadThrough
+func ReadThrough(c *api.Client) ReadResponder {
+	return api.ReadThrough(c)
+}
+
+// WithDryRun 开启试运行模式，写请求只记录不发送 - 见 api.WithDryRun
+func WithDryRun(recorder *DryRunRecorder) Option {
+	return api.WithDryRun(recorder)
+}
+
+// WithDeleteGrace 开启 DeleteRoute 的删除保护，d 时间内可通过 UndoDelete 恢复
+func WithDeleteGrace(d time.Duration) Option {
+	return api.WithDeleteGrace(d)
+}
+
+// WithProvenance 在 fastcaddy 创建的路由上记录库版本、创建时间和操作名称
+func WithProvenance(enabled bool) Option {
+	return api.WithProvenance(enabled)
+}
+
+// WithUpdateTimestamps 在路由的创建和修改请求中记录最后修改时间
+func WithUpdateTimestamps(enabled bool) Option {
+	return api.WithUpdateTimestamps(enabled)
+}
+
+// WithUseNumber 将读取到的配置中的数字解码为 json.Number，避免大整数丢失精度
+func WithUseNumber() Option {
+	return api.WithUseNumber()
+}
+
+// WithReadTimeout 设置读操作的默认超时
+func WithReadTimeout(d time.Duration) Option {
+	return api.WithReadTimeout(d)
+}
+
+// WithWriteTimeout 设置写操作的默认超时
+func WithWriteTimeout(d time.Duration) Option {
+	return api.WithWriteTimeout(d)
+}
+
+// WithLoadTimeout 设置大体积配置上传的默认超时
+func WithLoadTimeout(d time.Duration) Option {
+	return api.WithLoadTimeout(d)
+}
+
+// RetryPolicy 重试策略 - 见 api.RetryPolicy
+type RetryPolicy = api.RetryPolicy
+
+// WithRetry 设置传输层错误和可重试状态码的重试策略
+func WithRetry(policy RetryPolicy) Option {
+	return api.WithRetry(policy)
+}
+
+// WithRetryStatuses 设置需要重试的响应状态码，429/503 带 Retry-After 时按其等待 - 见 api.WithRetryStatuses
+func WithRetryStatuses(codes ...int) Option {
+	return api.WithRetryStatuses(codes...)
+}
+
+// WithWriteRetry 设置配置变更冲突 (409、503 或 Caddy 正在更新配置) 的重试策略 - 见 api.WithWriteRetry
+func WithWriteRetry(policy RetryPolicy) Option {
+	return api.WithWriteRetry(policy)
+}
@@ -0,0 +1,387 @@
+package clienttest
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// FakeCaddy 模拟 Caddy 管理 API 的测试服务器 - 支持 /config/、/id/ 和 /load 端点
+// 路径遍历与 Caddy 相同：根配置为空时值为 null；PUT 已存在的键返回 409，PATCH、DELETE 不存在的键返回 404，
+// 经过不存在的中间键返回 400 (invalid traversal path)；数组路径支持下标插入、替换、删除和 "/..." 展开追加；
+// GET 响应带有 ETag，写请求的 If-Match 不一致时返回 412；重复的 @id 使写入失败并保持原配置。
+// 其他端点 (如 /pki/ca/local、/reverse_proxy/upstreams) 可通过 Handle 注册
+type FakeCaddy struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	config   interface{}
+	ids      map[string]string // @id -> 配置路径 (不含 /config 前缀)
+	extra    map[string]http.HandlerFunc
+	requests []string
+}
+
+// NewFakeCaddy 启动以 initial 为初始配置的假管理端点 - initial 为空表示 null 配置 (新启动的 Caddy)
+// initial 不是有效的 JSON 时 panic；使用完毕后调用 Close
+func NewFakeCaddy(initial string) *FakeCaddy {
+	f := &FakeCaddy{extra: make(map[string]http.HandlerFunc)}
+	if err := f.SetConfig(initial); err != nil {
+		panic(err)
+	}
+	f.Server = httptest.NewServer(http.HandlerFunc(f.serve))
+	return f
+}
+
+// SetConfig 替换当前配置 - data 为空表示 null
+func (f *FakeCaddy) SetConfig(data string) error {
+	var config interface{}
+	if strings.TrimSpace(data) != "" {
+		if err := json.Unmarshal([]byte(data), &config); err != nil {
+			return err
+		}
+	}
+	ids, err := indexIDs(config)
+	if err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.config, f.ids = config, ids
+	return nil
+}
+
+// Config 返回当前配置的副本
+func (f *FakeCaddy) Config() interface{} {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return deepCopy(f.config)
+}
+
+// ConfigJSON 返回当前配置的 JSON 表示 (键按字典序排列)
+func (f *FakeCaddy) ConfigJSON() string {
+	data, _ := json.Marshal(f.Config())
+	return string(data)
+}
+
+// Handle 为 /config/、/id/、/load 以外的路径注册处理函数 - pattern 为路径前缀，如 "/pki/ca/"
+func (f *FakeCaddy) Handle(pattern string, handler http.HandlerFunc) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.extra[pattern] = handler
+}
+
+// Requests 返回已收到的请求，格式为 "METHOD /path"
+func (f *FakeCaddy) Requests() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.requests...)
+}
+
+// serve 处理单个请求 - 内部辅助函数
+func (f *FakeCaddy) serve(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	f.requests = append(f.requests, r.Method+" "+r.URL.Path)
+	var handler http.HandlerFunc
+	var longest string
+	for pattern, h := range f.extra {
+		if strings.HasPrefix(r.URL.Path, pattern) && len(pattern) > len(longest) {
+			handler, longest = h, pattern
+		}
+	}
+	f.mu.Unlock()
+	if handler != nil {
+		handler(w, r)
+		return
+	}
+
+	body, err := readBody(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	path := r.URL.Path
+	switch {
+	case path == "/load" || path == "/load/":
+		if r.Method != http.MethodPost {
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		var config interface{}
+		if err := json.Unmarshal(body, &config); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		f.commit(w, config)
+		return
+	case strings.HasPrefix(path, "/id/"):
+		rest := strings.Trim(strings.TrimPrefix(path, "/id/"), "/")
+		id, tail, _ := strings.Cut(rest, "/")
+		base, ok := f.ids[id]
+		if !ok {
+			writeError(w, http.StatusNotFound, "unknown object ID '"+id+"'")
+			return
+		}
+		path = base
+		if tail != "" {
+			path += "/" + tail
+		}
+	case strings.HasPrefix(path, "/config"):
+		path = strings.TrimPrefix(path, "/config")
+	default:
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	if match := r.Header.Get("If-Match"); match != "" && r.Method != http.MethodGet {
+		if etagHash(match) != etagHash(f.etag(r.URL.Path)) {
+			writeError(w, http.StatusPreconditionFailed, "If-Match header did not match current config hash")
+			return
+		}
+	}
+
+	var val interface{}
+	if r.Method != http.MethodGet && r.Method != http.MethodDelete {
+		if err := json.Unmarshal(body, &val); err != nil {
+			writeError(w, http.StatusBadRequest, "decoding request body: "+err.Error())
+			return
+		}
+	}
+
+	holder := map[string]interface{}{"config": deepCopy(f.config)}
+	parts := []string{"config"}
+	for _, part := range strings.Split(strings.Trim(path, "/"), "/") {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	spread := parts[len(parts)-1] == "..."
+	if spread {
+		if _, ok := val.([]interface{}); r.Method != http.MethodPost || !ok {
+			writeError(w, http.StatusBadRequest, "the '...' suffix requires POST with an array body")
+			return
+		}
+		parts = parts[:len(parts)-1]
+	}
+	result, status, err := traverse(holder, parts, r.Method, val, spread)
+	if err != nil {
+		writeError(w, status, fmt.Sprintf("[%s] %v", r.URL.Path, err))
+		return
+	}
+	if r.Method == http.MethodGet {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Etag", f.etag(r.URL.Path))
+		json.NewEncoder(w).Encode(result)
+		return
+	}
+	f.commit(w, holder["config"])
+}
+
+// commit 校验并应用新配置 - 内部辅助函数，调用方持有锁
+func (f *FakeCaddy) commit(w http.ResponseWriter, config interface{}) {
+	ids, err := indexIDs(config)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	f.config, f.ids = config, ids
+	w.WriteHeader(http.StatusOK)
+}
+
+// etag 返回与 Caddy 相同格式的 ETag："<path> <配置哈希>" - 内部辅助函数，调用方持有锁
+func (f *FakeCaddy) etag(path string) string {
+	data, _ := json.Marshal(f.config)
+	sum := sha256.Sum256(data)
+	return fmt.Sprintf(`"%s %s"`, path, hex.EncodeToString(sum[:8]))
+}
+
+// etagHash 提取 ETag 中的配置哈希部分 - 内部辅助函数
+func etagHash(etag string) string {
+	fields := strings.Fields(strings.Trim(etag, `"`))
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[len(fields)-1]
+}
+
+// traverse 按 Caddy 的规则访问或修改 parts 指向的值 - 内部辅助函数
+// spread 表示原路径以 "/..." 结尾 (将数组请求体展开追加)；返回 GET 的结果，出错时返回对应的状态码
+func traverse(holder map[string]interface{}, parts []string, method string, val interface{}, spread bool) (interface{}, int, error) {
+	var ptr interface{} = holder
+	for i, part := range parts {
+		last := i == len(parts)-1
+		switch v := ptr.(type) {
+		case map[string]interface{}:
+			if arr, ok := v[part].([]interface{}); ok && i == len(parts)-2 {
+				return traverseArray(v, part, arr, parts[i+1], method, val)
+			}
+			if !last {
+				ptr = v[part]
+				continue
+			}
+			existing, exists := v[part]
+			switch method {
+			case http.MethodGet:
+				return existing, 0, nil
+			case http.MethodPost:
+				if arr, ok := existing.([]interface{}); ok && spread {
+					v[part] = append(arr, val.([]interface{})...)
+				} else if ok {
+					v[part] = append(arr, val)
+				} else {
+					v[part] = val
+				}
+			case http.MethodPut:
+				if exists {
+					return nil, http.StatusConflict, fmt.Errorf("key already exists: %s", part)
+				}
+				v[part] = val
+			case http.MethodPatch:
+				if !exists {
+					return nil, http.StatusNotFound, fmt.Errorf("key does not exist: %s", part)
+				}
+				v[part] = val
+			case http.MethodDelete:
+				if !exists {
+					return nil, http.StatusNotFound, fmt.Errorf("key does not exist: %s", part)
+				}
+				delete(v, part)
+			default:
+				return nil, http.StatusMethodNotAllowed, fmt.Errorf("method not allowed")
+			}
+			return nil, 0, nil
+		case []interface{}:
+			index, err := strconv.Atoi(part)
+			if err != nil {
+				return nil, http.StatusBadRequest, fmt.Errorf("invalid array index '%s'", part)
+			}
+			if index < 0 || index >= len(v) {
+				return nil, http.StatusNotFound, fmt.Errorf("array index out of bounds: %s", part)
+			}
+			if last {
+				if method != http.MethodGet {
+					return nil, http.StatusBadRequest, fmt.Errorf("invalid traversal path")
+				}
+				return v[index], 0, nil
+			}
+			ptr = v[index]
+		default:
+			return nil, http.StatusBadRequest, fmt.Errorf("invalid traversal path at: %s", strings.Join(parts[:i+1], "/"))
+		}
+	}
+	return ptr, 0, nil
+}
+
+// traverseArray 处理以数组下标结尾的路径 - 内部辅助函数
+func traverseArray(parent map[string]interface{}, key string, arr []interface{}, indexPart, method string, val interface{}) (interface{}, int, error) {
+	index, err := strconv.Atoi(indexPart)
+	if err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("invalid array index '%s'", indexPart)
+	}
+	outOfBounds := index < 0 || index >= len(arr)
+	switch method {
+	case http.MethodGet:
+		if outOfBounds {
+			return nil, http.StatusNotFound, fmt.Errorf("array index out of bounds: %s", indexPart)
+		}
+		return arr[index], 0, nil
+	case http.MethodPut:
+		// 与 Caddy 相同，PUT 只能插入到已有元素的位置，追加到末尾需要 POST
+		if outOfBounds {
+			return nil, http.StatusNotFound, fmt.Errorf("array index out of bounds: %s", indexPart)
+		}
+		result := append([]interface{}{}, arr[:index]...)
+		result = append(result, val)
+		parent[key] = append(result, arr[index:]...)
+	case http.MethodPatch:
+		if outOfBounds {
+			return nil, http.StatusNotFound, fmt.Errorf("array index out of bounds: %s", indexPart)
+		}
+		arr[index] = val
+	case http.MethodDelete:
+		if outOfBounds {
+			return nil, http.StatusNotFound, fmt.Errorf("array index out of bounds: %s", indexPart)
+		}
+		parent[key] = append(arr[:index:index], arr[index+1:]...)
+	default:
+		return nil, http.StatusBadRequest, fmt.Errorf("use PUT to insert or PATCH to replace array elements")
+	}
+	return nil, 0, nil
+}
+
+// indexIDs 建立 @id 到配置路径的索引，重复的 ID 返回错误 - 内部辅助函数
+func indexIDs(config interface{}) (map[string]string, error) {
+	ids := make(map[string]string)
+	var walk func(value interface{}, path string) error
+	walk = func(value interface{}, path string) error {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			if id, ok := v["@id"].(string); ok {
+				if _, dup := ids[id]; dup {
+					return fmt.Errorf("duplicate ID '%s' found at %s", id, path)
+				}
+				ids[id] = path
+			}
+			keys := make([]string, 0, len(v))
+			for key := range v {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				if err := walk(v[key], path+"/"+key); err != nil {
+					return err
+				}
+			}
+		case []interface{}:
+			for i, item := range v {
+				if err := walk(item, path+"/"+strconv.Itoa(i)); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	return ids, walk(config, "")
+}
+
+// readBody 读取请求体，支持 gzip 压缩 - 内部辅助函数
+func readBody(r *http.Request) ([]byte, error) {
+	var reader io.Reader = r.Body
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		gz, err := gzip.NewReader(r.Body)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		reader = gz
+	}
+	return io.ReadAll(reader)
+}
+
+// writeError 以 Caddy 的格式返回错误 - 内部辅助函数
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
+
+// deepCopy 复制 JSON 值 - 内部辅助函数
+func deepCopy(value interface{}) interface{} {
+	data, _ := json.Marshal(value)
+	var result interface{}
+	json.Unmarshal(bytes.TrimSpace(data), &result)
+	return result
+}
@@ -0,0 +1,186 @@
+// Package clienttest 提供管理 API 客户端的故障注入工具，用于测试控制器在管理端点异常时的行为
+//
+// FaultInjector 实现 http.RoundTripper，包装真实的传输层，可与真实的 Caddy 或测试用的假服务器组合：
+//
+//	faults := clienttest.NewFaultInjector(nil).FailNth(2).Latency(100 * time.Millisecond)
+//	fc := gofastcaddy.New(gofastcaddy.WithHTTPClient(&http.Client{Transport: faults}))
+package clienttest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrInjected 注入的传输层错误
+var ErrInjected = errors.New("clienttest: 注入的网络故障")
+
+// FaultInjector 按规则向请求注入故障的传输层
+// 规则按添加顺序依次生效，可安全地在多个 goroutine 中使用
+type FaultInjector struct {
+	next http.RoundTripper
+
+	mu     sync.Mutex
+	count  int
+	faults []fault
+}
+
+// fault 单条故障规则 - 返回非 nil 的响应或错误时中断请求
+type fault func(n int, req *http.Request) (*http.Response, error)
+
+// NewFaultInjector 创建包装 next 的故障注入器 - next 为 nil 时使用 http.DefaultTransport
+func NewFaultInjector(next http.RoundTripper) *FaultInjector {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &FaultInjector{next: next}
+}
+
+// RoundTrip 执行请求并按规则注入故障
+func (f *FaultInjector) RoundTrip(req *http.Request) (*http.Response, error) {
+	f.mu.Lock()
+	f.count++
+	n := f.count
+	faults := append([]fault(nil), f.faults...)
+	f.mu.Unlock()
+
+	for _, apply := range faults {
+		if resp, err := apply(n, req); resp != nil || err != nil {
+			return resp, err
+		}
+	}
+	return f.next.RoundTrip(req)
+}
+
+// Requests 返回已经过注入器的请求数
+func (f *FaultInjector) Requests() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.count
+}
+
+// add 追加故障规则 - 内部辅助函数
+func (f *FaultInjector) add(rule fault) *FaultInjector {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.faults = append(f.faults, rule)
+	return f
+}
+
+// FailNth 第 n 个请求 (从 1 开始计数) 返回传输层错误
+func (f *FaultInjector) FailNth(n int) *FaultInjector {
+	return f.add(func(count int, req *http.Request) (*http.Response, error) {
+		if count == n {
+			return nil, fmt.Errorf("%w: 第 %d 个请求 %s %s", ErrInjected, n, req.Method, req.URL.Path)
+		}
+		return nil, nil
+	})
+}
+
+// FailEvery 每 n 个请求中的第 n 个返回传输层错误
+func (f *FaultInjector) FailEvery(n int) *FaultInjector {
+	return f.add(func(count int, req *http.Request) (*http.Response, error) {
+		if n > 0 && count%n == 0 {
+			return nil, fmt.Errorf("%w: 第 %d 个请求 %s %s", ErrInjected, count, req.Method, req.URL.Path)
+		}
+		return nil, nil
+	})
+}
+
+// Latency 每个请求延迟 d 后再发送 - 请求的 ctx 结束时立即返回错误
+func (f *FaultInjector) Latency(d time.Duration) *FaultInjector {
+	return f.add(func(_ int, req *http.Request) (*http.Response, error) {
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-timer.C:
+			return nil, nil
+		}
+	})
+}
+
+// StatusFor 对路径以 pathPrefix 开头的请求直接返回 status，methods 为空时匹配所有方法
+// 响应体为 Caddy 风格的 {"error": "..."}
+func (f *FaultInjector) StatusFor(pathPrefix string, status int, methods ...string) *FaultInjector {
+	return f.add(func(_ int, req *http.Request) (*http.Response, error) {
+		if !matches(req, pathPrefix, methods) {
+			return nil, nil
+		}
+		body := fmt.Sprintf(`{"error":"clienttest: 注入的 %d 响应"}`, status)
+		return newResponse(req, status, body), nil
+	})
+}
+
+// CorruptBody 对路径以 pathPrefix 开头的请求返回截断的响应体
+// 请求仍会发送到下一层传输层，因此写操作会真正生效
+func (f *FaultInjector) CorruptBody(pathPrefix string) *FaultInjector {
+	return f.add(func(_ int, req *http.Request) (*http.Response, error) {
+		if !matches(req, pathPrefix, nil) {
+			return nil, nil
+		}
+		resp, err := f.next.RoundTrip(req)
+		if err != nil {
+			return nil, err
+		}
+		data, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		data = data[:len(data)/2]
+		resp.Body = io.NopCloser(bytes.NewReader(data))
+		resp.ContentLength = int64(len(data))
+		return resp, nil
+	})
+}
+
+// FlakyNetwork 场景：每 3 个请求中有 1 个返回传输层错误，用于测试重试
+func FlakyNetwork(next http.RoundTripper) *FaultInjector {
+	return NewFaultInjector(next).FailEvery(3)
+}
+
+// SlowAdmin 场景：每个请求延迟 d，用于测试超时设置
+func SlowAdmin(next http.RoundTripper, d time.Duration) *FaultInjector {
+	return NewFaultInjector(next).Latency(d)
+}
+
+// ReadOnlyAdmin 场景：所有写操作返回 403，读操作正常，用于测试回滚和错误处理
+func ReadOnlyAdmin(next http.RoundTripper) *FaultInjector {
+	return NewFaultInjector(next).StatusFor("/", http.StatusForbidden,
+		http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete)
+}
+
+// matches 判断请求是否匹配路径前缀和方法 - 内部辅助函数
+func matches(req *http.Request, pathPrefix string, methods []string) bool {
+	if !strings.HasPrefix(req.URL.Path, pathPrefix) {
+		return false
+	}
+	if len(methods) == 0 {
+		return true
+	}
+	for _, method := range methods {
+		if strings.EqualFold(req.Method, method) {
+			return true
+		}
+	}
+	return false
+}
+
+// newResponse 构造注入的响应 - 内部辅助函数
+func newResponse(req *http.Request, status int, body string) *http.Response {
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+		Body:          io.NopCloser(strings.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
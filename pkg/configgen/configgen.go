@@ -0,0 +1,78 @@
+// Package configgen 在没有 Caddy 管理端点的情况下生成 fastcaddy 会写入的完整配置
+//
+// 生成的 JSON 可直接作为容器镜像中的初始 caddy.json (caddy run --config caddy.json)。
+// 路由、服务器和 TLS 策略使用与路由管理器、TLS 管理器相同的构建函数创建，
+// 因此由该文件启动的 Caddy 与通过管理 API 逐步写入得到的配置一致
+package configgen
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/youfun/gofastcaddy/internal/routes"
+	"github.com/youfun/gofastcaddy/internal/tls"
+	"github.com/youfun/gofastcaddy/pkg/spec"
+	"github.com/youfun/gofastcaddy/pkg/types"
+)
+
+// GenerateInitialConfig 根据站点配置生成完整的 Caddy JSON 配置
+// 每个站点生成一个 @id 为主机名的反向代理路由，位于默认服务器 srv0；
+// TLS 模式为 internal 的站点共用一个内部 CA 自动化策略，off 的站点加入 automatic_https.skip，
+// auto (默认) 的站点使用 Caddy 默认的 ACME 颁发者
+func GenerateInitialConfig(s spec.SiteSpec) ([]byte, error) {
+	cfg, err := Build(s)
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(cfg, "", "  ")
+}
+
+// Build 根据站点配置构建 Caddy 配置结构 - 与 GenerateInitialConfig 相同，但不序列化
+func Build(s spec.SiteSpec) (map[string]interface{}, error) {
+	if err := s.Validate(); err != nil {
+		return nil, fmt.Errorf("站点配置无效: %w", err)
+	}
+
+	server := routes.NewHTTPServer()
+	var internalHosts, skipHosts []string
+	for _, site := range s.Sites {
+		server.Routes = append(server.Routes, routes.ReverseProxyRoute(site.Host, site.Upstreams...))
+
+		switch tlsMode(site, s) {
+		case spec.TLSInternal:
+			internalHosts = append(internalHosts, site.Host)
+		case spec.TLSOff:
+			skipHosts = append(skipHosts, site.Host)
+		}
+	}
+	if len(skipHosts) > 0 {
+		server.AutomaticHTTPS = &types.AutomaticHTTPS{Skip: skipHosts}
+	}
+
+	apps := map[string]interface{}{
+		"http": map[string]interface{}{
+			"servers": map[string]interface{}{
+				routes.DefaultServerName: server,
+			},
+		},
+	}
+	if len(internalHosts) > 0 {
+		apps["tls"] = map[string]interface{}{
+			"automation": map[string]interface{}{
+				"policies": []map[string]interface{}{tls.InternalPolicy(internalHosts...)},
+			},
+		}
+	}
+	return map[string]interface{}{"apps": apps}, nil
+}
+
+// tlsMode 返回站点实际使用的 TLS 模式 - 内部辅助函数
+func tlsMode(site spec.Site, s spec.SiteSpec) string {
+	switch {
+	case site.TLS != "":
+		return site.TLS
+	case s.TLS != "":
+		return s.TLS
+	}
+	return spec.TLSAuto
+}
@@ -0,0 +1,337 @@
+// Package metrics 导出 fastcaddy 控制进程的 Prometheus 指标
+//
+// Collector 实现 prometheus.Collector，可注册到控制进程已有的 Registry；
+// 同时实现 http.Handler，使用独立的 Registry 输出文本暴露格式，便于单独挂载：
+//
+//	collector := metrics.NewCollector()
+//	fc := gofastcaddy.New(gofastcaddy.WithHTTPClient(&http.Client{Transport: collector.Transport(nil)}))
+//	collector.FastCaddy = fc
+//	prometheus.MustRegister(collector) // 或 http.Handle("/metrics", collector)
+//
+// Collector 同时实现 gofastcaddy.Instrumentation，通过 WithInstrumentation 注册后
+// 还会按方法和状态类别统计调用次数和耗时：
+//
+//	fc := gofastcaddy.New(gofastcaddy.WithInstrumentation(collector))
+package metrics
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/common/expfmt"
+
+	"github.com/youfun/gofastcaddy"
+	"github.com/youfun/gofastcaddy/internal/routes"
+)
+
+// DefaultCacheTTL 受管状态指标的默认缓存时长 - 避免每次抓取都请求管理 API
+const DefaultCacheTTL = 10 * time.Second
+
+// Collector fastcaddy 指标收集器
+// 管理 API 调用计数来自 Transport 返回的传输层；受管状态指标在抓取时按需读取并缓存
+type Collector struct {
+	FastCaddy *gofastcaddy.FastCaddy // 读取受管状态的客户端 (nil 时只输出调用计数)
+	CacheTTL  time.Duration          // 受管状态的缓存时长 (0 表示使用默认值)
+
+	// CertExpiry 同时通过数据面读取受管路由各主机名的证书到期时间，见 FastCaddy.DataPlaneCertificate
+	CertExpiry bool
+
+	mu       sync.Mutex
+	requests map[requestKey]uint64
+	errors   map[string]uint64
+	calls    map[callKey]uint64
+	latency  map[string]*latencyStat
+	inFlight int64
+
+	cachedAt time.Time
+	state    managedState
+
+	registryOnce sync.Once
+	registry     *prometheus.Registry // ServeHTTP 和 WriteTo 使用的独立 Registry
+}
+
+// requestKey 管理 API 调用计数的标签
+type requestKey struct {
+	method string
+	code   string
+}
+
+// callKey 指标回调统计的标签 - class 为 2xx、4xx 等状态类别，请求失败时为 error
+type callKey struct {
+	method string
+	class  string
+}
+
+// latencyStat 单个方法的耗时累计
+type latencyStat struct {
+	count uint64
+	sum   time.Duration
+}
+
+// managedState 受管状态的快照
+type managedState struct {
+	routes    int
+	wildcards int
+	err       bool
+	certs     map[string]time.Time // 主机名 -> 证书到期时间 (读取失败的主机名不记录)
+}
+
+// 指标描述
+var (
+	requestsDesc = prometheus.NewDesc("fastcaddy_admin_requests_total",
+		"管理 API 请求数，按方法和状态码区分", []string{"method", "code"}, nil)
+	errorsDesc = prometheus.NewDesc("fastcaddy_admin_errors_total",
+		"管理 API 失败的请求数 (传输层错误或 4xx/5xx 响应)", []string{"method"}, nil)
+	callsDesc = prometheus.NewDesc("fastcaddy_admin_calls_total",
+		"管理 API 调用数，按方法和状态类别区分 (请求失败为 error)", []string{"method", "class"}, nil)
+	callDurationDesc = prometheus.NewDesc("fastcaddy_admin_call_duration_seconds",
+		"管理 API 调用耗时，按方法区分", []string{"method"}, nil)
+	inFlightDesc = prometheus.NewDesc("fastcaddy_admin_calls_in_flight",
+		"进行中的管理 API 调用数", nil, nil)
+	stateUpDesc = prometheus.NewDesc("fastcaddy_state_up",
+		"最近一次读取受管状态是否成功", nil, nil)
+	managedRoutesDesc = prometheus.NewDesc("fastcaddy_managed_routes",
+		"默认服务器上带 @id 的顶层路由数", nil, nil)
+	wildcardDomainsDesc = prometheus.NewDesc("fastcaddy_wildcard_domains",
+		"通配符域名路由数", nil, nil)
+	certExpiryDesc = prometheus.NewDesc("fastcaddy_cert_expiry_seconds",
+		"数据面为主机名提供的证书的到期时间 (Unix 时间戳，秒)", []string{"host"}, nil)
+)
+
+// NewCollector 创建指标收集器
+func NewCollector() *Collector {
+	return &Collector{
+		requests: make(map[requestKey]uint64),
+		errors:   make(map[string]uint64),
+		calls:    make(map[callKey]uint64),
+		latency:  make(map[string]*latencyStat),
+	}
+}
+
+// OnRequest 记录一次进行中的管理 API 调用 - 实现 gofastcaddy.Instrumentation
+func (c *Collector) OnRequest(method, path string) {
+	c.mu.Lock()
+	c.inFlight++
+	c.mu.Unlock()
+}
+
+// OnResponse 按方法和状态类别记录一次完成的管理 API 调用 - 实现 gofastcaddy.Instrumentation
+func (c *Collector) OnResponse(method, path string, status int, d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.inFlight--
+	c.calls[callKey{method: method, class: statusClass(status)}]++
+	stat := c.latency[method]
+	if stat == nil {
+		stat = &latencyStat{}
+		c.latency[method] = stat
+	}
+	stat.count++
+	stat.sum += d
+}
+
+// Calls 返回指标回调统计的调用次数 - class 为 2xx、4xx 等状态类别，请求失败时为 error
+func (c *Collector) Calls(method, class string) uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.calls[callKey{method: method, class: class}]
+}
+
+// Latency 返回指标回调统计的指定方法调用次数和累计耗时
+func (c *Collector) Latency(method string) (count uint64, total time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if stat := c.latency[method]; stat != nil {
+		return stat.count, stat.sum
+	}
+	return 0, 0
+}
+
+// Transport 返回记录管理 API 调用的传输层，包装 next (nil 时使用 http.DefaultTransport)
+func (c *Collector) Transport(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		resp, err := next.RoundTrip(req)
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if err != nil {
+			c.errors[req.Method]++
+			return resp, err
+		}
+		c.requests[requestKey{method: req.Method, code: strconv.Itoa(resp.StatusCode)}]++
+		if resp.StatusCode >= 400 {
+			c.errors[req.Method]++
+		}
+		return resp, nil
+	})
+}
+
+// Describe 发送全部指标描述 - 实现 prometheus.Collector
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range []*prometheus.Desc{
+		requestsDesc, errorsDesc, callsDesc, callDurationDesc, inFlightDesc,
+		stateUpDesc, managedRoutesDesc, wildcardDomainsDesc, certExpiryDesc,
+	} {
+		ch <- desc
+	}
+}
+
+// Collect 发送全部指标的当前值 - 实现 prometheus.Collector
+// 受管状态指标只在设置了 FastCaddy 时输出，调用统计指标只在注册为 Instrumentation 后输出
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	state := c.managedState()
+
+	c.mu.Lock()
+	for key, n := range c.requests {
+		ch <- prometheus.MustNewConstMetric(requestsDesc, prometheus.CounterValue, float64(n), key.method, key.code)
+	}
+	for method, n := range c.errors {
+		ch <- prometheus.MustNewConstMetric(errorsDesc, prometheus.CounterValue, float64(n), method)
+	}
+	if len(c.calls) > 0 {
+		for key, n := range c.calls {
+			ch <- prometheus.MustNewConstMetric(callsDesc, prometheus.CounterValue, float64(n), key.method, key.class)
+		}
+		for method, stat := range c.latency {
+			ch <- prometheus.MustNewConstSummary(callDurationDesc, stat.count, stat.sum.Seconds(), nil, method)
+		}
+		ch <- prometheus.MustNewConstMetric(inFlightDesc, prometheus.GaugeValue, float64(c.inFlight))
+	}
+	c.mu.Unlock()
+
+	if c.FastCaddy == nil {
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(stateUpDesc, prometheus.GaugeValue, float64(boolValue(!state.err)))
+	if !state.err {
+		ch <- prometheus.MustNewConstMetric(managedRoutesDesc, prometheus.GaugeValue, float64(state.routes))
+		ch <- prometheus.MustNewConstMetric(wildcardDomainsDesc, prometheus.GaugeValue, float64(state.wildcards))
+	}
+	for host, expiry := range state.certs {
+		ch <- prometheus.MustNewConstMetric(certExpiryDesc, prometheus.GaugeValue, float64(expiry.Unix()), host)
+	}
+}
+
+// ServeHTTP 以 Prometheus 文本暴露格式输出全部指标
+func (c *Collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	promhttp.HandlerFor(c.ownRegistry(), promhttp.HandlerOpts{}).ServeHTTP(w, r)
+}
+
+// WriteTo 将全部指标以 Prometheus 文本暴露格式写入 w
+func (c *Collector) WriteTo(w io.Writer) (int64, error) {
+	families, err := c.ownRegistry().Gather()
+	if err != nil {
+		return 0, err
+	}
+	var total int64
+	for _, family := range families {
+		n, err := expfmt.MetricFamilyToText(w, family)
+		total += int64(n)
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+// ownRegistry 返回只注册了该收集器的 Registry，按需创建 - 内部辅助函数
+func (c *Collector) ownRegistry() *prometheus.Registry {
+	c.registryOnce.Do(func() {
+		c.registry = prometheus.NewRegistry()
+		c.registry.MustRegister(c)
+	})
+	return c.registry
+}
+
+// managedState 返回缓存的受管状态，过期时重新读取 - 内部辅助函数
+func (c *Collector) managedState() managedState {
+	if c.FastCaddy == nil {
+		return managedState{}
+	}
+	ttl := c.CacheTTL
+	if ttl <= 0 {
+		ttl = DefaultCacheTTL
+	}
+
+	c.mu.Lock()
+	if !c.cachedAt.IsZero() && time.Since(c.cachedAt) < ttl {
+		state := c.state
+		c.mu.Unlock()
+		return state
+	}
+	c.mu.Unlock()
+
+	var state managedState
+	list, err := c.FastCaddy.Routes.ListManagedRoutes()
+	if err != nil {
+		state.err = true
+	}
+	var hosts []string
+	for _, route := range list {
+		state.routes++
+		if strings.HasPrefix(route.ID, routes.WildcardRoutePrefix) {
+			state.wildcards++
+		}
+		hosts = append(hosts, route.Hosts...)
+	}
+	if c.CertExpiry {
+		state.certs = c.certExpiry(hosts)
+	}
+
+	c.mu.Lock()
+	c.state, c.cachedAt = state, time.Now()
+	c.mu.Unlock()
+	return state
+}
+
+// certExpiry 通过数据面读取各主机名的证书到期时间 - 内部辅助函数
+// 通配符主机名无法用于 SNI，跳过；读取失败的主机名不记录，对应的时间序列随之消失
+func (c *Collector) certExpiry(hosts []string) map[string]time.Time {
+	sort.Strings(hosts)
+	result := make(map[string]time.Time)
+	for i, host := range hosts {
+		if strings.HasPrefix(host, "*") || (i > 0 && hosts[i-1] == host) {
+			continue
+		}
+		cert, err := c.FastCaddy.DataPlaneCertificate(context.Background(), host)
+		if err != nil {
+			continue
+		}
+		result[host] = cert.NotAfter
+	}
+	return result
+}
+
+// statusClass 返回状态码的类别，如 2xx；0 表示请求失败 - 内部辅助函数
+func statusClass(status int) string {
+	if status <= 0 {
+		return "error"
+	}
+	return strconv.Itoa(status/100) + "xx"
+}
+
+// boolValue 将布尔值转换为指标值 - 内部辅助函数
+func boolValue(v bool) int {
+	if v {
+		return 1
+	}
+	return 0
+}
+
+// roundTripFunc 函数形式的 http.RoundTripper
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+// RoundTrip 执行请求
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
@@ -0,0 +1,130 @@
+package metrics
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/youfun/gofastcaddy"
+	"github.com/youfun/gofastcaddy/pkg/clienttest"
+)
+
+const config = `{"apps":{"http":{"servers":{"srv0":{"listen":[":443"],"routes":[` +
+	`{"@id":"example.com","match":[{"host":["example.com"]}],"handle":[{"handler":"reverse_proxy","upstreams":[{"dial":"app:80"}]}]},` +
+	`{"@id":"wildcard-example.org","match":[{"host":["*.example.org"]}],"handle":[{"handler":"subroute"}]}` +
+	`]}}}}}`
+
+func TestCollector(t *testing.T) {
+	// 数据面：httptest 的 TLS 证书对任意 SNI 都返回同一证书
+	dataPlane := httptest.NewTLSServer(http.NotFoundHandler())
+	defer dataPlane.Close()
+	expiry := dataPlane.Certificate().NotAfter.Unix()
+
+	tests := []struct {
+		name       string
+		fastCaddy  bool // 是否设置 FastCaddy
+		certExpiry bool
+		metrics    []string // 比较的指标名
+		want       string
+	}{
+		{
+			name:    "只有请求计数",
+			metrics: []string{"fastcaddy_admin_requests_total", "fastcaddy_state_up"},
+			want: `
+# HELP fastcaddy_admin_requests_total 管理 API 请求数，按方法和状态码区分
+# TYPE fastcaddy_admin_requests_total counter
+fastcaddy_admin_requests_total{code="200",method="GET"} 1
+`,
+		},
+		{
+			name:      "受管状态",
+			fastCaddy: true,
+			metrics:   []string{"fastcaddy_state_up", "fastcaddy_managed_routes", "fastcaddy_wildcard_domains", "fastcaddy_cert_expiry_seconds"},
+			want: `
+# HELP fastcaddy_managed_routes 默认服务器上带 @id 的顶层路由数
+# TYPE fastcaddy_managed_routes gauge
+fastcaddy_managed_routes 2
+# HELP fastcaddy_state_up 最近一次读取受管状态是否成功
+# TYPE fastcaddy_state_up gauge
+fastcaddy_state_up 1
+# HELP fastcaddy_wildcard_domains 通配符域名路由数
+# TYPE fastcaddy_wildcard_domains gauge
+fastcaddy_wildcard_domains 1
+`,
+		},
+		{
+			name:       "证书到期时间",
+			fastCaddy:  true,
+			certExpiry: true,
+			metrics:    []string{"fastcaddy_cert_expiry_seconds"},
+			want: fmt.Sprintf(`
+# HELP fastcaddy_cert_expiry_seconds 数据面为主机名提供的证书的到期时间 (Unix 时间戳，秒)
+# TYPE fastcaddy_cert_expiry_seconds gauge
+fastcaddy_cert_expiry_seconds{host="example.com"} %d
+`, expiry),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := clienttest.NewFakeCaddy(config)
+			defer fake.Close()
+
+			collector := NewCollector()
+			collector.CertExpiry = tt.certExpiry
+			fc := gofastcaddy.New(
+				gofastcaddy.WithBaseURL(fake.URL),
+				gofastcaddy.WithHTTPClient(&http.Client{Transport: collector.Transport(nil)}),
+			)
+			fc.DataPlane.Address = dataPlane.Listener.Addr().String()
+			if _, err := fc.API.GetConfig("/apps"); err != nil {
+				t.Fatal(err)
+			}
+			if tt.fastCaddy {
+				collector.FastCaddy = fc
+			}
+
+			if err := testutil.CollectAndCompare(collector, strings.NewReader(tt.want), tt.metrics...); err != nil {
+				t.Error(err)
+			}
+			if problems, err := testutil.CollectAndLint(collector); err != nil || len(problems) > 0 {
+				t.Errorf("指标不符合规范: %v %v", problems, err)
+			}
+		})
+	}
+}
+
+func TestCollectorInstrumentation(t *testing.T) {
+	fake := clienttest.NewFakeCaddy(config)
+	defer fake.Close()
+
+	collector := NewCollector()
+	fc := gofastcaddy.New(gofastcaddy.WithBaseURL(fake.URL), gofastcaddy.WithInstrumentation(collector))
+	fc.API.GetConfig("/apps")
+	fc.API.DeleteConfig("/apps/missing")
+
+	want := `
+# HELP fastcaddy_admin_calls_total 管理 API 调用数，按方法和状态类别区分 (请求失败为 error)
+# TYPE fastcaddy_admin_calls_total counter
+fastcaddy_admin_calls_total{class="2xx",method="GET"} 1
+fastcaddy_admin_calls_total{class="4xx",method="DELETE"} 1
+# HELP fastcaddy_admin_calls_in_flight 进行中的管理 API 调用数
+# TYPE fastcaddy_admin_calls_in_flight gauge
+fastcaddy_admin_calls_in_flight 0
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(want), "fastcaddy_admin_calls_total", "fastcaddy_admin_calls_in_flight"); err != nil {
+		t.Error(err)
+	}
+	if n := testutil.CollectAndCount(collector, "fastcaddy_admin_call_duration_seconds"); n != 2 {
+		t.Errorf("耗时指标数 = %d, 期望 2", n)
+	}
+
+	rec := httptest.NewRecorder()
+	collector.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if !strings.Contains(rec.Body.String(), `fastcaddy_admin_calls_total{class="2xx",method="GET"} 1`) {
+		t.Errorf("ServeHTTP 输出缺少调用计数:\n%s", rec.Body.String())
+	}
+}
@@ -0,0 +1,190 @@
+// Package spec 描述站点的声明式配置，并支持按环境叠加差异
+//
+// 一个 Spec 由基础 SiteSpec 和各环境的 Overlay 组成，Resolve(env) 将两者合并为具体的 SiteSpec。
+// 解析是纯函数：不访问网络或环境变量，相同的输入总是得到相同的结果
+package spec
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// TLS 模式
+const (
+	TLSAuto     = "auto"     // 由 ACME 自动申请证书
+	TLSInternal = "internal" // 使用 Caddy 内部 CA
+	TLSOff      = "off"      // 不启用 HTTPS
+)
+
+// Site 单个站点 - 一个主机名反向代理到一组上游
+type Site struct {
+	Host      string   `json:"host"`          // 主机名，可包含 ${name} 占位符
+	Upstreams []string `json:"upstreams"`     // 上游地址 (host:port)，可包含 ${name} 占位符
+	TLS       string   `json:"tls,omitempty"` // TLS 模式，为空时使用 SiteSpec.TLS
+}
+
+// SiteSpec 一组站点的声明式配置
+type SiteSpec struct {
+	TLS   string `json:"tls,omitempty"` // 默认 TLS 模式
+	Sites []Site `json:"sites"`         // 站点列表
+}
+
+// Overlay 单个环境相对基础配置的差异
+type Overlay struct {
+	HostSuffix    string            `json:"host_suffix,omitempty"`    // 追加到每个主机名第一段之后的后缀，如 "-staging"
+	UpstreamHosts map[string]string `json:"upstream_hosts,omitempty"` // 上游主机替换表，键为基础配置中的主机
+	TLS           string            `json:"tls,omitempty"`            // 覆盖所有站点的 TLS 模式
+	Vars          map[string]string `json:"vars,omitempty"`           // 占位符的取值
+}
+
+// Spec 基础配置与各环境的差异
+type Spec struct {
+	Base         SiteSpec           `json:"base"`         // 基础配置
+	Environments map[string]Overlay `json:"environments"` // 各环境的差异，键为环境名称
+}
+
+// placeholderPattern 占位符 ${name} 的匹配规则
+var placeholderPattern = regexp.MustCompile(`\$\{([A-Za-z0-9_.-]+)\}`)
+
+// Resolve 将基础配置与指定环境的差异合并为具体的 SiteSpec
+// 环境不存在、占位符缺少取值、TLS 模式无效或合并后主机名重复时返回错误
+func (s Spec) Resolve(env string) (SiteSpec, error) {
+	overlay, ok := s.Environments[env]
+	if !ok {
+		return SiteSpec{}, fmt.Errorf("环境 %s 不存在 (可用: %s)", env, strings.Join(s.EnvironmentNames(), ", "))
+	}
+
+	resolved := SiteSpec{TLS: s.Base.TLS}
+	if overlay.TLS != "" {
+		resolved.TLS = overlay.TLS
+	}
+
+	var missing []string
+	expand := func(value string) string {
+		return placeholderPattern.ReplaceAllStringFunc(value, func(match string) string {
+			name := placeholderPattern.FindStringSubmatch(match)[1]
+			v, ok := overlay.Vars[name]
+			if !ok {
+				missing = append(missing, name)
+				return match
+			}
+			return v
+		})
+	}
+
+	seen := make(map[string]bool)
+	for _, site := range s.Base.Sites {
+		out := Site{
+			Host: applySuffix(expand(site.Host), overlay.HostSuffix),
+			TLS:  site.TLS,
+		}
+		if overlay.TLS != "" {
+			out.TLS = overlay.TLS
+		}
+		for _, upstream := range site.Upstreams {
+			out.Upstreams = append(out.Upstreams, substituteHost(expand(upstream), overlay.UpstreamHosts))
+		}
+
+		if seen[out.Host] {
+			return SiteSpec{}, fmt.Errorf("环境 %s 中主机名 %s 重复", env, out.Host)
+		}
+		seen[out.Host] = true
+		resolved.Sites = append(resolved.Sites, out)
+	}
+
+	if len(missing) > 0 {
+		return SiteSpec{}, fmt.Errorf("环境 %s 缺少占位符的取值: %s", env, strings.Join(uniqueSorted(missing), ", "))
+	}
+	if err := resolved.Validate(); err != nil {
+		return SiteSpec{}, fmt.Errorf("环境 %s: %w", env, err)
+	}
+	return resolved, nil
+}
+
+// EnvironmentNames 返回全部环境名称，按字典序排列
+func (s Spec) EnvironmentNames() []string {
+	names := make([]string, 0, len(s.Environments))
+	for name := range s.Environments {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Validate 校验具体配置：主机名非空、至少一个上游、TLS 模式有效且没有残留的占位符
+func (s SiteSpec) Validate() error {
+	if err := validTLS(s.TLS); err != nil {
+		return err
+	}
+	for _, site := range s.Sites {
+		if site.Host == "" {
+			return fmt.Errorf("站点主机名不能为空")
+		}
+		if len(site.Upstreams) == 0 {
+			return fmt.Errorf("站点 %s 没有上游", site.Host)
+		}
+		if err := validTLS(site.TLS); err != nil {
+			return fmt.Errorf("站点 %s: %w", site.Host, err)
+		}
+		for _, value := range append([]string{site.Host}, site.Upstreams...) {
+			if placeholderPattern.MatchString(value) {
+				return fmt.Errorf("站点 %s 中存在未解析的占位符: %s", site.Host, value)
+			}
+		}
+	}
+	return nil
+}
+
+// validTLS 校验 TLS 模式 - 内部辅助函数
+func validTLS(mode string) error {
+	switch mode {
+	case "", TLSAuto, TLSInternal, TLSOff:
+		return nil
+	}
+	return fmt.Errorf("无效的 TLS 模式: %s", mode)
+}
+
+// applySuffix 在主机名第一段之后追加后缀 - 内部辅助函数
+// 例如 api.example.com + "-staging" 得到 api-staging.example.com；通配符主机名保持不变
+func applySuffix(host, suffix string) string {
+	if suffix == "" || strings.HasPrefix(host, "*.") {
+		return host
+	}
+	if i := strings.Index(host, "."); i >= 0 {
+		return host[:i] + suffix + host[i:]
+	}
+	return host + suffix
+}
+
+// substituteHost 按替换表替换上游地址中的主机部分 - 内部辅助函数
+func substituteHost(upstream string, hosts map[string]string) string {
+	if len(hosts) == 0 {
+		return upstream
+	}
+	host, port, err := net.SplitHostPort(upstream)
+	if err != nil {
+		if replacement, ok := hosts[upstream]; ok {
+			return replacement
+		}
+		return upstream
+	}
+	if replacement, ok := hosts[host]; ok {
+		return net.JoinHostPort(replacement, port)
+	}
+	return upstream
+}
+
+// uniqueSorted 去重并排序 - 内部辅助函数
+func uniqueSorted(values []string) []string {
+	sort.Strings(values)
+	var out []string
+	for i, v := range values {
+		if i == 0 || v != values[i-1] {
+			out = append(out, v)
+		}
+	}
+	return out
+}
@@ -0,0 +1,132 @@
+package spec_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/youfun/gofastcaddy/pkg/configgen"
+	"github.com/youfun/gofastcaddy/pkg/spec"
+)
+
+// update 为 true 时重新生成 testdata 中的 golden 文件: go test ./pkg/spec -update
+var update = flag.Bool("update", false, "重新生成 golden 文件")
+
+// loadSpec 读取 testdata/spec.json - 测试辅助函数
+func loadSpec(t *testing.T) spec.Spec {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", "spec.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var s spec.Spec
+	if err := json.Unmarshal(data, &s); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// TestResolveGolden 每个环境解析出的站点配置和生成的 Caddy 配置与 golden 文件一致
+func TestResolveGolden(t *testing.T) {
+	s := loadSpec(t)
+	for _, env := range s.EnvironmentNames() {
+		t.Run(env, func(t *testing.T) {
+			resolved, err := s.Resolve(env)
+			if err != nil {
+				t.Fatal(err)
+			}
+			config, err := configgen.Build(resolved)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := json.MarshalIndent(map[string]interface{}{"spec": resolved, "config": config}, "", "  ")
+			if err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, '\n')
+
+			golden := filepath.Join("testdata", env+".golden.json")
+			if *update {
+				if err := os.WriteFile(golden, got, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("环境 %s 的结果与 %s 不一致:\n%s", env, golden, got)
+			}
+
+			// 解析是纯函数，重复解析得到相同的结果
+			again, err := s.Resolve(env)
+			if err != nil {
+				t.Fatal(err)
+			}
+			first, _ := json.Marshal(resolved)
+			second, _ := json.Marshal(again)
+			if !bytes.Equal(first, second) {
+				t.Errorf("重复解析结果不同:\n%s\n%s", first, second)
+			}
+		})
+	}
+}
+
+func TestResolveErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    spec.Spec
+		env     string
+		wantErr string
+	}{
+		{
+			name:    "环境不存在",
+			spec:    spec.Spec{Environments: map[string]spec.Overlay{"prod": {}}},
+			env:     "qa",
+			wantErr: "环境 qa 不存在",
+		},
+		{
+			name: "占位符缺少取值",
+			spec: spec.Spec{
+				Base:         spec.SiteSpec{Sites: []spec.Site{{Host: "api.${domain}", Upstreams: []string{"${api}:80"}}}},
+				Environments: map[string]spec.Overlay{"prod": {}},
+			},
+			env:     "prod",
+			wantErr: "缺少占位符的取值: api, domain",
+		},
+		{
+			name: "合并后主机名重复",
+			spec: spec.Spec{
+				Base: spec.SiteSpec{Sites: []spec.Site{
+					{Host: "${a}.example.com", Upstreams: []string{"a:80"}},
+					{Host: "${b}.example.com", Upstreams: []string{"b:80"}},
+				}},
+				Environments: map[string]spec.Overlay{"prod": {Vars: map[string]string{"a": "app", "b": "app"}}},
+			},
+			env:     "prod",
+			wantErr: "主机名 app.example.com 重复",
+		},
+		{
+			name: "无效的 TLS 模式",
+			spec: spec.Spec{
+				Base:         spec.SiteSpec{Sites: []spec.Site{{Host: "app.example.com", Upstreams: []string{"app:80"}}}},
+				Environments: map[string]spec.Overlay{"prod": {TLS: "manual"}},
+			},
+			env:     "prod",
+			wantErr: "无效的 TLS 模式: manual",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.spec.Resolve(tt.env)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, 期望包含 %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// knownApps fastcaddy 能够理解并管理的 Caddy 应用
+var knownApps = map[string]bool{"http": true, "tls": true, "pki": true}
+
+// UnmarshalJSON 解码完整配置
+// 已知应用 (http、tls、pki) 解码为通用 JSON 结构；其他应用 (如 layer4、events)
+// 以 json.RawMessage 原样保存，再次序列化时按字节原样输出，不会被本库修改；
+// apps 以外的顶层配置 (admin、logging、storage 等) 同样原样保存在 Extra 中
+func (c *CaddyConfig) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*c = CaddyConfig{}
+	for key, value := range raw {
+		if key == "apps" {
+			continue
+		}
+		if c.Extra == nil {
+			c.Extra = make(map[string]json.RawMessage, len(raw))
+		}
+		c.Extra[key] = value
+	}
+
+	var apps map[string]json.RawMessage
+	if value, ok := raw["apps"]; ok {
+		if err := json.Unmarshal(value, &apps); err != nil {
+			return err
+		}
+	}
+	if apps == nil {
+		return nil
+	}
+	c.Apps = make(map[string]interface{}, len(apps))
+	for name, value := range apps {
+		if !knownApps[name] {
+			c.Apps[name] = value
+			continue
+		}
+		var app interface{}
+		if err := json.Unmarshal(value, &app); err != nil {
+			return fmt.Errorf("解析应用 %s 失败: %w", name, err)
+		}
+		c.Apps[name] = app
+	}
+	return nil
+}
+
+// MarshalJSON 序列化完整配置 - Extra 中的顶层配置与 apps 合并到同一层级
+func (c CaddyConfig) MarshalJSON() ([]byte, error) {
+	merged := make(map[string]interface{}, len(c.Extra)+1)
+	for key, value := range c.Extra {
+		merged[key] = value
+	}
+	merged["apps"] = c.Apps
+	return marshalJSON(merged)
+}
+
+// KnownApps 返回配置中 fastcaddy 能够管理的应用名称，按字典序排列
+func (c *CaddyConfig) KnownApps() []string {
+	var names []string
+	for name := range c.Apps {
+		if knownApps[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// RawApp 返回指定应用的原始 JSON - 未知应用返回解码时保存的原始字节
+// 应用不存在时返回 nil
+func (c *CaddyConfig) RawApp(name string) (json.RawMessage, error) {
+	app, ok := c.Apps[name]
+	if !ok {
+		return nil, nil
+	}
+	if raw, ok := app.(json.RawMessage); ok {
+		return raw, nil
+	}
+	data, err := json.Marshal(app)
+	if err != nil {
+		return nil, fmt.Errorf("序列化应用 %s 失败: %w", name, err)
+	}
+	return data, nil
+}
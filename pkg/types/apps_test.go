@@ -0,0 +1,83 @@
+package types
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestCaddyConfigRoundTrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "顶层 admin、logging、storage",
+			input: `{"admin":{"listen":"unix//run/caddy.sock"},"apps":{"http":{"servers":{}}},"logging":{"logs":{"default":{"level":"DEBUG"}}},"storage":{"module":"file_system","root":"/data"}}`,
+			want:  `{"admin":{"listen":"unix//run/caddy.sock"},"apps":{"http":{"servers":{}}},"logging":{"logs":{"default":{"level":"DEBUG"}}},"storage":{"module":"file_system","root":"/data"}}`,
+		},
+		{
+			name:  "未知应用原样保留",
+			input: `{"apps":{"layer4":{"servers":{"a":{"listen":[":22"]}}}}}`,
+			want:  `{"apps":{"layer4":{"servers":{"a":{"listen":[":22"]}}}}}`,
+		},
+		{
+			name:  "未知应用保留键顺序",
+			input: `{"apps":{"events":{"subscriptions":[{"handlers":[],"events":["cert_obtained"]}]}}}`,
+			want:  `{"apps":{"events":{"subscriptions":[{"handlers":[],"events":["cert_obtained"]}]}}}`,
+		},
+		{
+			name:  "只有顶层配置",
+			input: `{"admin":{"disabled":true}}`,
+			want:  `{"admin":{"disabled":true},"apps":null}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg CaddyConfig
+			if err := json.Unmarshal([]byte(tt.input), &cfg); err != nil {
+				t.Fatalf("解码失败: %v", err)
+			}
+			data, err := json.Marshal(cfg)
+			if err != nil {
+				t.Fatalf("序列化失败: %v", err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("序列化结果 = %s\n期望 = %s", data, tt.want)
+			}
+		})
+	}
+}
+
+func TestKnownAppsAndRawApp(t *testing.T) {
+	var cfg CaddyConfig
+	input := `{"apps":{"tls":{"automation":{}},"layer4":{"servers": {}},"http":{"servers":{}}}}`
+	if err := json.Unmarshal([]byte(input), &cfg); err != nil {
+		t.Fatal(err)
+	}
+	if got := cfg.KnownApps(); !reflect.DeepEqual(got, []string{"http", "tls"}) {
+		t.Errorf("KnownApps = %v", got)
+	}
+
+	tests := []struct {
+		name string
+		app  string
+		want string
+	}{
+		{name: "未知应用返回原始字节", app: "layer4", want: `{"servers": {}}`},
+		{name: "已知应用重新序列化", app: "tls", want: `{"automation":{}}`},
+		{name: "不存在的应用", app: "pki", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw, err := cfg.RawApp(tt.app)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(raw) != tt.want {
+				t.Errorf("RawApp(%s) = %s, 期望 %s", tt.app, raw, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package types
+
+// EventsApp Caddy 的 events 应用配置 - 对应 /apps/events
+type EventsApp struct {
+	Subscriptions []EventSubscription `json:"subscriptions,omitempty"` // 事件订阅列表
+}
+
+// EventSubscription 单个事件订阅 - 将事件名称绑定到处理器
+type EventSubscription struct {
+	ID       string             `json:"@id,omitempty"`     // 配置 ID，便于单独更新或删除
+	Events   []string           `json:"events,omitempty"`  // 订阅的事件名称 (为空表示全部事件)
+	Modules  []string           `json:"modules,omitempty"` // 只接收这些模块发出的事件 (为空表示不限)
+	Handlers []ExecEventHandler `json:"handlers"`          // 事件处理器
+}
+
+// ExecEventHandler 执行外部命令的事件处理器 - 对应 caddy-events-exec 插件的 events.handlers.exec
+// 需要使用 xcaddy build --with github.com/mholt/caddy-events-exec 构建 Caddy；
+// 命令参数中可以使用 {event.name}、{event.data.*} 等占位符
+type ExecEventHandler struct {
+	Handler string   `json:"handler"`           // 处理器名称，固定为 "exec"
+	Command string   `json:"command"`           // 要执行的命令
+	Args    []string `json:"args,omitempty"`    // 命令参数
+	Timeout string   `json:"timeout,omitempty"` // 命令超时，如 "10s"
+	Abort   bool     `json:"abort,omitempty"`   // 命令失败时中止事件 (仅对可中止的事件有效)
+}
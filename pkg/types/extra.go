@@ -0,0 +1,80 @@
+package types
+
+import "encoding/json"
+
+// routeFields Route 已建模的 JSON 字段
+var routeFields = []string{"@id", "match", "handle", "terminal"}
+
+// upstreamFields Upstream 已建模的 JSON 字段
+var upstreamFields = []string{"dial"}
+
+// MarshalJSON 序列化路由 - Extra 中的字段展开到顶层，与已建模字段同名的键会被忽略
+func (r Route) MarshalJSON() ([]byte, error) {
+	type plain Route
+	data, err := marshalJSON(plain(r))
+	if err != nil {
+		return nil, err
+	}
+	return mergeExtra(data, r.Extra)
+}
+
+// UnmarshalJSON 解码路由 - 未建模的字段 (如 group) 保留在 Extra，重新序列化时原样输出
+func (r *Route) UnmarshalJSON(data []byte) error {
+	type plain Route
+	var decoded plain
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	*r = Route(decoded)
+	return unmarshalExtra(data, routeFields, &r.Extra)
+}
+
+// MarshalJSON 序列化上游 - Extra 中的字段展开到顶层，与 dial 同名的键会被忽略
+func (u Upstream) MarshalJSON() ([]byte, error) {
+	type plain Upstream
+	data, err := marshalJSON(plain(u))
+	if err != nil {
+		return nil, err
+	}
+	return mergeExtra(data, u.Extra)
+}
+
+// UnmarshalJSON 解码上游 - 未建模的字段 (如 max_requests) 保留在 Extra
+func (u *Upstream) UnmarshalJSON(data []byte) error {
+	type plain Upstream
+	var decoded plain
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	*u = Upstream(decoded)
+	return unmarshalExtra(data, upstreamFields, &u.Extra)
+}
+
+// mergeExtra 将 Extra 中的字段合并到已序列化的 JSON 对象中 - 内部辅助函数
+func mergeExtra(data []byte, extra map[string]interface{}) ([]byte, error) {
+	if len(extra) == 0 {
+		return data, nil
+	}
+	var merged map[string]interface{}
+	if err := json.Unmarshal(data, &merged); err != nil {
+		return nil, err
+	}
+	for k, v := range extra {
+		if _, ok := merged[k]; !ok {
+			merged[k] = v
+		}
+	}
+	return marshalJSON(merged)
+}
+
+// unmarshalExtra 将已建模字段之外的原始字段解码到 Extra - 内部辅助函数
+func unmarshalExtra(data []byte, fields []string, extra *map[string]interface{}) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	for _, field := range fields {
+		delete(raw, field)
+	}
+	return decodeExtra(raw, extra)
+}
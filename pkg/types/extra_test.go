@@ -0,0 +1,54 @@
+package types
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestExtraFieldsRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		into func() interface{}
+	}{
+		{
+			name: "Route group",
+			data: `{"@id":"app","group":"apps","handle":[{"handler":"file_server"}],"match":[{"host":["app.example.com"]}],"terminal":true}`,
+			into: func() interface{} { return new(Route) },
+		},
+		{
+			name: "Upstream max_requests",
+			data: `{"dial":"app:80","max_requests":100}`,
+			into: func() interface{} { return new(Upstream) },
+		},
+		{
+			name: "反向代理中的上游和负载均衡",
+			data: `{"handler":"reverse_proxy","load_balancing":{"selection_policy":{"policy":"first"}},"upstreams":[{"dial":"a:80","max_requests":5},{"dial":"b:80"}]}`,
+			into: func() interface{} { return new(Handler) },
+		},
+		{
+			name: "子路由中的路由",
+			data: `{"handler":"subroute","routes":[{"group":"g1","handle":[{"handler":"reverse_proxy","upstreams":[{"dial":"a:80","max_requests":5}]}],"match":null,"terminal":false}]}`,
+			into: func() interface{} { return new(Handler) },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value := tt.into()
+			if err := json.Unmarshal([]byte(tt.data), value); err != nil {
+				t.Fatalf("解码失败: %v", err)
+			}
+			if got := encodeUnescaped(t, value); got != tt.data {
+				t.Errorf("往返结果 = %s\n期望 = %s", got, tt.data)
+			}
+		})
+	}
+}
+
+func TestExtraDoesNotOverrideModeledFields(t *testing.T) {
+	route := Route{ID: "app", Terminal: true, Extra: map[string]interface{}{"terminal": false, "group": "g"}}
+	want := `{"@id":"app","group":"g","handle":null,"match":null,"terminal":true}`
+	if got := encodeUnescaped(t, route); got != want {
+		t.Errorf("序列化结果 = %s\n期望 = %s", got, want)
+	}
+}
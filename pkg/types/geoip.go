@@ -0,0 +1,69 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+)
+
+// GeoIPMatcher 按客户端 IP 所属国家匹配请求 - 对应 caddy-maxmind-geolocation 插件的 maxmind_geolocation 匹配器
+// 需要使用 xcaddy build --with github.com/porech/caddy-maxmind-geolocation 构建 Caddy，序列化结果如：
+//
+//	"maxmind_geolocation": {"db_path": "/usr/share/GeoIP/GeoLite2-Country.mmdb", "deny_countries": ["CN", "RU"]}
+//
+// 插件在同时设置时先检查 deny_countries，再检查 allow_countries
+type GeoIPMatcher struct {
+	DBPath         string   `json:"db_path"`                   // MaxMind 国家数据库 (.mmdb) 的路径
+	AllowCountries []string `json:"allow_countries,omitempty"` // 允许的国家代码 (ISO 3166-1 alpha-2)
+	DenyCountries  []string `json:"deny_countries,omitempty"`  // 拒绝的国家代码 (ISO 3166-1 alpha-2)
+}
+
+// GeoIPMatcherModule GeoIPMatcher 的匹配器名称
+const GeoIPMatcherModule = "maxmind_geolocation"
+
+// CaddyMatcher 返回匹配器名称
+func (GeoIPMatcher) CaddyMatcher() string { return GeoIPMatcherModule }
+
+// Validate 校验数据库路径和国家代码
+func (m GeoIPMatcher) Validate() error {
+	if m.DBPath == "" {
+		return fmt.Errorf("GeoIP 数据库路径不能为空")
+	}
+	if len(m.AllowCountries) == 0 && len(m.DenyCountries) == 0 {
+		return fmt.Errorf("至少需要设置一个允许或拒绝的国家")
+	}
+	for _, list := range [][]string{m.AllowCountries, m.DenyCountries} {
+		for _, code := range list {
+			if !IsCountryCode(code) {
+				return fmt.Errorf("无效的国家代码: %q (应为 ISO 3166-1 alpha-2, 如 US)", code)
+			}
+		}
+	}
+	return nil
+}
+
+// IsCountryCode 判断 code 是否为已分配的 ISO 3166-1 alpha-2 国家代码 (须为大写)
+func IsCountryCode(code string) bool {
+	return countryCodes[code]
+}
+
+// countryCodes 已分配的 ISO 3166-1 alpha-2 国家代码
+var countryCodes = func() map[string]bool {
+	codes := make(map[string]bool)
+	for _, code := range strings.Fields(`
+		AD AE AF AG AI AL AM AO AQ AR AS AT AU AW AX AZ BA BB BD BE BF BG BH BI BJ BL BM BN BO BQ
+		BR BS BT BV BW BY BZ CA CC CD CF CG CH CI CK CL CM CN CO CR CU CV CW CX CY CZ DE DJ DK DM
+		DO DZ EC EE EG EH ER ES ET FI FJ FK FM FO FR GA GB GD GE GF GG GH GI GL GM GN GP GQ GR GS
+		GT GU GW GY HK HM HN HR HT HU ID IE IL IM IN IO IQ IR IS IT JE JM JO JP KE KG KH KI KM KN
+		KP KR KW KY KZ LA LB LC LI LK LR LS LT LU LV LY MA MC MD ME MF MG MH MK ML MM MN MO MP MQ
+		MR MS MT MU MV MW MX MY MZ NA NC NE NF NG NI NL NO NP NR NU NZ OM PA PE PF PG PH PK PL PM
+		PN PR PS PT PW PY QA RE RO RS RU RW SA SB SC SD SE SG SH SI SJ SK SL SM SN SO SR SS ST SV
+		SX SY SZ TC TD TF TG TH TJ TK TL TM TN TO TR TT TV TW TZ UA UG UM US UY UZ VA VC VE VG VI
+		VN VU WF WS YE YT ZA ZM ZW`) {
+		codes[code] = true
+	}
+	return codes
+}()
+
+func init() {
+	RegisterMatcherModule(GeoIPMatcherModule, func() MatcherModule { return new(GeoIPMatcher) })
+}
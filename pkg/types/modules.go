@@ -0,0 +1,336 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// HandlerModule 可注册的处理器模块 - 对应 Caddy 的 http.handlers.<name>
+// 模块自身序列化为处理器的字段，handler 字段由 CaddyHandler 的返回值自动填充
+type HandlerModule interface {
+	CaddyHandler() string
+}
+
+// MatcherModule 可注册的匹配器模块 - 对应 Caddy 的 http.matchers.<name>
+// 模块自身序列化为匹配器集合中 CaddyMatcher 键的值
+type MatcherModule interface {
+	CaddyMatcher() string
+}
+
+// Validator 可选接口 - 模块实现后在序列化前进行校验
+type Validator interface {
+	Validate() error
+}
+
+// builtinHandler 内置处理器模块 - 解码后回填到 Handler 的已建模字段
+type builtinHandler interface {
+	HandlerModule
+	apply(h *Handler)
+	fields() []string
+}
+
+// builtinMatcher 内置匹配器模块 - 解码后回填到 RouteMatch 的已建模字段
+type builtinMatcher interface {
+	MatcherModule
+	apply(m *RouteMatch)
+}
+
+// 模块注册表
+var (
+	registryMu     sync.RWMutex
+	handlerModules = make(map[string]func() HandlerModule)
+	matcherModules = make(map[string]func() MatcherModule)
+)
+
+// RegisterHandlerModule 按模块名称注册处理器模块
+// factory 需返回可供 JSON 解码的指针；名称为空或重复注册时 panic
+func RegisterHandlerModule(name string, factory func() HandlerModule) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if name == "" || factory == nil {
+		panic("types: 处理器模块名称和构造函数不能为空")
+	}
+	if _, ok := handlerModules[name]; ok {
+		panic(fmt.Sprintf("types: 处理器模块 %s 重复注册", name))
+	}
+	handlerModules[name] = factory
+}
+
+// RegisterMatcherModule 按模块名称注册匹配器模块
+// factory 需返回可供 JSON 解码的指针；名称为空或重复注册时 panic
+func RegisterMatcherModule(name string, factory func() MatcherModule) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if name == "" || factory == nil {
+		panic("types: 匹配器模块名称和构造函数不能为空")
+	}
+	if _, ok := matcherModules[name]; ok {
+		panic(fmt.Sprintf("types: 匹配器模块 %s 重复注册", name))
+	}
+	matcherModules[name] = factory
+}
+
+// HandlerModules 返回已注册的处理器模块名称（含内置模块），按字典序排列
+func HandlerModules() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	names := make([]string, 0, len(handlerModules))
+	for name := range handlerModules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// MatcherModules 返回已注册的匹配器模块名称（含内置模块），按字典序排列
+func MatcherModules() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	names := make([]string, 0, len(matcherModules))
+	for name := range matcherModules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// lookupHandlerModule 查找处理器模块构造函数 - 内部辅助函数
+func lookupHandlerModule(name string) (func() HandlerModule, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	factory, ok := handlerModules[name]
+	return factory, ok
+}
+
+// lookupMatcherModule 查找匹配器模块构造函数 - 内部辅助函数
+func lookupMatcherModule(name string) (func() MatcherModule, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	factory, ok := matcherModules[name]
+	return factory, ok
+}
+
+// ReverseProxyHandler 内置反向代理处理器模块
+type ReverseProxyHandler struct {
+	Upstreams []Upstream `json:"upstreams,omitempty"`
+}
+
+// CaddyHandler 返回处理器名称
+func (ReverseProxyHandler) CaddyHandler() string { return "reverse_proxy" }
+
+func (p *ReverseProxyHandler) apply(h *Handler) { h.Upstreams = p.Upstreams }
+func (p *ReverseProxyHandler) fields() []string { return []string{"upstreams"} }
+
+// SubrouteHandler 内置子路由处理器模块
+type SubrouteHandler struct {
+	Routes []Route `json:"routes,omitempty"`
+}
+
+// CaddyHandler 返回处理器名称
+func (SubrouteHandler) CaddyHandler() string { return "subroute" }
+
+func (s *SubrouteHandler) apply(h *Handler) { h.Routes = s.Routes }
+func (s *SubrouteHandler) fields() []string { return []string{"routes"} }
+
+// HostMatcher 内置主机名匹配器模块
+type HostMatcher []string
+
+// CaddyMatcher 返回匹配器名称
+func (HostMatcher) CaddyMatcher() string { return "host" }
+
+func (m *HostMatcher) apply(r *RouteMatch) { r.Host = *m }
+
+// PathMatcher 内置路径匹配器模块
+type PathMatcher []string
+
+// CaddyMatcher 返回匹配器名称
+func (PathMatcher) CaddyMatcher() string { return "path" }
+
+func (m *PathMatcher) apply(r *RouteMatch) { r.Path = *m }
+
+func init() {
+	RegisterHandlerModule("reverse_proxy", func() HandlerModule { return new(ReverseProxyHandler) })
+	RegisterHandlerModule("subroute", func() HandlerModule { return new(SubrouteHandler) })
+	RegisterMatcherModule("host", func() MatcherModule { return new(HostMatcher) })
+	RegisterMatcherModule("path", func() MatcherModule { return new(PathMatcher) })
+}
+
+// MarshalJSON 序列化处理器
+// 设置了 Module 时由模块提供字段；Extra 中的字段展开到顶层，与已有字段同名的键会被忽略
+func (h Handler) MarshalJSON() ([]byte, error) {
+	type plain Handler
+	if h.Module != nil {
+		if v, ok := h.Module.(Validator); ok {
+			if err := v.Validate(); err != nil {
+				return nil, fmt.Errorf("处理器 %s 校验失败: %w", h.Module.CaddyHandler(), err)
+			}
+		}
+		h.Handler = h.Module.CaddyHandler()
+	}
+
+	data, err := marshalJSON(plain(h))
+	if err != nil || (h.Module == nil && len(h.Extra) == 0) {
+		return data, err
+	}
+
+	var merged map[string]interface{}
+	if err := json.Unmarshal(data, &merged); err != nil {
+		return nil, err
+	}
+	if h.Module != nil {
+		fields, err := objectFields(h.Module)
+		if err != nil {
+			return nil, fmt.Errorf("序列化处理器 %s 失败: %w", h.Handler, err)
+		}
+		for k, v := range fields {
+			merged[k] = v
+		}
+		merged["handler"] = h.Handler
+	}
+	for k, v := range h.Extra {
+		if _, ok := merged[k]; !ok {
+			merged[k] = v
+		}
+	}
+	return marshalJSON(merged)
+}
+
+// UnmarshalJSON 解码处理器 - 按 handler 名称在注册表中分派
+// 内置模块回填到已建模字段，自定义模块存入 Module，未注册处理器的字段保留在 Extra
+func (h *Handler) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*h = Handler{}
+	if name, ok := raw["handler"]; ok {
+		if err := json.Unmarshal(name, &h.Handler); err != nil {
+			return fmt.Errorf("解析处理器名称失败: %w", err)
+		}
+	}
+	delete(raw, "handler")
+
+	if factory, ok := lookupHandlerModule(h.Handler); ok {
+		module := factory()
+		if err := json.Unmarshal(data, module); err != nil {
+			return fmt.Errorf("解析处理器 %s 失败: %w", h.Handler, err)
+		}
+		builtin, ok := module.(builtinHandler)
+		if !ok {
+			h.Module = module
+			return nil
+		}
+		builtin.apply(h)
+		for _, field := range builtin.fields() {
+			delete(raw, field)
+		}
+	}
+
+	return decodeExtra(raw, &h.Extra)
+}
+
+// MarshalJSON 序列化匹配器集合，将 Modules 和 Extra 合并到同一层级
+func (m RouteMatch) MarshalJSON() ([]byte, error) {
+	type plain RouteMatch
+	data, err := marshalJSON(plain(m))
+	if err != nil || (len(m.Modules) == 0 && len(m.Extra) == 0) {
+		return data, err
+	}
+
+	var merged map[string]interface{}
+	if err := json.Unmarshal(data, &merged); err != nil {
+		return nil, err
+	}
+	for _, module := range m.Modules {
+		if v, ok := module.(Validator); ok {
+			if err := v.Validate(); err != nil {
+				return nil, fmt.Errorf("匹配器 %s 校验失败: %w", module.CaddyMatcher(), err)
+			}
+		}
+		merged[module.CaddyMatcher()] = module
+	}
+	for k, v := range m.Extra {
+		if _, ok := merged[k]; !ok {
+			merged[k] = v
+		}
+	}
+	return marshalJSON(merged)
+}
+
+// UnmarshalJSON 解码匹配器集合 - 每个匹配器按名称在注册表中分派
+func (m *RouteMatch) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*m = RouteMatch{}
+	for name, value := range raw {
+		factory, ok := lookupMatcherModule(name)
+		if !ok {
+			continue
+		}
+		module := factory()
+		if err := json.Unmarshal(value, module); err != nil {
+			return fmt.Errorf("解析匹配器 %s 失败: %w", name, err)
+		}
+		if builtin, ok := module.(builtinMatcher); ok {
+			builtin.apply(m)
+		} else {
+			m.Modules = append(m.Modules, module)
+		}
+		delete(raw, name)
+	}
+	// 按名称排序，保证解码结果稳定
+	sort.Slice(m.Modules, func(i, j int) bool {
+		return m.Modules[i].CaddyMatcher() < m.Modules[j].CaddyMatcher()
+	})
+
+	return decodeExtra(raw, &m.Extra)
+}
+
+// objectFields 将模块序列化为 JSON 对象的字段 - 内部辅助函数
+func objectFields(module interface{}) (map[string]interface{}, error) {
+	data, err := marshalJSON(module)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("模块必须序列化为 JSON 对象: %w", err)
+	}
+	return fields, nil
+}
+
+// decodeExtra 将剩余的原始字段解码到 Extra - 内部辅助函数
+func decodeExtra(raw map[string]json.RawMessage, extra *map[string]interface{}) error {
+	if len(raw) == 0 {
+		return nil
+	}
+	*extra = make(map[string]interface{}, len(raw))
+	for k, v := range raw {
+		var value interface{}
+		if err := json.Unmarshal(v, &value); err != nil {
+			return err
+		}
+		(*extra)[k] = value
+	}
+	return nil
+}
+
+// marshalJSON 序列化时不转义 HTML 字符 (<、>、&) - 内部辅助函数
+// json.Marshal 会把正则表达式和模板中的这些字符转义为 \u003c 等形式，
+// 自定义 MarshalJSON 使用该函数，配合 api.CompactMarshal 保证配置原样发送
+func marshalJSON(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
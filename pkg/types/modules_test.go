@@ -0,0 +1,141 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// cacheHandler 示例第三方处理器模块 (如编译进 Caddy 的缓存插件)
+type cacheHandler struct {
+	TTL string `json:"ttl"`
+}
+
+func (cacheHandler) CaddyHandler() string { return "cache" }
+
+func (c *cacheHandler) Validate() error {
+	if c.TTL == "" {
+		return errors.New("ttl 不能为空")
+	}
+	return nil
+}
+
+// geoIPMatcher 示例第三方匹配器模块
+type geoIPMatcher struct {
+	AllowCountries []string `json:"allow_countries"`
+}
+
+func (geoIPMatcher) CaddyMatcher() string { return "geoip" }
+
+func init() {
+	RegisterHandlerModule("cache", func() HandlerModule { return &cacheHandler{} })
+	RegisterMatcherModule("geoip", func() MatcherModule { return &geoIPMatcher{} })
+}
+
+// encodeUnescaped 以不转义 HTML 字符的方式序列化，与 api.CompactMarshal 相同 - 测试辅助函数
+func encodeUnescaped(t *testing.T, v interface{}) string {
+	t.Helper()
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		t.Fatalf("序列化失败: %v", err)
+	}
+	return string(bytes.TrimSuffix(buf.Bytes(), []byte("\n")))
+}
+
+func TestMarshalDoesNotEscapeHTML(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+		want  string
+	}{
+		{
+			name:  "Handler Extra",
+			value: Handler{Handler: "static_response", Extra: map[string]interface{}{"body": "<b>a&b</b>"}},
+			want:  `{"body":"<b>a&b</b>","handler":"static_response"}`,
+		},
+		{
+			name:  "Handler 无 Extra",
+			value: Handler{Handler: "reverse_proxy", Upstreams: []Upstream{{Dial: "a&b:80"}}},
+			want:  `{"handler":"reverse_proxy","upstreams":[{"dial":"a&b:80"}]}`,
+		},
+		{
+			name:  "RouteMatch Extra",
+			value: RouteMatch{Host: []string{"example.com"}, Extra: map[string]interface{}{"expression": "{path} < 5 && true"}},
+			want:  `{"expression":"{path} < 5 && true","host":["example.com"]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := encodeUnescaped(t, tt.value); got != tt.want {
+				t.Errorf("序列化结果 = %s\n期望 = %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegisteredModulesRoundTrip(t *testing.T) {
+	const data = `{"match":[{"geoip":{"allow_countries":["CN"]},"host":["example.com"],"remote_ip":{"ranges":["10.0.0.0/8"]}}],` +
+		`"handle":[{"handler":"cache","ttl":"5m"},{"handler":"reverse_proxy","transport":{"protocol":"http"},"upstreams":[{"dial":"app:80"}]},{"handler":"unknown","x":1}],` +
+		`"terminal":false}`
+
+	var route Route
+	if err := json.Unmarshal([]byte(data), &route); err != nil {
+		t.Fatal(err)
+	}
+
+	// 注册的第三方模块解码为对应的类型
+	if cache, ok := route.Handle[0].Module.(*cacheHandler); !ok || cache.TTL != "5m" {
+		t.Errorf("cache 处理器 = %#v", route.Handle[0].Module)
+	}
+	// 内置模块经同一注册表回填到已建模字段，未建模的字段保留在 Extra
+	proxy := route.Handle[1]
+	if proxy.Module != nil || !reflect.DeepEqual(proxy.Upstreams, []Upstream{{Dial: "app:80"}}) || proxy.Extra["transport"] == nil {
+		t.Errorf("reverse_proxy 处理器 = %#v", proxy)
+	}
+	// 未注册的处理器原样保留
+	if unknown := route.Handle[2]; unknown.Module != nil || unknown.Extra["x"] != float64(1) {
+		t.Errorf("未注册的处理器 = %#v", unknown)
+	}
+	match := route.Match[0]
+	if len(match.Modules) != 1 || !reflect.DeepEqual(match.Modules[0], &geoIPMatcher{AllowCountries: []string{"CN"}}) {
+		t.Errorf("geoip 匹配器 = %#v", match.Modules)
+	}
+	if !reflect.DeepEqual(match.Host, []string{"example.com"}) || match.Extra["remote_ip"] == nil {
+		t.Errorf("匹配器集合 = %#v", match)
+	}
+
+	if got := encodeUnescaped(t, route); got != data {
+		t.Errorf("重新序列化 = %s\n期望 = %s", got, data)
+	}
+}
+
+func TestModuleValidateOnMarshal(t *testing.T) {
+	_, err := json.Marshal(Handler{Module: &cacheHandler{}})
+	if err == nil {
+		t.Fatal("期望校验失败")
+	}
+}
+
+func TestModuleRegistry(t *testing.T) {
+	for _, name := range []string{"cache", "reverse_proxy", "subroute"} {
+		if _, ok := lookupHandlerModule(name); !ok {
+			t.Errorf("处理器模块 %s 未注册", name)
+		}
+	}
+	for _, name := range []string{"geoip", "host", "path"} {
+		if _, ok := lookupMatcherModule(name); !ok {
+			t.Errorf("匹配器模块 %s 未注册", name)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("重复注册应 panic")
+		}
+	}()
+	RegisterHandlerModule("cache", func() HandlerModule { return &cacheHandler{} })
+}
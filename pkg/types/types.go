@@ -1,8 +1,13 @@
 package types
 
+import "encoding/json"
+
 // Caddy 配置结构 - 表示整个 Caddy 配置的顶层结构
 type CaddyConfig struct {
 	Apps map[string]interface{} `json:"apps"`
+
+	// Extra apps 以外的顶层配置 (如 admin、logging、storage)，按原始字节保存，序列化时原样输出
+	Extra map[string]json.RawMessage `json:"-"`
 }
 
 // 路由规则结构 - 定义单个路由规则
@@ -11,12 +16,20 @@ type Route struct {
 	Match    []RouteMatch  `json:"match"`               // 匹配条件列表
 	Handle   []Handler     `json:"handle"`              // 处理器列表
 	Terminal bool          `json:"terminal"`            // 是否为终端路由
+
+	// Extra 未建模的路由字段 (如 group)，序列化时与上面的字段合并到同一层级
+	Extra map[string]interface{} `json:"-"`
 }
 
 // 路由匹配规则 - 定义路由匹配条件
 type RouteMatch struct {
 	Host []string `json:"host,omitempty"` // 主机名匹配列表
 	Path []string `json:"path,omitempty"` // 路径匹配列表
+
+	// Modules 通过 RegisterMatcherModule 注册的自定义匹配器
+	Modules []MatcherModule `json:"-"`
+	// Extra 未注册的匹配器，按匹配器名称保留原始 JSON 结构
+	Extra map[string]interface{} `json:"-"`
 }
 
 // 处理器结构 - 定义路由处理逻辑
@@ -24,18 +37,42 @@ type Handler struct {
 	Handler   string     `json:"handler"`              // 处理器类型 (如 "reverse_proxy", "subroute")
 	Upstreams []Upstream `json:"upstreams,omitempty"`  // 上游服务器列表 (用于反向代理)
 	Routes    []Route    `json:"routes,omitempty"`     // 子路由列表 (用于子路由处理器)
+
+	// Module 通过 RegisterHandlerModule 注册的自定义处理器，设置后由其提供处理器的全部字段
+	Module HandlerModule `json:"-"`
+	// Extra 未建模的处理器字段 (如 vars 处理器的键值)，序列化时与上面的字段合并到同一层级
+	Extra map[string]interface{} `json:"-"`
 }
 
 // 上游服务器 - 定义反向代理的目标服务器
 type Upstream struct {
 	Dial string `json:"dial"` // 目标服务器地址 (如 "localhost:8080")
+
+	// Extra 未建模的上游字段 (如 max_requests)，序列化时与 Dial 合并到同一层级
+	Extra map[string]interface{} `json:"-"`
+}
+
+// 上游运行状态 - 对应 Caddy /reverse_proxy/upstreams 端点返回的单项
+type UpstreamStatus struct {
+	Address     string `json:"address"`      // 上游地址
+	NumRequests int    `json:"num_requests"` // 正在处理的请求数
+	Fails       int    `json:"fails"`        // 被动健康检查记录的失败次数
 }
 
 // HTTP 服务器配置 - 定义 HTTP 服务器的配置
 type HTTPServer struct {
-	Listen    []string `json:"listen"`              // 监听地址列表
-	Routes    []Route  `json:"routes"`              // 路由列表
-	Protocols []string `json:"protocols,omitempty"` // 支持的协议列表
+	Listen         []string        `json:"listen"`                    // 监听地址列表
+	Routes         []Route         `json:"routes"`                    // 路由列表
+	Protocols      []string        `json:"protocols,omitempty"`       // 支持的协议列表
+	AutomaticHTTPS *AutomaticHTTPS `json:"automatic_https,omitempty"` // 自动 HTTPS 设置
+}
+
+// 自动 HTTPS 设置 - 对应服务器的 automatic_https 字段
+type AutomaticHTTPS struct {
+	Disable          bool     `json:"disable,omitempty"`           // 完全禁用自动 HTTPS
+	DisableRedirects bool     `json:"disable_redirects,omitempty"` // 禁用所有主机的 HTTP->HTTPS 重定向
+	Skip             []string `json:"skip,omitempty"`              // 不启用自动 HTTPS（证书和重定向）的主机
+	SkipCertificates []string `json:"skip_certificates,omitempty"` // 只跳过证书管理、保留重定向的主机
 }
 
 // TLS 自动化策略 - 定义 TLS 证书自动化策略
@@ -55,7 +92,22 @@ type ACMEProvider struct {
 	APIToken string `json:"api_token"` // API 令牌
 }
 
+// ACME DNS 提供商 - 用于 tls 应用级别的全局 DNS 提供商配置，结构与 ACMEProvider 相同
+type ACMEDNSProvider = ACMEProvider
+
 // PKI 配置 - 定义 PKI 证书颁发机构配置
 type PKIConfig struct {
 	InstallTrust bool `json:"install_trust"` // 是否安装信任根证书
-}
\ No newline at end of file
+}
+
+// 站点导出包 - 描述单个主机的完整可移植配置
+// 各部分保留 Caddy 原始 JSON 结构，确保导入时不丢失未建模的字段
+type SiteBundle struct {
+	Host               string                   `json:"host"`                              // 站点主机名
+	Server             string                   `json:"server"`                            // 路由所在的 HTTP 服务器名
+	Routes             []map[string]interface{} `json:"routes"`                            // 匹配该主机的路由
+	ConnectionPolicies []map[string]interface{} `json:"tls_connection_policies,omitempty"` // 匹配该主机 SNI 的 TLS 连接策略
+	AutomationPolicies []map[string]interface{} `json:"automation_policies,omitempty"`     // 覆盖该主机的 TLS 自动化策略
+	Certificates       []map[string]interface{} `json:"certificates,omitempty"`            // 覆盖该主机的手动加载证书 (load_pem)
+	Redacted           bool                     `json:"redacted"`                          // 密钥是否已被脱敏
+}
@@ -0,0 +1,107 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Validate 校验上游地址 - dial 不能为空或包含空白字符
+func (u Upstream) Validate() error {
+	if u.Dial == "" {
+		return fmt.Errorf("upstream dial 为空")
+	}
+	if strings.ContainsAny(u.Dial, " \t\r\n") {
+		return fmt.Errorf("upstream dial %q 包含空白字符", u.Dial)
+	}
+	return nil
+}
+
+// Validate 校验路由结构 - 在发送给 Caddy 之前发现明显无效的配置
+// 处理器列表不能为空；匹配器集合至少包含一个条件，host 不能为空字符串；
+// reverse_proxy 至少需要一个上游 (使用 dynamic_upstreams 时除外)；
+// subroute 的子路由列表可以为空 (如刚创建的通配符路由)，非空时逐个校验
+func (r Route) Validate() error {
+	if err := r.validate(); err != nil {
+		if r.ID != "" {
+			return fmt.Errorf("路由 %s: %w", r.ID, err)
+		}
+		return err
+	}
+	return nil
+}
+
+// validate 校验路由，错误信息不含路由 ID - 内部辅助函数
+func (r Route) validate() error {
+	for i, match := range r.Match {
+		if len(match.Host) == 0 && len(match.Path) == 0 && len(match.Modules) == 0 && len(match.Extra) == 0 {
+			return fmt.Errorf("match[%d] 没有任何匹配条件", i)
+		}
+		for _, host := range match.Host {
+			if strings.TrimSpace(host) == "" {
+				return fmt.Errorf("match[%d] 的 host 为空", i)
+			}
+		}
+	}
+
+	if len(r.Handle) == 0 {
+		return fmt.Errorf("handle 为空")
+	}
+	for i, handler := range r.Handle {
+		if err := handler.validate(); err != nil {
+			return fmt.Errorf("handle[%d]: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// validate 校验单个处理器 - 内部辅助函数
+// 自定义模块由其自身的 Validate 校验 (见 Validator)；解码得到的内置模块字段已回填，按字段校验
+func (h Handler) validate() error {
+	if h.Module != nil {
+		if _, builtin := h.Module.(builtinHandler); !builtin {
+			if v, ok := h.Module.(Validator); ok {
+				return v.Validate()
+			}
+			return nil
+		}
+		h.Handler = h.Module.CaddyHandler()
+	}
+
+	switch h.Handler {
+	case "":
+		return fmt.Errorf("处理器名称为空")
+	case "reverse_proxy":
+		if _, dynamic := h.Extra["dynamic_upstreams"]; len(h.Upstreams) == 0 && !dynamic {
+			return fmt.Errorf("reverse_proxy 没有上游")
+		}
+	}
+	for _, upstream := range h.Upstreams {
+		if err := upstream.Validate(); err != nil {
+			return err
+		}
+	}
+	for _, route := range h.Routes {
+		if err := route.Validate(); err != nil {
+			return fmt.Errorf("子路由: %w", err)
+		}
+	}
+	return nil
+}
+
+// Validate 校验 HTTP 服务器配置 - 至少监听一个地址，并逐个校验路由
+func (s HTTPServer) Validate() error {
+	if len(s.Listen) == 0 {
+		return fmt.Errorf("服务器没有监听地址")
+	}
+	for _, addr := range s.Listen {
+		if strings.TrimSpace(addr) == "" {
+			return fmt.Errorf("服务器监听地址为空")
+		}
+	}
+	for i, route := range s.Routes {
+		if err := route.Validate(); err != nil {
+			return fmt.Errorf("routes[%d]: %w", i, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,397 @@
+package gofastcaddy
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/youfun/gofastcaddy/internal/api"
+	"github.com/youfun/gofastcaddy/internal/config"
+	"github.com/youfun/gofastcaddy/internal/routes"
+)
+
+// ChangeKind 计划变更的操作类型 - 与 Caddy 管理 API 的写方法一一对应
+type ChangeKind string
+
+// 计划变更的操作类型
+const (
+	ChangePost   ChangeKind = "post"   // 设置值，数组路径则追加 (POST)
+	ChangeCreate ChangeKind = "create" // 创建新值，数组索引则插入 (PUT)
+	ChangePatch  ChangeKind = "patch"  // 替换已存在的值 (PATCH)
+	ChangeDelete ChangeKind = "delete" // 删除值 (DELETE)
+)
+
+// PlannedChange 计划中的单个配置变更 - 可直接序列化为 JSON 供 CI 审阅
+// Path 和 ID 二选一：Path 为配置路径，ID 为 @id 路径（可带子路径）
+type PlannedChange struct {
+	Kind    ChangeKind  `json:"kind"`             // 操作类型
+	Path    string      `json:"path,omitempty"`   // 目标配置路径
+	ID      string      `json:"id,omitempty"`     // 目标 @id 路径
+	Before  interface{} `json:"before,omitempty"` // 生成计划时目标位置的值 (不存在则省略)
+	After   interface{} `json:"after,omitempty"`  // 写入的值 (删除时省略)
+	Summary string      `json:"summary"`          // 人类可读的一行摘要
+}
+
+// Plan 配置变更计划 - 变更按添加顺序排列，即执行顺序
+// BaseHash 记录生成计划时的配置哈希，Apply 前会校验线上配置未被修改
+type Plan struct {
+	BaseHash string          `json:"base_hash"` // 生成计划时的规范化配置哈希
+	Changes  []PlannedChange `json:"changes"`   // 按执行顺序排列的变更
+
+	snapshot map[string]interface{}
+}
+
+// ConfigHash 计算当前完整配置的规范化哈希
+// 配置经 JSON 重新编码后对象键按字典序排列，因此与 Caddy 返回的键顺序无关
+func (fc *FastCaddy) ConfigHash() (string, error) {
+	cfg, err := fc.API.GetConfig("/")
+	if err != nil {
+		return "", err
+	}
+	return hashConfig(cfg)
+}
+
+// NewPlan 基于当前线上配置创建空的变更计划
+func (fc *FastCaddy) NewPlan() (*Plan, error) {
+	cfg, err := fc.API.GetConfig("/")
+	if err != nil {
+		return nil, err
+	}
+	hash, err := hashConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &Plan{BaseHash: hash, snapshot: cfg}, nil
+}
+
+// PlanDryRun 以试运行方式执行 fn，并把它发出的写请求转换为变更计划 - 供 CI 审阅后再 Apply
+// fn 收到的 FastCaddy 读取线上配置 (见 ReadThrough)，写请求只记录不发送；
+// fn 中后续的读请求看不到先前记录的写请求，依赖写后再读的多步操作可能生成与实际执行不同的计划；
+// 计划的基线哈希在执行 fn 之前计算，fn 返回错误时不生成计划
+func (fc *FastCaddy) PlanDryRun(fn func(dry *FastCaddy) error) (*Plan, error) {
+	plan, err := fc.NewPlan()
+	if err != nil {
+		return nil, err
+	}
+
+	client := fc.API.Clone()
+	client.DryRun = &api.DryRunRecorder{Read: api.ReadThrough(fc.API)}
+	dry := NewWithClient(client)
+	dry.DataPlane = fc.DataPlane
+	dry.Routes.Warn = fc.Routes.Warn
+	dry.TLS.Warn = fc.TLS.Warn
+	if err := fn(dry); err != nil {
+		return nil, err
+	}
+
+	if err := plan.addRecorded(fc.API.BaseURL, dry.RecordedRequests()); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// PlanFromRecorded 把试运行记录的写请求 (见 WithDryRun) 转换为基于当前线上配置的变更计划
+// 请求必须发往 fc 的管理端点的 /config/ 或 /id/ 路径，否则返回错误
+func (fc *FastCaddy) PlanFromRecorded(requests []RecordedRequest) (*Plan, error) {
+	plan, err := fc.NewPlan()
+	if err != nil {
+		return nil, err
+	}
+	if err := plan.addRecorded(fc.API.BaseURL, requests); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// PostConfig 记录一次 PostConfig 变更
+func (p *Plan) PostConfig(data interface{}, path string) {
+	p.addPath(ChangePost, path, data)
+}
+
+// CreateConfig 记录一次 CreateConfig 变更
+func (p *Plan) CreateConfig(data interface{}, path string) {
+	p.addPath(ChangeCreate, path, data)
+}
+
+// PatchConfig 记录一次 PatchConfig 变更
+func (p *Plan) PatchConfig(data interface{}, path string) {
+	p.addPath(ChangePatch, path, data)
+}
+
+// DeleteConfig 记录一次 DeleteConfig 变更
+func (p *Plan) DeleteConfig(path string) {
+	p.addPath(ChangeDelete, path, nil)
+}
+
+// PostByID 记录一次 PostByID 变更
+func (p *Plan) PostByID(data interface{}, id string) {
+	p.addID(ChangePost, id, data)
+}
+
+// CreateByID 记录一次 CreateByID 变更
+func (p *Plan) CreateByID(data interface{}, id string) {
+	p.addID(ChangeCreate, id, data)
+}
+
+// PatchByID 记录一次 PatchByID 变更
+func (p *Plan) PatchByID(data interface{}, id string) {
+	p.addID(ChangePatch, id, data)
+}
+
+// DeleteByID 记录一次 DeleteByID 变更
+func (p *Plan) DeleteByID(id string) {
+	p.addID(ChangeDelete, id, nil)
+}
+
+// RenderJSON 以缩进 JSON 输出计划
+func (p *Plan) RenderJSON() ([]byte, error) {
+	return json.MarshalIndent(p, "", "  ")
+}
+
+// RenderMarkdown 以 Markdown 输出计划 - 适合作为 PR 评论发布
+func (p *Plan) RenderMarkdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "### Caddy 配置变更计划\n\n")
+	fmt.Fprintf(&b, "基线配置哈希: `%s`\n\n", p.BaseHash)
+	if len(p.Changes) == 0 {
+		b.WriteString("无变更\n")
+		return b.String()
+	}
+
+	for i, change := range p.Changes {
+		fmt.Fprintf(&b, "%d. %s\n", i+1, change.Summary)
+	}
+	for i, change := range p.Changes {
+		fmt.Fprintf(&b, "\n#### %d. %s\n", i+1, change.Summary)
+		if change.Before != nil {
+			b.WriteString("\n变更前:\n\n")
+			writeJSONBlock(&b, change.Before)
+		}
+		if change.After != nil {
+			b.WriteString("\n变更后:\n\n")
+			writeJSONBlock(&b, change.After)
+		}
+	}
+	return b.String()
+}
+
+// Apply 按顺序执行已审阅的计划
+// 线上配置哈希与 BaseHash 不一致时拒绝执行，避免在过期的计划上修改配置
+func (p *Plan) Apply(fc *FastCaddy) error {
+	hash, err := fc.ConfigHash()
+	if err != nil {
+		return err
+	}
+	if hash != p.BaseHash {
+		return fmt.Errorf("配置已在生成计划后被修改 (计划基线 %s, 当前 %s), 请重新生成计划", p.BaseHash, hash)
+	}
+
+	for i, change := range p.Changes {
+		if err := applyChange(fc, change); err != nil {
+			return fmt.Errorf("执行第 %d 项变更失败 (%s): %w", i+1, change.Summary, err)
+		}
+	}
+	return nil
+}
+
+// addRecorded 按顺序把试运行记录的写请求加入计划 - 内部辅助函数
+// baseURL 为发出请求的客户端的管理端点地址，用于从请求 URL 中分离出配置路径或 @id 路径
+func (p *Plan) addRecorded(baseURL string, requests []RecordedRequest) error {
+	base, err := url.Parse(baseURL)
+	if err != nil {
+		return fmt.Errorf("解析管理端点地址失败: %w", err)
+	}
+	prefix := strings.TrimSuffix(base.Path, "/")
+
+	for _, req := range requests {
+		u, err := url.Parse(req.URL)
+		if err != nil {
+			return fmt.Errorf("解析请求 URL %s 失败: %w", req.URL, err)
+		}
+		kind, ok := recordedKinds[req.Method]
+		if !ok {
+			return fmt.Errorf("无法转换为计划变更: %s %s", req.Method, req.URL)
+		}
+		var data interface{}
+		if len(req.Body) > 0 {
+			dec := json.NewDecoder(bytes.NewReader(req.Body))
+			dec.UseNumber()
+			if err := dec.Decode(&data); err != nil {
+				return fmt.Errorf("解析请求 %s %s 的请求体失败: %w", req.Method, req.URL, err)
+			}
+		}
+
+		path := strings.TrimPrefix(u.Path, prefix)
+		switch {
+		case strings.HasPrefix(path, "/config/"):
+			p.addPath(kind, "/"+strings.Trim(strings.TrimPrefix(path, "/config/"), "/"), data)
+		case strings.HasPrefix(path, "/id/"):
+			p.addID(kind, strings.Trim(strings.TrimPrefix(path, "/id/"), "/"), data)
+		default:
+			return fmt.Errorf("无法转换为计划变更: %s %s", req.Method, req.URL)
+		}
+	}
+	return nil
+}
+
+// recordedKinds 写请求方法对应的变更类型
+var recordedKinds = map[string]ChangeKind{
+	http.MethodPost:   ChangePost,
+	http.MethodPut:    ChangeCreate,
+	http.MethodPatch:  ChangePatch,
+	http.MethodDelete: ChangeDelete,
+}
+
+// addPath 记录针对配置路径的变更 - 内部辅助函数
+func (p *Plan) addPath(kind ChangeKind, path string, data interface{}) {
+	before, _ := lookupPath(p.snapshot, config.PathToKeys(path))
+	p.Changes = append(p.Changes, PlannedChange{
+		Kind:    kind,
+		Path:    path,
+		Before:  before,
+		After:   normalizeBody(data),
+		Summary: fmt.Sprintf("%s %s", strings.ToUpper(string(kind)), path),
+	})
+}
+
+// addID 记录针对 @id 路径的变更 - 内部辅助函数
+func (p *Plan) addID(kind ChangeKind, id string, data interface{}) {
+	keys := config.PathToKeys(id)
+	var before interface{}
+	if len(keys) > 0 {
+		if target := findByID(p.snapshot, keys[0]); target != nil {
+			before, _ = lookupPath(target, keys[1:])
+		}
+	}
+	p.Changes = append(p.Changes, PlannedChange{
+		Kind:    kind,
+		ID:      id,
+		Before:  before,
+		After:   normalizeBody(data),
+		Summary: fmt.Sprintf("%s @id %s", strings.ToUpper(string(kind)), id),
+	})
+}
+
+// applyChange 执行单个计划变更 - 内部辅助函数
+func applyChange(fc *FastCaddy, change PlannedChange) error {
+	if change.ID != "" {
+		switch change.Kind {
+		case ChangePost:
+			return fc.API.PostByID(change.After, change.ID)
+		case ChangeCreate:
+			return fc.API.CreateByID(change.After, change.ID)
+		case ChangePatch:
+			return fc.API.PatchByID(change.After, change.ID)
+		case ChangeDelete:
+			return fc.API.DeleteByID(change.ID)
+		}
+	} else {
+		switch change.Kind {
+		case ChangePost:
+			return fc.API.PostConfig(change.After, change.Path)
+		case ChangeCreate:
+			return fc.API.CreateConfig(change.After, change.Path)
+		case ChangePatch:
+			return fc.API.PatchConfig(change.After, change.Path)
+		case ChangeDelete:
+			return fc.API.DeleteConfig(change.Path)
+		}
+	}
+	return fmt.Errorf("不支持的变更类型: %s", change.Kind)
+}
+
+// hashConfig 计算配置的规范化 SHA-256 哈希 - 内部辅助函数
+// 路由来源信息 (见 routes.Provenance) 不参与计算
+func hashConfig(cfg interface{}) (string, error) {
+	data, err := json.Marshal(routes.StripProvenance(cfg))
+	if err != nil {
+		return "", fmt.Errorf("序列化配置失败: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// normalizeBody 将请求体转换为通用 JSON 结构 - 内部辅助函数
+// 保证计划序列化后再反序列化执行时，发送的内容与审阅的内容完全一致
+func normalizeBody(data interface{}) interface{} {
+	if data == nil {
+		return nil
+	}
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return data
+	}
+	// 使用 json.Number 保留大整数的原始写法，避免经 float64 转换后丢失精度
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var result interface{}
+	if err := dec.Decode(&result); err != nil {
+		return data
+	}
+	return result
+}
+
+// lookupPath 沿键路径在 JSON 结构中查找值，支持对象键和数组下标 - 内部辅助函数
+func lookupPath(value interface{}, keys []string) (interface{}, bool) {
+	current := value
+	for _, key := range keys {
+		switch v := current.(type) {
+		case map[string]interface{}:
+			next, ok := v[key]
+			if !ok {
+				return nil, false
+			}
+			current = next
+		case []interface{}:
+			index, err := strconv.Atoi(key)
+			if err != nil || index < 0 || index >= len(v) {
+				return nil, false
+			}
+			current = v[index]
+		default:
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// findByID 在 JSON 结构中递归查找 @id 等于 id 的对象 - 内部辅助函数
+func findByID(value interface{}, id string) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if v["@id"] == id {
+			return v
+		}
+		for _, item := range v {
+			if found := findByID(item, id); found != nil {
+				return found
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			if found := findByID(item, id); found != nil {
+				return found
+			}
+		}
+	}
+	return nil
+}
+
+// writeJSONBlock 以 Markdown 代码块写入缩进 JSON - 内部辅助函数
+func writeJSONBlock(b *strings.Builder, value interface{}) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(value); err != nil {
+		fmt.Fprintf(&buf, "%v\n", value)
+	}
+	b.WriteString("```json\n")
+	b.WriteString(buf.String())
+	b.WriteString("```\n")
+}
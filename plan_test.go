@@ -0,0 +1,113 @@
+package gofastcaddy
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// planServer 用于计划测试的初始配置 - 测试辅助常量
+const planServer = `{"apps":{"http":{"servers":{"srv0":{"listen":[":80",":443"],"routes":[{"@id":"old.example.com","match":[{"host":["old.example.com"]}],"handle":[{"handler":"reverse_proxy","upstreams":[{"dial":"old:80"}]}]}]}}}}}`
+
+func TestPlanDryRunApply(t *testing.T) {
+	tests := []struct {
+		name  string
+		run   func(fc *FastCaddy) error
+		kinds []ChangeKind
+	}{
+		{
+			name:  "添加反向代理",
+			run:   func(fc *FastCaddy) error { return fc.AddReverseProxy("app.example.com", "app:8080") },
+			kinds: []ChangeKind{ChangePost},
+		},
+		{
+			name: "按路径和 @id 修改与删除",
+			run: func(fc *FastCaddy) error {
+				if err := fc.PostConfig([]string{":8080"}, "/apps/http/servers/srv0/listen"); err != nil {
+					return err
+				}
+				if err := fc.API.PatchByID([]map[string]string{{"dial": "new:80"}}, "old.example.com/handle/0/upstreams"); err != nil {
+					return err
+				}
+				return fc.DeleteConfig("/apps/http/servers/srv0/routes/0/match")
+			},
+			kinds: []ChangeKind{ChangePost, ChangePatch, ChangeDelete},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fc, fake := newTestFastCaddy(t, planServer)
+			plan, err := fc.PlanDryRun(tt.run)
+			if err != nil {
+				t.Fatalf("PlanDryRun: %v", err)
+			}
+			for _, req := range fake.Requests() {
+				if !strings.HasPrefix(req, "GET ") {
+					t.Fatalf("生成计划时发送了写请求: %s", req)
+				}
+			}
+			var kinds []ChangeKind
+			for _, change := range plan.Changes {
+				kinds = append(kinds, change.Kind)
+			}
+			if len(kinds) != len(tt.kinds) {
+				t.Fatalf("变更类型 = %v, 期望 %v", kinds, tt.kinds)
+			}
+			for i := range kinds {
+				if kinds[i] != tt.kinds[i] {
+					t.Fatalf("变更类型 = %v, 期望 %v", kinds, tt.kinds)
+				}
+			}
+
+			// 经 JSON 往返后执行，结果应与直接执行相同
+			data, err := plan.RenderJSON()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var reviewed Plan
+			if err := json.Unmarshal(data, &reviewed); err != nil {
+				t.Fatal(err)
+			}
+			if err := reviewed.Apply(fc); err != nil {
+				t.Fatalf("Apply: %v", err)
+			}
+			direct, directFake := newTestFastCaddy(t, planServer)
+			if err := tt.run(direct); err != nil {
+				t.Fatal(err)
+			}
+			if got, want := fake.ConfigJSON(), directFake.ConfigJSON(); got != want {
+				t.Errorf("执行计划后的配置\n%s\n与直接执行的配置不同\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestPlanApplyRejectsStalePlan(t *testing.T) {
+	fc, fake := newTestFastCaddy(t, planServer)
+	plan, err := fc.PlanDryRun(func(dry *FastCaddy) error {
+		return dry.AddReverseProxy("app.example.com", "app:8080")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := fc.API.PatchByID([]map[string]string{{"dial": "other:80"}}, "old.example.com/handle/0/upstreams"); err != nil {
+		t.Fatal(err)
+	}
+	before := fake.ConfigJSON()
+
+	err = plan.Apply(fc)
+	if err == nil || !strings.Contains(err.Error(), "重新生成计划") {
+		t.Fatalf("err = %v, 期望拒绝过期的计划", err)
+	}
+	if fake.ConfigJSON() != before {
+		t.Error("拒绝执行时修改了配置")
+	}
+}
+
+func TestPlanFromRecordedRejectsOtherEndpoints(t *testing.T) {
+	fc, _ := newTestFastCaddy(t, planServer)
+	_, err := fc.PlanFromRecorded([]RecordedRequest{{Method: "POST", URL: fc.API.BaseURL + "/load", Body: []byte(`{}`)}})
+	if err == nil {
+		t.Fatal("期望 /load 请求无法转换为计划变更")
+	}
+}
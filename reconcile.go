@@ -0,0 +1,245 @@
+package gofastcaddy
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/youfun/gofastcaddy/internal/config"
+	"github.com/youfun/gofastcaddy/internal/routes"
+	"github.com/youfun/gofastcaddy/internal/tls"
+	"github.com/youfun/gofastcaddy/pkg/configgen"
+	"github.com/youfun/gofastcaddy/pkg/spec"
+)
+
+// ReconcileOptions Reconcile 的选项
+type ReconcileOptions struct {
+	// ExpectedStateHash 上一次运行结束时持久化的状态哈希 (见 GetStateHash)
+	ExpectedStateHash string
+
+	// RequireCleanState 线上受管配置与 ExpectedStateHash 不一致时返回 *DriftError 而不修改配置，
+	// 避免在被外部修改 (如 Caddy 从旧的 autosave 恢复) 的配置上盲目收敛
+	RequireCleanState bool
+}
+
+// DriftError 受管配置在控制器之外被修改 - RequireCleanState 时 Reconcile 返回该错误
+type DriftError struct {
+	ExpectedHash string   // 调用方期望的状态哈希
+	Drift        []Change // 漂移列表，见 VerifyState
+}
+
+// Error 实现 error 接口
+func (e *DriftError) Error() string {
+	return fmt.Sprintf("受管配置与状态 %s 不一致 (%d 处差异), 拒绝收敛", e.ExpectedHash, len(e.Drift))
+}
+
+// Reconcile 使线上配置收敛到站点配置 s，返回受管配置实际发生的变化 (已一致时为空)
+// 期望的配置与 configgen.Build 生成的相同，收敛只涉及 s 中的站点，其他路由和 TLS 策略保持不变：
+//   - 每个站点在默认服务器中有一个 @id 为主机名的反向代理路由，缺少的追加，内容不同的整体替换
+//   - TLS 模式为 off 的站点在 automatic_https.skip 中，其他站点不在
+//   - TLS 模式为 internal 的站点由一条带主体列表的 internal 颁发者策略覆盖
+//
+// 不会删除 s 之外的站点；一次收敛包含多次写请求，期间持有 LockWrites
+func (fc *FastCaddy) Reconcile(s spec.SiteSpec, opts ReconcileOptions) ([]Change, error) {
+	desired, err := configgen.Build(s)
+	if err != nil {
+		return nil, err
+	}
+	if opts.RequireCleanState && opts.ExpectedStateHash == "" {
+		return nil, fmt.Errorf("RequireCleanState 需要 ExpectedStateHash")
+	}
+
+	defer fc.API.LockWrites()()
+
+	if opts.RequireCleanState {
+		ok, drift, err := fc.VerifyState(opts.ExpectedStateHash)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, &DriftError{ExpectedHash: opts.ExpectedStateHash, Drift: drift}
+		}
+	}
+
+	before, err := fc.managedState()
+	if err != nil {
+		return nil, err
+	}
+	if err := fc.reconcileServer(s, desired); err != nil {
+		return nil, err
+	}
+	if err := fc.reconcileInternalPolicy(s); err != nil {
+		return nil, err
+	}
+	after, err := fc.managedState()
+	if err != nil {
+		return nil, err
+	}
+	return config.Diff(before, after), nil
+}
+
+// reconcileServer 收敛默认服务器中的站点路由和 automatic_https.skip - 内部辅助函数
+func (fc *FastCaddy) reconcileServer(s spec.SiteSpec, desired map[string]interface{}) error {
+	serverPath := routes.ServersPath + "/" + routes.DefaultServerName
+	cfg, err := fc.API.GetConfig("/")
+	if err != nil {
+		return err
+	}
+	server, ok := lookupMap(cfg, "apps", "http", "servers", routes.DefaultServerName)
+	if !ok {
+		// 服务器不存在时整体创建
+		if _, err := fc.API.PutConfigIfAbsent(map[string]interface{}{}, "/"); err != nil {
+			return err
+		}
+		if err := fc.Config.EnsurePath(routes.ServersPath, 0); err != nil {
+			return err
+		}
+		servers, _ := lookupMap(desired, "apps", "http", "servers")
+		return fc.API.PostConfig(servers[routes.DefaultServerName], serverPath)
+	}
+
+	live := make(map[string]interface{})
+	for _, route := range mapsOf(server["routes"]) {
+		if id, _ := route["@id"].(string); id != "" {
+			live[id] = routes.StripProvenance(route)
+		}
+	}
+	for _, site := range s.Sites {
+		route := routes.ReverseProxyRoute(site.Host, site.Upstreams...)
+		current, exists := live[route.ID]
+		switch {
+		case !exists:
+			if err := fc.API.PostConfig(route, serverPath+"/routes"); err != nil {
+				return err
+			}
+		case !reflect.DeepEqual(normalizeBody(current), normalizeBody(route)):
+			if err := fc.API.PatchByID(route, route.ID); err != nil {
+				return err
+			}
+		}
+	}
+
+	// skip 中保留 s 之外的主机，s 中的主机按 TLS 模式重新加入
+	automatic, hasAutomatic := server["automatic_https"].(map[string]interface{})
+	current := stringsOf(automatic["skip"])
+	var skip []string
+	for _, host := range current {
+		if !specHost(s, host) {
+			skip = append(skip, host)
+		}
+	}
+	for _, site := range s.Sites {
+		if siteTLS(s, site) == spec.TLSOff {
+			skip = append(skip, site.Host)
+		}
+	}
+	switch {
+	case reflect.DeepEqual(skip, current):
+		return nil
+	case len(skip) == 0:
+		return fc.API.DeleteConfig(serverPath + "/automatic_https/skip")
+	case !hasAutomatic:
+		return fc.API.PostConfig(map[string]interface{}{"skip": skip}, serverPath+"/automatic_https")
+	case automatic["skip"] == nil:
+		return fc.API.PostConfig(skip, serverPath+"/automatic_https/skip")
+	}
+	// 对数组路径 POST 会追加元素，替换整个列表需要 PATCH
+	return fc.API.PatchConfig(skip, serverPath+"/automatic_https/skip")
+}
+
+// reconcileInternalPolicy 收敛 internal 颁发者策略的主体列表 - 内部辅助函数
+// 只修改第一条带主体列表的 internal 策略；没有主体列表的策略作用于所有主机，不属于站点配置
+func (fc *FastCaddy) reconcileInternalPolicy(s spec.SiteSpec) error {
+	var internal []string
+	for _, site := range s.Sites {
+		if siteTLS(s, site) == spec.TLSInternal {
+			internal = append(internal, site.Host)
+		}
+	}
+
+	cfg, err := fc.API.GetConfig("/")
+	if err != nil {
+		return err
+	}
+	automation, _ := lookupMap(cfg, "apps", "tls", "automation")
+	policies := mapsOf(automation["policies"])
+	index := -1
+	for i, policy := range policies {
+		if isInternalPolicy(policy) && len(stringsOf(policy["subjects"])) > 0 {
+			index = i
+			break
+		}
+	}
+
+	if index < 0 {
+		if len(internal) == 0 {
+			return nil
+		}
+		if _, err := fc.API.PutConfigIfAbsent(map[string]interface{}{}, "/"); err != nil {
+			return err
+		}
+		if err := fc.Config.EnsurePath(tls.AutomationPath, 0); err != nil {
+			return err
+		}
+		if _, ok := automation["policies"]; !ok {
+			return fc.API.PostConfig([]interface{}{tls.InternalPolicy(internal...)}, tls.PoliciesPath)
+		}
+		return fc.API.PostConfig(tls.InternalPolicy(internal...), tls.PoliciesPath)
+	}
+
+	current := stringsOf(policies[index]["subjects"])
+	var subjects []string
+	for _, host := range current {
+		if !specHost(s, host) {
+			subjects = append(subjects, host)
+		}
+	}
+	subjects = append(subjects, internal...)
+	path := fmt.Sprintf("%s/%d", tls.PoliciesPath, index)
+	switch {
+	case reflect.DeepEqual(subjects, current):
+		return nil
+	case len(subjects) == 0:
+		return fc.API.DeleteConfig(path)
+	}
+	return fc.API.PatchConfig(subjects, path+"/subjects")
+}
+
+// isInternalPolicy 检查策略是否只使用 internal 颁发者 - 内部辅助函数
+func isInternalPolicy(policy map[string]interface{}) bool {
+	issuers := mapsOf(policy["issuers"])
+	return len(issuers) == 1 && issuers[0]["module"] == "internal"
+}
+
+// siteTLS 返回站点实际使用的 TLS 模式 - 内部辅助函数
+func siteTLS(s spec.SiteSpec, site spec.Site) string {
+	switch {
+	case site.TLS != "":
+		return site.TLS
+	case s.TLS != "":
+		return s.TLS
+	}
+	return spec.TLSAuto
+}
+
+// specHost 检查主机是否属于站点配置 - 内部辅助函数
+func specHost(s spec.SiteSpec, host string) bool {
+	for _, site := range s.Sites {
+		if strings.EqualFold(site.Host, host) {
+			return true
+		}
+	}
+	return false
+}
+
+// stringsOf 将 JSON 字符串数组转换为字符串切片，忽略非字符串元素 - 内部辅助函数
+func stringsOf(value interface{}) []string {
+	items, _ := value.([]interface{})
+	var out []string
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
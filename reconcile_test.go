@@ -0,0 +1,134 @@
+package gofastcaddy
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/youfun/gofastcaddy/pkg/configgen"
+	"github.com/youfun/gofastcaddy/pkg/spec"
+)
+
+// reconcileSpec 用于收敛测试的站点配置 - 测试辅助变量
+var reconcileSpec = spec.SiteSpec{Sites: []spec.Site{
+	{Host: "app.example.com", Upstreams: []string{"app:80"}},
+	{Host: "dev.example.com", Upstreams: []string{"dev:80"}, TLS: spec.TLSInternal},
+	{Host: "plain.example.com", Upstreams: []string{"plain:80"}, TLS: spec.TLSOff},
+}}
+
+func TestReconcile(t *testing.T) {
+	tests := []struct {
+		name      string
+		initial   string
+		wantDrift bool
+		keep      string // 收敛后必须保留的配置片段
+	}{
+		{
+			name:      "空配置",
+			initial:   `null`,
+			wantDrift: true,
+		},
+		{
+			name:      "修改上游并保留其他站点",
+			initial:   `{"apps":{"http":{"servers":{"srv0":{"listen":[":443"],"routes":[{"@id":"other.example.com","match":[{"host":["other.example.com"]}],"handle":[{"handler":"file_server"}]},{"@id":"app.example.com","match":[{"host":["app.example.com"]}],"handle":[{"handler":"reverse_proxy","upstreams":[{"dial":"old:80"}]}],"terminal":true}],"automatic_https":{"skip":["other.example.com","dev.example.com"]}}}},"tls":{"automation":{"policies":[{"subjects":["other.internal","plain.example.com"],"issuers":[{"module":"internal"}]}]}}}}`,
+			wantDrift: true,
+			keep:      `"other.example.com"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fc, fake := newTestFastCaddy(t, tt.initial)
+			changes, err := fc.Reconcile(reconcileSpec, ReconcileOptions{})
+			if err != nil {
+				t.Fatalf("Reconcile: %v", err)
+			}
+			if (len(changes) > 0) != tt.wantDrift {
+				t.Fatalf("changes = %v", changes)
+			}
+			if tt.keep != "" && !strings.Contains(fake.ConfigJSON(), tt.keep) {
+				t.Errorf("收敛删除了规格之外的配置: %s", fake.ConfigJSON())
+			}
+
+			// 再次收敛不应产生变化
+			changes, err = fc.Reconcile(reconcileSpec, ReconcileOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(changes) != 0 {
+				t.Errorf("第二次收敛仍有变化: %v", changes)
+			}
+		})
+	}
+}
+
+func TestReconcileZeroDriftFromGeneratedConfig(t *testing.T) {
+	generated, err := configgen.GenerateInitialConfig(reconcileSpec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fc, fake := newTestFastCaddy(t, string(generated))
+	changes, err := fc.Reconcile(reconcileSpec, ReconcileOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 0 {
+		t.Errorf("由生成的配置启动后收敛产生了变化: %v", changes)
+	}
+	for _, req := range fake.Requests() {
+		if !strings.HasPrefix(req, "GET ") {
+			t.Errorf("收敛发送了写请求: %s", req)
+		}
+	}
+}
+
+func TestReconcileRequireCleanState(t *testing.T) {
+	generated, err := configgen.GenerateInitialConfig(reconcileSpec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name      string
+		edit      bool
+		wantDrift bool
+	}{
+		{name: "状态一致时收敛", edit: false},
+		{name: "外部修改后拒绝收敛", edit: true, wantDrift: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			first, fake := newTestFastCaddy(t, string(generated))
+			first.StateStore = FileStateStore{Dir: dir}
+			hash, err := first.GetStateHash()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.edit {
+				if err := first.API.PatchByID([]map[string]string{{"dial": "stale:80"}}, "app.example.com/handle/0/upstreams"); err != nil {
+					t.Fatal(err)
+				}
+			}
+			before := fake.ConfigJSON()
+
+			second := NewWithURL(fake.URL)
+			second.StateStore = FileStateStore{Dir: dir}
+			_, err = second.Reconcile(reconcileSpec, ReconcileOptions{ExpectedStateHash: hash, RequireCleanState: true})
+			var drift *DriftError
+			if errors.As(err, &drift) != tt.wantDrift {
+				t.Fatalf("err = %v, 期望漂移 %v", err, tt.wantDrift)
+			}
+			if !tt.wantDrift {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if len(drift.Drift) != 1 || !strings.HasSuffix(drift.Drift[0].Path, "/dial") {
+				t.Errorf("漂移 = %+v, 期望上游地址的差异", drift.Drift)
+			}
+			if fake.ConfigJSON() != before {
+				t.Error("拒绝收敛时修改了配置")
+			}
+		})
+	}
+}
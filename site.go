@@ -0,0 +1,285 @@
+package gofastcaddy
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/youfun/gofastcaddy/internal/routes"
+	"github.com/youfun/gofastcaddy/internal/tls"
+	"github.com/youfun/gofastcaddy/internal/utils"
+	"github.com/youfun/gofastcaddy/pkg/types"
+)
+
+// 站点导入导出涉及的配置路径
+const (
+	certificatesPath = "/apps/tls/certificates"
+	loadPEMPath      = certificatesPath + "/load_pem"
+)
+
+// SiteExportOptions 站点导出选项
+type SiteExportOptions struct {
+	IncludeSecrets bool // 是否保留令牌、私钥等敏感信息（默认脱敏）
+}
+
+// ExportSite 导出指定主机的完整配置包 - 默认对敏感信息脱敏
+// 包含匹配该主机的顶层路由、TLS 连接策略、TLS 自动化策略和手动加载的证书；
+// 多个 HTTP 服务器都有该主机的路由时，使用按名称排序的第一个服务器
+func (fc *FastCaddy) ExportSite(host string) ([]byte, error) {
+	return fc.ExportSiteWithOptions(host, SiteExportOptions{})
+}
+
+// ExportSiteWithOptions 按选项导出指定主机的完整配置包
+// 需要在其他实例上导入时，应设置 IncludeSecrets 保留敏感信息
+func (fc *FastCaddy) ExportSiteWithOptions(host string, opts SiteExportOptions) ([]byte, error) {
+	if !utils.ValidateHost(host) {
+		return nil, fmt.Errorf("无效的主机名: %q", host)
+	}
+
+	cfg, err := fc.API.GetConfig("/")
+	if err != nil {
+		return nil, err
+	}
+
+	bundle := types.SiteBundle{Host: host}
+
+	// 按名称顺序查找第一个有该主机路由的 HTTP 服务器，路由和连接策略都取自该服务器
+	servers, _ := lookupMap(cfg, "apps", "http", "servers")
+	names := make([]string, 0, len(servers))
+	for name := range servers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		server, ok := servers[name].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for _, route := range mapsOf(server["routes"]) {
+			if routeMatchesHost(route, host) {
+				bundle.Routes = append(bundle.Routes, route)
+			}
+		}
+		if len(bundle.Routes) == 0 {
+			continue
+		}
+		bundle.Server = name
+		for _, policy := range mapsOf(server["tls_connection_policies"]) {
+			if policyMatchesSNI(policy, host) {
+				bundle.ConnectionPolicies = append(bundle.ConnectionPolicies, policy)
+			}
+		}
+		break
+	}
+	if len(bundle.Routes) == 0 {
+		return nil, fmt.Errorf("未找到主机 %s 的路由", host)
+	}
+
+	// 覆盖该主机的 TLS 自动化策略
+	automation, _ := lookupMap(cfg, "apps", "tls", "automation")
+	for _, policy := range mapsOf(automation["policies"]) {
+		if subjects, ok := policy["subjects"].([]interface{}); ok && containsString(subjects, host) {
+			bundle.AutomationPolicies = append(bundle.AutomationPolicies, policy)
+		}
+	}
+
+	// 覆盖该主机的手动加载证书
+	certificates, _ := lookupMap(cfg, "apps", "tls", "certificates")
+	for _, cert := range mapsOf(certificates["load_pem"]) {
+		if certCoversHost(cert, host) {
+			bundle.Certificates = append(bundle.Certificates, cert)
+		}
+	}
+
+	// 只有确实去除了敏感信息时才标记为已脱敏，不含敏感信息的配置包可以直接导入
+	if !opts.IncludeSecrets {
+		for _, section := range []*[]map[string]interface{}{
+			&bundle.Routes, &bundle.ConnectionPolicies, &bundle.AutomationPolicies, &bundle.Certificates,
+		} {
+			if len(*section) == 0 {
+				continue
+			}
+			redacted := utils.RedactSecrets(*section, utils.DefaultSecretKeys).([]map[string]interface{})
+			if !reflect.DeepEqual(redacted, *section) {
+				bundle.Redacted = true
+			}
+			*section = redacted
+		}
+	}
+
+	return json.MarshalIndent(bundle, "", "  ")
+}
+
+// ImportSite 导入 ExportSite 生成的站点配置包
+// 同 @id 的路由会先被删除再添加；与现有配置完全相同的策略和证书不会重复添加
+func (fc *FastCaddy) ImportSite(data []byte) error {
+	var bundle types.SiteBundle
+	if err := json.Unmarshal(data, &bundle); err != nil {
+		return fmt.Errorf("解析站点配置包失败: %w", err)
+	}
+	if bundle.Redacted {
+		return fmt.Errorf("站点配置包 %s 中的敏感信息已被脱敏, 无法导入; 请使用 IncludeSecrets 重新导出", bundle.Host)
+	}
+	if bundle.Server == "" || len(bundle.Routes) == 0 {
+		return fmt.Errorf("站点配置包缺少服务器或路由信息")
+	}
+
+	cfg, err := fc.API.GetConfig("/")
+	if err != nil {
+		return err
+	}
+	servers, _ := lookupMap(cfg, "apps", "http", "servers")
+	server, ok := servers[bundle.Server].(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("目标实例中不存在服务器 %s, 请先执行 SetupCaddy", bundle.Server)
+	}
+	serverPath := fmt.Sprintf("%s/%s", routes.ServersPath, bundle.Server)
+
+	// 导入路由：先删除同 ID 的旧路由，保证重复导入不会产生重复路由
+	for _, route := range bundle.Routes {
+		if id, ok := route["@id"].(string); ok && id != "" {
+			exists, err := fc.API.IDExists(id)
+			if err != nil {
+				return err
+			}
+			if exists {
+				if err := fc.API.DeleteByID(id); err != nil {
+					return fmt.Errorf("删除现有路由 %s 失败: %w", id, err)
+				}
+			}
+		}
+		if err := fc.API.PostConfig(route, serverPath+"/routes"); err != nil {
+			return err
+		}
+	}
+
+	// 导入 TLS 连接策略
+	if err := fc.appendMissing(server["tls_connection_policies"], bundle.ConnectionPolicies, serverPath+"/tls_connection_policies"); err != nil {
+		return err
+	}
+
+	// 导入 TLS 自动化策略
+	if len(bundle.AutomationPolicies) > 0 {
+		if err := fc.Config.EnsurePath(tls.AutomationPath, 1); err != nil {
+			return err
+		}
+		automation, _ := lookupMap(cfg, "apps", "tls", "automation")
+		if err := fc.appendMissing(automation["policies"], bundle.AutomationPolicies, tls.AutomationPath+"/policies"); err != nil {
+			return err
+		}
+	}
+
+	// 导入手动加载的证书
+	if len(bundle.Certificates) > 0 {
+		if err := fc.Config.EnsurePath(certificatesPath, 1); err != nil {
+			return err
+		}
+		certificates, _ := lookupMap(cfg, "apps", "tls", "certificates")
+		if err := fc.appendMissing(certificates["load_pem"], bundle.Certificates, loadPEMPath); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// appendMissing 将 existing 中尚不存在的条目追加到数组路径 - 内部辅助函数
+// 数组不存在时直接设置整个数组
+func (fc *FastCaddy) appendMissing(existing interface{}, items []map[string]interface{}, path string) error {
+	current := mapsOf(existing)
+	var missing []map[string]interface{}
+	for _, item := range items {
+		found := false
+		for _, cur := range current {
+			if reflect.DeepEqual(cur, item) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, item)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	if existing == nil {
+		return fc.API.PostConfig(missing, path)
+	}
+	return fc.API.AppendConfig(missing, path)
+}
+
+// lookupMap 沿键路径查找嵌套的 map - 内部辅助函数
+func lookupMap(m map[string]interface{}, keys ...string) (map[string]interface{}, bool) {
+	current := m
+	for _, key := range keys {
+		next, ok := current[key].(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		current = next
+	}
+	return current, true
+}
+
+// mapsOf 将 JSON 数组转换为对象切片，忽略非对象元素 - 内部辅助函数
+func mapsOf(value interface{}) []map[string]interface{} {
+	items, _ := value.([]interface{})
+	var result []map[string]interface{}
+	for _, item := range items {
+		if m, ok := item.(map[string]interface{}); ok {
+			result = append(result, m)
+		}
+	}
+	return result
+}
+
+// containsString 检查 JSON 数组是否包含指定字符串 - 内部辅助函数
+func containsString(items []interface{}, value string) bool {
+	for _, item := range items {
+		if s, ok := item.(string); ok && s == value {
+			return true
+		}
+	}
+	return false
+}
+
+// routeMatchesHost 检查路由的 host 匹配器是否包含指定主机 - 内部辅助函数
+func routeMatchesHost(route map[string]interface{}, host string) bool {
+	matchers, _ := route["match"].([]interface{})
+	for _, raw := range matchers {
+		if matcher, ok := raw.(map[string]interface{}); ok {
+			if hosts, ok := matcher["host"].([]interface{}); ok && containsString(hosts, host) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// policyMatchesSNI 检查 TLS 连接策略的 SNI 匹配器是否包含指定主机 - 内部辅助函数
+func policyMatchesSNI(policy map[string]interface{}, host string) bool {
+	match, ok := policy["match"].(map[string]interface{})
+	if !ok {
+		return false
+	}
+	sni, _ := match["sni"].([]interface{})
+	return containsString(sni, host)
+}
+
+// certCoversHost 检查 load_pem 证书是否对指定主机有效 - 内部辅助函数
+func certCoversHost(cert map[string]interface{}, host string) bool {
+	certPEM, _ := cert["certificate"].(string)
+	block, _ := pem.Decode([]byte(certPEM))
+	if block == nil {
+		return false
+	}
+	parsed, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return false
+	}
+	return parsed.VerifyHostname(host) == nil
+}
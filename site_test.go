@@ -0,0 +1,84 @@
+package gofastcaddy
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/youfun/gofastcaddy/pkg/clienttest"
+	"github.com/youfun/gofastcaddy/pkg/types"
+)
+
+// newTestFastCaddy 创建连接假管理端点的 FastCaddy - 测试辅助函数
+func newTestFastCaddy(t *testing.T, initial string, opts ...Option) (*FastCaddy, *clienttest.FakeCaddy) {
+	t.Helper()
+	fake := clienttest.NewFakeCaddy(initial)
+	t.Cleanup(fake.Close)
+	return New(append([]Option{WithBaseURL(fake.URL)}, opts...)...), fake
+}
+
+func TestExportSite(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   string
+		server   string
+		routes   int
+		redacted bool
+	}{
+		{
+			name:   "不含敏感信息时不标记脱敏",
+			config: `{"apps":{"http":{"servers":{"srv0":{"listen":[":443"],"routes":[{"@id":"app.example.com","match":[{"host":["app.example.com"]}],"handle":[{"handler":"reverse_proxy","upstreams":[{"dial":"app:80"}]}]}]}}}}}`,
+			server: "srv0",
+			routes: 1,
+		},
+		{
+			name:     "去除了密码时标记脱敏",
+			config:   `{"apps":{"http":{"servers":{"srv0":{"listen":[":443"],"routes":[{"@id":"app.example.com","match":[{"host":["app.example.com"]}],"handle":[{"handler":"authentication","providers":{"http_basic":{"accounts":[{"username":"admin","password":"hash"}]}}}]}]}}}}}`,
+			server:   "srv0",
+			routes:   1,
+			redacted: true,
+		},
+		{
+			name: "多个服务器时按名称选择",
+			config: `{"apps":{"http":{"servers":{
+				"zeta":{"listen":[":8443"],"routes":[{"match":[{"host":["app.example.com"]}],"handle":[{"handler":"file_server"}]}]},
+				"alpha":{"listen":[":443"],"routes":[{"match":[{"host":["app.example.com"]}],"handle":[{"handler":"file_server"}]},{"match":[{"host":["app.example.com"]}],"handle":[{"handler":"static_response"}]}]}}}}}`,
+			server: "alpha",
+			routes: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := 0; i < 5; i++ { // 服务器顺序不能依赖 map 的遍历顺序
+				fc, _ := newTestFastCaddy(t, tt.config)
+				data, err := fc.ExportSite("app.example.com")
+				if err != nil {
+					t.Fatalf("ExportSite: %v", err)
+				}
+				var bundle types.SiteBundle
+				if err := json.Unmarshal(data, &bundle); err != nil {
+					t.Fatal(err)
+				}
+				if bundle.Server != tt.server || len(bundle.Routes) != tt.routes || bundle.Redacted != tt.redacted {
+					t.Fatalf("server = %s, routes = %d, redacted = %v; 期望 %s, %d, %v",
+						bundle.Server, len(bundle.Routes), bundle.Redacted, tt.server, tt.routes, tt.redacted)
+				}
+			}
+		})
+	}
+}
+
+func TestExportImportSiteWithoutSecrets(t *testing.T) {
+	source, _ := newTestFastCaddy(t, `{"apps":{"http":{"servers":{"srv0":{"listen":[":443"],"routes":[{"@id":"app.example.com","match":[{"host":["app.example.com"]}],"handle":[{"handler":"reverse_proxy","upstreams":[{"dial":"app:80"}]}]}]}}}}}`)
+	data, err := source.ExportSite("app.example.com")
+	if err != nil {
+		t.Fatalf("ExportSite: %v", err)
+	}
+
+	target, _ := newTestFastCaddy(t, `{"apps":{"http":{"servers":{"srv0":{"listen":[":443"],"routes":[]}}}}}`)
+	if err := target.ImportSite(data); err != nil {
+		t.Fatalf("ImportSite: %v", err)
+	}
+	if exists, err := target.IDExists("app.example.com"); err != nil || !exists {
+		t.Errorf("导入后路由不存在: %v", err)
+	}
+}
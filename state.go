@@ -0,0 +1,160 @@
+package gofastcaddy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/youfun/gofastcaddy/internal/config"
+	"github.com/youfun/gofastcaddy/internal/routes"
+)
+
+// ManagedApps fastcaddy 管理的 Caddy 应用 - 状态哈希和漂移检测只覆盖这些应用
+var ManagedApps = []string{"http", "tls", "pki"}
+
+// Change 配置差异 - 见 config.Change
+type Change = config.Change
+
+// StateStore 受管配置快照的持久化存储 - 见 FastCaddy.StateStore
+// 快照按状态哈希保存，使控制器或 Caddy 重启后 VerifyState 仍能还原期望的配置并给出逐项漂移
+type StateStore interface {
+	SaveState(hash string, state map[string]interface{}) error
+	LoadState(hash string) (map[string]interface{}, error) // 快照不存在时返回 nil, nil
+}
+
+// FileStateStore 将快照保存为目录下以哈希命名的 JSON 文件的 StateStore
+type FileStateStore struct {
+	Dir string // 快照目录，不存在时自动创建
+}
+
+// SaveState 保存快照，先写临时文件再重命名，避免进程中断留下不完整的文件
+func (s FileStateStore) SaveState(hash string, state map[string]interface{}) error {
+	if err := os.MkdirAll(s.Dir, 0o700); err != nil {
+		return fmt.Errorf("创建状态目录失败: %w", err)
+	}
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("序列化状态快照失败: %w", err)
+	}
+	tmp := s.path(hash) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("写入状态快照失败: %w", err)
+	}
+	return os.Rename(tmp, s.path(hash))
+}
+
+// LoadState 读取快照，不存在时返回 nil
+func (s FileStateStore) LoadState(hash string) (map[string]interface{}, error) {
+	data, err := os.ReadFile(s.path(hash))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取状态快照失败: %w", err)
+	}
+	var state map[string]interface{}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("解析状态快照失败: %w", err)
+	}
+	return state, nil
+}
+
+// path 返回哈希对应的快照文件路径 - 内部辅助函数
+func (s FileStateStore) path(hash string) string {
+	return filepath.Join(s.Dir, filepath.Base(hash)+".json")
+}
+
+// GetStateHash 计算受管配置范围的规范化哈希
+// 建议在每次成功修改配置后持久化该值，重启时交给 VerifyState 校验；
+// 设置了 StateStore 时同时保存对应的快照
+func (fc *FastCaddy) GetStateHash() (string, error) {
+	state, err := fc.managedState()
+	if err != nil {
+		return "", err
+	}
+	hash, err := hashConfig(state)
+	if err != nil {
+		return "", err
+	}
+	if fc.StateStore != nil {
+		if err := fc.StateStore.SaveState(hash, state); err != nil {
+			return "", err
+		}
+	}
+	fc.lastState = state
+	fc.lastStateHash = hash
+	return hash, nil
+}
+
+// VerifyState 校验线上受管配置是否与调用方持久化的哈希一致
+// 不一致时返回漂移列表：若本进程曾通过 GetStateHash 得到过该哈希，或 StateStore 中保存了对应的快照
+// (控制器或 Caddy 重启后)，则给出逐项差异；否则无法还原期望的配置，只返回一项描述哈希不一致的根路径变更
+func (fc *FastCaddy) VerifyState(expectedHash string) (bool, []Change, error) {
+	state, err := fc.managedState()
+	if err != nil {
+		return false, nil, err
+	}
+	hash, err := hashConfig(state)
+	if err != nil {
+		return false, nil, err
+	}
+	if hash == expectedHash {
+		return true, nil, nil
+	}
+
+	if fc.lastState != nil && fc.lastStateHash == expectedHash {
+		return false, config.Diff(fc.lastState, state), nil
+	}
+	if fc.StateStore != nil {
+		expected, err := fc.StateStore.LoadState(expectedHash)
+		if err != nil {
+			return false, nil, err
+		}
+		if expected != nil {
+			return false, config.Diff(expected, state), nil
+		}
+	}
+	return false, []Change{{
+		Type:   config.ChangeModified,
+		Path:   "/",
+		Before: expectedHash,
+		After:  hash,
+	}}, nil
+}
+
+// VerifyStateAgainst 将线上受管配置与调用方持久化的完整快照逐项比较
+// expected 为之前某次 ExportState 的结果，适用于控制器重启后需要详细漂移报告的场景
+func (fc *FastCaddy) VerifyStateAgainst(expected map[string]interface{}) (bool, []Change, error) {
+	state, err := fc.managedState()
+	if err != nil {
+		return false, nil, err
+	}
+	drift := config.Diff(routes.StripProvenance(expected), state)
+	return len(drift) == 0, drift, nil
+}
+
+// ExportState 导出受管配置范围的快照 - 供 VerifyStateAgainst 使用
+func (fc *FastCaddy) ExportState() (map[string]interface{}, error) {
+	return fc.managedState()
+}
+
+// managedState 读取受管配置范围 - 内部辅助函数
+// 不存在的应用不出现在结果中，因此“尚未配置”和“配置为空对象”可以区分；
+// 路由来源信息随每次创建变化，不属于语义内容，会被去除
+func (fc *FastCaddy) managedState() (map[string]interface{}, error) {
+	cfg, err := fc.API.GetConfig("/")
+	if err != nil {
+		return nil, fmt.Errorf("读取线上配置失败: %w", err)
+	}
+
+	apps, _ := lookupMap(cfg, "apps")
+	state := make(map[string]interface{})
+	for _, name := range ManagedApps {
+		if app, ok := apps[name]; ok {
+			state[name] = routes.StripProvenance(app)
+		}
+	}
+	return state, nil
+}
@@ -0,0 +1,75 @@
+package gofastcaddy
+
+import (
+	"strings"
+	"testing"
+)
+
+// stateConfig 控制器上一次运行结束时的配置 - 测试辅助常量
+const stateConfig = `{"apps":{"http":{"servers":{"srv0":{"listen":[":443"],"routes":[{"@id":"app.example.com","match":[{"host":["app.example.com"]}],"handle":[{"handler":"reverse_proxy","upstreams":[{"dial":"app:80"}]}]}]}}}}}`
+
+func TestVerifyStateAcrossRestarts(t *testing.T) {
+	tests := []struct {
+		name      string
+		store     bool
+		edit      string // 两次运行之间 Caddy 恢复的配置，为空表示没有外部修改
+		wantOK    bool
+		wantPaths []string
+	}{
+		{
+			name:   "没有外部修改",
+			store:  true,
+			wantOK: true,
+		},
+		{
+			name:      "从快照给出逐项漂移",
+			store:     true,
+			edit:      strings.Replace(stateConfig, "app:80", "stale:80", 1),
+			wantPaths: []string{"/http/servers/srv0/routes/0/handle/0/upstreams/0/dial"},
+		},
+		{
+			name:      "没有快照时只报告哈希不一致",
+			edit:      strings.Replace(stateConfig, "app:80", "stale:80", 1),
+			wantPaths: []string{"/"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			first, fake := newTestFastCaddy(t, stateConfig)
+			if tt.store {
+				first.StateStore = FileStateStore{Dir: dir}
+			}
+			hash, err := first.GetStateHash()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.edit != "" {
+				if err := fake.SetConfig(tt.edit); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			// 第二次运行：新的进程只有持久化的哈希和快照目录
+			second := NewWithURL(fake.URL)
+			if tt.store {
+				second.StateStore = FileStateStore{Dir: dir}
+			}
+			ok, drift, err := second.VerifyState(hash)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok != tt.wantOK {
+				t.Fatalf("ok = %v, 期望 %v", ok, tt.wantOK)
+			}
+			var paths []string
+			for _, change := range drift {
+				paths = append(paths, change.Path)
+			}
+			if strings.Join(paths, ",") != strings.Join(tt.wantPaths, ",") {
+				t.Errorf("漂移路径 = %v, 期望 %v", paths, tt.wantPaths)
+			}
+		})
+	}
+}